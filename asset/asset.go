@@ -61,16 +61,9 @@ func load(name string) (mmap, error) {
 	}
 
 	mtx.RUnlock()
-	mtx.Lock()
-	defer mtx.Unlock()
-
-	// be 100% sure it wasn't added between the RUnlock() and Lock()
-	if m, ok := cache[name]; ok {
-		logger.VPrintf("Loading [%s] from cache", name)
-		atomic.AddInt64(m.refs, 1)
-		return m, nil
-	}
 
+	// the file is mapped and verified without holding mtx so that hashing a
+	// large file doesn't block every other load
 	logger.VPrintf("Loading [%s] from disk", name)
 
 	info, err := os.Stat(name)
@@ -83,16 +76,31 @@ func load(name string) (mmap, error) {
 	}
 
 	if unsafe.Sizeof(int(0)) != unsafe.Sizeof(int64(0)) && info.Size() > math.MaxInt32 {
-		return mmap{}, debug.ErrorWrapf(debug.Errorf("File too big"), "Failed to load asset %q", name)
+		return mmap{}, debug.ErrorWrapf(debug.Errorf("File too big, use LoadWindowed"), "Failed to load asset %q", name)
 	}
 
 	s, err := mapFile(name, int(info.Size()))
 	if err != nil {
 		return mmap{}, debug.ErrorWrapf(err, "Failed to load asset %q", name)
 	}
+	if err := verifyLoad(name, s.bytes()); err != nil {
+		s.close()
+		return mmap{}, debug.ErrorWrapf(err, "Failed to load asset %q", name)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	// another load may have added it in the meantime
+	if m, ok := cache[name]; ok {
+		s.close()
+		logger.VPrintf("Loading [%s] from cache", name)
+		atomic.AddInt64(m.refs, 1)
+		return m, nil
+	}
 
 	m := mmap{
-		info: fileinfo{name: name, size: int(info.Size())},
+		info: fileinfo{name: name, size: info.Size()},
 		sys:  s, refs: new(int64),
 	}
 	atomic.AddInt64(m.refs, 1)
@@ -102,7 +110,7 @@ func load(name string) (mmap, error) {
 
 type fileinfo struct {
 	name string
-	size int
+	size int64
 }
 
 func (i *fileinfo) Name() string {
@@ -110,7 +118,7 @@ func (i *fileinfo) Name() string {
 }
 
 func (i *fileinfo) Size() int64 {
-	return int64(i.size)
+	return i.size
 }
 
 func (i *fileinfo) Mode() fs.FileMode {
@@ -145,7 +153,7 @@ func (f *File) Uintptr() uintptr {
 }
 
 func (f *File) Size() int {
-	return f.mmap.info.size
+	return int(f.mmap.info.size)
 }
 
 func (f *File) Stat() (fs.FileInfo, error) {
@@ -168,6 +176,18 @@ func (f *File) Peek(n int) ([]byte, error) {
 	return slices.Clone(f.mmap.sys.bytes()[i : i+n]), nil
 }
 
+/*
+View returns size bytes starting at offset without copying, offset is from the
+start of the file not the current position. The slice aliases the read only
+mapping, writing to it will crash, and it must not be used after Close.
+*/
+func (f *File) View(offset, size int) ([]byte, error) {
+	if offset < 0 || size < 0 || offset > f.Size()-size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return f.mmap.sys.bytes()[offset : offset+size : offset+size], nil
+}
+
 func (f *File) Read(b []byte) (n int, err error) {
 	return f.reader.Read(b)
 }
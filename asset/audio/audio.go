@@ -42,12 +42,24 @@ const (
 )
 
 /*
-Track in 32 bit Float non-interleaved format, map key represents the individual channels
-
-Driver is then responsible for converting it into the appropriate signal for output
-audio is non-interleaved to make it easier to support different channel orders
+Speaker positions without a channel above are user channels after ChannelCount,
+so every decoder maps them the same way. ChannelBackCenter has the same value as
+sdl.AudioChannelBackCenter. Channels without a known position are decoded to
+user channels starting at ChannelUnknown.
 */
-type Track map[Channel][]float32
+const (
+	ChannelBackCenter Channel = ChannelCount + iota
+	ChannelFrontLeftOfCenter
+	ChannelFrontRightOfCenter
+	ChannelTopCenter
+	ChannelTopFrontLeft
+	ChannelTopFrontCenter
+	ChannelTopFrontRight
+	ChannelTopBackLeft
+	ChannelTopBackCenter
+	ChannelTopBackRight
+	ChannelUnknown
+)
 
 type Spec struct {
 	Channels  []Channel
@@ -66,11 +78,14 @@ type assetImpl struct {
 	durationSeconds float64
 	durationSamples int
 	track           Track
+	metadata        Metadata
 }
 
 type format struct {
-	magic  []byte
-	decode func(*asset.File) (Spec, int, []float32, error)
+	magic    []byte
+	decode   func(*asset.File) (Spec, int, []float32, error)
+	stream   func(*asset.File) (StreamDecoder, error)
+	metadata func(*asset.File) (Metadata, error)
 }
 
 var (
@@ -95,22 +110,28 @@ func Channels7Point1() []Channel {
 }
 
 func RegisterFormat(magic string, decode func(*asset.File) (Spec, int, []float32, error)) {
+	RegisterStreamFormat(magic, decode, nil)
+}
+
+/*
+RegisterStreamFormat is RegisterFormat for formats that can also be decoded
+incrementally by LoadStream, stream may be nil if they cannot.
+*/
+func RegisterStreamFormat(magic string, decode func(*asset.File) (Spec, int, []float32, error), stream func(*asset.File) (StreamDecoder, error)) {
 	mtx.Lock()
 	f, _ := formats.Load().([]format)
-	formats.Store(append(f, format{[]byte(magic), decode}))
+	formats.Store(append(f, format{[]byte(magic), decode, stream, nil}))
 	mtx.Unlock()
 }
 
-func Load(file string) (Asset, error) {
-	a, err := asset.Load(file)
-	if err != nil {
-		return nil, debug.ErrorWrapf(err, "Failed to load audio")
-	}
-
+/*
+findFormat returns the first registered format matching the magic of a or nil.
+*/
+func findFormat(a *asset.File) (*format, error) {
 	formats, _ := formats.Load().([]format)
 
 formats:
-	for _, f := range formats {
+	for i, f := range formats {
 		if a.Size() < len(f.magic) {
 			continue
 		}
@@ -118,7 +139,7 @@ formats:
 		magic, err := a.Peek(len(f.magic))
 
 		if errors.Is(err, io.EOF) {
-			return nil, debug.ErrorWrapf(err, "Failed to load audio")
+			return nil, err
 		}
 
 		for i, b := range f.magic {
@@ -126,28 +147,46 @@ formats:
 				continue formats
 			}
 		}
+		return &formats[i], nil
+	}
+	return nil, nil
+}
 
-		spec, samples, interleavedTrack, err := f.decode(a)
-		if err != nil {
-			return nil, err
-		}
-
-		duration := float64(samples) / float64(spec.Frequency) / float64(len(spec.Channels))
-		track := make(Track)
+func Load(file string) (Asset, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load audio")
+	}
 
-		for i, s := range interleavedTrack {
-			track[spec.Channels[i%len(spec.Channels)]] = append(track[spec.Channels[i%len(spec.Channels)]], s)
-		}
+	f, err := findFormat(a)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load audio")
+	}
+	if f == nil {
+		return nil, debug.Errorf("Failed to load audio, unknown format")
+	}
 
-		return &assetImpl{
-			spec,
-			duration,
-			samples / len(spec.Channels),
-			track,
-		}, nil
+	spec, samples, interleavedTrack, err := f.decode(a)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, debug.Errorf("Failed to load audio, unknown format")
+	duration := float64(samples) / float64(spec.Frequency) / float64(len(spec.Channels))
+	track := Deinterleave(spec.Channels, interleavedTrack)
+
+	metadata, err := readMetadata(f, a, samples/len(spec.Channels))
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load audio metadata")
+	}
+	metadata.Loudness = Loudness(track, spec.Frequency)
+
+	return &assetImpl{
+		spec,
+		duration,
+		samples / len(spec.Channels),
+		track,
+		metadata,
+	}, nil
 }
 
 func (s *assetImpl) Track() Track {
@@ -166,6 +205,10 @@ func (s *assetImpl) DurationSamples() int {
 	return s.durationSamples
 }
 
+func (s *assetImpl) Metadata() Metadata {
+	return s.metadata
+}
+
 func (c Channel) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
@@ -0,0 +1,183 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dsp
+
+import (
+	"math"
+
+	"goarrg.com/asset/audio"
+)
+
+type FilterType int
+
+const (
+	LowPass FilterType = iota
+	HighPass
+	// BandPass has a peak gain of 0dB at Frequency.
+	BandPass
+	Notch
+	// Peak boosts or cuts by Gain around Frequency.
+	Peak
+	// LowShelf boosts or cuts by Gain below Frequency.
+	LowShelf
+	// HighShelf boosts or cuts by Gain above Frequency.
+	HighShelf
+)
+
+type BiquadConfig struct {
+	Type FilterType
+	// Frequency is the cutoff or center frequency in Hz.
+	Frequency float32
+	// Q is the resonance, or the inverse of the bandwidth for band filters.
+	// Defaults to √½ if <= 0.
+	Q float32
+	// Gain in dB of the Peak and shelf filters.
+	Gain float32
+}
+
+/*
+Biquad is a second order filter from the Audio EQ Cookbook.
+*/
+type Biquad struct {
+	Frequency Param
+	Q         Param
+	Gain      Param
+
+	typ       FilterType
+	frequency int
+	// coefficients are normalized by a0, state holds the transposed direct
+	// form II state of every channel
+	b0, b1, b2, a1, a2 float64
+	state              [][2]float64
+	// last holds the parameters the coefficients were computed for
+	last [3]float32
+}
+
+func NewBiquad(cfg BiquadConfig) *Biquad {
+	if cfg.Q <= 0 {
+		cfg.Q = math.Sqrt2 / 2
+	}
+	b := &Biquad{typ: cfg.Type}
+	b.Frequency.Set(cfg.Frequency)
+	b.Q.Set(cfg.Q)
+	b.Gain.Set(cfg.Gain)
+	return b
+}
+
+func (b *Biquad) Init(spec audio.Spec) {
+	b.frequency = spec.Frequency
+	b.state = make([][2]float64, len(spec.Channels))
+	b.Frequency.init(spec.Frequency)
+	b.Q.init(spec.Frequency)
+	b.Gain.init(spec.Frequency)
+	b.compute(b.Frequency.value, b.Q.value, b.Gain.value)
+}
+
+/*
+compute sets the coefficients for the parameters.
+*/
+func (b *Biquad) compute(frequency, q, gain float32) {
+	b.last = [3]float32{frequency, q, gain}
+	f := max(1, min(float64(frequency), 0.49*float64(b.frequency)))
+	w := 2 * math.Pi * f / float64(b.frequency)
+	cos, sin := math.Cos(w), math.Sin(w)
+	alpha := sin / (2 * max(1e-3, float64(q)))
+	a := math.Pow(10, float64(gain)/40)
+
+	var b0, b1, b2, a0, a1, a2 float64
+	switch b.typ {
+	case LowPass:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case HighPass:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case BandPass:
+		b0, b1, b2 = alpha, 0, -alpha
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case Notch:
+		b0, b1, b2 = 1, -2*cos, 1
+		a0, a1, a2 = 1+alpha, -2*cos, 1-alpha
+	case Peak:
+		b0, b1, b2 = 1+alpha*a, -2*cos, 1-alpha*a
+		a0, a1, a2 = 1+alpha/a, -2*cos, 1-alpha/a
+	case LowShelf:
+		s := 2 * math.Sqrt(a) * alpha
+		b0, b1, b2 = a*((a+1)-(a-1)*cos+s), 2*a*((a-1)-(a+1)*cos), a*((a+1)-(a-1)*cos-s)
+		a0, a1, a2 = (a+1)+(a-1)*cos+s, -2*((a-1)+(a+1)*cos), (a+1)+(a-1)*cos-s
+	case HighShelf:
+		s := 2 * math.Sqrt(a) * alpha
+		b0, b1, b2 = a*((a+1)+(a-1)*cos+s), -2*a*((a-1)+(a+1)*cos), a*((a+1)+(a-1)*cos-s)
+		a0, a1, a2 = (a+1)-(a-1)*cos+s, 2*((a-1)-(a+1)*cos), (a+1)-(a-1)*cos-s
+	}
+	b.b0, b.b1, b.b2, b.a1, b.a2 = b0/a0, b1/a0, b2/a0, a1/a0, a2/a0
+}
+
+func (b *Biquad) Process(buf [][]float32, frames int) {
+	for start := 0; start < frames; start += controlFrames {
+		n := min(controlFrames, frames-start)
+		params := [3]float32{b.Frequency.advance(n), b.Q.advance(n), b.Gain.advance(n)}
+		if params != b.last {
+			b.compute(params[0], params[1], params[2])
+		}
+		for c, s := range b.state {
+			z1, z2 := s[0], s[1]
+			for i, x := range buf[c][start : start+n] {
+				in := float64(x)
+				out := b.b0*in + z1
+				z1 = b.b1*in - b.a1*out + z2
+				z2 = b.b2*in - b.a2*out
+				buf[c][start+i] = float32(out)
+			}
+			b.state[c] = [2]float64{z1, z2}
+		}
+	}
+}
+
+/*
+EQ is a parametric equalizer of biquad bands in series.
+*/
+type EQ struct {
+	bands []*Biquad
+}
+
+func NewEQ(bands ...BiquadConfig) *EQ {
+	eq := &EQ{bands: make([]*Biquad, len(bands))}
+	for i, cfg := range bands {
+		eq.bands[i] = NewBiquad(cfg)
+	}
+	return eq
+}
+
+/*
+Band returns the filter of band i to change its parameters.
+*/
+func (eq *EQ) Band(i int) *Biquad {
+	return eq.bands[i]
+}
+
+func (eq *EQ) Init(spec audio.Spec) {
+	for _, b := range eq.bands {
+		b.Init(spec)
+	}
+}
+
+func (eq *EQ) Process(buf [][]float32, frames int) {
+	for _, b := range eq.bands {
+		b.Process(buf, frames)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dsp
+
+import (
+	"time"
+
+	"goarrg.com/asset/audio"
+)
+
+type DelayConfig struct {
+	// Time between the input and its echo.
+	Time time.Duration
+	// MaxTime is the longest Time can be set to. Defaults to Time if <= Time.
+	MaxTime time.Duration
+	// Feedback is the gain of the echo fed back into the delay, below 1 for
+	// the echoes to die out.
+	Feedback float32
+	// Mix crossfades from only the input at 0 to only the echoes at 1.
+	Mix float32
+}
+
+/*
+Delay is a feedback delay, changing Time glides the echo like a tape delay.
+*/
+type Delay struct {
+	// Time is in seconds and clamped to MaxTime.
+	Time     Param
+	Feedback Param
+	Mix      Param
+
+	maxTime   time.Duration
+	frequency int
+	lines     [][]float32
+	write     int
+}
+
+func NewDelay(cfg DelayConfig) *Delay {
+	d := &Delay{maxTime: max(cfg.Time, cfg.MaxTime)}
+	d.Time.Set(float32(cfg.Time.Seconds()))
+	d.Feedback.Set(cfg.Feedback)
+	d.Mix.Set(cfg.Mix)
+	return d
+}
+
+func (d *Delay) Init(spec audio.Spec) {
+	d.frequency = spec.Frequency
+	size := int(d.maxTime.Seconds()*float64(spec.Frequency)) + 2
+	d.lines = make([][]float32, len(spec.Channels))
+	for c := range d.lines {
+		d.lines[c] = make([]float32, size)
+	}
+	d.write = 0
+	d.Time.init(spec.Frequency)
+	d.Feedback.init(spec.Frequency)
+	d.Mix.init(spec.Frequency)
+}
+
+func (d *Delay) Process(buf [][]float32, frames int) {
+	if len(d.lines) == 0 {
+		return
+	}
+	size := len(d.lines[0])
+	for i := 0; i < frames; i++ {
+		delay := min(float64(d.Time.next())*float64(d.frequency), float64(size-2))
+		delay = max(1, delay)
+		feedback, mix := d.Feedback.next(), d.Mix.next()
+
+		read := float64(d.write) - delay
+		if read < 0 {
+			read += float64(size)
+		}
+		at := int(read)
+		frac := float32(read - float64(at))
+		next := at + 1
+		if next == size {
+			next = 0
+		}
+		for c, line := range d.lines {
+			x := buf[c][i]
+			echo := line[at] + (line[next]-line[at])*frac
+			line[d.write] = x + echo*feedback
+			buf[c][i] = x*(1-mix) + echo*mix
+		}
+		if d.write++; d.write == size {
+			d.write = 0
+		}
+	}
+}
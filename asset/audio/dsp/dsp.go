@@ -0,0 +1,176 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package dsp has real-time effects that process planar blocks of float32
+samples, one slice per channel of an audio.Spec, in place.
+
+Effects are stateful and must only be used in one place at a time. Their
+parameters are Params that can be set from any goroutine while the effect is
+processing, and glide to the new value to avoid zipper noise.
+*/
+package dsp
+
+import (
+	"math"
+	"sync/atomic"
+
+	"goarrg.com/asset/audio"
+)
+
+const (
+	// smoothTime is the time constant Params glide to their target with
+	smoothTime = 0.01
+	// controlFrames is how often effects with expensive parameters, like
+	// filter coefficients, pick up the smoothed value
+	controlFrames = 32
+)
+
+type Processor interface {
+	// Init prepares the processor for spec and clears its state, it is
+	// called before the first Process and whenever the spec changes.
+	Init(spec audio.Spec)
+	// Process processes frames of buf, a slice per channel of the spec, in
+	// place.
+	Process(buf [][]float32, frames int)
+}
+
+/*
+Pitcher is a Processor that also changes the pitch of what it processes, such
+as a doppler shift, which the player of the audio applies by resampling.
+*/
+type Pitcher interface {
+	Processor
+	// Pitch returns the factor to scale the playback rate by.
+	Pitch() float32
+}
+
+/*
+Chain is a Processor running its processors in order.
+*/
+type Chain []Processor
+
+func (c Chain) Init(spec audio.Spec) {
+	for _, p := range c {
+		p.Init(spec)
+	}
+}
+
+func (c Chain) Process(buf [][]float32, frames int) {
+	for _, p := range c {
+		p.Process(buf, frames)
+	}
+}
+
+/*
+ProcessTrack runs p over t, a track in spec, in blocks of a second. p is
+initialized for spec first. Channels of spec that t does not have are processed
+as silence and the result discarded.
+*/
+func ProcessTrack(p Processor, spec audio.Spec, t audio.Track) {
+	p.Init(spec)
+	frames := t.Frames()
+	block := max(1, spec.Frequency)
+	src := make([][]float32, len(spec.Channels))
+	var scratch []float32
+	for i, c := range spec.Channels {
+		src[i] = t.Channel(c)
+		if src[i] == nil && scratch == nil {
+			scratch = make([]float32, min(block, frames))
+		}
+	}
+	buf := make([][]float32, len(spec.Channels))
+	for start := 0; start < frames; start += block {
+		n := min(block, frames-start)
+		clear(scratch)
+		for i := range src {
+			if src[i] == nil {
+				buf[i] = scratch[:n]
+			} else {
+				buf[i] = src[i][start : start+n]
+			}
+		}
+		p.Process(buf, n)
+	}
+}
+
+/*
+Param is an effect parameter that is safe to set from any goroutine, the
+effect glides from the current value to the one set over about 10ms.
+*/
+type Param struct {
+	target atomic.Uint32
+	value  float32
+	// coef is how far value moves to target each frame
+	coef float32
+}
+
+func (p *Param) Set(v float32) {
+	p.target.Store(math.Float32bits(v))
+}
+
+/*
+Value returns the value last set, not the one the effect is gliding through.
+*/
+func (p *Param) Value() float32 {
+	return math.Float32frombits(p.target.Load())
+}
+
+/*
+init jumps to the target and sets up gliding at frequency.
+*/
+func (p *Param) init(frequency int) {
+	p.value = p.Value()
+	p.coef = float32(1 - math.Exp(-1/(smoothTime*float64(frequency))))
+}
+
+/*
+next returns the value for the next frame.
+*/
+func (p *Param) next() float32 {
+	target := p.Value()
+	if p.value == target {
+		return target
+	}
+	p.value += (target - p.value) * p.coef
+	if math.Abs(float64(target-p.value)) <= 1e-4*max(1, math.Abs(float64(target))) {
+		p.value = target
+	}
+	return p.value
+}
+
+/*
+advance moves the value frames frames ahead and returns it.
+*/
+func (p *Param) advance(frames int) float32 {
+	target := p.Value()
+	if p.value == target {
+		return target
+	}
+	p.value = target + (p.value-target)*float32(math.Pow(float64(1-p.coef), float64(frames)))
+	if math.Abs(float64(target-p.value)) <= 1e-4*max(1, math.Abs(float64(target))) {
+		p.value = target
+	}
+	return p.value
+}
+
+func dbToGain(db float32) float32 {
+	return float32(math.Pow(10, float64(db)/20))
+}
+
+func gainToDB(gain float32) float32 {
+	return float32(20 * math.Log10(max(float64(gain), 1e-9)))
+}
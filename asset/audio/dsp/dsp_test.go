@@ -0,0 +1,212 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dsp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"goarrg.com/asset/audio"
+)
+
+const rate = 48000
+
+func monoSpec() audio.Spec {
+	return audio.Spec{Channels: audio.ChannelsMono(), Frequency: rate}
+}
+
+func sine(frequency float64, amplitude float32, frames int) []float32 {
+	s := make([]float32, frames)
+	for i := range s {
+		s[i] = amplitude * float32(math.Sin(2*math.Pi*frequency*float64(i)/rate))
+	}
+	return s
+}
+
+func peak(s []float32) float32 {
+	p := float32(0)
+	for _, v := range s {
+		p = max(p, float32(math.Abs(float64(v))))
+	}
+	return p
+}
+
+/*
+response returns the peak gain of p for a sine at frequency once settled.
+*/
+func response(p Processor, frequency float64) float32 {
+	s := sine(frequency, 1, rate/2)
+	ProcessTrack(p, monoSpec(), audio.TrackOf(audio.ChannelsMono(), [][]float32{s}))
+	return peak(s[rate/4:])
+}
+
+func TestBiquad(t *testing.T) {
+	db := func(g float32) float64 { return float64(gainToDB(g)) }
+	for _, tc := range []struct {
+		name      string
+		cfg       BiquadConfig
+		frequency float64
+		want      float64
+	}{
+		{"low pass pass band", BiquadConfig{Type: LowPass, Frequency: 1000}, 100, 0},
+		{"low pass cutoff", BiquadConfig{Type: LowPass, Frequency: 1000}, 1000, -3},
+		{"low pass stop band", BiquadConfig{Type: LowPass, Frequency: 1000}, 10000, -40},
+		{"high pass stop band", BiquadConfig{Type: HighPass, Frequency: 1000}, 100, -40},
+		{"high pass pass band", BiquadConfig{Type: HighPass, Frequency: 1000}, 10000, 0},
+		{"band pass", BiquadConfig{Type: BandPass, Frequency: 1000, Q: 2}, 1000, 0},
+		{"notch", BiquadConfig{Type: Notch, Frequency: 1000}, 1000, -60},
+		{"peak", BiquadConfig{Type: Peak, Frequency: 1000, Q: 1, Gain: 6}, 1000, 6},
+		{"low shelf", BiquadConfig{Type: LowShelf, Frequency: 1000, Gain: -12}, 50, -12},
+		{"high shelf", BiquadConfig{Type: HighShelf, Frequency: 1000, Gain: 12}, 15000, 12},
+	} {
+		got := db(response(NewBiquad(tc.cfg), tc.frequency))
+		switch {
+		case tc.want <= -40 && got > tc.want:
+			t.Errorf("%s: got %.1fdB want below %.0fdB", tc.name, got, tc.want)
+		case tc.want > -40 && math.Abs(got-tc.want) > 0.5:
+			t.Errorf("%s: got %.1fdB want %.0fdB", tc.name, got, tc.want)
+		}
+	}
+
+	eq := NewEQ(BiquadConfig{Type: Peak, Frequency: 1000, Gain: 6}, BiquadConfig{Type: Peak, Frequency: 1000, Gain: 6})
+	if got := db(response(eq, 1000)); math.Abs(got-12) > 0.5 {
+		t.Errorf("eq: got %.1fdB want 12dB", got)
+	}
+	eq.Band(1).Gain.Set(-6)
+	if got := eq.Band(1).Gain.Value(); got != -6 {
+		t.Errorf("got band gain %f", got)
+	}
+}
+
+func TestParam(t *testing.T) {
+	var p Param
+	p.Set(0)
+	p.init(rate)
+	p.Set(1)
+	prev := float32(0)
+	for i := 0; i < rate/10; i++ {
+		v := p.next()
+		if v < prev || v-prev > 0.01 {
+			t.Fatalf("frame %d: jumped from %f to %f", i, prev, v)
+		}
+		prev = v
+	}
+	if prev != 1 {
+		t.Errorf("got %f after 100ms", prev)
+	}
+
+	// sweeping a filter glides instead of stepping
+	b := NewBiquad(BiquadConfig{Type: LowPass, Frequency: 500})
+	b.Init(monoSpec())
+	s := sine(100, 1, rate/10)
+	b.Process([][]float32{s[:rate/20]}, rate/20)
+	b.Frequency.Set(2000)
+	b.Process([][]float32{s[rate/20:]}, rate/20)
+	for i := 1; i < len(s); i++ {
+		if d := math.Abs(float64(s[i] - s[i-1])); d > 0.02 {
+			t.Fatalf("frame %d: jump of %f", i, d)
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	d := NewDelay(DelayConfig{Time: 10 * time.Millisecond, Feedback: 0.5, Mix: 0.5})
+	s := make([]float32, rate/10)
+	s[0] = 1
+	ProcessTrack(d, monoSpec(), audio.TrackOf(audio.ChannelsMono(), [][]float32{s}))
+	for i, want := range map[int]float32{0: 0.5, 480: 0.5, 960: 0.25, 1440: 0.125, 100: 0} {
+		if math.Abs(float64(s[i]-want)) > 1e-4 {
+			t.Errorf("frame %d: got %f want %f", i, s[i], want)
+		}
+	}
+}
+
+func TestReverb(t *testing.T) {
+	spec := audio.Spec{Channels: audio.ChannelsStereo(), Frequency: rate}
+	l, r := make([]float32, rate*2), make([]float32, rate*2)
+	l[0] = 1
+	ProcessTrack(NewReverb(ReverbConfig{}), spec, audio.TrackOf(spec.Channels, [][]float32{l, r}))
+	if l[0] != 0 {
+		t.Errorf("got dry signal %f with Dry 0", l[0])
+	}
+	early, late := peak(l[:rate/2]), peak(l[rate*3/2:])
+	if early < 0.01 || late >= early/10 {
+		t.Errorf("tail does not decay, early peak %f late peak %f", early, late)
+	}
+	if peak(r[:rate/2]) < 0.01 {
+		t.Error("no reverb on the right channel")
+	}
+
+	// the right channel is missing from the track and processed as silence
+	m := make([]float32, rate*2)
+	m[0] = 1
+	ProcessTrack(NewReverb(ReverbConfig{}), spec, audio.TrackOf(audio.ChannelsMono(), [][]float32{m}))
+	if peak(m[:rate/2]) < 0.01 || peak(m[rate:]) == 0 {
+		t.Error("missing channel stopped processing")
+	}
+
+	s := sine(440, 0.5, rate/10)
+	ProcessTrack(NewReverb(ReverbConfig{Wet: 1e-9, Dry: 1}), monoSpec(), audio.TrackOf(audio.ChannelsMono(), [][]float32{s}))
+	if want := sine(440, 0.5, rate/10); math.Abs(float64(s[1000]-want[1000])) > 1e-6 {
+		t.Errorf("dry signal changed, got %f want %f", s[1000], want[1000])
+	}
+}
+
+func TestCompressor(t *testing.T) {
+	c := NewCompressor(CompressorConfig{Threshold: -20, Ratio: 4})
+	// 0dB is 20dB over the threshold which becomes 5dB
+	if got := float64(gainToDB(response(c, 1000))); math.Abs(got+15) > 0.5 {
+		t.Errorf("got %.1fdB want -15dB", got)
+	}
+	c = NewCompressor(CompressorConfig{Threshold: -20, Ratio: 4, Makeup: 6})
+	if got := float64(gainToDB(response(c, 1000))); math.Abs(got+9) > 0.5 {
+		t.Errorf("makeup: got %.1fdB want -9dB", got)
+	}
+	// below the threshold the signal is left alone
+	s := sine(1000, 0.05, rate/10)
+	ProcessTrack(NewCompressor(CompressorConfig{Threshold: -20}), monoSpec(), audio.TrackOf(audio.ChannelsMono(), [][]float32{s}))
+	if want := sine(1000, 0.05, rate/10); s[2000] != want[2000] {
+		t.Errorf("got %f want %f", s[2000], want[2000])
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	spec := audio.Spec{Channels: audio.ChannelsStereo(), Frequency: rate}
+	l, r := sine(1000, 0.25, rate/2), sine(50, 0.25, rate/2)
+	for i := rate / 8; i < rate/4; i++ {
+		l[i] *= 8
+	}
+	r[rate/16] = 4
+	ProcessTrack(NewLimiter(LimiterConfig{Ceiling: -1}), spec, audio.TrackOf(spec.Channels, [][]float32{l, r}))
+	ceiling := dbToGain(-1)
+	if p := max(peak(l), peak(r)); p > ceiling+1e-6 {
+		t.Errorf("got peak %f above the ceiling %f", p, ceiling)
+	}
+	// the quiet start passes through delayed by the lookahead
+	want := sine(1000, 0.25, rate/2)
+	if math.Abs(float64(l[1000]-want[1000-240])) > 1e-6 {
+		t.Errorf("got %f want %f", l[1000], want[1000-240])
+	}
+	// the gain recovers after the loud part
+	if p := peak(l[rate*7/16 : rate/2]); p < 0.24 {
+		t.Errorf("gain did not recover, got peak %f", p)
+	}
+}
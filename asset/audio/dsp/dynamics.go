@@ -0,0 +1,265 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dsp
+
+import (
+	"math"
+	"time"
+
+	"goarrg.com/asset/audio"
+)
+
+const (
+	defaultAttack    = 10 * time.Millisecond
+	defaultRelease   = 100 * time.Millisecond
+	defaultRatio     = 4
+	defaultLookahead = 5 * time.Millisecond
+	defaultLimiter   = 50 * time.Millisecond
+)
+
+/*
+timeCoef returns the per frame coefficient of a one pole filter with a time
+constant of seconds.
+*/
+func timeCoef(seconds float32, frequency int) float32 {
+	if seconds <= 0 {
+		return 0
+	}
+	return float32(math.Exp(-1 / (float64(seconds) * float64(frequency))))
+}
+
+type CompressorConfig struct {
+	// Threshold in dBFS above which the signal is compressed.
+	Threshold float32
+	// Ratio of the input level above the threshold to the output level.
+	// Defaults to 4 if <= 0.
+	Ratio float32
+	// Knee in dB softens the transition around the threshold, 0 for a hard
+	// knee.
+	Knee float32
+	// Attack is how fast the gain is reduced. Defaults to 10ms if <= 0.
+	Attack time.Duration
+	// Release is how fast the gain recovers. Defaults to 100ms if <= 0.
+	Release time.Duration
+	// Makeup gain in dB applied after compression.
+	Makeup float32
+}
+
+/*
+Compressor is a feed forward compressor smoothing the gain reduction with
+Attack and Release, the level of all channels is linked so the balance between
+them does not shift.
+*/
+type Compressor struct {
+	Threshold Param
+	Ratio     Param
+	Knee      Param
+	// Attack and Release are in seconds.
+	Attack  Param
+	Release Param
+	Makeup  Param
+
+	frequency int
+	// level holds the peak level decaying with Release, envelope is the gain
+	// reduction in dB
+	level    float32
+	envelope float32
+}
+
+func NewCompressor(cfg CompressorConfig) *Compressor {
+	if cfg.Ratio <= 0 {
+		cfg.Ratio = defaultRatio
+	}
+	if cfg.Attack <= 0 {
+		cfg.Attack = defaultAttack
+	}
+	if cfg.Release <= 0 {
+		cfg.Release = defaultRelease
+	}
+	c := &Compressor{}
+	c.Threshold.Set(cfg.Threshold)
+	c.Ratio.Set(cfg.Ratio)
+	c.Knee.Set(max(0, cfg.Knee))
+	c.Attack.Set(float32(cfg.Attack.Seconds()))
+	c.Release.Set(float32(cfg.Release.Seconds()))
+	c.Makeup.Set(cfg.Makeup)
+	return c
+}
+
+func (c *Compressor) Init(spec audio.Spec) {
+	c.frequency = spec.Frequency
+	c.level, c.envelope = 0, 0
+	for _, p := range []*Param{&c.Threshold, &c.Ratio, &c.Knee, &c.Attack, &c.Release, &c.Makeup} {
+		p.init(spec.Frequency)
+	}
+}
+
+/*
+reduction returns the gain reduction in dB, <= 0, for a level in dB.
+*/
+func reduction(level, threshold, ratio, knee float32) float32 {
+	slope := 1/max(1, ratio) - 1
+	over := level - threshold
+	switch {
+	case 2*over <= -knee:
+		return 0
+	case knee > 0 && 2*over < knee:
+		return slope * (over + knee/2) * (over + knee/2) / (2 * knee)
+	default:
+		return slope * over
+	}
+}
+
+func (c *Compressor) Process(buf [][]float32, frames int) {
+	for start := 0; start < frames; start += controlFrames {
+		n := min(controlFrames, frames-start)
+		threshold, ratio, knee := c.Threshold.advance(n), c.Ratio.advance(n), c.Knee.advance(n)
+		attack := timeCoef(c.Attack.advance(n), c.frequency)
+		release := timeCoef(c.Release.advance(n), c.frequency)
+		makeup := c.Makeup.advance(n)
+
+		for i := start; i < start+n; i++ {
+			peak := float32(0)
+			for _, ch := range buf {
+				peak = max(peak, float32(math.Abs(float64(ch[i]))))
+			}
+			c.level = max(peak, c.level*release)
+			target := reduction(gainToDB(c.level), threshold, ratio, knee)
+			coef := release
+			if target < c.envelope {
+				coef = attack
+			}
+			c.envelope = target + (c.envelope-target)*coef
+			gain := dbToGain(c.envelope + makeup)
+			for _, ch := range buf {
+				ch[i] *= gain
+			}
+		}
+	}
+}
+
+type LimiterConfig struct {
+	// Ceiling in dBFS the output never exceeds.
+	Ceiling float32
+	// Release is how fast the gain recovers. Defaults to 50ms if <= 0.
+	Release time.Duration
+	// Lookahead delays the signal to reduce the gain before peaks arrive.
+	// Defaults to 5ms if <= 0.
+	Lookahead time.Duration
+}
+
+/*
+Limiter is a lookahead peak limiter, the output is delayed by the lookahead.
+*/
+type Limiter struct {
+	Ceiling Param
+	// Release is in seconds.
+	Release Param
+
+	lookahead time.Duration
+	frequency int
+	// lines delay the signal by the lookahead, required holds the gain every
+	// frame in them needs
+	lines    [][]float32
+	required []float32
+	pos      int
+	// window is a monotonic queue of positions in required whose minimum is
+	// the gain needed for the frames in the lookahead
+	window []int
+	gain   float32
+}
+
+func NewLimiter(cfg LimiterConfig) *Limiter {
+	if cfg.Release <= 0 {
+		cfg.Release = defaultLimiter
+	}
+	if cfg.Lookahead <= 0 {
+		cfg.Lookahead = defaultLookahead
+	}
+	l := &Limiter{lookahead: cfg.Lookahead}
+	l.Ceiling.Set(cfg.Ceiling)
+	l.Release.Set(float32(cfg.Release.Seconds()))
+	return l
+}
+
+func (l *Limiter) Init(spec audio.Spec) {
+	l.frequency = spec.Frequency
+	size := max(1, int(l.lookahead.Seconds()*float64(spec.Frequency)))
+	l.lines = make([][]float32, len(spec.Channels))
+	for c := range l.lines {
+		l.lines[c] = make([]float32, size)
+	}
+	l.required = make([]float32, size)
+	for i := range l.required {
+		l.required[i] = 1
+	}
+	l.pos, l.window, l.gain = 0, l.window[:0], 1
+	l.Ceiling.init(spec.Frequency)
+	l.Release.init(spec.Frequency)
+}
+
+func (l *Limiter) Process(buf [][]float32, frames int) {
+	if len(l.lines) == 0 {
+		return
+	}
+	size := len(l.required)
+	// reaching the required gain within the lookahead, the final clamp
+	// catches what the smoothing misses
+	attack := timeCoef(float32(size)/float32(l.frequency)/4, l.frequency)
+	for start := 0; start < frames; start += controlFrames {
+		n := min(controlFrames, frames-start)
+		ceiling := dbToGain(l.Ceiling.advance(n))
+		release := timeCoef(l.Release.advance(n), l.frequency)
+
+		for i := start; i < start+n; i++ {
+			peak := float32(0)
+			for _, ch := range buf {
+				peak = max(peak, float32(math.Abs(float64(ch[i]))))
+			}
+			required := float32(1)
+			if peak > ceiling {
+				required = ceiling / peak
+			}
+
+			// l.pos is about to be overwritten, drop it from the window
+			if len(l.window) > 0 && l.window[0] == l.pos {
+				l.window = l.window[1:]
+			}
+			l.required[l.pos] = required
+			for len(l.window) > 0 && l.required[l.window[len(l.window)-1]] >= required {
+				l.window = l.window[:len(l.window)-1]
+			}
+			l.window = append(l.window, l.pos)
+			target := l.required[l.window[0]]
+
+			coef := release
+			if target < l.gain {
+				coef = attack
+			}
+			l.gain = target + (l.gain-target)*coef
+
+			for c, ch := range buf {
+				delayed := l.lines[c][l.pos]
+				l.lines[c][l.pos] = ch[i]
+				ch[i] = max(-ceiling, min(ceiling, delayed*l.gain))
+			}
+			if l.pos++; l.pos == size {
+				l.pos = 0
+			}
+		}
+	}
+}
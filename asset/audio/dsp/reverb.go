@@ -0,0 +1,174 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dsp
+
+import (
+	"goarrg.com/asset/audio"
+)
+
+// Freeverb's tuning at 44.1kHz
+var (
+	reverbCombs     = [...]int{1116, 1188, 1277, 1356, 1422, 1491, 1557, 1617}
+	reverbAllpasses = [...]int{556, 441, 341, 225}
+)
+
+const (
+	reverbSpread    = 23
+	reverbInputGain = 0.015
+	reverbScaleRoom = 0.28
+	reverbRoom      = 0.7
+	reverbScaleDamp = 0.4
+	reverbScaleWet  = 3
+)
+
+type ReverbConfig struct {
+	// RoomSize from 0 to 1 sets the length of the tail. Defaults to 0.5 if <= 0.
+	RoomSize float32
+	// Damping from 0 to 1 darkens the tail.
+	Damping float32
+	// Wet is the gain of the reverb. Defaults to 1/3 if <= 0.
+	Wet float32
+	// Dry is the gain of the input, 0 for a reverb on a send bus and 1 for an
+	// insert.
+	Dry float32
+	// Width from 0 to 1 spreads the tail across channels. Defaults to 1 if <= 0.
+	Width float32
+}
+
+/*
+Reverb is Jezar's Freeverb, a bank of damped comb filters into allpass filters
+per channel with each channel tuned slightly apart.
+*/
+type Reverb struct {
+	RoomSize Param
+	Damping  Param
+	Wet      Param
+	Dry      Param
+	Width    Param
+
+	channels []reverbChannel
+	out      []float32
+}
+
+type reverbChannel struct {
+	combs     [len(reverbCombs)]reverbLine
+	allpasses [len(reverbAllpasses)]reverbLine
+}
+
+type reverbLine struct {
+	buf []float32
+	pos int
+	// store is the state of the comb's damping filter
+	store float32
+}
+
+func NewReverb(cfg ReverbConfig) *Reverb {
+	if cfg.RoomSize <= 0 {
+		cfg.RoomSize = 0.5
+	}
+	if cfg.Wet <= 0 {
+		cfg.Wet = 1.0 / 3
+	}
+	if cfg.Width <= 0 {
+		cfg.Width = 1
+	}
+	r := &Reverb{}
+	r.RoomSize.Set(cfg.RoomSize)
+	r.Damping.Set(cfg.Damping)
+	r.Wet.Set(cfg.Wet)
+	r.Dry.Set(cfg.Dry)
+	r.Width.Set(cfg.Width)
+	return r
+}
+
+func (r *Reverb) Init(spec audio.Spec) {
+	scale := float64(spec.Frequency) / 44100
+	r.channels = make([]reverbChannel, len(spec.Channels))
+	r.out = make([]float32, len(spec.Channels))
+	for c := range r.channels {
+		for i, n := range reverbCombs {
+			r.channels[c].combs[i].buf = make([]float32, max(1, int(float64(n+c*reverbSpread)*scale)))
+		}
+		for i, n := range reverbAllpasses {
+			r.channels[c].allpasses[i].buf = make([]float32, max(1, int(float64(n+c*reverbSpread)*scale)))
+		}
+	}
+	for _, p := range []*Param{&r.RoomSize, &r.Damping, &r.Wet, &r.Dry, &r.Width} {
+		p.init(spec.Frequency)
+	}
+}
+
+func (r *Reverb) Process(buf [][]float32, frames int) {
+	if len(r.channels) == 0 {
+		return
+	}
+	for start := 0; start < frames; start += controlFrames {
+		n := min(controlFrames, frames-start)
+		feedback := r.RoomSize.advance(n)*reverbScaleRoom + reverbRoom
+		damp := r.Damping.advance(n) * reverbScaleDamp
+		wet := r.Wet.advance(n) * reverbScaleWet
+		dry := r.Dry.advance(n)
+		width := r.Width.advance(n)
+		// with width 1 every channel hears only its own tail, with 0 the mean
+		// of all tails
+		others := float32(len(r.channels) - 1)
+		own, rest := wet*(width/2+0.5), wet*(1-width)/2
+		if others == 0 {
+			own, rest = wet, 0
+		} else {
+			rest /= others
+		}
+
+		for i := start; i < start+n; i++ {
+			in := float32(0)
+			for c := range r.channels {
+				in += buf[c][i]
+			}
+			in *= reverbInputGain
+
+			sum := float32(0)
+			for c := range r.channels {
+				ch := &r.channels[c]
+				out := float32(0)
+				for j := range ch.combs {
+					comb := &ch.combs[j]
+					y := comb.buf[comb.pos]
+					comb.store = y*(1-damp) + comb.store*damp
+					comb.buf[comb.pos] = in + comb.store*feedback
+					if comb.pos++; comb.pos == len(comb.buf) {
+						comb.pos = 0
+					}
+					out += y
+				}
+				for j := range ch.allpasses {
+					ap := &ch.allpasses[j]
+					y := ap.buf[ap.pos]
+					ap.buf[ap.pos] = out + y*0.5
+					out = y - out
+					if ap.pos++; ap.pos == len(ap.buf) {
+						ap.pos = 0
+					}
+				}
+				r.out[c] = out
+				sum += out
+			}
+			for c, out := range r.out {
+				buf[c][i] = buf[c][i]*dry + out*own + (sum-out)*rest
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flac
+
+import (
+	"math/bits"
+)
+
+/*
+bitReader reads MSB first, reading past the end sets overrun and returns zero
+bits so that callers only need to check once per frame.
+*/
+type bitReader struct {
+	data    []byte
+	off     int
+	acc     uint64
+	n       uint
+	overrun bool
+}
+
+func (b *bitReader) fill() {
+	for b.n <= 56 && b.off < len(b.data) {
+		b.acc |= uint64(b.data[b.off]) << (56 - b.n)
+		b.off++
+		b.n += 8
+	}
+}
+
+/*
+read returns the next n bits, n must be at most 56.
+*/
+func (b *bitReader) read(n uint) uint64 {
+	if n == 0 {
+		return 0
+	}
+	if b.n < n {
+		b.fill()
+		if b.n < n {
+			b.acc, b.n, b.overrun = 0, 0, true
+			return 0
+		}
+	}
+	v := b.acc >> (64 - n)
+	b.acc <<= n
+	b.n -= n
+	return v
+}
+
+func (b *bitReader) signed(n uint) int64 {
+	if n == 0 {
+		return 0
+	}
+	return int64(b.read(n)<<(64-n)) >> (64 - n)
+}
+
+/*
+unary returns the number of 0 bits before the next 1 bit.
+*/
+func (b *bitReader) unary() uint64 {
+	count := uint64(0)
+	for {
+		if b.n == 0 {
+			b.fill()
+			if b.n == 0 {
+				b.overrun = true
+				return 0
+			}
+		}
+		if zeros := uint(bits.LeadingZeros64(b.acc)); zeros < b.n {
+			b.acc <<= zeros + 1
+			b.n -= zeros + 1
+			return count + uint64(zeros)
+		}
+		count += uint64(b.n)
+		b.acc, b.n = 0, 0
+	}
+}
+
+/*
+align skips to the next byte boundary.
+*/
+func (b *bitReader) align() {
+	b.read(b.n % 8)
+}
+
+/*
+pos returns the number of bytes consumed, only valid when byte aligned.
+*/
+func (b *bitReader) pos() int {
+	return b.off - int(b.n/8)
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package flac registers a pure Go FLAC decoder with the audio package, import
+it for its side effects:
+
+	import _ "goarrg.com/asset/audio/flac"
+
+All bit depths up to 32, block sizes and stereo decorrelation modes are
+supported. The MD5 signature of the decoded audio is only checked in debug
+builds.
+*/
+package flac
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"hash"
+	"io"
+
+	"goarrg.com/asset"
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+const (
+	blockStreamInfo = 0
+	blockSeekTable  = 3
+
+	seekPlaceholder = ^uint64(0)
+)
+
+func init() {
+	audio.RegisterStreamFormat("fLaC", decode, stream)
+}
+
+func decode(a *asset.File) (audio.Spec, int, []float32, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return audio.Spec{}, 0, nil, debug.ErrorWrapf(err, "Failed to decode FLAC")
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return audio.Spec{}, 0, nil, err
+	}
+	track := make([]float32, 0, max(0, d.Frames())*d.channels)
+	for {
+		samples, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return audio.Spec{}, 0, nil, err
+		}
+		track = append(track, samples...)
+	}
+	return d.Spec(), len(track), track, nil
+}
+
+func stream(a *asset.File) (audio.StreamDecoder, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode FLAC")
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+/*
+channelList maps the FLAC channel order, which is the WAV order without a
+channel mask, onto audio.Channel.
+*/
+func channelList(count int) []audio.Channel {
+	l, r, c := audio.ChannelLeft, audio.ChannelRight, audio.ChannelCenter
+	lfe := audio.ChannelLowFrequency
+	sl, sr := audio.ChannelSurroundLeft, audio.ChannelSurroundRight
+	bl, br := audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight
+	switch count {
+	case 1:
+		return audio.ChannelsMono()
+	case 2:
+		return []audio.Channel{l, r}
+	case 3:
+		return []audio.Channel{l, r, c}
+	case 4:
+		return []audio.Channel{l, r, bl, br}
+	case 5:
+		return []audio.Channel{l, r, c, sl, sr}
+	case 6:
+		return []audio.Channel{l, r, c, lfe, sl, sr}
+	case 7:
+		return []audio.Channel{l, r, c, lfe, audio.ChannelBackCenter, sl, sr}
+	case 8:
+		return []audio.Channel{l, r, c, lfe, bl, br, sl, sr}
+	}
+	channels := make([]audio.Channel, count)
+	for i := range channels {
+		channels[i] = audio.ChannelUnknown + audio.Channel(i)
+	}
+	return channels
+}
+
+/*
+SeekPoint is an entry of the seek table, Offset is the byte offset of the frame
+starting at Frame relative to the first frame.
+*/
+type SeekPoint struct {
+	Frame  int64
+	Offset int64
+	Frames int
+}
+
+/*
+Decoder decodes a FLAC stream one frame at a time.
+*/
+type Decoder struct {
+	data          []byte
+	firstFrame    int
+	off           int
+	channels      int
+	rate          int
+	bitsPerSample int
+	minBlockSize  int
+	totalFrames   int64
+	md5           [md5.Size]byte
+	seekTable     []SeekPoint
+
+	samples [][]int64
+	out     []float32
+	// pos is the frame the next call to Decode returns
+	pos int64
+	// target is the frame to seek to, frames before it are dropped
+	target int64
+	hash   hash.Hash
+}
+
+/*
+NewDecoder parses the metadata of the stream in data, which must stay valid
+while the decoder is in use.
+*/
+func NewDecoder(data []byte) (*Decoder, error) {
+	if len(data) < 4 || string(data[:4]) != "fLaC" {
+		return nil, debug.Errorf("Not a FLAC file")
+	}
+	d := &Decoder{data: data, totalFrames: -1}
+	off := 4
+	for i := 0; ; i++ {
+		if len(data) < off+4 {
+			return nil, debug.Errorf("Failed to decode FLAC: truncated metadata")
+		}
+		last := data[off]&0x80 != 0
+		t := data[off] & 0x7F
+		size := int(data[off+1])<<16 | int(data[off+2])<<8 | int(data[off+3])
+		off += 4
+		if len(data) < off+size {
+			return nil, debug.Errorf("Failed to decode FLAC: truncated metadata")
+		}
+		block := data[off : off+size]
+		off += size
+
+		switch {
+		case i == 0 && t != blockStreamInfo:
+			return nil, debug.Errorf("Failed to decode FLAC: missing STREAMINFO")
+		case t == blockStreamInfo:
+			if err := d.parseStreamInfo(block); err != nil {
+				return nil, err
+			}
+		case t == blockSeekTable:
+			d.parseSeekTable(block)
+		}
+		if last {
+			break
+		}
+	}
+	d.firstFrame, d.off = off, off
+
+	d.samples = make([][]int64, d.channels)
+	if checkMD5 && d.md5 != ([md5.Size]byte{}) {
+		d.hash = md5.New()
+	}
+	return d, nil
+}
+
+func (d *Decoder) parseStreamInfo(b []byte) error {
+	if len(b) < 34 {
+		return debug.Errorf("Failed to decode FLAC: truncated STREAMINFO")
+	}
+	be := binary.BigEndian
+	d.minBlockSize = int(be.Uint16(b))
+	v := be.Uint64(b[10:])
+	d.rate = int(v >> 44)
+	d.channels = int(v>>41&0x07) + 1
+	d.bitsPerSample = int(v>>36&0x1F) + 1
+	if total := int64(v & 0xFFFFFFFFF); total > 0 {
+		d.totalFrames = total
+	}
+	copy(d.md5[:], b[18:34])
+	if d.rate == 0 || d.bitsPerSample < 4 {
+		return debug.Errorf("Failed to decode FLAC: invalid sample rate %d or bits per sample %d", d.rate, d.bitsPerSample)
+	}
+	return nil
+}
+
+func (d *Decoder) parseSeekTable(b []byte) {
+	be := binary.BigEndian
+	for ; len(b) >= 18; b = b[18:] {
+		if frame := be.Uint64(b); frame != seekPlaceholder {
+			d.seekTable = append(d.seekTable, SeekPoint{
+				Frame:  int64(frame),
+				Offset: int64(be.Uint64(b[8:])),
+				Frames: int(be.Uint16(b[16:])),
+			})
+		}
+	}
+}
+
+func (d *Decoder) Spec() audio.Spec {
+	return audio.Spec{Channels: channelList(d.channels), Frequency: d.rate}
+}
+
+/*
+Frames returns the length of the stream in frames from STREAMINFO or -1 if it
+is unknown.
+*/
+func (d *Decoder) Frames() int {
+	return int(d.totalFrames)
+}
+
+/*
+SeekTable returns the seek points of the stream without placeholders, it must
+not be modified.
+*/
+func (d *Decoder) SeekTable() []SeekPoint {
+	return d.seekTable
+}
+
+/*
+Decode returns the interleaved samples of the next FLAC frame, the slice is
+only valid until the next call. It returns io.EOF at the end of the stream.
+*/
+func (d *Decoder) Decode() ([]float32, error) {
+	for {
+		if d.off >= len(d.data) {
+			return nil, d.finish()
+		}
+		start, n, err := d.decodeFrame()
+		if err == errNoSync && d.totalFrames >= 0 && d.pos >= d.totalFrames {
+			// trailing data such as an ID3v1 tag is ignored
+			return nil, d.finish()
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decode FLAC frame at byte %d", d.off)
+		}
+		d.pos = start + int64(n)
+		if d.pos <= d.target {
+			continue
+		}
+		skip := int(max(0, d.target-start))
+		if d.hash != nil {
+			d.hashFrame(n)
+		}
+
+		d.out = d.out[:0]
+		scale := 1 / float32(int64(1)<<(d.bitsPerSample-1))
+		for i := skip; i < n; i++ {
+			for ch := range d.samples {
+				d.out = append(d.out, float32(d.samples[ch][i])*scale)
+			}
+		}
+		return d.out, nil
+	}
+}
+
+/*
+Seek makes the next call to Decode start at frame, using the seek table to
+skip as much of the stream as possible.
+*/
+func (d *Decoder) Seek(frame int) error {
+	if frame < 0 || (d.totalFrames >= 0 && int64(frame) > d.totalFrames) {
+		return debug.Errorf("Invalid seek to frame %d", frame)
+	}
+	d.off, d.pos = d.firstFrame, 0
+	for _, p := range d.seekTable {
+		if p.Frame > int64(frame) {
+			break
+		}
+		if off := int64(d.firstFrame) + p.Offset; off < int64(len(d.data)) {
+			d.off, d.pos = int(off), p.Frame
+		}
+	}
+	d.target = int64(frame)
+	// the signature only covers decoding the whole stream in order
+	d.hash = nil
+	return nil
+}
+
+func (d *Decoder) hashFrame(n int) {
+	width := (d.bitsPerSample + 7) / 8
+	buf := make([]byte, 0, n*d.channels*width)
+	for i := 0; i < n; i++ {
+		for ch := range d.samples {
+			v := d.samples[ch][i]
+			for b := 0; b < width; b++ {
+				buf = append(buf, byte(v>>(8*b)))
+			}
+		}
+	}
+	d.hash.Write(buf)
+}
+
+/*
+finish returns io.EOF, or an error if the MD5 signature is checked and does not
+match.
+*/
+func (d *Decoder) finish() error {
+	if d.hash == nil {
+		return io.EOF
+	}
+	sum := d.hash.Sum(nil)
+	d.hash = nil
+	if !bytes.Equal(sum, d.md5[:]) {
+		return debug.Errorf("Failed to decode FLAC: MD5 mismatch, Got: %x Want: %x", sum, d.md5)
+	}
+	return io.EOF
+}
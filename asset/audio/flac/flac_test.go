@@ -0,0 +1,558 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flac
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/internal/testutil"
+)
+
+type bitWriter struct {
+	data []byte
+	n    uint
+}
+
+func (w *bitWriter) write(v uint64, count uint) {
+	for i := int(count) - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(v>>i&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+func (w *bitWriter) unary(q uint64) {
+	for ; q > 0; q-- {
+		w.write(0, 1)
+	}
+	w.write(1, 1)
+}
+
+func (w *bitWriter) align() {
+	for w.n%8 != 0 {
+		w.write(0, 1)
+	}
+}
+
+const (
+	subConstant = iota
+	subVerbatim
+	subFixed
+	subLPC
+)
+
+type testSubframe struct {
+	kind           int
+	order          int
+	coefficients   []int64
+	precision      uint
+	shift          uint
+	wasted         uint
+	partitionOrder uint
+	rice2          bool
+	// escape codes the first partition as raw signed values
+	escape bool
+}
+
+type testFrame struct {
+	number        uint64
+	blockSizeCode uint64
+	assignment    int
+	// channels holds the decorrelated channels that are coded
+	channels  [][]int64
+	subframes []testSubframe
+}
+
+func writeSubframe(w *bitWriter, samples []int64, bps uint, sf testSubframe) {
+	w.write(0, 1)
+	switch sf.kind {
+	case subConstant:
+		w.write(0, 6)
+	case subVerbatim:
+		w.write(1, 6)
+	case subFixed:
+		w.write(uint64(8+sf.order), 6)
+		sf.coefficients = fixedCoefficients[sf.order]
+	case subLPC:
+		w.write(uint64(32+sf.order-1), 6)
+	}
+	if sf.wasted > 0 {
+		w.write(1, 1)
+		w.unary(uint64(sf.wasted - 1))
+	} else {
+		w.write(0, 1)
+	}
+	bps -= sf.wasted
+	s := make([]int64, len(samples))
+	for i, v := range samples {
+		s[i] = v >> sf.wasted
+	}
+
+	switch sf.kind {
+	case subConstant:
+		w.write(uint64(s[0]), bps)
+		return
+	case subVerbatim:
+		for _, v := range s {
+			w.write(uint64(v), bps)
+		}
+		return
+	}
+	for _, v := range s[:sf.order] {
+		w.write(uint64(v), bps)
+	}
+	if sf.kind == subLPC {
+		w.write(uint64(sf.precision-1), 4)
+		w.write(uint64(sf.shift), 5)
+		for _, c := range sf.coefficients {
+			w.write(uint64(c), sf.precision)
+		}
+	}
+
+	residual := make([]int64, len(s))
+	for i := sf.order; i < len(s); i++ {
+		sum := int64(0)
+		for j, c := range sf.coefficients {
+			sum += c * s[i-1-j]
+		}
+		residual[i] = s[i] - sum>>sf.shift
+	}
+	paramBits, maxParam := uint(4), uint64(14)
+	if sf.rice2 {
+		w.write(1, 2)
+		paramBits, maxParam = 5, 30
+	} else {
+		w.write(0, 2)
+	}
+	w.write(uint64(sf.partitionOrder), 4)
+	size := len(s) >> sf.partitionOrder
+	for p := 0; p < 1<<sf.partitionOrder; p++ {
+		part := residual[max(sf.order, p*size) : (p+1)*size]
+		if sf.escape && p == 0 {
+			w.write(maxParam+1, paramBits)
+			w.write(31, 5)
+			for _, v := range part {
+				w.write(uint64(v), 31)
+			}
+			continue
+		}
+		maxU := uint64(0)
+		for _, v := range part {
+			maxU = max(maxU, uint64(v<<1^v>>63))
+		}
+		param := uint64(bits.Len64(maxU))
+		if param > maxParam {
+			panic("residual too large")
+		}
+		w.write(param, paramBits)
+		for _, v := range part {
+			u := uint64(v<<1 ^ v>>63)
+			w.unary(u >> param)
+			w.write(u, uint(param))
+		}
+	}
+}
+
+func utf8Number(v uint64) []byte {
+	if v < 0x80 {
+		return []byte{byte(v)}
+	}
+	extra := 1
+	for v >= 1<<(5*extra+6) {
+		extra++
+	}
+	out := []byte{byte(0xFF<<(7-extra)) | byte(v>>(6*extra))}
+	for i := extra - 1; i >= 0; i-- {
+		out = append(out, 0x80|byte(v>>(6*i))&0x3F)
+	}
+	return out
+}
+
+func writeFrame(f testFrame, bps uint, sizeCode uint64, variable bool) []byte {
+	w := &bitWriter{}
+	w.write(0x3FFE, 14)
+	w.write(0, 1)
+	if variable {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+	n := len(f.channels[0])
+	w.write(f.blockSizeCode, 4)
+	w.write(0, 4)
+	w.write(uint64(f.assignment), 4)
+	w.write(sizeCode, 3)
+	w.write(0, 1)
+	for _, b := range utf8Number(f.number) {
+		w.write(uint64(b), 8)
+	}
+	switch f.blockSizeCode {
+	case 6:
+		w.write(uint64(n-1), 8)
+	case 7:
+		w.write(uint64(n-1), 16)
+	}
+	w.write(uint64(crc8(w.data)), 8)
+
+	for ch, samples := range f.channels {
+		sideBits := uint(0)
+		if (f.assignment == channelsLeftSide || f.assignment == channelsMidSide) && ch == 1 ||
+			f.assignment == channelsSideRight && ch == 0 {
+			sideBits = 1
+		}
+		writeSubframe(w, samples, bps+sideBits, f.subframes[ch])
+	}
+	w.align()
+	w.write(uint64(crc16(w.data)), 16)
+	return w.data
+}
+
+/*
+decorrelate returns the channels coded for the assignment from left and right.
+*/
+func decorrelate(assignment int, left, right []int64) [][]int64 {
+	a, b := slices.Clone(left), slices.Clone(right)
+	for i := range a {
+		switch assignment {
+		case channelsLeftSide:
+			b[i] = left[i] - right[i]
+		case channelsSideRight:
+			a[i] = left[i] - right[i]
+		case channelsMidSide:
+			a[i] = (left[i] + right[i]) >> 1
+			b[i] = left[i] - right[i]
+		}
+	}
+	return [][]int64{a, b}
+}
+
+type testStream struct {
+	bps       uint
+	rate      int
+	channels  int
+	blockSize int
+	samples   [][]int64
+	frames    [][]byte
+	seekTable []SeekPoint
+}
+
+func (s *testStream) md5() [md5.Size]byte {
+	width := (int(s.bps) + 7) / 8
+	buf := []byte{}
+	for i := range s.samples[0] {
+		for ch := range s.samples {
+			for b := 0; b < width; b++ {
+				buf = append(buf, byte(s.samples[ch][i]>>(8*b)))
+			}
+		}
+	}
+	return md5.Sum(buf)
+}
+
+func (s *testStream) encode() []byte {
+	be := binary.BigEndian
+	out := []byte("fLaC")
+	info := be.AppendUint16(nil, uint16(s.blockSize))
+	info = be.AppendUint16(info, uint16(s.blockSize))
+	info = append(info, 0, 0, 0, 0, 0, 0)
+	info = be.AppendUint64(info, uint64(s.rate)<<44|uint64(s.channels-1)<<41|uint64(s.bps-1)<<36|uint64(len(s.samples[0])))
+	sum := s.md5()
+	info = append(info, sum[:]...)
+	out = append(out, blockStreamInfo, 0, 0, byte(len(info)))
+	out = append(out, info...)
+
+	// a padding block is skipped
+	out = append(out, 1, 0, 0, 3, 0, 0, 0)
+
+	table := []byte{}
+	for _, p := range s.seekTable {
+		table = be.AppendUint64(table, uint64(p.Frame))
+		table = be.AppendUint64(table, uint64(p.Offset))
+		table = be.AppendUint16(table, uint16(p.Frames))
+	}
+	table = be.AppendUint64(table, seekPlaceholder)
+	table = append(table, make([]byte, 10)...)
+	out = append(out, 0x80|blockSeekTable, 0, byte(len(table)>>8), byte(len(table)))
+	out = append(out, table...)
+
+	for _, f := range s.frames {
+		out = append(out, f...)
+	}
+	return out
+}
+
+func sine(n int, amplitude, freq float64, phase float64) []int64 {
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = int64(amplitude * math.Sin(freq*float64(i)+phase))
+	}
+	return out
+}
+
+/*
+stream16 is a 16 bit stereo stream with fixed blocking that uses every
+subframe type and stereo mode.
+*/
+func stream16() *testStream {
+	s := &testStream{bps: 16, rate: 44100, channels: 2, blockSize: 192}
+	left := sine(192*3+100, 20000, 0.05, 0)
+	right := sine(len(left), 12000, 0.031, 1)
+	for i := range right {
+		left[i] += int64(i%7) - 3
+		if i >= 384 && i < 576 {
+			// wasted bits
+			right[i] &^= 3
+		}
+	}
+	s.samples = [][]int64{left, right}
+
+	frames := []testFrame{
+		{blockSizeCode: 1, assignment: 1, subframes: []testSubframe{
+			{kind: subFixed, order: 2, partitionOrder: 2},
+			{kind: subLPC, order: 3, coefficients: []int64{1100, -600, 12}, precision: 12, shift: 9, partitionOrder: 1, escape: true},
+		}},
+		{blockSizeCode: 1, assignment: channelsLeftSide, subframes: []testSubframe{
+			{kind: subVerbatim},
+			{kind: subFixed, order: 1, rice2: true},
+		}},
+		{blockSizeCode: 1, assignment: channelsSideRight, subframes: []testSubframe{
+			{kind: subFixed, order: 4, partitionOrder: 3},
+			{kind: subVerbatim, wasted: 2},
+		}},
+		{blockSizeCode: 6, assignment: channelsMidSide, subframes: []testSubframe{
+			{kind: subLPC, order: 2, coefficients: []int64{31, -15}, precision: 6, shift: 4, partitionOrder: 2},
+			{kind: subFixed, order: 3},
+		}},
+	}
+	offset := 0
+	for i, f := range frames {
+		start := i * 192
+		end := min(start+192, len(left))
+		f.number = uint64(i)
+		f.channels = decorrelate(f.assignment, left[start:end], right[start:end])
+		if i == 2 {
+			s.seekTable = append(s.seekTable, SeekPoint{Frame: int64(start), Offset: int64(offset), Frames: 192})
+		}
+		frame := writeFrame(f, s.bps, 4, false)
+		offset += len(frame)
+		s.frames = append(s.frames, frame)
+	}
+	return s
+}
+
+/*
+stream32 is a 32 bit stereo stream with variable blocking, mid-side needs 33
+bit side samples.
+*/
+func stream32() *testStream {
+	s := &testStream{bps: 32, rate: 96000, channels: 2, blockSize: 300}
+	left := sine(4096+300, 1<<30, 0.001, 0)
+	right := sine(len(left), -(1<<31 - 1), 0.0007, 2)
+	for i := 4096; i < len(left); i++ {
+		right[i] = -123456789
+	}
+	s.samples = [][]int64{left, right}
+	s.blockSize = 300
+
+	mid := decorrelate(channelsMidSide, left[:4096], right[:4096])
+	s.frames = append(s.frames, writeFrame(testFrame{
+		number: 0, blockSizeCode: 12, assignment: channelsMidSide, channels: mid,
+		subframes: []testSubframe{
+			{kind: subLPC, order: 2, coefficients: []int64{7, -3}, precision: 4, shift: 2, partitionOrder: 4, rice2: true},
+			{kind: subVerbatim},
+		},
+	}, s.bps, 7, true))
+	s.frames = append(s.frames, writeFrame(testFrame{
+		number: 4096, blockSizeCode: 7, assignment: 1, channels: [][]int64{left[4096:], right[4096:]},
+		subframes: []testSubframe{
+			{kind: subFixed, order: 2, rice2: true, escape: true},
+			{kind: subConstant},
+		},
+	}, s.bps, 7, true))
+	return s
+}
+
+func decodeAll(t *testing.T, d *Decoder) []float32 {
+	t.Helper()
+	got := []float32{}
+	for {
+		samples, err := d.Decode()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, samples...)
+	}
+}
+
+func checkSamples(t *testing.T, name string, s *testStream, got []float32, from int) {
+	t.Helper()
+	frames := len(s.samples[0]) - from
+	if len(got) != frames*s.channels {
+		t.Fatalf("%s: got %d samples want %d", name, len(got), frames*s.channels)
+	}
+	scale := 1 / float32(int64(1)<<(s.bps-1))
+	for i := 0; i < frames; i++ {
+		for ch := range s.samples {
+			if want := float32(s.samples[ch][from+i]) * scale; got[i*s.channels+ch] != want {
+				t.Fatalf("%s: frame %d channel %d got %v want %v", name, from+i, ch, got[i*s.channels+ch], want)
+			}
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for name, s := range map[string]*testStream{"16": stream16(), "32": stream32()} {
+		d, err := NewDecoder(s.encode())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if spec := d.Spec(); spec.Frequency != s.rate || !reflect.DeepEqual(spec.Channels, audio.ChannelsStereo()) {
+			t.Errorf("%s: got spec %+v", name, spec)
+		}
+		if d.Frames() != len(s.samples[0]) {
+			t.Errorf("%s: got %d frames", name, d.Frames())
+		}
+		// the MD5 signature is checked when forced on
+		d.hash = md5.New()
+		checkSamples(t, name, s, decodeAll(t, d), 0)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	s := stream16()
+	data := s.encode()
+	d, err := NewDecoder(append(data, "TAG trailing ID3v1"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d.SeekTable(), s.seekTable) {
+		t.Errorf("got seek table %v want %v", d.SeekTable(), s.seekTable)
+	}
+	for _, frame := range []int{500, 384, 100, 0} {
+		if err := d.Seek(frame); err != nil {
+			t.Fatal(err)
+		}
+		checkSamples(t, "seek", s, decodeAll(t, d), frame)
+	}
+	if err := d.Seek(len(s.samples[0]) + 1); err == nil {
+		t.Error("seeked past the end")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := stream16()
+	data := s.encode()
+
+	d, err := NewDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.md5[0] ^= 0xFF
+	d.hash = md5.New()
+	for {
+		if _, err = d.Decode(); err != nil {
+			break
+		}
+	}
+	if err == io.EOF || !strings.Contains(err.Error(), "MD5 mismatch") {
+		t.Errorf("got %v", err)
+	}
+
+	corrupt := slices.Clone(data)
+	corrupt[len(corrupt)-10] ^= 0x10
+	if d, err = NewDecoder(corrupt); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err = d.Decode(); err != nil {
+			break
+		}
+	}
+	if err == io.EOF || !strings.Contains(err.Error(), "CRC mismatch") {
+		t.Errorf("got %v", err)
+	}
+
+	for name, data := range map[string][]byte{
+		"not flac":       []byte("RIFF"),
+		"no streaminfo":  []byte("fLaC\x81\x00\x00\x00"),
+		"truncated info": data[:20],
+	} {
+		if _, err := NewDecoder(data); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	s := stream16()
+	name := testutil.WriteFile(t, t.TempDir(), "test.flac", s.encode())
+	a, err := audio.Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.DurationSamples() != len(s.samples[0]) {
+		t.Errorf("got %d samples", a.DurationSamples())
+	}
+	if got, want := a.Track().Channel(audio.ChannelRight)[400], float32(s.samples[1][400])/32768; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	stream, err := audio.LoadStream(name, audio.StreamConfig{BufferFrames: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if err := stream.Seek(350); err != nil {
+		t.Fatal(err)
+	}
+	track, err := stream.Read(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := track.Channel(audio.ChannelRight)[50], a.Track().Channel(audio.ChannelRight)[400]; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestChannelList(t *testing.T) {
+	if got := channelList(6); !reflect.DeepEqual(got, audio.Channels5Point1()) {
+		t.Errorf("got %v", got)
+	}
+	if got := channelList(8); !reflect.DeepEqual(got, audio.Channels7Point1()) {
+		t.Errorf("got %v", got)
+	}
+	if got := channelList(7)[4]; got != audio.ChannelBackCenter {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flac
+
+import (
+	"slices"
+
+	"goarrg.com/debug"
+)
+
+const (
+	channelsLeftSide  = 8
+	channelsSideRight = 9
+	channelsMidSide   = 10
+)
+
+var errNoSync = debug.Errorf("Invalid frame sync code")
+
+var crc8Table, crc16Table = func() ([256]uint8, [256]uint16) {
+	var t8 [256]uint8
+	var t16 [256]uint16
+	for i := range t8 {
+		c8 := uint8(i)
+		c16 := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if c8&0x80 != 0 {
+				c8 = c8<<1 ^ 0x07
+			} else {
+				c8 <<= 1
+			}
+			if c16&0x8000 != 0 {
+				c16 = c16<<1 ^ 0x8005
+			} else {
+				c16 <<= 1
+			}
+		}
+		t8[i], t16[i] = c8, c16
+	}
+	return t8, t16
+}()
+
+func crc8(b []byte) uint8 {
+	crc := uint8(0)
+	for _, c := range b {
+		crc = crc8Table[crc^c]
+	}
+	return crc
+}
+
+func crc16(b []byte) uint16 {
+	crc := uint16(0)
+	for _, c := range b {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^c]
+	}
+	return crc
+}
+
+var fixedCoefficients = [5][]int64{
+	{},
+	{1},
+	{2, -1},
+	{3, -3, 1},
+	{4, -6, 4, -1},
+}
+
+var sampleSizes = [8]int{0, 8, 12, 0, 16, 20, 24, 32}
+
+/*
+decodeFrame decodes the frame at d.off into d.samples and returns its first
+frame number in the stream and its block size.
+*/
+func (d *Decoder) decodeFrame() (int64, int, error) {
+	data := d.data[d.off:]
+	if len(data) < 2 || data[0] != 0xFF || data[1]&0xFE != 0xF8 {
+		return 0, 0, errNoSync
+	}
+	variable := data[1]&1 == 1
+	br := &bitReader{data: data}
+	br.read(16)
+	blockSizeCode := br.read(4)
+	rateCode := br.read(4)
+	assignment := int(br.read(4))
+	sizeCode := br.read(3)
+	br.read(1)
+
+	// the frame or sample number is coded like UTF-8 but up to 36 bits
+	number := br.read(8)
+	if extra := 0; number >= 0xC0 {
+		for mask := uint64(0x40); number&mask != 0 && mask > 0; mask >>= 1 {
+			extra++
+		}
+		if extra > 6 {
+			return 0, 0, debug.Errorf("Invalid frame number")
+		}
+		number &= 0x3F >> extra
+		for i := 0; i < extra; i++ {
+			c := br.read(8)
+			if c&0xC0 != 0x80 {
+				return 0, 0, debug.Errorf("Invalid frame number")
+			}
+			number = number<<6 | c&0x3F
+		}
+	} else if number >= 0x80 {
+		return 0, 0, debug.Errorf("Invalid frame number")
+	}
+
+	var n int
+	switch {
+	case blockSizeCode == 0:
+		return 0, 0, debug.Errorf("Invalid block size")
+	case blockSizeCode == 1:
+		n = 192
+	case blockSizeCode <= 5:
+		n = 576 << (blockSizeCode - 2)
+	case blockSizeCode == 6:
+		n = int(br.read(8)) + 1
+	case blockSizeCode == 7:
+		n = int(br.read(16)) + 1
+	default:
+		n = 256 << (blockSizeCode - 8)
+	}
+	switch rateCode {
+	case 12:
+		br.read(8)
+	case 13, 14:
+		br.read(16)
+	case 15:
+		return 0, 0, debug.Errorf("Invalid sample rate")
+	}
+	if br.overrun {
+		return 0, 0, debug.Errorf("Truncated frame header")
+	}
+	if crc := uint8(br.read(8)); crc != crc8(data[:br.pos()-1]) {
+		return 0, 0, debug.Errorf("Frame header CRC mismatch")
+	}
+
+	bps := d.bitsPerSample
+	if sizeCode != 0 {
+		bps = sampleSizes[sizeCode]
+		if bps == 0 {
+			return 0, 0, debug.Errorf("Invalid sample size")
+		}
+	}
+	if bps != d.bitsPerSample {
+		return 0, 0, debug.Errorf("Unsupported sample size change from %d to %d", d.bitsPerSample, bps)
+	}
+	channels := assignment + 1
+	if assignment >= channelsLeftSide {
+		if assignment > channelsMidSide {
+			return 0, 0, debug.Errorf("Invalid channel assignment %d", assignment)
+		}
+		channels = 2
+	}
+	if channels != d.channels {
+		return 0, 0, debug.Errorf("Unsupported channel count change from %d to %d", d.channels, channels)
+	}
+
+	for ch := range d.samples {
+		// the side channel needs an extra bit
+		sideBits := 0
+		if (assignment == channelsLeftSide || assignment == channelsMidSide) && ch == 1 ||
+			assignment == channelsSideRight && ch == 0 {
+			sideBits = 1
+		}
+		d.samples[ch] = slices.Grow(d.samples[ch][:0], n)[:n]
+		if err := decodeSubframe(br, d.samples[ch], uint(bps+sideBits)); err != nil {
+			return 0, 0, debug.ErrorWrapf(err, "Invalid subframe %d", ch)
+		}
+	}
+	br.align()
+	if br.overrun {
+		return 0, 0, debug.Errorf("Truncated frame")
+	}
+	end := br.pos()
+	if crc := uint16(br.read(16)); br.overrun || crc != crc16(data[:end]) {
+		return 0, 0, debug.Errorf("Frame CRC mismatch")
+	}
+	d.off += end + 2
+
+	switch assignment {
+	case channelsLeftSide:
+		for i, side := range d.samples[1] {
+			d.samples[1][i] = d.samples[0][i] - side
+		}
+	case channelsSideRight:
+		for i, side := range d.samples[0] {
+			d.samples[0][i] = side + d.samples[1][i]
+		}
+	case channelsMidSide:
+		for i, side := range d.samples[1] {
+			mid := d.samples[0][i]<<1 | side&1
+			d.samples[0][i] = (mid + side) >> 1
+			d.samples[1][i] = (mid - side) >> 1
+		}
+	}
+
+	if variable {
+		return int64(number), n, nil
+	}
+	return int64(number) * int64(d.minBlockSize), n, nil
+}
+
+func decodeSubframe(br *bitReader, out []int64, bps uint) error {
+	if br.read(1) != 0 {
+		return debug.Errorf("Invalid padding")
+	}
+	t := br.read(6)
+	wasted := uint(0)
+	if br.read(1) == 1 {
+		wasted = uint(br.unary()) + 1
+		if wasted >= bps {
+			return debug.Errorf("Invalid wasted bits %d", wasted)
+		}
+		bps -= wasted
+	}
+
+	switch {
+	case t == 0:
+		v := br.signed(bps)
+		for i := range out {
+			out[i] = v
+		}
+	case t == 1:
+		for i := range out {
+			out[i] = br.signed(bps)
+		}
+	case t >= 8 && t <= 12:
+		order := int(t - 8)
+		if order > len(out) {
+			return debug.Errorf("Invalid predictor order %d", order)
+		}
+		for i := 0; i < order; i++ {
+			out[i] = br.signed(bps)
+		}
+		if err := decodePredicted(br, out, order, fixedCoefficients[order], 0); err != nil {
+			return err
+		}
+	case t >= 32:
+		order := int(t-32) + 1
+		if order > len(out) {
+			return debug.Errorf("Invalid predictor order %d", order)
+		}
+		for i := 0; i < order; i++ {
+			out[i] = br.signed(bps)
+		}
+		precision := uint(br.read(4)) + 1
+		if precision == 16 {
+			return debug.Errorf("Invalid LPC precision")
+		}
+		shift := br.signed(5)
+		if shift < 0 {
+			return debug.Errorf("Invalid LPC shift %d", shift)
+		}
+		coefficients := make([]int64, order)
+		for i := range coefficients {
+			coefficients[i] = br.signed(precision)
+		}
+		if err := decodePredicted(br, out, order, coefficients, uint(shift)); err != nil {
+			return err
+		}
+	default:
+		return debug.Errorf("Invalid subframe type %d", t)
+	}
+	if br.overrun {
+		return debug.Errorf("Truncated subframe")
+	}
+
+	if wasted > 0 {
+		for i := range out {
+			out[i] <<= wasted
+		}
+	}
+	return nil
+}
+
+/*
+decodePredicted decodes the residual of a fixed or LPC subframe after its
+warm-up samples and restores the samples.
+*/
+func decodePredicted(br *bitReader, out []int64, order int, coefficients []int64, shift uint) error {
+	if err := decodeResidual(br, out, order); err != nil {
+		return err
+	}
+	for i := order; i < len(out); i++ {
+		sum := int64(0)
+		for j, c := range coefficients {
+			sum += c * out[i-1-j]
+		}
+		out[i] += sum >> shift
+	}
+	return nil
+}
+
+/*
+decodeResidual reads the rice coded residual into out[order:].
+*/
+func decodeResidual(br *bitReader, out []int64, order int) error {
+	method := br.read(2)
+	if method > 1 {
+		return debug.Errorf("Invalid residual coding method %d", method)
+	}
+	paramBits, escape := uint(4), uint64(15)
+	if method == 1 {
+		paramBits, escape = 5, 31
+	}
+	partitionOrder := uint(br.read(4))
+	partitionSize := len(out) >> partitionOrder
+	if partitionSize<<partitionOrder != len(out) || partitionSize < order {
+		return debug.Errorf("Invalid partition order %d", partitionOrder)
+	}
+
+	i := order
+	for p := 0; p < 1<<partitionOrder; p++ {
+		end := (p + 1) * partitionSize
+		param := br.read(paramBits)
+		if param == escape {
+			bits := uint(br.read(5))
+			for ; i < end; i++ {
+				out[i] = br.signed(bits)
+			}
+			continue
+		}
+		for ; i < end; i++ {
+			v := br.unary()<<param | br.read(uint(param))
+			out[i] = int64(v>>1) ^ -int64(v&1)
+		}
+		if br.overrun {
+			return debug.Errorf("Truncated residual")
+		}
+	}
+	return nil
+}
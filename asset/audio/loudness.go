@@ -0,0 +1,155 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"math"
+)
+
+const (
+	// loudnessStep is the spacing of the 400ms gating blocks in seconds,
+	// loudnessSteps the number of steps in a block
+	loudnessStep  = 0.1
+	loudnessSteps = 4
+	// absoluteGate in LUFS and relativeGate in LU below the ungated loudness
+	absoluteGate = -70
+	relativeGate = -10
+)
+
+/*
+kFilter is the K-weighting of ITU-R BS.1770, a high shelf modelling the head
+followed by a high pass, as a cascade of two biquads.
+*/
+type kFilter struct {
+	b, a [2][3]float64
+	z    [2][2]float64
+}
+
+func newKFilter(frequency int) kFilter {
+	fs := float64(frequency)
+	var f kFilter
+
+	// the coefficients are derived for any rate from the 48kHz ones the
+	// standard gives, as in libebur128
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	f.b[0] = [3]float64{(vh + vb*k/q + k*k) / a0, 2 * (k*k - vh) / a0, (vh - vb*k/q + k*k) / a0}
+	f.a[0] = [3]float64{1, 2 * (k*k - 1) / a0, (1 - k/q + k*k) / a0}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	f.b[1] = [3]float64{1, -2, 1}
+	f.a[1] = [3]float64{1, 2 * (k*k - 1) / a0, (1 - k/q + k*k) / a0}
+	return f
+}
+
+func (f *kFilter) process(x float64) float64 {
+	for i := range f.b {
+		b, a, z := &f.b[i], &f.a[i], &f.z[i]
+		y := b[0]*x + z[0]
+		z[0] = b[1]*x - a[1]*y + z[1]
+		z[1] = b[2]*x - a[2]*y
+		x = y
+	}
+	return x
+}
+
+/*
+channelWeight returns the BS.1770 weight of c, surrounds count 1.5dB more and
+the LFE is left out. Channels without a position count like front channels.
+*/
+func channelWeight(c Channel) float64 {
+	switch c {
+	case ChannelLowFrequency:
+		return 0
+	case ChannelSurroundLeft, ChannelSurroundRight, ChannelBackSurroundLeft, ChannelBackSurroundRight:
+		return 1.41
+	}
+	return 1
+}
+
+/*
+Loudness returns the integrated loudness of t, at frequency, in LUFS as
+defined by ITU-R BS.1770-4 and EBU R 128. Tracks shorter than the 400ms
+gating block are measured as a single block, silence is -Inf.
+*/
+func Loudness(t Track, frequency int) float64 {
+	frames := t.Frames()
+	if frames == 0 || frequency <= 0 {
+		return math.Inf(-1)
+	}
+	step := max(1, int(loudnessStep*float64(frequency)+0.5))
+
+	// the weighted sum of squares of every step, the frames after the last
+	// whole step only count for tracks shorter than a block
+	steps := make([]float64, frames/step)
+	total := 0.0
+	for i, c := range t.Channels() {
+		w := channelWeight(c)
+		if w == 0 {
+			continue
+		}
+		f := newKFilter(frequency)
+		for j, s := range t.Samples()[i] {
+			y := f.process(float64(s))
+			p := w * y * y
+			total += p
+			if j/step < len(steps) {
+				steps[j/step] += p
+			}
+		}
+	}
+
+	var blocks []float64
+	for i := 0; i+loudnessSteps <= len(steps); i++ {
+		sum := 0.0
+		for _, s := range steps[i : i+loudnessSteps] {
+			sum += s
+		}
+		blocks = append(blocks, sum/float64(loudnessSteps*step))
+	}
+	if len(blocks) == 0 {
+		blocks = append(blocks, total/float64(frames))
+	}
+
+	gated := func(threshold float64) float64 {
+		sum, n := 0.0, 0
+		for _, b := range blocks {
+			if lufs(b) > threshold {
+				sum += b
+				n++
+			}
+		}
+		if n == 0 {
+			return 0
+		}
+		return sum / float64(n)
+	}
+	ungated := gated(absoluteGate)
+	if ungated == 0 {
+		return math.Inf(-1)
+	}
+	return lufs(gated(lufs(ungated) + relativeGate))
+}
+
+func lufs(meanSquare float64) float64 {
+	return -0.691 + 10*math.Log10(meanSquare)
+}
@@ -0,0 +1,91 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"goarrg.com/asset"
+)
+
+func sineTrack(channels []Channel, frequency, frames int, amplitude float64) Track {
+	t := NewTrack(channels, frames)
+	for _, s := range t.Samples() {
+		for i := range s {
+			s[i] = float32(amplitude * math.Sin(2*math.Pi*1000*float64(i)/float64(frequency)))
+		}
+	}
+	return t
+}
+
+func TestLoudness(t *testing.T) {
+	// EBU Tech 3341: a stereo 1kHz sine at -23dBFS is -23 LUFS
+	amplitude := math.Pow(10, -23.0/20)
+	for _, frequency := range []int{44100, 48000} {
+		if l := Loudness(sineTrack(ChannelsStereo(), frequency, 5*frequency, amplitude), frequency); math.Abs(l+23) > 0.1 {
+			t.Errorf("%dHz: got %f LUFS", frequency, l)
+		}
+	}
+
+	// silence is gated away
+	track := sineTrack(ChannelsStereo(), 48000, 10*48000, amplitude)
+	for _, s := range track.Samples() {
+		clear(s[:5*48000])
+	}
+	if l := Loudness(track, 48000); math.Abs(l+23) > 0.2 {
+		t.Errorf("half silent: got %f LUFS", l)
+	}
+	if l := Loudness(sineTrack(ChannelsStereo(), 48000, 4800, amplitude), 48000); math.Abs(l+23) > 0.2 {
+		t.Errorf("short: got %f LUFS", l)
+	}
+	// the LFE does not count
+	if l := Loudness(sineTrack([]Channel{ChannelLowFrequency}, 48000, 48000, 1), 48000); !math.IsInf(l, -1) {
+		t.Errorf("LFE: got %f LUFS", l)
+	}
+	if l := Loudness(NewTrack(ChannelsStereo(), 48000), 48000); !math.IsInf(l, -1) {
+		t.Errorf("silence: got %f LUFS", l)
+	}
+}
+
+func TestReadMetadata(t *testing.T) {
+	f := &format{metadata: func(*asset.File) (Metadata, error) {
+		return Metadata{
+			LoopStart: 10, LoopEnd: 200,
+			Markers: []Marker{{Frame: 50, Label: "b"}, {Frame: -1}, {Frame: 5, Label: "a"}, {Frame: 101}},
+		}, nil
+	}}
+	m, err := readMetadata(f, nil, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Metadata{LoopStart: 10, LoopEnd: 100, Markers: []Marker{{Frame: 5, Label: "a"}, {Frame: 50, Label: "b"}}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got %+v want %+v", m, want)
+	}
+	// the loop is clamped away and markers are kept for unknown lengths
+	if m, _ = readMetadata(f, nil, 5); m.HasLoop() || len(m.Markers) != 1 {
+		t.Errorf("got %+v", m)
+	}
+	if m, _ = readMetadata(f, nil, -1); m.LoopEnd != 200 || len(m.Markers) != 3 {
+		t.Errorf("got %+v", m)
+	}
+}
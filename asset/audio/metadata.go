@@ -0,0 +1,123 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"bytes"
+	"slices"
+
+	"goarrg.com/asset"
+)
+
+/*
+Marker is a labeled position in a track, such as a cue point.
+*/
+type Marker struct {
+	Frame int
+	// Length is the number of frames the marker spans, 0 for a point.
+	Length int
+	Label  string
+}
+
+/*
+Metadata is optional information about audio besides its samples.
+*/
+type Metadata struct {
+	// LoopStart and LoopEnd are the frames a loop starts at and ends before,
+	// LoopEnd is 0 if there is no loop.
+	LoopStart int
+	LoopEnd   int
+	// Markers sorted by Frame.
+	Markers []Marker
+	// Loudness is the integrated loudness in LUFS, see Loudness. It is 0 if
+	// it is unknown, which is the case for streams.
+	Loudness float64
+}
+
+/*
+MetadataProvider is implemented by Assets and StreamAssets with metadata, which
+includes those returned by Load and LoadStream.
+*/
+type MetadataProvider interface {
+	Metadata() Metadata
+}
+
+/*
+MetadataOf returns the metadata of a, an Asset or StreamAsset, or the zero
+Metadata if it has none.
+*/
+func MetadataOf(a any) Metadata {
+	if p, ok := a.(MetadataProvider); ok {
+		return p.Metadata()
+	}
+	return Metadata{}
+}
+
+/*
+HasLoop returns whether the metadata has a loop.
+*/
+func (m Metadata) HasLoop() bool {
+	return m.LoopEnd > 0
+}
+
+/*
+RegisterMetadata makes Load and LoadStream read metadata with parse for files
+of the format registered with magic, which must be registered first. Parsers
+should skip metadata they can not make sense of rather than fail.
+*/
+func RegisterMetadata(magic string, parse func(*asset.File) (Metadata, error)) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	f, _ := formats.Load().([]format)
+	f = slices.Clone(f)
+	found := false
+	for i := range f {
+		if bytes.Equal(f[i].magic, []byte(magic)) {
+			f[i].metadata = parse
+			found = true
+		}
+	}
+	if !found {
+		panic("audio: no format registered with magic " + magic)
+	}
+	formats.Store(f)
+}
+
+/*
+readMetadata returns the metadata of a in format f for a track of frames
+frames, or of unknown length if frames < 0.
+*/
+func readMetadata(f *format, a *asset.File, frames int) (Metadata, error) {
+	if f.metadata == nil {
+		return Metadata{}, nil
+	}
+	m, err := f.metadata(a)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if frames >= 0 {
+		m.LoopEnd = min(m.LoopEnd, frames)
+	}
+	if m.LoopStart < 0 || m.LoopStart >= m.LoopEnd {
+		m.LoopStart, m.LoopEnd = 0, 0
+	}
+	m.Markers = slices.DeleteFunc(slices.Clone(m.Markers), func(mk Marker) bool {
+		return mk.Frame < 0 || (frames >= 0 && mk.Frame > frames)
+	})
+	slices.SortStableFunc(m.Markers, func(a, b Marker) int { return a.Frame - b.Frame })
+	return m, nil
+}
@@ -0,0 +1,350 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package remix converts audio between channel layouts with matrices, either the
+ITU-R BS.775 down-mix and up-mix built by New or custom ones.
+
+A layout with a left but no right channel is mono, as with audio.ChannelsMono.
+Channels beyond audio.ChannelCount, such as platform specific ones, only map to
+themselves.
+*/
+package remix
+
+import (
+	"math"
+	"slices"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+type LFEMode int
+
+const (
+	// LFEDrop drops the LFE channel if the output has none, as BS.775 does.
+	LFEDrop LFEMode = iota
+	// LFEMix mixes the LFE channel into the front channels if the output has
+	// none.
+	LFEMix
+)
+
+type Config struct {
+	LFE LFEMode
+	// LFEGain is the gain LFEMix mixes the LFE channel with. Defaults to √½ if
+	// <= 0.
+	LFEGain float32
+	// Normalize scales the matrix down so no output channel can exceed the
+	// loudest input channel, trading level for headroom.
+	Normalize bool
+}
+
+/*
+Matrix mixes the In channels into the Out channels, Gains[o][i] is the gain of
+In[i] in Out[o].
+*/
+type Matrix struct {
+	In    []audio.Channel
+	Out   []audio.Channel
+	Gains [][]float32
+}
+
+/*
+Custom returns a matrix with gains, which must have a row of len(in) gains for
+every channel in out.
+*/
+func Custom(in, out []audio.Channel, gains [][]float32) (Matrix, error) {
+	if len(in) == 0 || len(out) == 0 {
+		return Matrix{}, debug.Errorf("Invalid matrix from %v to %v", in, out)
+	}
+	for i, c := range out {
+		if slices.Index(out, c) != i {
+			return Matrix{}, debug.Errorf("Invalid matrix: duplicate output channel %v", c)
+		}
+	}
+	if len(gains) != len(out) {
+		return Matrix{}, debug.Errorf("Invalid matrix: got %d rows for %d output channels", len(gains), len(out))
+	}
+	m := Matrix{In: slices.Clone(in), Out: slices.Clone(out), Gains: make([][]float32, len(out))}
+	for o, row := range gains {
+		if len(row) != len(in) {
+			return Matrix{}, debug.Errorf("Invalid matrix: got %d gains for %d input channels in row %d", len(row), len(in), o)
+		}
+		m.Gains[o] = slices.Clone(row)
+	}
+	return m, nil
+}
+
+/*
+New returns the BS.775 matrix from in to out. Channels present in both pass
+through, missing ones are folded into their neighbours at -3dB, the centre at
+-3dB into left and right and the surrounds at -6dB into mono. Up-mixing
+synthesises nothing, apart from mono going to the centre if the output has one
+and to left and right at -3dB otherwise.
+*/
+func New(in, out []audio.Channel, cfg Config) Matrix {
+	if cfg.LFEGain <= 0 {
+		cfg.LFEGain = math.Sqrt2 / 2
+	}
+	const half = math.Sqrt2 / 2
+
+	m := Matrix{In: slices.Clone(in), Out: slices.Clone(out), Gains: make([][]float32, len(out))}
+	for o := range m.Gains {
+		m.Gains[o] = make([]float32, len(in))
+	}
+	index := func(c audio.Channel) int {
+		return slices.Index(out, c)
+	}
+	has := func(layout []audio.Channel, c audio.Channel) bool {
+		return slices.Contains(layout, c)
+	}
+	l, r, center := index(audio.ChannelLeft), index(audio.ChannelRight), index(audio.ChannelCenter)
+	monoIn := has(in, audio.ChannelLeft) && !has(in, audio.ChannelRight)
+	monoOut := l >= 0 && r < 0
+
+	for i, c := range in {
+		add := func(o int, gain float32) {
+			if o >= 0 {
+				m.Gains[o][i] += gain
+			}
+		}
+
+		if monoIn && c == audio.ChannelLeft {
+			switch {
+			case monoOut:
+				add(l, 1)
+			case center >= 0:
+				add(center, 1)
+			default:
+				add(l, half)
+				add(r, half)
+			}
+			continue
+		}
+		if o := index(c); o >= 0 && !(monoOut && c == audio.ChannelLeft) {
+			add(o, 1)
+			continue
+		}
+
+		switch c {
+		case audio.ChannelLeft, audio.ChannelRight:
+			if monoOut {
+				add(l, half)
+			}
+		case audio.ChannelCenter:
+			if monoOut {
+				add(l, 1)
+			} else {
+				add(l, half)
+				add(r, half)
+			}
+		case audio.ChannelSurroundLeft, audio.ChannelSurroundRight, audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight:
+			if monoOut {
+				add(l, 0.5)
+				continue
+			}
+			// side and back surrounds stand in for each other before folding
+			// into the front
+			other, front := surroundPair(c), l
+			if c == audio.ChannelSurroundRight || c == audio.ChannelBackSurroundRight {
+				front = r
+			}
+			if o := index(other); o >= 0 {
+				if has(in, other) {
+					add(o, half)
+				} else {
+					add(o, 1)
+				}
+			} else {
+				add(front, half)
+			}
+		case audio.ChannelLowFrequency:
+			if cfg.LFE != LFEMix {
+				continue
+			}
+			if monoOut {
+				add(l, cfg.LFEGain)
+			} else {
+				add(l, cfg.LFEGain)
+				add(r, cfg.LFEGain)
+			}
+		}
+	}
+
+	if cfg.Normalize {
+		m.normalize()
+	}
+	return m
+}
+
+func surroundPair(c audio.Channel) audio.Channel {
+	switch c {
+	case audio.ChannelSurroundLeft:
+		return audio.ChannelBackSurroundLeft
+	case audio.ChannelSurroundRight:
+		return audio.ChannelBackSurroundRight
+	case audio.ChannelBackSurroundLeft:
+		return audio.ChannelSurroundLeft
+	default:
+		return audio.ChannelSurroundRight
+	}
+}
+
+/*
+normalize scales all gains by the largest sum of gains of an output channel if
+it is above 1, keeping the balance between channels.
+*/
+func (m *Matrix) normalize() {
+	peak := float32(0)
+	for _, row := range m.Gains {
+		sum := float32(0)
+		for _, g := range row {
+			sum += float32(math.Abs(float64(g)))
+		}
+		peak = max(peak, sum)
+	}
+	if peak <= 1 {
+		return
+	}
+	for _, row := range m.Gains {
+		for i := range row {
+			row[i] /= peak
+		}
+	}
+}
+
+/*
+Apply mixes frames of src, a slice per channel of In, into dst, a slice per
+channel of Out, overwriting dst.
+*/
+func (m *Matrix) Apply(dst, src [][]float32, frames int) {
+	for o, row := range m.Gains {
+		d := dst[o][:frames]
+		clear(d)
+		for i, g := range row {
+			if g == 0 {
+				continue
+			}
+			for f, s := range src[i][:frames] {
+				d[f] += s * g
+			}
+		}
+	}
+}
+
+/*
+Track returns t, which holds the In channels, remixed to the Out channels.
+Missing input channels are silent.
+*/
+func (m *Matrix) Track(t audio.Track) audio.Track {
+	frames := t.Frames()
+	silence := []float32(nil)
+	src := make([][]float32, len(m.In))
+	for i, c := range m.In {
+		src[i] = t.Channel(c)
+		if src[i] == nil {
+			if silence == nil {
+				silence = make([]float32, frames)
+			}
+			src[i] = silence
+		}
+	}
+	result := audio.NewTrack(m.Out, frames)
+	m.Apply(result.Samples(), src, frames)
+	return result
+}
+
+type assetImpl struct {
+	spec     audio.Spec
+	track    audio.Track
+	frames   int
+	metadata audio.Metadata
+}
+
+/*
+Asset returns a remixed to the out layout with the BS.775 matrix, or a itself
+if it already is in that layout.
+*/
+func Asset(a audio.Asset, out []audio.Channel, cfg Config) audio.Asset {
+	spec := a.Spec()
+	if slices.Equal(spec.Channels, out) {
+		return a
+	}
+	m := New(spec.Channels, out, cfg)
+	spec.Channels = slices.Clone(out)
+	r := &assetImpl{spec: spec, track: m.Track(a.Track()), frames: a.DurationSamples(), metadata: audio.MetadataOf(a)}
+	if r.metadata.Loudness != 0 {
+		r.metadata.Loudness = audio.Loudness(r.track, spec.Frequency)
+	}
+	return r
+}
+
+func (a *assetImpl) Track() audio.Track {
+	return a.track
+}
+
+func (a *assetImpl) Spec() audio.Spec {
+	return a.spec
+}
+
+func (a *assetImpl) DurationSeconds() float64 {
+	return float64(a.frames) / float64(a.spec.Frequency)
+}
+
+func (a *assetImpl) DurationSamples() int {
+	return a.frames
+}
+
+func (a *assetImpl) Metadata() audio.Metadata {
+	return a.metadata
+}
+
+type streamImpl struct {
+	audio.StreamAsset
+	matrix Matrix
+	spec   audio.Spec
+}
+
+/*
+Stream returns s remixed to the out layout with the BS.775 matrix as it is
+read, or s itself if it already is in that layout. Closing the returned stream
+closes s.
+*/
+func Stream(s audio.StreamAsset, out []audio.Channel, cfg Config) audio.StreamAsset {
+	spec := s.Spec()
+	if slices.Equal(spec.Channels, out) {
+		return s
+	}
+	m := New(spec.Channels, out, cfg)
+	spec.Channels = slices.Clone(out)
+	return &streamImpl{StreamAsset: s, matrix: m, spec: spec}
+}
+
+func (s *streamImpl) Spec() audio.Spec {
+	return s.spec
+}
+
+func (s *streamImpl) Metadata() audio.Metadata {
+	return audio.MetadataOf(s.StreamAsset)
+}
+
+func (s *streamImpl) Read(frames int) (audio.Track, error) {
+	t, err := s.StreamAsset.Read(frames)
+	if err != nil {
+		return audio.Track{}, err
+	}
+	return s.matrix.Track(t), nil
+}
@@ -0,0 +1,195 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remix
+
+import (
+	"io"
+	"math"
+	"testing"
+
+	"goarrg.com/asset/audio"
+)
+
+const h = math.Sqrt2 / 2
+
+func quad() []audio.Channel {
+	return []audio.Channel{audio.ChannelLeft, audio.ChannelRight, audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight}
+}
+
+func expectGains(t *testing.T, name string, m Matrix, want [][]float32) {
+	t.Helper()
+	if len(m.Gains) != len(want) {
+		t.Fatalf("%s: got %v want %v", name, m.Gains, want)
+	}
+	for o := range want {
+		if len(m.Gains[o]) != len(want[o]) {
+			t.Fatalf("%s: got %v want %v", name, m.Gains, want)
+		}
+		for i := range want[o] {
+			if math.Abs(float64(m.Gains[o][i]-want[o][i])) > 1e-6 {
+				t.Fatalf("%s: got %v want %v", name, m.Gains, want)
+			}
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	mono, stereo, surround, surround7 := audio.ChannelsMono(), audio.ChannelsStereo(), audio.Channels5Point1(), audio.Channels7Point1()
+	for _, tc := range []struct {
+		name    string
+		in, out []audio.Channel
+		cfg     Config
+		want    [][]float32
+	}{
+		{"mono to stereo", mono, stereo, Config{}, [][]float32{{h}, {h}}},
+		{"mono to 5.1", mono, surround, Config{}, [][]float32{{0}, {0}, {1}, {0}, {0}, {0}}},
+		{"stereo to mono", stereo, mono, Config{}, [][]float32{{h, h}}},
+		{"stereo to 5.1", stereo, surround, Config{}, [][]float32{{1, 0}, {0, 1}, {0, 0}, {0, 0}, {0, 0}, {0, 0}}},
+		{"5.1 to stereo", surround, stereo, Config{}, [][]float32{
+			{1, 0, h, 0, h, 0},
+			{0, 1, h, 0, 0, h},
+		}},
+		{"5.1 to mono", surround, mono, Config{}, [][]float32{{h, h, 1, 0, 0.5, 0.5}}},
+		{"5.1 to stereo with LFE", surround, stereo, Config{LFE: LFEMix, LFEGain: 0.5}, [][]float32{
+			{1, 0, h, 0.5, h, 0},
+			{0, 1, h, 0.5, 0, h},
+		}},
+		{"5.1 to quad", surround, quad(), Config{}, [][]float32{
+			{1, 0, h, 0, 0, 0},
+			{0, 1, h, 0, 0, 0},
+			{0, 0, 0, 0, 1, 0},
+			{0, 0, 0, 0, 0, 1},
+		}},
+		{"7.1 to 5.1", surround7, surround, Config{}, [][]float32{
+			{1, 0, 0, 0, 0, 0, 0, 0},
+			{0, 1, 0, 0, 0, 0, 0, 0},
+			{0, 0, 1, 0, 0, 0, 0, 0},
+			{0, 0, 0, 1, 0, 0, 0, 0},
+			{0, 0, 0, 0, h, 0, 1, 0},
+			{0, 0, 0, 0, 0, h, 0, 1},
+		}},
+		{"5.1 to stereo normalized", surround, stereo, Config{Normalize: true}, [][]float32{
+			{1 / (1 + 2*h), 0, h / (1 + 2*h), 0, h / (1 + 2*h), 0},
+			{0, 1 / (1 + 2*h), h / (1 + 2*h), 0, 0, h / (1 + 2*h)},
+		}},
+		{"user channel", []audio.Channel{audio.ChannelLeft, audio.ChannelRight, audio.ChannelCount}, append(stereo, audio.ChannelCount), Config{}, [][]float32{
+			{1, 0, 0},
+			{0, 1, 0},
+			{0, 0, 1},
+		}},
+	} {
+		expectGains(t, tc.name, New(tc.in, tc.out, tc.cfg), tc.want)
+	}
+}
+
+func TestCustom(t *testing.T) {
+	stereo := audio.ChannelsStereo()
+	m, err := Custom(stereo, stereo, [][]float32{{0, 1}, {1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.Track(audio.TrackOf(stereo, [][]float32{{1, 2}, {3, 4}}))
+	if got.Channel(audio.ChannelLeft)[1] != 4 || got.Channel(audio.ChannelRight)[0] != 1 {
+		t.Errorf("channels were not swapped: %v", got)
+	}
+
+	for _, tc := range []struct {
+		in, out []audio.Channel
+		gains   [][]float32
+	}{
+		{nil, stereo, [][]float32{{}, {}}},
+		{stereo, stereo, [][]float32{{1, 0}}},
+		{stereo, stereo, [][]float32{{1, 0}, {1}}},
+		{stereo, []audio.Channel{audio.ChannelLeft, audio.ChannelLeft}, [][]float32{{1, 0}, {0, 1}}},
+	} {
+		if _, err := Custom(tc.in, tc.out, tc.gains); err == nil {
+			t.Errorf("expected an error for %v to %v with %v", tc.in, tc.out, tc.gains)
+		}
+	}
+}
+
+type testAsset struct {
+	spec  audio.Spec
+	track audio.Track
+}
+
+func (a *testAsset) Track() audio.Track   { return a.track }
+func (a *testAsset) Spec() audio.Spec     { return a.spec }
+func (a *testAsset) DurationSamples() int { return len(a.track.Channel(a.spec.Channels[0])) }
+func (a *testAsset) DurationSeconds() float64 {
+	return float64(a.DurationSamples()) / float64(a.spec.Frequency)
+}
+
+type testStream struct {
+	audio.StreamAsset
+	track audio.Track
+	read  bool
+}
+
+func (s *testStream) Spec() audio.Spec {
+	return audio.Spec{Channels: audio.Channels5Point1(), Frequency: 100}
+}
+
+func (s *testStream) Read(frames int) (audio.Track, error) {
+	if s.read {
+		return audio.Track{}, io.EOF
+	}
+	s.read = true
+	return s.track, nil
+}
+
+func TestConvert(t *testing.T) {
+	// a missing channel is silent
+	track := audio.TrackOf(audio.Channels5Point1()[:5], [][]float32{{1, 0}, {0, 1}, {1, 1}, {1, 1}, {0, 0}})
+	a := Asset(&testAsset{audio.Spec{Channels: audio.Channels5Point1(), Frequency: 100}, track}, audio.ChannelsStereo(), Config{})
+	if a.DurationSamples() != 2 || a.Spec().Frequency != 100 || len(a.Spec().Channels) != 2 {
+		t.Fatalf("got spec %+v with %d frames", a.Spec(), a.DurationSamples())
+	}
+	want := audio.TrackOf(audio.ChannelsStereo(), [][]float32{{1 + h, h}, {h, 1 + h}})
+	for _, c := range a.Spec().Channels {
+		for i := range want.Channel(c) {
+			if math.Abs(float64(a.Track().Channel(c)[i]-want.Channel(c)[i])) > 1e-6 {
+				t.Fatalf("asset: got %v want %v", a.Track(), want)
+			}
+		}
+	}
+	if b := Asset(a, audio.ChannelsStereo(), Config{}); b != a {
+		t.Error("asset in the layout was remixed")
+	}
+
+	s := Stream(&testStream{track: track}, audio.ChannelsStereo(), Config{})
+	if len(s.Spec().Channels) != 2 {
+		t.Fatalf("got stream spec %+v", s.Spec())
+	}
+	got, err := s.Read(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range s.Spec().Channels {
+		for i := range want.Channel(c) {
+			if math.Abs(float64(got.Channel(c)[i]-want.Channel(c)[i])) > 1e-6 {
+				t.Fatalf("stream: got %v want %v", got, want)
+			}
+		}
+	}
+	if _, err := s.Read(2); err != io.EOF {
+		t.Errorf("got %v want EOF", err)
+	}
+}
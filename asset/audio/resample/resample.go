@@ -0,0 +1,315 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package resample converts audio between sample rates, either a whole track at
+load time with Track and Asset or a stream with a Resampler whose rate may
+change from one call to the next, e.g. for pitch shifting.
+
+ModeSinc is a polyphase windowed sinc filter that band limits the signal to
+the lower of the two rates, ModeLinear interpolates linearly which is cheaper
+but aliases.
+*/
+package resample
+
+import (
+	"math"
+	"slices"
+
+	"goarrg.com/asset/audio"
+)
+
+type Mode int
+
+const (
+	ModeSinc Mode = iota
+	ModeLinear
+)
+
+const (
+	// sincZeros is the number of zero crossings of the kernel on each side
+	sincZeros = 16
+	// sincPhases is the resolution of the kernel table per zero crossing
+	sincPhases = 512
+	// sincCutoff keeps the transition band of the kernel below Nyquist
+	sincCutoff = 0.9
+	sincBeta   = 8
+)
+
+/*
+sincTable holds one side of the Kaiser windowed sinc kernel from 0 to sincZeros
+with an extra entry for interpolation.
+*/
+var sincTable = func() []float32 {
+	t := make([]float32, sincZeros*sincPhases+2)
+	for i := range t {
+		x := float64(i) / sincPhases
+		if x > sincZeros {
+			break
+		}
+		s := 1.0
+		if x != 0 {
+			s = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		r := x / sincZeros
+		t[i] = float32(s * bessel0(sincBeta*math.Sqrt(1-r*r)) / bessel0(sincBeta))
+	}
+	return t
+}()
+
+/*
+bessel0 is the zeroth order modified Bessel function of the first kind.
+*/
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; term > sum*1e-12; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+	}
+	return sum
+}
+
+func kernel(x float64) float32 {
+	x = math.Abs(x) * sincPhases
+	i := int(x)
+	if i >= sincZeros*sincPhases {
+		return 0
+	}
+	f := float32(x - float64(i))
+	return sincTable[i] + (sincTable[i+1]-sincTable[i])*f
+}
+
+/*
+Resampler converts planar frames written to it by a step, the number of input
+frames per output frame. The step may change between calls to Read without
+discontinuities, a step of 1 at a whole frame copies the input as is.
+*/
+type Resampler struct {
+	mode Mode
+	// buf holds the written frames that are still needed starting at frame
+	// base of the input, pos is the fractional position of the next output
+	// frame in the input so dropping frames does not change its rounding
+	buf   [][]float32
+	base  int
+	pos   float64
+	ended bool
+}
+
+func New(channels int, mode Mode) *Resampler {
+	return &Resampler{mode: mode, buf: make([][]float32, channels)}
+}
+
+/*
+reach returns how many frames around a position the filter reads at step.
+*/
+func (r *Resampler) reach(step float64) int {
+	if r.mode == ModeLinear {
+		return 1
+	}
+	return int(math.Ceil(sincZeros / sincCutoff * max(1, step)))
+}
+
+/*
+Need returns how many more frames have to be written before Read can produce
+frames frames at step.
+*/
+func (r *Resampler) Need(frames int, step float64) int {
+	if frames <= 0 || r.ended {
+		return 0
+	}
+	// one frame extra as Read accumulates step and may round past last
+	last := r.pos + float64(frames-1)*step
+	return max(0, int(last)-r.base+r.reach(step)+2-len(r.buf[0]))
+}
+
+/*
+Write appends planar frames, in must have a slice per channel of equal length.
+*/
+func (r *Resampler) Write(in [][]float32) {
+	for c := range r.buf {
+		r.buf[c] = append(r.buf[c], in[c]...)
+	}
+}
+
+/*
+End marks the end of the input, Read then pads it with silence and stops once
+all written frames have been passed.
+*/
+func (r *Resampler) End() {
+	r.ended = true
+}
+
+/*
+Reset discards all state so the resampler can be used for a new stream.
+*/
+func (r *Resampler) Reset() {
+	for c := range r.buf {
+		r.buf[c] = r.buf[c][:0]
+	}
+	r.base, r.pos, r.ended = 0, 0, false
+}
+
+/*
+Buffered returns the number of written frames at or after the position of the
+next output frame.
+*/
+func (r *Resampler) Buffered() int {
+	return max(0, r.base+len(r.buf[0])-int(r.pos))
+}
+
+/*
+Read fills out, a slice per channel, with up to len(out[0]) frames at step and
+returns how many were produced. It produces fewer frames if more input is
+needed, see Need, or at the end of the input.
+*/
+func (r *Resampler) Read(out [][]float32, step float64) int {
+	if len(out) == 0 || step <= 0 {
+		return 0
+	}
+	reach := r.reach(step)
+	n := 0
+	for ; n < len(out[0]); n++ {
+		at := int(r.pos) - r.base
+		if r.ended {
+			if at >= len(r.buf[0]) {
+				break
+			}
+		} else if at+reach >= len(r.buf[0]) {
+			break
+		}
+		for c := range out {
+			out[c][n] = r.sample(r.buf[c], step)
+		}
+		r.pos += step
+	}
+	r.compact(reach)
+	return n
+}
+
+func (r *Resampler) sample(buf []float32, step float64) float32 {
+	at := int(r.pos)
+	frac := r.pos - float64(at)
+	at -= r.base
+	if frac == 0 && step == 1 {
+		return buf[at]
+	}
+	if r.mode == ModeLinear {
+		a, b := buf[at], float32(0)
+		if at+1 < len(buf) {
+			b = buf[at+1]
+		}
+		return a + (b-a)*float32(frac)
+	}
+
+	// downsampling stretches the kernel to cut off at the output's Nyquist
+	scale := sincCutoff / max(1, step)
+	reach := r.reach(step)
+	sum := float32(0)
+	for i := max(0, at-reach+1); i <= min(len(buf)-1, at+reach); i++ {
+		if k := kernel((float64(i-at) - frac) * scale); k != 0 {
+			sum += buf[i] * k
+		}
+	}
+	return sum * float32(scale)
+}
+
+/*
+compact drops the frames that are further behind pos than the filter reaches.
+*/
+func (r *Resampler) compact(reach int) {
+	drop := int(r.pos) - r.base - reach
+	if drop < len(r.buf[0])/2 || drop <= 0 {
+		return
+	}
+	for c := range r.buf {
+		r.buf[c] = r.buf[c][:copy(r.buf[c], r.buf[c][drop:])]
+	}
+	r.base += drop
+}
+
+/*
+Track converts track, in the channels and frequency of spec, to frequency.
+*/
+func Track(track audio.Track, spec audio.Spec, frequency int, mode Mode) audio.Track {
+	if len(spec.Channels) == 0 {
+		return audio.Track{}
+	}
+	frames := track.Frames()
+	step := float64(spec.Frequency) / float64(frequency)
+	length := int(math.Ceil(float64(frames) / step))
+
+	r := New(len(spec.Channels), mode)
+	in := make([][]float32, len(spec.Channels))
+	for i, c := range spec.Channels {
+		in[i] = track.Channel(c)
+	}
+	result := audio.NewTrack(spec.Channels, length)
+	r.Write(in)
+	r.End()
+	r.Read(result.Samples(), step)
+	return result
+}
+
+type assetImpl struct {
+	spec  audio.Spec
+	track audio.Track
+	// frames is the length of the track in frames
+	frames   int
+	metadata audio.Metadata
+}
+
+/*
+Asset returns a converted to frequency, or a itself if it already is at that
+frequency.
+*/
+func Asset(a audio.Asset, frequency int, mode Mode) audio.Asset {
+	spec := a.Spec()
+	if spec.Frequency == frequency {
+		return a
+	}
+	track := Track(a.Track(), spec, frequency, mode)
+	metadata := audio.MetadataOf(a)
+	scale := func(frame int) int {
+		return min(track.Frames(), int(math.Round(float64(frame)*float64(frequency)/float64(spec.Frequency))))
+	}
+	metadata.LoopStart, metadata.LoopEnd = scale(metadata.LoopStart), scale(metadata.LoopEnd)
+	metadata.Markers = slices.Clone(metadata.Markers)
+	for i, m := range metadata.Markers {
+		metadata.Markers[i].Frame, metadata.Markers[i].Length = scale(m.Frame), scale(m.Frame+m.Length)-scale(m.Frame)
+	}
+	spec.Frequency = frequency
+	return &assetImpl{spec: spec, track: track, frames: track.Frames(), metadata: metadata}
+}
+
+func (a *assetImpl) Track() audio.Track {
+	return a.track
+}
+
+func (a *assetImpl) Spec() audio.Spec {
+	return a.spec
+}
+
+func (a *assetImpl) DurationSeconds() float64 {
+	return float64(a.frames) / float64(a.spec.Frequency)
+}
+
+func (a *assetImpl) DurationSamples() int {
+	return a.frames
+}
+
+func (a *assetImpl) Metadata() audio.Metadata {
+	return a.metadata
+}
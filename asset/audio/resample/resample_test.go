@@ -0,0 +1,191 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resample
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"goarrg.com/asset/audio"
+)
+
+func sine(frequency, rate float64, frames int) []float32 {
+	s := make([]float32, frames)
+	for i := range s {
+		s[i] = float32(math.Sin(2 * math.Pi * frequency * float64(i) / rate))
+	}
+	return s
+}
+
+/*
+rms returns the root mean square of s without the first and last frames where
+the filter reaches past the signal.
+*/
+func rms(s []float32, edge int) float64 {
+	sum := 0.0
+	for _, v := range s[edge : len(s)-edge] {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum / float64(len(s)-2*edge))
+}
+
+func TestAliasing(t *testing.T) {
+	spec := audio.Spec{Channels: audio.ChannelsMono(), Frequency: 48000}
+	for _, tc := range []struct {
+		mode Mode
+		// tone is above the new Nyquist of 8kHz and pass is below
+		tone, pass float64
+	}{
+		{ModeSinc, 0.001, 0.5},
+		{ModeLinear, 0.1, 0.5},
+	} {
+		out := Track(audio.TrackOf(audio.ChannelsMono(), [][]float32{sine(12000, 48000, 4800)}), spec, 16000, tc.mode).Channel(audio.ChannelLeft)
+		if len(out) != 1600 {
+			t.Fatalf("mode %d: got %d frames", tc.mode, len(out))
+		}
+		aliased := rms(out, 100)
+		if tc.mode == ModeSinc && aliased > tc.tone {
+			t.Errorf("sinc: 12kHz aliased at rms %f", aliased)
+		}
+		if tc.mode == ModeLinear && aliased < tc.tone {
+			t.Errorf("linear: expected 12kHz to alias, got rms %f", aliased)
+		}
+
+		out = Track(audio.TrackOf(audio.ChannelsMono(), [][]float32{sine(1000, 48000, 4800)}), spec, 16000, tc.mode).Channel(audio.ChannelLeft)
+		if passed := rms(out, 100); math.Abs(passed-math.Sqrt2/2) > 0.01 {
+			t.Errorf("mode %d: 1kHz passed at rms %f", tc.mode, passed)
+		}
+	}
+}
+
+func TestPhase(t *testing.T) {
+	// the filter is centered so output frame i is the input at i*step
+	in := sine(1000, 44100, 4410)
+	out := Track(audio.TrackOf(audio.ChannelsMono(), [][]float32{in}), audio.Spec{Channels: audio.ChannelsMono(), Frequency: 44100}, 48000, ModeSinc).Channel(audio.ChannelLeft)
+	want := sine(1000, 48000, len(out))
+	for i := 100; i < len(out)-100; i++ {
+		if math.Abs(float64(out[i]-want[i])) > 1e-3 {
+			t.Fatalf("frame %d: got %f want %f", i, out[i], want[i])
+		}
+	}
+}
+
+/*
+stream resamples in through a Resampler writing and reading blocks of the
+given sizes in turn, changing the step after every read with steps.
+*/
+func stream(in []float32, mode Mode, blocks []int, steps func(int) float64) []float32 {
+	r := New(1, mode)
+	var out []float32
+	buf := make([]float32, 64)
+	written := 0
+	for i := 0; ; i++ {
+		n := blocks[i%len(blocks)]
+		step := steps(len(out))
+		if need := r.Need(n, step); need > 0 && written < len(in) {
+			end := min(len(in), written+need)
+			r.Write([][]float32{in[written:end]})
+			written = end
+			if written == len(in) {
+				r.End()
+			}
+		}
+		got := r.Read([][]float32{buf[:n]}, step)
+		out = append(out, buf[:got]...)
+		if got < n && written == len(in) {
+			return out
+		}
+	}
+}
+
+func TestContinuity(t *testing.T) {
+	in := sine(440, 8000, 2000)
+	for _, mode := range []Mode{ModeSinc, ModeLinear} {
+		whole := stream(in, mode, []int{64}, func(int) float64 { return 0.7 })
+		blocks := stream(in, mode, []int{1, 7, 13, 64, 3}, func(int) float64 { return 0.7 })
+		if len(whole) != len(blocks) || len(whole) != int(math.Ceil(2000/0.7)) {
+			t.Fatalf("mode %d: got %d and %d frames", mode, len(whole), len(blocks))
+		}
+		for i := range whole {
+			if whole[i] != blocks[i] {
+				t.Fatalf("mode %d: frame %d differs across blocks, %f != %f", mode, i, whole[i], blocks[i])
+			}
+		}
+
+		// sweeping the step must not click, the largest difference between two
+		// frames stays close to that of the sine at the highest pitch
+		swept := stream(in, mode, []int{5, 11, 17}, func(i int) float64 { return 0.5 + float64(i)/2000 })
+		limit := 2 * math.Pi * 440 / 8000 * 1.5 * 1.1
+		for i := 200; i < len(swept)-200; i++ {
+			if d := math.Abs(float64(swept[i] - swept[i-1])); d > limit {
+				t.Fatalf("mode %d: jump of %f at frame %d", mode, d, i)
+			}
+		}
+	}
+}
+
+func TestAsset(t *testing.T) {
+	a := Asset(&testAsset{audio.Spec{Channels: audio.ChannelsStereo(), Frequency: 22050}, audio.TrackOf(audio.ChannelsStereo(), [][]float32{
+		sine(100, 22050, 2205),
+		sine(200, 22050, 2205),
+	})}, 44100, ModeSinc)
+	if a.Spec().Frequency != 44100 || a.DurationSamples() != 4410 || a.DurationSeconds() != 0.1 {
+		t.Fatalf("got spec %+v with %d frames", a.Spec(), a.DurationSamples())
+	}
+	if len(a.Track().Channel(audio.ChannelLeft)) != 4410 || len(a.Track().Channel(audio.ChannelRight)) != 4410 {
+		t.Fatal("wrong track length")
+	}
+	// frames at whole input positions keep their value when upsampling by 2
+	// apart from the band limiting
+	if d := math.Abs(float64(a.Track().Channel(audio.ChannelRight)[1000] - sine(200, 22050, 2205)[500])); d > 1e-3 {
+		t.Errorf("got difference %f", d)
+	}
+	m := audio.Metadata{LoopStart: 100, LoopEnd: 2205, Markers: []audio.Marker{{Frame: 3, Length: 10, Label: "a"}}, Loudness: -20}
+	b := Asset(&metadataAsset{&testAsset{audio.Spec{Channels: audio.ChannelsMono(), Frequency: 22050}, audio.TrackOf(audio.ChannelsMono(), [][]float32{
+		sine(100, 22050, 2205),
+	})}, m}, 11025, ModeLinear)
+	want := audio.Metadata{LoopStart: 50, LoopEnd: 1103, Markers: []audio.Marker{{Frame: 2, Length: 5, Label: "a"}}, Loudness: -20}
+	if got := audio.MetadataOf(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("got metadata %+v want %+v", got, want)
+	}
+	if b := Asset(a, 44100, ModeSinc); b != a {
+		t.Error("asset at the frequency was converted")
+	}
+}
+
+type metadataAsset struct {
+	*testAsset
+	metadata audio.Metadata
+}
+
+func (a *metadataAsset) Metadata() audio.Metadata { return a.metadata }
+
+type testAsset struct {
+	spec  audio.Spec
+	track audio.Track
+}
+
+func (a *testAsset) Track() audio.Track   { return a.track }
+func (a *testAsset) Spec() audio.Spec     { return a.spec }
+func (a *testAsset) DurationSamples() int { return len(a.track.Channel(a.spec.Channels[0])) }
+func (a *testAsset) DurationSeconds() float64 {
+	return float64(a.DurationSamples()) / float64(a.spec.Frequency)
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package spatial places sounds in 3D around a listener. An Emitter is a
+dsp.Processor for a voice that attenuates it by distance and cone, pans it
+onto the output channels with vector based amplitude panning and reports the
+doppler shift as its pitch.
+
+	listener := &spatial.Listener{}
+	e := spatial.NewEmitter(spatial.Config{Listener: listener})
+	m.Play(a, mixer.PlayConfig{Effects: []dsp.Processor{e}})
+
+	// every frame
+	listener.Set(camera, cameraVelocity)
+	e.Set(transform, velocity)
+
+Transforms follow gmath.Transform.LookAt, +Z is forward, +Y up and +X right.
+*/
+package spatial
+
+import (
+	"math"
+	"sync"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/dsp"
+	"goarrg.com/gmath"
+)
+
+const defaultSpeedOfSound = 343.3
+
+type DistanceModel int
+
+const (
+	// DistanceInverse attenuates by MinDistance / (MinDistance + Rolloff *
+	// (distance - MinDistance)).
+	DistanceInverse DistanceModel = iota
+	// DistanceLinear fades out linearly to silence at MaxDistance with a
+	// Rolloff of 1.
+	DistanceLinear
+	// DistanceExponential attenuates by (distance / MinDistance) ^ -Rolloff.
+	DistanceExponential
+	// DistanceNone does not attenuate.
+	DistanceNone
+)
+
+type Config struct {
+	// Listener the emitter is heard by, must not be nil.
+	Listener *Listener
+	Model    DistanceModel
+	// MinDistance is the distance up to which there is no attenuation.
+	// Defaults to 1 if <= 0.
+	MinDistance float32
+	// MaxDistance is the distance beyond which the attenuation does not
+	// change. Defaults to 1000 if <= MinDistance.
+	MaxDistance float32
+	// Rolloff scales how fast the distance attenuates. Defaults to 1 if <= 0.
+	Rolloff float32
+	// ConeInner and ConeOuter are the full angles in radians around the
+	// emitter's forward direction inside which it plays at full gain and
+	// outside which it plays at ConeOuterGain, with a linear blend between.
+	// The emitter is omnidirectional if ConeOuter <= 0.
+	ConeInner     float32
+	ConeOuter     float32
+	ConeOuterGain float32
+	// SpeedOfSound in units per second. Defaults to 343.3 if <= 0.
+	SpeedOfSound float32
+	// Doppler scales the doppler shift, 0 disables it.
+	Doppler float32
+}
+
+/*
+Listener is the position sounds are heard from, it is safe to use from any
+goroutine.
+*/
+type Listener struct {
+	mtx       sync.Mutex
+	transform gmath.Transformf32
+	velocity  gmath.Vector3f32
+}
+
+/*
+Set moves the listener, velocity is in units per second.
+*/
+func (l *Listener) Set(transform gmath.Transformf32, velocity gmath.Vector3f32) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.transform, l.velocity = transform, velocity
+}
+
+func (l *Listener) get() (gmath.Transformf32, gmath.Vector3f32) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.transform, l.velocity
+}
+
+/*
+Emitter is a dsp.Processor placing a voice in 3D, the voice is folded to mono
+and panned onto the output channels. Its methods are safe to call from any
+goroutine.
+*/
+type Emitter struct {
+	cfg Config
+
+	mtx       sync.Mutex
+	transform gmath.Transformf32
+	velocity  gmath.Vector3f32
+
+	speakers speakers
+	// gains are the per channel gains the last block ended at, next is the
+	// target for the next block
+	gains []float32
+	next  []float32
+	mono  []float32
+	ready bool
+}
+
+var (
+	_ dsp.Processor = (*Emitter)(nil)
+	_ dsp.Pitcher   = (*Emitter)(nil)
+)
+
+func NewEmitter(cfg Config) *Emitter {
+	if cfg.Listener == nil {
+		panic("spatial: nil listener")
+	}
+	if cfg.MinDistance <= 0 {
+		cfg.MinDistance = 1
+	}
+	if cfg.MaxDistance <= cfg.MinDistance {
+		cfg.MaxDistance = max(1000, cfg.MinDistance)
+	}
+	if cfg.Rolloff <= 0 {
+		cfg.Rolloff = 1
+	}
+	if cfg.SpeedOfSound <= 0 {
+		cfg.SpeedOfSound = defaultSpeedOfSound
+	}
+	return &Emitter{cfg: cfg}
+}
+
+/*
+Set moves the emitter, velocity is in units per second.
+*/
+func (e *Emitter) Set(transform gmath.Transformf32, velocity gmath.Vector3f32) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.transform, e.velocity = transform, velocity
+}
+
+func (e *Emitter) get() (gmath.Transformf32, gmath.Vector3f32) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.transform, e.velocity
+}
+
+/*
+distanceGain returns the attenuation at distance.
+*/
+func (e *Emitter) distanceGain(distance float32) float32 {
+	c := e.cfg
+	d := max(c.MinDistance, min(c.MaxDistance, distance))
+	switch c.Model {
+	case DistanceInverse:
+		return c.MinDistance / (c.MinDistance + c.Rolloff*(d-c.MinDistance))
+	case DistanceLinear:
+		return max(0, 1-c.Rolloff*(d-c.MinDistance)/(c.MaxDistance-c.MinDistance))
+	case DistanceExponential:
+		return float32(math.Pow(float64(d/c.MinDistance), float64(-c.Rolloff)))
+	default:
+		return 1
+	}
+}
+
+/*
+coneGain returns the attenuation for the listener being at angle radians from
+the emitter's forward direction.
+*/
+func (e *Emitter) coneGain(angle float32) float32 {
+	c := e.cfg
+	if c.ConeOuter <= 0 {
+		return 1
+	}
+	inner, outer := min(c.ConeInner, c.ConeOuter)/2, c.ConeOuter/2
+	switch {
+	case angle <= inner:
+		return 1
+	case angle >= outer:
+		return c.ConeOuterGain
+	default:
+		t := (angle - inner) / (outer - inner)
+		return 1 + (c.ConeOuterGain-1)*t
+	}
+}
+
+/*
+Gain returns the combined distance and cone attenuation of the emitter.
+*/
+func (e *Emitter) Gain() float32 {
+	gain, _ := e.locate()
+	return gain
+}
+
+/*
+locate returns the attenuation and the direction from the listener to the
+emitter in the listener's space.
+*/
+func (e *Emitter) locate() (float32, gmath.Vector3f32) {
+	lt, _ := e.cfg.Listener.get()
+	et, _ := e.get()
+
+	toEmitter := lt.Pos.VectorTo(et.Pos)
+	distance := toEmitter.Magnitude()
+	gain := e.distanceGain(distance)
+	if distance > 0 {
+		forward := et.Rot.Rotate(gmath.Vector3f32{Z: 1})
+		gain *= e.coneGain(forward.Angle(toEmitter.ScaleUniform(-1)))
+	}
+	inverse := lt.Rot
+	inverse.X, inverse.Y, inverse.Z = -inverse.X, -inverse.Y, -inverse.Z
+	return gain, inverse.Rotate(toEmitter)
+}
+
+/*
+Pitch returns the doppler shift from the velocities of the listener and the
+emitter along the line between them, as in OpenAL.
+*/
+func (e *Emitter) Pitch() float32 {
+	if e.cfg.Doppler <= 0 {
+		return 1
+	}
+	lt, lv := e.cfg.Listener.get()
+	et, ev := e.get()
+
+	toListener := et.Pos.VectorTo(lt.Pos)
+	distance := toListener.Magnitude()
+	if distance == 0 {
+		return 1
+	}
+	limit := e.cfg.SpeedOfSound / e.cfg.Doppler
+	vl := min(limit, toListener.Dot(lv)/distance)
+	ve := min(limit, toListener.Dot(ev)/distance)
+	ss := e.cfg.SpeedOfSound
+	pitch := (ss - e.cfg.Doppler*vl) / (ss - e.cfg.Doppler*ve)
+	if pitch <= 0 || math.IsNaN(float64(pitch)) || math.IsInf(float64(pitch), 0) {
+		// moving at the speed of sound
+		return 1
+	}
+	return pitch
+}
+
+func (e *Emitter) Init(spec audio.Spec) {
+	e.speakers = newSpeakers(spec.Channels)
+	e.gains = make([]float32, len(spec.Channels))
+	e.next = make([]float32, len(spec.Channels))
+	e.mono = make([]float32, spec.Frequency)
+	e.ready = false
+}
+
+/*
+Process folds buf to mono by summing its channels at -3dB, which restores a
+mono voice panned to the center, and pans it to the emitter's position. The
+gains glide across the block.
+*/
+func (e *Emitter) Process(buf [][]float32, frames int) {
+	if len(e.gains) == 0 || frames <= 0 {
+		return
+	}
+	if len(e.mono) < frames {
+		e.mono = make([]float32, frames)
+	}
+
+	fold := float32(math.Sqrt2 / 2)
+	if len(buf) == 1 {
+		fold = 1
+	}
+	mono := e.mono[:frames]
+	clear(mono)
+	for _, c := range buf {
+		for i, s := range c[:frames] {
+			mono[i] += s * fold
+		}
+	}
+
+	gain, direction := e.locate()
+	e.speakers.gains(e.next, direction)
+	for c := range e.next {
+		e.next[c] *= gain
+	}
+	if !e.ready {
+		copy(e.gains, e.next)
+		e.ready = true
+	}
+
+	for c, out := range buf {
+		from, step := e.gains[c], (e.next[c]-e.gains[c])/float32(frames)
+		for i, s := range mono {
+			out[i] = s * (from + step*float32(i+1))
+		}
+	}
+	copy(e.gains, e.next)
+}
@@ -0,0 +1,257 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spatial
+
+import (
+	"math"
+	"testing"
+
+	"goarrg.com"
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/dsp"
+	"goarrg.com/gmath"
+	"goarrg.com/mixer"
+)
+
+const (
+	h     = math.Sqrt2 / 2
+	sqrt3 = 1.7320508075688772
+)
+
+func at(x, y, z float32) gmath.Transformf32 {
+	return gmath.Transformf32{Pos: gmath.Point3f32{X: x, Y: y, Z: z}}
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func TestDistance(t *testing.T) {
+	l := &Listener{}
+	for _, tc := range []struct {
+		cfg      Config
+		distance float32
+		want     float32
+	}{
+		{Config{Model: DistanceInverse}, 0.5, 1},
+		{Config{Model: DistanceInverse}, 4, 0.25},
+		{Config{Model: DistanceInverse, Rolloff: 2}, 4, 1.0 / 7},
+		{Config{Model: DistanceInverse, MaxDistance: 2}, 4, 0.5},
+		{Config{Model: DistanceLinear, MaxDistance: 11}, 6, 0.5},
+		{Config{Model: DistanceLinear, MaxDistance: 11}, 20, 0},
+		{Config{Model: DistanceExponential, MinDistance: 2}, 8, 0.25},
+		{Config{Model: DistanceExponential, MinDistance: 2, Rolloff: 0.5}, 8, 0.5},
+		{Config{Model: DistanceNone}, 100, 1},
+	} {
+		tc.cfg.Listener = l
+		e := NewEmitter(tc.cfg)
+		e.Set(at(0, 0, tc.distance), gmath.Vector3f32{})
+		if got := e.Gain(); !near(got, tc.want) {
+			t.Errorf("%+v at %f: got %f want %f", tc.cfg, tc.distance, got, tc.want)
+		}
+	}
+}
+
+func TestCone(t *testing.T) {
+	e := NewEmitter(Config{Listener: &Listener{}, Model: DistanceNone, ConeInner: math.Pi / 2, ConeOuter: math.Pi, ConeOuterGain: 0.2})
+	face := func(x, z float32) {
+		// the emitter sits in front of the listener facing the direction
+		tr := at(0, 0, 1)
+		tr.LookAt(gmath.Vector3f32{Y: 1}, gmath.Point3f32{X: x, Z: 1 + z})
+		e.Set(tr, gmath.Vector3f32{})
+	}
+	for _, tc := range []struct {
+		x, z float32
+		want float32
+	}{
+		{0, -1, 1},
+		{1, -1, 1},
+		{1, -0.4142135, 0.6},
+		{1, 0, 0.2},
+		{0, 1, 0.2},
+	} {
+		face(tc.x, tc.z)
+		if got := e.Gain(); !near(got, tc.want) {
+			t.Errorf("facing %f,%f: got %f want %f", tc.x, tc.z, got, tc.want)
+		}
+	}
+}
+
+func TestDoppler(t *testing.T) {
+	l := &Listener{}
+	e := NewEmitter(Config{Listener: l, Doppler: 1})
+	e.Set(at(0, 0, 10), gmath.Vector3f32{Z: -34.33})
+	if got := e.Pitch(); !near(got, 343.3/(343.3-34.33)) {
+		t.Errorf("approaching emitter: got %f", got)
+	}
+	e.Set(at(0, 0, 10), gmath.Vector3f32{X: 34.33})
+	if got := e.Pitch(); !near(got, 1) {
+		t.Errorf("passing emitter: got %f", got)
+	}
+	e.Set(at(0, 0, 10), gmath.Vector3f32{})
+	l.Set(at(0, 0, 0), gmath.Vector3f32{Z: 34.33})
+	if got := e.Pitch(); !near(got, (343.3+34.33)/343.3) {
+		t.Errorf("approaching listener: got %f", got)
+	}
+	if got := NewEmitter(Config{Listener: l}).Pitch(); got != 1 {
+		t.Errorf("doppler disabled: got %f", got)
+	}
+}
+
+func TestPanning(t *testing.T) {
+	stereo, surround, surround7 := audio.ChannelsStereo(), audio.Channels5Point1(), audio.Channels7Point1()
+	deg := func(d float64) gmath.Vector3f32 {
+		r := d * math.Pi / 180
+		return gmath.Vector3f32{X: float32(math.Sin(r)), Z: float32(math.Cos(r))}
+	}
+	for _, tc := range []struct {
+		name      string
+		layout    []audio.Channel
+		direction gmath.Vector3f32
+		want      []float32
+	}{
+		{"mono", audio.ChannelsMono(), deg(90), []float32{1}},
+		{"stereo front", stereo, deg(0), []float32{h, h}},
+		{"stereo right", stereo, deg(30), []float32{0, 1}},
+		{"stereo far right", stereo, deg(90), []float32{0, 1}},
+		{"stereo behind", stereo, deg(180), []float32{h, h}},
+		// mirrored to -15°
+		{"stereo behind left", stereo, deg(-165), []float32{0.9390708, 0.34372377}},
+		{"5.1 front", surround, deg(0), []float32{0, 0, 1, 0, 0, 0}},
+		{"5.1 left", surround, deg(-30), []float32{1, 0, 0, 0, 0, 0}},
+		{"5.1 behind", surround, deg(180), []float32{0, 0, 0, 0, h, h}},
+		{"5.1 right surround", surround, deg(110), []float32{0, 0, 0, 0, 0, 1}},
+		{"7.1 right", surround7, deg(90), []float32{0, 0, 0, 0, 0, 0, 0, 1}},
+		{"7.1 back left", surround7, deg(-150), []float32{0, 0, 0, 0, 1, 0, 0, 0}},
+		{"above", surround, gmath.Vector3f32{Y: 1}, []float32{
+			float32(math.Sqrt(0.2)), float32(math.Sqrt(0.2)), float32(math.Sqrt(0.2)), 0, float32(math.Sqrt(0.2)), float32(math.Sqrt(0.2)),
+		}},
+	} {
+		s := newSpeakers(tc.layout)
+		got := make([]float32, len(tc.layout))
+		s.gains(got, tc.direction)
+		for i := range got {
+			if !near(got[i], tc.want[i]) {
+				t.Errorf("%s: got %v want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+
+	// power is constant anywhere in between
+	s := newSpeakers(surround)
+	got := make([]float32, len(surround))
+	for d := -180.0; d < 180; d += 7 {
+		s.gains(got, deg(d))
+		power := float32(0)
+		for _, g := range got {
+			power += g * g
+		}
+		if !near(power, 1) {
+			t.Fatalf("%f°: got power %f", d, power)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	l := &Listener{}
+	// facing +X turns -Z to the right
+	facing := at(0, 0, 0)
+	facing.LookAt(gmath.Vector3f32{Y: 1}, gmath.Point3f32{X: 1})
+	l.Set(facing, gmath.Vector3f32{})
+
+	e := NewEmitter(Config{Listener: l, Model: DistanceNone})
+	e.Set(at(0, 0, -1), gmath.Vector3f32{})
+	e.Init(audio.Spec{Channels: audio.ChannelsStereo(), Frequency: 1000})
+
+	// a mono voice panned to the center
+	left, right := []float32{h, h, h, h}, []float32{h, h, h, h}
+	e.Process([][]float32{left, right}, 4)
+	for i := range left {
+		if !near(left[i], 0) || !near(right[i], 1) {
+			t.Fatalf("got %v %v want the right channel", left, right)
+		}
+	}
+
+	// moving glides the gains over the next block
+	e.Set(at(1, 0, 0), gmath.Vector3f32{})
+	left, right = []float32{h, h, h, h}, []float32{h, h, h, h}
+	e.Process([][]float32{left, right}, 4)
+	want := []float32{
+		h / 4, h / 2, 3 * h / 4, h,
+		1 + (h-1)/4, 1 + (h-1)/2, 1 + 3*(h-1)/4, h,
+	}
+	for i := range left {
+		if !near(left[i], want[i]) || !near(right[i], want[4+i]) {
+			t.Fatalf("got %v %v want %v", left, right, want)
+		}
+	}
+}
+
+type testAsset struct {
+	samples []float32
+}
+
+func (a *testAsset) Track() audio.Track {
+	return audio.TrackOf(audio.ChannelsMono(), [][]float32{a.samples})
+}
+func (a *testAsset) Spec() audio.Spec {
+	return audio.Spec{Channels: audio.ChannelsMono(), Frequency: 1000}
+}
+func (a *testAsset) DurationSamples() int     { return len(a.samples) }
+func (a *testAsset) DurationSeconds() float64 { return float64(len(a.samples)) / 1000 }
+
+func TestMixer(t *testing.T) {
+	m := mixer.New(mixer.Config{Spec: audio.Spec{Channels: audio.Channels5Point1(), Frequency: 1000}})
+	if err := m.Init(nil, goarrg.AudioConfig{Spec: audio.Spec{Channels: audio.Channels5Point1(), Frequency: 1000}}); err != nil {
+		t.Fatal(err)
+	}
+	ones := make([]float32, 1000)
+	for i := range ones {
+		ones[i] = 1
+	}
+
+	l := &Listener{}
+	e := NewEmitter(Config{Listener: l, Doppler: 1})
+	// 2 units behind on the left, approaching at half the speed of sound
+	e.Set(at(-2, 0, -2*sqrt3), gmath.Vector3f32{X: 343.3 / 4, Z: 343.3 / 4 * sqrt3})
+	m.Play(&testAsset{ones}, mixer.PlayConfig{Effects: []dsp.Processor{e}})
+
+	frames, track := m.Mix()
+	if frames == 0 {
+		t.Fatal("mixed nothing")
+	}
+	// the emitter is at -150°, between the left surround at -110° and the
+	// center of the back
+	gl, gr := vbap(-110*math.Pi/180, 110*math.Pi/180-2*math.Pi, -150*math.Pi/180)
+	for c, want := range map[audio.Channel]float64{
+		audio.ChannelSurroundLeft:  0.25 * gl,
+		audio.ChannelSurroundRight: 0.25 * gr,
+		audio.ChannelLeft:          0,
+		audio.ChannelCenter:        0,
+	} {
+		if got := track.Channel(c)[frames-1]; !near(got, float32(want)) {
+			t.Errorf("channel %v: got %f want %f", c, got, want)
+		}
+	}
+	if !near(e.Pitch(), 2) {
+		t.Errorf("got pitch %f", e.Pitch())
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spatial
+
+import (
+	"math"
+	"slices"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/gmath"
+)
+
+/*
+speaker is an output channel at an azimuth in radians, 0 is in front and
+positive angles are to the right.
+*/
+type speaker struct {
+	channel int
+	azimuth float64
+}
+
+/*
+speakers is a horizontal speaker layout sorted by azimuth.
+*/
+type speakers struct {
+	list []speaker
+	// front is true if no speaker is behind the listener, sounds from behind
+	// are then mirrored to the front
+	front bool
+	// power holds the power of every channel while computing gains
+	power []float64
+}
+
+/*
+newSpeakers places the channels of a layout where BS.775 and BS.2051 put them,
+the LFE and channels beyond audio.ChannelCount are not used. The side
+surrounds are at 90° if the layout also has back surrounds and at 110°
+otherwise, the back surrounds at 150° if the layout also has side surrounds
+and at 110° otherwise.
+*/
+func newSpeakers(layout []audio.Channel) speakers {
+	deg := func(d float64) float64 { return d * math.Pi / 180 }
+	sides, backs := slices.Contains(layout, audio.ChannelSurroundLeft), slices.Contains(layout, audio.ChannelBackSurroundLeft)
+	mono := !slices.Contains(layout, audio.ChannelRight)
+
+	s := speakers{front: true, power: make([]float64, len(layout))}
+	for i, c := range layout {
+		var a float64
+		switch c {
+		case audio.ChannelLeft:
+			a = deg(-30)
+			if mono {
+				a = 0
+			}
+		case audio.ChannelRight:
+			a = deg(30)
+		case audio.ChannelCenter:
+			a = 0
+		case audio.ChannelSurroundLeft, audio.ChannelSurroundRight:
+			a = deg(110)
+			if backs {
+				a = deg(90)
+			}
+		case audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight:
+			a = deg(110)
+			if sides {
+				a = deg(150)
+			}
+		default:
+			continue
+		}
+		if c == audio.ChannelSurroundLeft || c == audio.ChannelBackSurroundLeft {
+			a = -a
+		}
+		if math.Abs(a) > math.Pi/2 {
+			s.front = false
+		}
+		s.list = append(s.list, speaker{channel: i, azimuth: a})
+	}
+	slices.SortFunc(s.list, func(a, b speaker) int {
+		switch {
+		case a.azimuth < b.azimuth:
+			return -1
+		case a.azimuth > b.azimuth:
+			return 1
+		}
+		return 0
+	})
+	return s
+}
+
+/*
+gains sets the gain of every channel for a sound from direction in the
+listener's space, with a total power of 1. The horizontal direction is panned
+between the two speakers around it and elevation spreads the sound evenly over
+all speakers up to straight above or below.
+*/
+func (s *speakers) gains(dst []float32, direction gmath.Vector3f32) {
+	clear(dst)
+	n := len(s.list)
+	if n == 0 {
+		return
+	}
+	if n == 1 {
+		dst[s.list[0].channel] = 1
+		return
+	}
+
+	x, z := float64(direction.X), float64(direction.Z)
+	horizontal := x*x + z*z
+	total := horizontal + float64(direction.Y)*float64(direction.Y)
+	// share of the power that is panned, the rest is spread
+	panned := 0.0
+	if total > 0 {
+		panned = horizontal / total
+	}
+	spread := (1 - panned) / float64(n)
+
+	power := s.power
+	clear(power)
+	if panned > 0 {
+		azimuth := math.Atan2(x, z)
+		if s.front && math.Abs(azimuth) > math.Pi/2 {
+			azimuth = math.Copysign(math.Pi, azimuth) - azimuth
+		}
+		a, b, ga, gb := s.pair(azimuth)
+		power[a] += panned * ga * ga
+		power[b] += panned * gb * gb
+	}
+	for _, sp := range s.list {
+		power[sp.channel] += spread
+	}
+	for c, p := range power {
+		dst[c] = float32(math.Sqrt(p))
+	}
+}
+
+/*
+pair returns the channels of the speakers around azimuth and their VBAP gains
+with a total power of 1, clamping to the closest speaker outside the layout's
+arc.
+*/
+func (s *speakers) pair(azimuth float64) (int, int, float64, float64) {
+	n := len(s.list)
+	first, last := s.list[0], s.list[n-1]
+	for i := 0; i < n-1; i++ {
+		a, b := s.list[i], s.list[i+1]
+		if azimuth >= a.azimuth && azimuth <= b.azimuth {
+			ga, gb := vbap(a.azimuth, b.azimuth, azimuth)
+			return a.channel, b.channel, ga, gb
+		}
+	}
+
+	// the arc through the back from the last speaker to the first
+	if first.azimuth+2*math.Pi-last.azimuth < math.Pi {
+		if azimuth < last.azimuth {
+			azimuth += 2 * math.Pi
+		}
+		ga, gb := vbap(last.azimuth, first.azimuth+2*math.Pi, azimuth)
+		return last.channel, first.channel, ga, gb
+	}
+	if math.Abs(azimuth-first.azimuth) < math.Abs(azimuth-last.azimuth) {
+		return first.channel, first.channel, 1, 0
+	}
+	return last.channel, last.channel, 1, 0
+}
+
+/*
+vbap returns the gains of speakers at azimuths a and b, less than 180° apart,
+for a source at azimuth between them, with a total power of 1.
+*/
+func vbap(a, b, azimuth float64) (float64, float64) {
+	// solving ga*(sin a, cos a) + gb*(sin b, cos b) = (sin azimuth, cos azimuth)
+	det := math.Sin(a - b)
+	ga, gb := math.Sin(azimuth-b)/det, math.Sin(a-azimuth)/det
+	norm := math.Hypot(ga, gb)
+	return ga / norm, gb / norm
+}
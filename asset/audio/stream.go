@@ -0,0 +1,498 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+)
+
+const memoryStreamChunk = 4096
+
+const (
+	// maxStreamBufferFrames caps the default buffer so a bogus frequency in a
+	// file can't make it huge
+	maxStreamBufferFrames = 1 << 17
+	// maxStreamFrequency and maxStreamChannels bound the specs read from files
+	// before anything is allocated for them
+	maxStreamFrequency = 768000
+	maxStreamChannels  = 64
+)
+
+/*
+StreamDecoder decodes a file incrementally, it is only used by one goroutine at
+a time.
+*/
+type StreamDecoder interface {
+	Spec() Spec
+	// Frames returns the length of the stream in frames or -1 if it is unknown.
+	Frames() int
+	// Decode returns the next interleaved frames, the slice is only valid until
+	// the next call. It returns io.EOF at the end of the stream.
+	Decode() ([]float32, error)
+	// Seek makes the next call to Decode start at frame.
+	Seek(frame int) error
+}
+
+type StreamConfig struct {
+	// BufferFrames is the size of the ring buffer between the decoder and
+	// Read in frames. Defaults to a second of audio if <= 0, but no more than
+	// 131072 frames or the length of the stream.
+	BufferFrames int
+}
+
+/*
+StreamAsset is audio that is decoded while it is played rather than all at once
+by Load, making it suitable for long music tracks. Decoding happens on a
+background goroutine that keeps a ring buffer filled, which is stopped by
+Close.
+*/
+type StreamAsset interface {
+	Spec() Spec
+	// DurationSeconds and DurationSamples are negative if the length of the
+	// stream is unknown.
+	DurationSeconds() float64
+	DurationSamples() int
+	/*
+		Read returns up to frames frames in a Track that is only valid until the
+		next call. It waits for the decoder if the buffer runs empty and only
+		returns fewer frames at the end of the stream, after which it returns
+		io.EOF or the error that stopped the decoder.
+	*/
+	Read(frames int) (Track, error)
+	// Buffered returns how many frames Read returns without waiting for the
+	// decoder, or -1 if Read returns an error right away.
+	Buffered() int
+	// Seek discards the buffer and makes Read continue at frame.
+	Seek(frame int) error
+	/*
+		Loop makes playback jump back to start when it reaches end, end <= 0
+		being the end of the stream, a negative start disables looping. The
+		buffer is discarded and decoded again with the new loop.
+	*/
+	Loop(start, end int) error
+	// Position returns the frame the next call to Read starts at.
+	Position() int
+	Close() error
+}
+
+/*
+streamSegment maps the frames written to the ring buffer back to frames of the
+stream, a new segment starts every time playback loops.
+*/
+type streamSegment struct {
+	written int
+	frame   int
+}
+
+type streamImpl struct {
+	file     *asset.File
+	decoder  StreamDecoder
+	spec     Spec
+	frames   int
+	channels int
+	// buf holds the frames returned by Read, which are sliced to view
+	buf      Track
+	view     [][]float32
+	metadata Metadata
+
+	mtx  sync.Mutex
+	cond sync.Cond
+	ring []float32
+	head int
+	size int
+	// read and written count frames since the last seek
+	read     int
+	written  int
+	segments []streamSegment
+	// seek is the frame the decoder has to seek to before decoding or -1
+	seek      int
+	loopStart int
+	loopEnd   int
+	err       error
+	closed    bool
+	done      chan struct{}
+}
+
+/*
+memoryDecoder streams formats that can only be decoded all at once.
+*/
+type memoryDecoder struct {
+	spec  Spec
+	track []float32
+	pos   int
+}
+
+/*
+LoadStream opens file for streaming, formats that were not registered with
+RegisterStreamFormat are decoded all at once and streamed from memory. The
+returned StreamAsset must be closed to stop its decoder.
+*/
+func LoadStream(file string, cfg StreamConfig) (StreamAsset, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load audio stream %q", file)
+	}
+
+	f, err := findFormat(a)
+	if err != nil {
+		a.Close()
+		return nil, debug.ErrorWrapf(err, "Failed to load audio stream %q", file)
+	}
+	if f == nil {
+		a.Close()
+		return nil, debug.Errorf("Failed to load audio stream %q, unknown format", file)
+	}
+
+	var decoder StreamDecoder
+	var metadata Metadata
+	if f.stream != nil {
+		decoder, err = f.stream(a)
+		if err == nil {
+			metadata, err = readMetadata(f, a, decoder.Frames())
+		}
+		if err != nil {
+			a.Close()
+			return nil, debug.ErrorWrapf(err, "Failed to load audio stream %q", file)
+		}
+	} else {
+		spec, _, track, err := f.decode(a)
+		if err == nil {
+			metadata, err = readMetadata(f, a, len(track)/len(spec.Channels))
+		}
+		a.Close()
+		a = nil
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to load audio stream %q", file)
+		}
+		decoder = &memoryDecoder{spec: spec, track: track}
+	}
+
+	if err := checkSpec(decoder.Spec()); err != nil {
+		if a != nil {
+			a.Close()
+		}
+		return nil, debug.ErrorWrapf(err, "Failed to load audio stream %q", file)
+	}
+	s := newStream(a, decoder, cfg)
+	s.metadata = metadata
+	return s, nil
+}
+
+func checkSpec(spec Spec) error {
+	if spec.Frequency <= 0 || spec.Frequency > maxStreamFrequency {
+		return debug.Errorf("Invalid frequency %d", spec.Frequency)
+	}
+	if len(spec.Channels) == 0 || len(spec.Channels) > maxStreamChannels {
+		return debug.Errorf("Invalid channel count %d", len(spec.Channels))
+	}
+	return nil
+}
+
+func newStream(a *asset.File, decoder StreamDecoder, cfg StreamConfig) *streamImpl {
+	spec := decoder.Spec()
+	if cfg.BufferFrames <= 0 {
+		cfg.BufferFrames = min(spec.Frequency, maxStreamBufferFrames)
+		if frames := decoder.Frames(); frames >= 0 {
+			cfg.BufferFrames = max(1, min(cfg.BufferFrames, frames))
+		}
+	}
+	s := &streamImpl{
+		file:      a,
+		decoder:   decoder,
+		spec:      spec,
+		frames:    decoder.Frames(),
+		channels:  len(spec.Channels),
+		buf:       NewTrack(spec.Channels, cfg.BufferFrames),
+		view:      make([][]float32, len(spec.Channels)),
+		ring:      make([]float32, cfg.BufferFrames*len(spec.Channels)),
+		segments:  []streamSegment{{}},
+		seek:      -1,
+		loopStart: -1,
+		done:      make(chan struct{}),
+	}
+	s.cond.L = &s.mtx
+	go s.run()
+	return s
+}
+
+func (s *streamImpl) Spec() Spec {
+	return s.spec
+}
+
+func (s *streamImpl) Metadata() Metadata {
+	return s.metadata
+}
+
+func (s *streamImpl) DurationSeconds() float64 {
+	return float64(s.frames) / float64(s.spec.Frequency)
+}
+
+func (s *streamImpl) DurationSamples() int {
+	return s.frames
+}
+
+func (s *streamImpl) Read(frames int) (Track, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.buf.Frames() < frames {
+		s.buf = NewTrack(s.spec.Channels, frames)
+	}
+	capacity := len(s.ring) / s.channels
+	n := 0
+	for n < frames {
+		for s.size == 0 && s.err == nil && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			return Track{}, fs.ErrClosed
+		}
+		if s.size == 0 {
+			break
+		}
+		count := min(frames-n, s.size, capacity-s.head)
+		ring := s.ring[s.head*s.channels : (s.head+count)*s.channels]
+		for i, dst := range s.buf.Samples() {
+			dst = dst[n : n+count]
+			for j := range dst {
+				dst[j] = ring[j*s.channels+i]
+			}
+		}
+		s.head = (s.head + count) % capacity
+		s.size -= count
+		s.read += count
+		n += count
+		for len(s.segments) > 1 && s.segments[1].written <= s.read {
+			s.segments = s.segments[1:]
+		}
+		s.cond.Broadcast()
+	}
+
+	if n == 0 && frames > 0 {
+		return Track{}, s.err
+	}
+	for i, src := range s.buf.Samples() {
+		s.view[i] = src[:n]
+	}
+	return TrackOf(s.spec.Channels, s.view), nil
+}
+
+func (s *streamImpl) Buffered() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.size == 0 && (s.err != nil || s.closed) {
+		return -1
+	}
+	return s.size
+}
+
+func (s *streamImpl) Seek(frame int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+	if frame < 0 || (s.frames >= 0 && frame > s.frames) {
+		return debug.Errorf("Invalid seek to frame %d", frame)
+	}
+	s.flush(frame)
+	return nil
+}
+
+func (s *streamImpl) Loop(start, end int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+	if start >= 0 && ((end > 0 && end <= start) || (s.frames >= 0 && start >= s.frames)) {
+		return debug.Errorf("Invalid loop from frame %d to %d", start, end)
+	}
+	s.loopStart, s.loopEnd = start, end
+	s.flush(s.position())
+	return nil
+}
+
+func (s *streamImpl) Position() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.position()
+}
+
+func (s *streamImpl) position() int {
+	return s.segments[0].frame + s.read - s.segments[0].written
+}
+
+/*
+flush discards the buffer and makes the decoder restart at frame, must be
+called with s.mtx held.
+*/
+func (s *streamImpl) flush(frame int) {
+	s.head, s.size = 0, 0
+	s.read, s.written = 0, 0
+	s.segments = append(s.segments[:0], streamSegment{0, frame})
+	s.seek = frame
+	s.err = nil
+	s.cond.Broadcast()
+}
+
+func (s *streamImpl) Close() error {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return fs.ErrClosed
+	}
+	s.closed = true
+	s.cond.Broadcast()
+	s.mtx.Unlock()
+
+	<-s.done
+	if s.file != nil {
+		return s.file.Close()
+	}
+	return nil
+}
+
+/*
+run decodes into the ring buffer until Close, the decoder is only used outside
+of s.mtx so that Read is never blocked by decoding.
+*/
+func (s *streamImpl) run() {
+	defer close(s.done)
+
+	// chunk holds decoded frames that did not fit into the ring buffer yet,
+	// starting at frame and followed by the decoder's position next
+	var chunk []float32
+	frame, next := 0, 0
+	// looped is true until a frame is decoded after jumping to the loop start
+	looped := false
+	capacity := len(s.ring) / s.channels
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for {
+		for !s.closed && s.seek < 0 && (s.err != nil || (len(chunk) > 0 && s.size == capacity)) {
+			s.cond.Wait()
+		}
+		if s.closed {
+			return
+		}
+
+		if s.seek >= 0 {
+			target := s.seek
+			s.seek = -1
+			chunk, looped = chunk[:0], false
+			s.mtx.Unlock()
+			err := s.decoder.Seek(target)
+			s.mtx.Lock()
+			frame, next = target, target
+			if err != nil && s.seek < 0 {
+				s.err = err
+				s.cond.Broadcast()
+			}
+			continue
+		}
+
+		if len(chunk) > 0 {
+			if last := s.segments[len(s.segments)-1]; frame != last.frame+s.written-last.written {
+				s.segments = append(s.segments, streamSegment{s.written, frame})
+			}
+			count := min(len(chunk)/s.channels, capacity-s.size)
+			tail := (s.head + s.size) % capacity
+			count = min(count, capacity-tail)
+			copy(s.ring[tail*s.channels:], chunk[:count*s.channels])
+			chunk = chunk[count*s.channels:]
+			frame += count
+			s.size += count
+			s.written += count
+			s.cond.Broadcast()
+			continue
+		}
+
+		loopStart, loopEnd := s.loopStart, s.loopEnd
+		s.mtx.Unlock()
+
+		var err error
+		if loopStart >= 0 && loopEnd > 0 && next >= loopEnd {
+			err = s.decoder.Seek(loopStart)
+			next, looped = loopStart, true
+		}
+		var samples []float32
+		if err == nil {
+			samples, err = s.decoder.Decode()
+		}
+		if err == io.EOF && loopStart >= 0 && !looped {
+			// the end of the stream is also the end of the loop
+			err = s.decoder.Seek(loopStart)
+			next, looped = loopStart, true
+		}
+
+		s.mtx.Lock()
+		if s.seek >= 0 || s.closed {
+			continue
+		}
+		if err != nil {
+			s.err = err
+			s.cond.Broadcast()
+			continue
+		}
+		count := len(samples) / s.channels
+		if loopStart >= 0 && loopEnd > 0 && next+count > loopEnd {
+			count = max(0, loopEnd-next)
+		}
+		if count > 0 {
+			chunk = append(chunk[:0], samples[:count*s.channels]...)
+			frame, looped = next, false
+		}
+		next += len(samples) / s.channels
+	}
+}
+
+func (d *memoryDecoder) Spec() Spec {
+	return d.spec
+}
+
+func (d *memoryDecoder) Frames() int {
+	return len(d.track) / len(d.spec.Channels)
+}
+
+func (d *memoryDecoder) Decode() ([]float32, error) {
+	if d.pos >= len(d.track) {
+		return nil, io.EOF
+	}
+	end := min(len(d.track), d.pos+memoryStreamChunk*len(d.spec.Channels))
+	samples := d.track[d.pos:end]
+	d.pos = end
+	return samples, nil
+}
+
+func (d *memoryDecoder) Seek(frame int) error {
+	if frame < 0 || frame > d.Frames() {
+		return debug.Errorf("Invalid seek to frame %d", frame)
+	}
+	d.pos = frame * len(d.spec.Channels)
+	return nil
+}
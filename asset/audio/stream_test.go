@@ -0,0 +1,193 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"goarrg.com/asset"
+	"goarrg.com/internal/testutil"
+)
+
+/*
+countDecoder decodes stereo frames with the frame number on the left channel
+and its negation on the right, in chunks of an awkward size.
+*/
+type countDecoder struct {
+	frames int
+	pos    int
+	out    []float32
+}
+
+func (d *countDecoder) Spec() Spec {
+	return Spec{Channels: ChannelsStereo(), Frequency: 100}
+}
+
+func (d *countDecoder) Frames() int {
+	return d.frames
+}
+
+func (d *countDecoder) Decode() ([]float32, error) {
+	if d.pos >= d.frames {
+		return nil, io.EOF
+	}
+	d.out = d.out[:0]
+	for end := min(d.frames, d.pos+37); d.pos < end; d.pos++ {
+		d.out = append(d.out, float32(d.pos), -float32(d.pos))
+	}
+	return d.out, nil
+}
+
+func (d *countDecoder) Seek(frame int) error {
+	d.pos = frame
+	return nil
+}
+
+/*
+readFrames reads n frames in reads of 23 frames and checks that they are
+numbered want(0) to want(n-1).
+*/
+func readFrames(t *testing.T, s StreamAsset, n int, want func(int) int) {
+	t.Helper()
+	for i := 0; i < n; {
+		if p := s.Position(); p != want(i) {
+			t.Fatalf("frame %d: got position %d want %d", i, p, want(i))
+		}
+		track, err := s.Read(min(23, n-i))
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if len(track.Channel(ChannelLeft)) != min(23, n-i) || len(track.Channel(ChannelRight)) != len(track.Channel(ChannelLeft)) {
+			t.Fatalf("frame %d: got %d frames", i, len(track.Channel(ChannelLeft)))
+		}
+		for j, v := range track.Channel(ChannelLeft) {
+			if int(v) != want(i+j) || int(-track.Channel(ChannelRight)[j]) != want(i+j) {
+				t.Fatalf("frame %d: got %v %v want %d", i+j, v, track.Channel(ChannelRight)[j], want(i+j))
+			}
+		}
+		i += len(track.Channel(ChannelLeft))
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := newStream(nil, &countDecoder{frames: 1000}, StreamConfig{BufferFrames: 50})
+	defer s.Close()
+
+	if s.DurationSamples() != 1000 || s.DurationSeconds() != 10 {
+		t.Errorf("got duration %d %f", s.DurationSamples(), s.DurationSeconds())
+	}
+	readFrames(t, s, 300, func(i int) int { return i })
+
+	if err := s.Seek(900); err != nil {
+		t.Fatal(err)
+	}
+	readFrames(t, s, 90, func(i int) int { return 900 + i })
+	if track, err := s.Read(100); err != nil || len(track.Channel(ChannelLeft)) != 10 {
+		t.Fatalf("got %d frames at the end: %v", len(track.Channel(ChannelLeft)), err)
+	}
+	if _, err := s.Read(100); err != io.EOF {
+		t.Fatalf("got %v want io.EOF", err)
+	}
+	if err := s.Seek(1001); err == nil {
+		t.Errorf("seek past the end succeeded")
+	}
+
+	if err := s.Seek(150); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Loop(100, 200); err != nil {
+		t.Fatal(err)
+	}
+	readFrames(t, s, 500, func(i int) int { return 100 + (50+i)%100 })
+
+	// looping at the end of the stream
+	if err := s.Loop(950, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Seek(980); err != nil {
+		t.Fatal(err)
+	}
+	readFrames(t, s, 200, func(i int) int {
+		if i < 20 {
+			return 980 + i
+		}
+		return 950 + (i-20)%50
+	})
+
+	if err := s.Loop(-1, 0); err != nil {
+		t.Fatal(err)
+	}
+	readFrames(t, s, 1000-s.Position(), func(i int) int { return 980 + i })
+	if _, err := s.Read(1); err != io.EOF {
+		t.Fatalf("got %v want io.EOF", err)
+	}
+	if n := s.Buffered(); n != -1 {
+		t.Errorf("got %d buffered frames at the end", n)
+	}
+
+	if err := s.Loop(500, 400); err == nil {
+		t.Errorf("invalid loop succeeded")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Read(1); !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("got %v want fs.ErrClosed", err)
+	}
+}
+
+func TestLoadStream(t *testing.T) {
+	RegisterFormat("goarrg stream test", func(a *asset.File) (Spec, int, []float32, error) {
+		track := []float32{}
+		for i := 0; i < 10000; i++ {
+			track = append(track, float32(i), -float32(i))
+		}
+		return Spec{Channels: ChannelsStereo(), Frequency: 48000}, len(track), track, nil
+	})
+	RegisterFormat("goarrg stream huge", func(a *asset.File) (Spec, int, []float32, error) {
+		return Spec{Channels: ChannelsStereo(), Frequency: 1<<31 - 1}, 2, []float32{0, 0}, nil
+	})
+	dir := t.TempDir()
+	name := testutil.WriteFile(t, dir, "test", []byte("goarrg stream test"))
+
+	s, err := LoadStream(name, StreamConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.DurationSamples() != 10000 {
+		t.Errorf("got %d samples", s.DurationSamples())
+	}
+	// the default buffer is a second of audio but no longer than the stream
+	if frames := len(s.(*streamImpl).ring) / 2; frames != 10000 {
+		t.Errorf("got a %d frame buffer", frames)
+	}
+	readFrames(t, s, 10000, func(i int) int { return i })
+	if _, err := s.Read(1); err != io.EOF {
+		t.Fatalf("got %v want io.EOF", err)
+	}
+
+	if _, err := LoadStream(testutil.WriteFile(t, dir, "huge", []byte("goarrg stream huge")), StreamConfig{}); err == nil {
+		t.Error("loaded a stream with an invalid frequency")
+	}
+}
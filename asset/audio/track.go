@@ -0,0 +1,210 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"math/bits"
+	"sync"
+)
+
+/*
+Track is audio in 32 bit float planar format, a slice per channel holding the
+same number of frames in the order of Channels.
+
+Driver is then responsible for converting it into the appropriate signal for
+output, audio is non-interleaved to make it easier to support different channel
+orders. Channel finds a channel's slice through a table instead of searching,
+so it is cheap enough to call per block but should still be resolved once
+outside of per sample loops.
+
+Tracks are small values that share their samples when copied. The zero Track
+has no channels.
+*/
+type Track struct {
+	channels []Channel
+	samples  [][]float32
+	// index holds the position in samples plus 1 of the standard channels,
+	// 0 if the track does not have them
+	index [ChannelCount]uint8
+	// pooled is the allocation from GetTrack, nil if the track was not pooled
+	pooled *[]float32
+}
+
+/*
+trackPools hold the allocations of GetTrack by their capacity, pool i holding
+buffers of 1 << i samples.
+*/
+var trackPools [bits.UintSize]sync.Pool
+
+/*
+NewTrack returns a silent track of frames frames in channels with all the
+samples in one contiguous allocation.
+*/
+func NewTrack(channels []Channel, frames int) Track {
+	return newTrack(channels, frames, make([]float32, len(channels)*frames), nil)
+}
+
+/*
+GetTrack is NewTrack reusing allocations returned by PutTrack. The samples are
+not cleared.
+*/
+func GetTrack(channels []Channel, frames int) Track {
+	size := len(channels) * frames
+	if size == 0 {
+		return newTrack(channels, frames, nil, nil)
+	}
+	class := bits.Len(uint(size - 1))
+	buf, _ := trackPools[class].Get().(*[]float32)
+	if buf == nil {
+		b := make([]float32, 1<<class)
+		buf = &b
+	}
+	return newTrack(channels, frames, (*buf)[:size], buf)
+}
+
+/*
+PutTrack returns the allocation of a track from GetTrack for reuse, t and every
+copy of it must not be used afterwards. Tracks not from GetTrack are ignored.
+*/
+func PutTrack(t Track) {
+	if t.pooled == nil {
+		return
+	}
+	trackPools[bits.Len(uint(cap(*t.pooled)-1))].Put(t.pooled)
+}
+
+func newTrack(channels []Channel, frames int, buf []float32, pooled *[]float32) Track {
+	samples := make([][]float32, len(channels))
+	for i := range samples {
+		samples[i] = buf[i*frames : (i+1)*frames : (i+1)*frames]
+	}
+	t := TrackOf(channels, samples)
+	t.pooled = pooled
+	return t
+}
+
+/*
+TrackOf returns a track over samples, a slice per channel in channels, without
+copying them. It panics if the number of slices does not match the channels or
+they are of different lengths.
+*/
+func TrackOf(channels []Channel, samples [][]float32) Track {
+	if len(channels) != len(samples) {
+		panic("audio: track channel count mismatch")
+	}
+	t := Track{channels: channels, samples: samples}
+	for i, c := range channels {
+		if len(samples[i]) != len(samples[0]) {
+			panic("audio: track channel length mismatch")
+		}
+		if c < ChannelCount && t.index[c] == 0 {
+			t.index[c] = uint8(i + 1)
+		}
+	}
+	return t
+}
+
+/*
+Deinterleave returns the frames in samples, interleaved in channels, as a
+track.
+*/
+func Deinterleave(channels []Channel, samples []float32) Track {
+	if len(channels) == 0 {
+		return Track{}
+	}
+	t := NewTrack(channels, len(samples)/len(channels))
+	stride := len(channels)
+	for i, dst := range t.samples {
+		for j := range dst {
+			dst[j] = samples[j*stride+i]
+		}
+	}
+	return t
+}
+
+/*
+Interleave writes the frames of t into dst interleaved in channels, which need
+not be the track's own, and returns the number of frames written. Channels the
+track does not have are silent.
+*/
+func (t Track) Interleave(dst []float32, channels []Channel) int {
+	if len(channels) == 0 {
+		return 0
+	}
+	stride := len(channels)
+	frames := min(t.Frames(), len(dst)/stride)
+	for i, c := range channels {
+		src := t.Channel(c)
+		if src == nil {
+			for j := 0; j < frames; j++ {
+				dst[j*stride+i] = 0
+			}
+			continue
+		}
+		for j, s := range src[:frames] {
+			dst[j*stride+i] = s
+		}
+	}
+	return frames
+}
+
+func (t Track) Channels() []Channel {
+	return t.channels
+}
+
+/*
+Samples returns the samples of every channel in the order of Channels.
+*/
+func (t Track) Samples() [][]float32 {
+	return t.samples
+}
+
+/*
+Frames returns the length of the track in frames.
+*/
+func (t Track) Frames() int {
+	if len(t.samples) == 0 {
+		return 0
+	}
+	return len(t.samples[0])
+}
+
+/*
+Index returns the position of c in Channels or -1 if the track does not have
+it.
+*/
+func (t Track) Index(c Channel) int {
+	if c < ChannelCount {
+		return int(t.index[c]) - 1
+	}
+	for i, tc := range t.channels {
+		if tc == c {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+Channel returns the samples of c or nil if the track does not have it.
+*/
+func (t Track) Channel(c Channel) []float32 {
+	if i := t.Index(c); i >= 0 {
+		return t.samples[i]
+	}
+	return nil
+}
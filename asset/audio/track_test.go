@@ -0,0 +1,167 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audio
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTrack(t *testing.T) {
+	user := Channel(ChannelCount + 2)
+	channels := []Channel{ChannelRight, user, ChannelLeft}
+	track := NewTrack(channels, 4)
+	if track.Frames() != 4 || len(track.Samples()) != 3 {
+		t.Fatalf("got %d frames in %d channels", track.Frames(), len(track.Samples()))
+	}
+	for c, want := range map[Channel]int{ChannelRight: 0, user: 1, ChannelLeft: 2, ChannelCenter: -1, user + 1: -1} {
+		if got := track.Index(c); got != want {
+			t.Errorf("%v: got index %d want %d", c, got, want)
+		}
+	}
+	if track.Channel(ChannelCenter) != nil {
+		t.Error("got samples for a missing channel")
+	}
+	// channels are contiguous and do not overlap
+	track.Channel(ChannelLeft)[0] = 1
+	if track.Channel(user)[3] != 0 || track.Samples()[2][0] != 1 {
+		t.Errorf("got %v", track.Samples())
+	}
+	if cap(track.Channel(ChannelRight)) != 4 {
+		t.Errorf("got capacity %d", cap(track.Channel(ChannelRight)))
+	}
+
+	var zero Track
+	if zero.Frames() != 0 || zero.Channel(ChannelLeft) != nil || zero.Index(ChannelLeft) != -1 {
+		t.Error("zero track has samples")
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	interleaved := []float32{1, -1, 2, -2, 3, -3}
+	track := Deinterleave(ChannelsStereo(), interleaved)
+	if !slices.Equal(track.Channel(ChannelLeft), []float32{1, 2, 3}) || !slices.Equal(track.Channel(ChannelRight), []float32{-1, -2, -3}) {
+		t.Fatalf("got %v", track.Samples())
+	}
+
+	// reordered with a missing channel
+	dst := []float32{9, 9, 9, 9, 9, 9, 9, 9, 9}
+	if n := track.Interleave(dst, []Channel{ChannelRight, ChannelCenter, ChannelLeft}); n != 3 {
+		t.Fatalf("wrote %d frames", n)
+	}
+	if want := []float32{-1, 0, 1, -2, 0, 2, -3, 0, 3}; !slices.Equal(dst, want) {
+		t.Errorf("got %v want %v", dst, want)
+	}
+	// short destinations get whole frames
+	dst = make([]float32, 5)
+	if n := track.Interleave(dst, ChannelsStereo()); n != 2 || !slices.Equal(dst, []float32{1, -1, 2, -2, 0}) {
+		t.Errorf("wrote %d frames: %v", n, dst)
+	}
+}
+
+func TestGetTrack(t *testing.T) {
+	track := GetTrack(ChannelsStereo(), 100)
+	if track.Frames() != 100 || len(track.Channel(ChannelRight)) != 100 || cap(track.Channel(ChannelLeft)) != 100 {
+		t.Fatalf("got %d frames", track.Frames())
+	}
+	PutTrack(track)
+	PutTrack(NewTrack(ChannelsStereo(), 100))
+
+	track = GetTrack(Channels5Point1(), 30)
+	if track.Frames() != 30 || len(track.Samples()) != 6 {
+		t.Fatalf("got %d frames in %d channels", track.Frames(), len(track.Samples()))
+	}
+	PutTrack(track)
+	if track := GetTrack(ChannelsStereo(), 0); track.Frames() != 0 || len(track.Samples()) != 2 {
+		t.Errorf("got %d frames in %d channels", track.Frames(), len(track.Samples()))
+	}
+}
+
+/*
+mapTrack is how tracks used to be stored, benchmarked against Track.
+*/
+type mapTrack map[Channel][]float32
+
+func benchmarkSamples() []float32 {
+	samples := make([]float32, 48000*len(Channels5Point1()))
+	for i := range samples {
+		samples[i] = float32(i)
+	}
+	return samples
+}
+
+func BenchmarkDeinterleave(b *testing.B) {
+	channels := Channels5Point1()
+	samples := benchmarkSamples()
+
+	b.Run("Map", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			track := make(mapTrack)
+			for i, s := range samples {
+				track[channels[i%len(channels)]] = append(track[channels[i%len(channels)]], s)
+			}
+		}
+	})
+	b.Run("Planar", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			_ = Deinterleave(channels, samples)
+		}
+	})
+}
+
+func BenchmarkInterleave(b *testing.B) {
+	channels := Channels5Point1()
+	samples := benchmarkSamples()
+	track := Deinterleave(channels, samples)
+	old := make(mapTrack)
+	for i, c := range channels {
+		old[c] = track.Samples()[i]
+	}
+	dst := make([]float32, len(samples))
+
+	b.Run("Map", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i := range dst {
+				dst[i] = old[channels[i%len(channels)]][i/len(channels)]
+			}
+		}
+	})
+	b.Run("Planar", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			track.Interleave(dst, channels)
+		}
+	})
+}
+
+func BenchmarkGetTrack(b *testing.B) {
+	channels := ChannelsStereo()
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = NewTrack(channels, 4096)
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			PutTrack(GetTrack(channels, 4096))
+		}
+	})
+}
@@ -0,0 +1,363 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"math"
+	"math/bits"
+
+	"goarrg.com/debug"
+)
+
+/*
+bitReader reads packets LSB first, reading past the end sets eop and returns
+zero bits as the spec's end-of-packet condition.
+*/
+type bitReader struct {
+	data []byte
+	off  int
+	acc  uint64
+	n    uint
+	eop  bool
+}
+
+func (b *bitReader) fill() {
+	for b.n <= 56 && b.off < len(b.data) {
+		b.acc |= uint64(b.data[b.off]) << b.n
+		b.off++
+		b.n += 8
+	}
+}
+
+func (b *bitReader) read(n uint) uint32 {
+	if n == 0 {
+		return 0
+	}
+	if b.n < n {
+		b.fill()
+		if b.n < n {
+			v := b.acc
+			b.acc, b.n, b.eop = 0, 0, true
+			return uint32(v)
+		}
+	}
+	v := b.acc & (1<<n - 1)
+	b.acc >>= n
+	b.n -= n
+	return uint32(v)
+}
+
+func (b *bitReader) flag() bool {
+	return b.read(1) == 1
+}
+
+/*
+peek returns up to n bits without consuming them and the number of bits that
+are actually available.
+*/
+func (b *bitReader) peek(n uint) (uint32, uint) {
+	if b.n < n {
+		b.fill()
+	}
+	return uint32(b.acc & (1<<n - 1)), min(n, b.n)
+}
+
+func (b *bitReader) skip(n uint) {
+	b.acc >>= n
+	b.n -= n
+}
+
+func ilog(x int) uint {
+	if x <= 0 {
+		return 0
+	}
+	return uint(bits.Len(uint(x)))
+}
+
+func float32Unpack(x uint32) float32 {
+	mantissa := float64(x & 0x1FFFFF)
+	if x&0x80000000 != 0 {
+		mantissa = -mantissa
+	}
+	return float32(math.Ldexp(mantissa, int((x&0x7FE00000)>>21)-788))
+}
+
+/*
+lookup1Values returns the largest r such that r^dimensions <= entries.
+*/
+func lookup1Values(entries, dimensions int) int {
+	pow := func(r int) int {
+		v := 1
+		for i := 0; i < dimensions; i++ {
+			v *= r
+			if v > entries {
+				break
+			}
+		}
+		return v
+	}
+	r := int(math.Floor(math.Pow(float64(entries), 1/float64(dimensions))))
+	for pow(r+1) <= entries {
+		r++
+	}
+	for r > 0 && pow(r) > entries {
+		r--
+	}
+	return r
+}
+
+const codebookFastBits = 10
+
+type codebook struct {
+	dimensions int
+	entries    int
+	lengths    []uint8
+	// single is the only used entry of codebooks with one entry, -1 otherwise
+	single int
+	// fast maps the next codebookFastBits bits to entry<<8|length or 0
+	fast []uint32
+	// tree nodes for codewords longer than codebookFastBits, a child is either
+	// a node index > 0, -(entry+1) or 0 for none
+	tree [][2]int32
+	// lookup holds dimensions values for every entry, nil if the codebook has
+	// no VQ lookup
+	lookup []float32
+}
+
+func readCodebook(br *bitReader) (*codebook, error) {
+	if br.read(24) != 0x564342 {
+		return nil, debug.Errorf("Invalid codebook sync pattern")
+	}
+	c := &codebook{
+		dimensions: int(br.read(16)),
+		entries:    int(br.read(24)),
+		single:     -1,
+	}
+	if br.eop {
+		return nil, debug.Errorf("Truncated codebook")
+	}
+	if c.dimensions == 0 {
+		return nil, debug.Errorf("Invalid codebook dimensions")
+	}
+	c.lengths = make([]uint8, c.entries)
+
+	if br.flag() {
+		// ordered
+		length := br.read(5) + 1
+		for entry := 0; entry < c.entries; length++ {
+			n := int(br.read(ilog(c.entries - entry)))
+			if entry+n > c.entries || length > 32 {
+				return nil, debug.Errorf("Invalid ordered codebook lengths")
+			}
+			for i := entry; i < entry+n; i++ {
+				c.lengths[i] = uint8(length)
+			}
+			entry += n
+			if br.eop {
+				return nil, debug.Errorf("Truncated codebook")
+			}
+		}
+	} else {
+		sparse := br.flag()
+		for i := range c.lengths {
+			if !sparse || br.flag() {
+				c.lengths[i] = uint8(br.read(5) + 1)
+			}
+		}
+	}
+
+	switch lookupType := br.read(4); lookupType {
+	case 0:
+	case 1, 2:
+		minimum := float32Unpack(br.read(32))
+		delta := float32Unpack(br.read(32))
+		valueBits := uint(br.read(4) + 1)
+		sequence := br.flag()
+		values := c.entries * c.dimensions
+		if lookupType == 1 {
+			values = lookup1Values(c.entries, c.dimensions)
+		}
+		multiplicands := make([]float32, values)
+		for i := range multiplicands {
+			multiplicands[i] = float32(br.read(valueBits))*delta + minimum
+		}
+		if br.eop {
+			return nil, debug.Errorf("Truncated codebook")
+		}
+		c.lookup = make([]float32, c.entries*c.dimensions)
+		for entry := 0; entry < c.entries; entry++ {
+			if c.lengths[entry] == 0 {
+				continue
+			}
+			last := float32(0)
+			divisor := 1
+			for i := 0; i < c.dimensions; i++ {
+				offset := entry*c.dimensions + i
+				if lookupType == 1 {
+					offset = (entry / divisor) % values
+					divisor *= values
+				}
+				v := multiplicands[offset] + last
+				if sequence {
+					last = v
+				}
+				c.lookup[entry*c.dimensions+i] = v
+			}
+		}
+	default:
+		return nil, debug.Errorf("Invalid codebook lookup type %d", lookupType)
+	}
+	if br.eop {
+		return nil, debug.Errorf("Truncated codebook")
+	}
+	return c, c.buildHuffman()
+}
+
+/*
+buildHuffman assigns codewords as in the spec, each entry in order takes the
+lowest available codeword of its length.
+*/
+func (c *codebook) buildHuffman() error {
+	first, used := -1, 0
+	for i, l := range c.lengths {
+		if l > 0 {
+			used++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	switch used {
+	case 0:
+		return nil
+	case 1:
+		c.single = first
+		return nil
+	}
+
+	codes := make([]uint32, c.entries)
+	var available [33]uint32
+	for i := 1; i <= int(c.lengths[first]); i++ {
+		available[i] = 1 << (32 - i)
+	}
+	for i := first + 1; i < c.entries; i++ {
+		l := int(c.lengths[i])
+		if l == 0 {
+			continue
+		}
+		z := l
+		for z > 0 && available[z] == 0 {
+			z--
+		}
+		if z == 0 {
+			return debug.Errorf("Overspecified codebook")
+		}
+		code := available[z]
+		available[z] = 0
+		// reversed so that the first bit read is bit 0
+		codes[i] = bits.Reverse32(code)
+		for y := l; y > z; y-- {
+			available[y] = code + 1<<(32-y)
+		}
+	}
+
+	c.fast = make([]uint32, 1<<codebookFastBits)
+	c.tree = [][2]int32{{}}
+	for entry, l := range c.lengths {
+		if l == 0 {
+			continue
+		}
+		code := codes[entry]
+		if l <= codebookFastBits {
+			for k := code; k < 1<<codebookFastBits; k += 1 << l {
+				c.fast[k] = uint32(entry)<<8 | uint32(l)
+			}
+			continue
+		}
+		node := 0
+		for i := uint8(0); i < l; i++ {
+			bit := (code >> i) & 1
+			if i == l-1 {
+				c.tree[node][bit] = -int32(entry + 1)
+				break
+			}
+			if c.tree[node][bit] < 0 {
+				return debug.Errorf("Invalid codebook")
+			}
+			if c.tree[node][bit] == 0 {
+				c.tree = append(c.tree, [2]int32{})
+				c.tree[node][bit] = int32(len(c.tree) - 1)
+			}
+			node = int(c.tree[node][bit])
+		}
+	}
+	return nil
+}
+
+/*
+decode returns the next entry or -1 on end of packet or an invalid codeword.
+*/
+func (c *codebook) decode(br *bitReader) int {
+	if c.single >= 0 {
+		br.read(uint(c.lengths[c.single]))
+		if br.eop {
+			return -1
+		}
+		return c.single
+	}
+	if c.fast == nil {
+		return -1
+	}
+	v, available := br.peek(codebookFastBits)
+	if e := c.fast[v]; e != 0 {
+		if l := uint(e & 0xFF); l <= available {
+			br.skip(l)
+			return int(e >> 8)
+		}
+		br.eop = true
+		return -1
+	}
+	node := int32(0)
+	for {
+		bit := br.read(1)
+		if br.eop {
+			return -1
+		}
+		child := c.tree[node][bit]
+		if child < 0 {
+			return int(-child - 1)
+		}
+		if child == 0 {
+			return -1
+		}
+		node = child
+	}
+}
+
+/*
+decodeVQ returns the lookup vector of the next entry or nil.
+*/
+func (c *codebook) decodeVQ(br *bitReader) []float32 {
+	if c.lookup == nil {
+		return nil
+	}
+	e := c.decode(br)
+	if e < 0 {
+		return nil
+	}
+	return c.lookup[e*c.dimensions : (e+1)*c.dimensions]
+}
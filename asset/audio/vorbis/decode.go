@@ -0,0 +1,181 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"slices"
+)
+
+/*
+decodePacket decodes an audio packet into the windowed blocks and returns the
+block size, ok is false if the packet is not a valid audio packet and must be
+skipped.
+*/
+func (d *Decoder) decodePacket(p []byte) (n int, ok bool) {
+	br := &bitReader{data: p}
+	if br.flag() {
+		return 0, false
+	}
+	modeNumber := int(br.read(ilog(len(d.modes) - 1)))
+	if br.eop || modeNumber >= len(d.modes) {
+		return 0, false
+	}
+	mode := d.modes[modeNumber]
+	blockflag := 0
+	prevLong, nextLong := false, false
+	if mode.blockflag {
+		blockflag = 1
+		prevLong, nextLong = br.flag(), br.flag()
+		if br.eop {
+			return 0, false
+		}
+	}
+	n = d.blocksize[blockflag]
+	m := d.mappings[mode.mapping]
+
+	for ch := 0; ch < d.channels; ch++ {
+		f := d.floors[m.floors[m.mux[ch]]]
+		d.floorUnused[ch] = !f.decode(d, br, &d.floorState[ch])
+		d.noResidue[ch] = d.floorUnused[ch]
+	}
+	// coupled channels are both decoded if either has audio
+	for i := range m.magnitude {
+		if !d.noResidue[m.magnitude[i]] || !d.noResidue[m.angle[i]] {
+			d.noResidue[m.magnitude[i]], d.noResidue[m.angle[i]] = false, false
+		}
+	}
+
+	spectrum := make([][]float32, d.channels)
+	for ch := range spectrum {
+		spectrum[ch] = d.spectrum[ch][:n/2]
+		clear(spectrum[ch])
+	}
+	var vectors [][]float32
+	var doNotDecode []bool
+	for submap, r := range m.residues {
+		vectors, doNotDecode = vectors[:0], doNotDecode[:0]
+		for ch := 0; ch < d.channels; ch++ {
+			if m.mux[ch] == submap {
+				vectors = append(vectors, spectrum[ch])
+				doNotDecode = append(doNotDecode, d.noResidue[ch])
+			}
+		}
+		if len(vectors) > 0 {
+			d.residues[r].decode(d, br, vectors, doNotDecode)
+		}
+	}
+
+	for i := len(m.magnitude) - 1; i >= 0; i-- {
+		magnitude, angle := spectrum[m.magnitude[i]], spectrum[m.angle[i]]
+		for j := range magnitude {
+			mv, av := magnitude[j], angle[j]
+			switch {
+			case mv > 0 && av > 0:
+				angle[j] = mv - av
+			case mv > 0:
+				magnitude[j], angle[j] = mv+av, mv
+			case av > 0:
+				angle[j] = mv + av
+			default:
+				magnitude[j], angle[j] = mv-av, mv
+			}
+		}
+	}
+
+	t := d.imdct[blockflag]
+	for ch := 0; ch < d.channels; ch++ {
+		block := d.block[ch][:n]
+		if d.floorUnused[ch] {
+			// the channel is silent even if coupling gave it a residue
+			clear(block)
+			continue
+		}
+		d.floors[m.floors[m.mux[ch]]].apply(&d.floorState[ch], blockflag, spectrum[ch])
+		t.transform(spectrum[ch], block)
+		d.window(block, blockflag == 1, prevLong, nextLong)
+	}
+	return n, true
+}
+
+/*
+window applies the window of a block, long blocks next to short blocks use the
+short slope on that side.
+*/
+func (d *Decoder) window(block []float32, long, prevLong, nextLong bool) {
+	n := len(block)
+	leftN, rightN := n/2, n/2
+	if long && !prevLong {
+		leftN = d.blocksize[0] / 2
+	}
+	if long && !nextLong {
+		rightN = d.blocksize[0] / 2
+	}
+	leftSlope, rightSlope := d.imdct[0].slope, d.imdct[0].slope
+	if leftN != d.blocksize[0]/2 {
+		leftSlope = d.imdct[1].slope
+	}
+	if rightN != d.blocksize[0]/2 {
+		rightSlope = d.imdct[1].slope
+	}
+
+	leftStart := n/4 - leftN/2
+	clear(block[:leftStart])
+	for i, w := range leftSlope {
+		block[leftStart+i] *= w
+	}
+	rightStart := n*3/4 - rightN/2
+	for i, w := range rightSlope {
+		block[rightStart+rightN-1-i] *= w
+	}
+	clear(block[rightStart+rightN:])
+}
+
+/*
+overlap adds the left half of the block of size n to the right half of the
+previous block into d.out and returns the number of finished frames.
+*/
+func (d *Decoder) overlap(n int) int {
+	prevN := d.prevN
+	d.prevN = n
+	if prevN == 0 {
+		// the first block only primes the overlap
+		for ch := range d.prev {
+			copy(d.prev[ch], d.block[ch][n/2:n])
+		}
+		return 0
+	}
+
+	// the blocks are aligned on the centers of their overlapping slopes
+	frames := prevN/4 + n/4
+	offset := prevN/4 - n/4
+	d.out = slices.Grow(d.out[:0], frames*d.channels)[:frames*d.channels]
+	for ch := 0; ch < d.channels; ch++ {
+		prev, block := d.prev[ch][:prevN/2], d.block[ch]
+		for i := 0; i < frames; i++ {
+			v := float32(0)
+			if i < len(prev) {
+				v = prev[i]
+			}
+			if j := i - offset; j >= 0 && j < n/2 {
+				v += block[j]
+			}
+			d.out[i*d.channels+ch] = v
+		}
+		copy(d.prev[ch], block[n/2:n])
+	}
+	return frames
+}
@@ -0,0 +1,358 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"math"
+	"slices"
+
+	"goarrg.com/debug"
+)
+
+/*
+floorState is the per channel result of decoding a floor, applied once the
+residue is decoded.
+*/
+type floorState struct {
+	y            []int
+	coefficients []float32
+	amplitude    int
+}
+
+type floor interface {
+	// decode returns false if the floor is unused for the channel
+	decode(d *Decoder, br *bitReader, s *floorState) bool
+	// apply multiplies out, which is half a block, by the floor curve
+	apply(s *floorState, blockflag int, out []float32)
+}
+
+var floor1InverseDB = func() [256]float32 {
+	var t [256]float32
+	for i := range t {
+		// 140dB over 256 steps
+		t[i] = float32(math.Pow(10, float64(i-255)*140/256/20))
+	}
+	return t
+}()
+
+var floor1Range = [4]int{256, 128, 86, 64}
+
+type floor1 struct {
+	partitionClass  []int
+	classDimensions []int
+	classSubclasses []uint
+	classMasterbook []int
+	subclassBooks   [][]int
+	multiplier      int
+	x               []int
+	// sorted holds the indices of x in ascending order of x
+	sorted    []int
+	low, high []int
+}
+
+func (d *Decoder) readFloor1(br *bitReader) (floor, error) {
+	f := &floor1{partitionClass: make([]int, br.read(5))}
+	classes := 0
+	for i := range f.partitionClass {
+		f.partitionClass[i] = int(br.read(4))
+		classes = max(classes, f.partitionClass[i]+1)
+	}
+	f.classDimensions = make([]int, classes)
+	f.classSubclasses = make([]uint, classes)
+	f.classMasterbook = make([]int, classes)
+	f.subclassBooks = make([][]int, classes)
+	for c := 0; c < classes; c++ {
+		f.classDimensions[c] = int(br.read(3)) + 1
+		f.classSubclasses[c] = uint(br.read(2))
+		if f.classSubclasses[c] > 0 {
+			b, err := d.book(int(br.read(8)), false)
+			if err != nil {
+				return nil, err
+			}
+			f.classMasterbook[c] = b
+		}
+		f.subclassBooks[c] = make([]int, 1<<f.classSubclasses[c])
+		for j := range f.subclassBooks[c] {
+			b := int(br.read(8)) - 1
+			if b >= 0 {
+				if _, err := d.book(b, false); err != nil {
+					return nil, err
+				}
+			}
+			f.subclassBooks[c][j] = b
+		}
+	}
+	f.multiplier = int(br.read(2)) + 1
+	rangeBits := uint(br.read(4))
+	f.x = []int{0, 1 << rangeBits}
+	for _, c := range f.partitionClass {
+		for j := 0; j < f.classDimensions[c]; j++ {
+			f.x = append(f.x, int(br.read(rangeBits)))
+		}
+	}
+	if br.eop {
+		return nil, debug.Errorf("Truncated floor")
+	}
+	if len(f.x) > 65 {
+		return nil, debug.Errorf("Too many floor points %d", len(f.x))
+	}
+
+	f.sorted = make([]int, len(f.x))
+	for i := range f.sorted {
+		f.sorted[i] = i
+	}
+	slices.SortFunc(f.sorted, func(a, b int) int { return f.x[a] - f.x[b] })
+	for i := 1; i < len(f.sorted); i++ {
+		if f.x[f.sorted[i]] == f.x[f.sorted[i-1]] {
+			return nil, debug.Errorf("Duplicate floor point %d", f.x[f.sorted[i]])
+		}
+	}
+
+	f.low = make([]int, len(f.x))
+	f.high = make([]int, len(f.x))
+	for i := 2; i < len(f.x); i++ {
+		low, high := 0, 1
+		for j := 0; j < i; j++ {
+			if f.x[j] < f.x[i] && f.x[j] > f.x[low] {
+				low = j
+			}
+			if f.x[j] > f.x[i] && f.x[j] < f.x[high] {
+				high = j
+			}
+		}
+		f.low[i], f.high[i] = low, high
+	}
+	return f, nil
+}
+
+func (f *floor1) decode(d *Decoder, br *bitReader, s *floorState) bool {
+	if !br.flag() {
+		return false
+	}
+	bits := ilog(floor1Range[f.multiplier-1] - 1)
+	s.y = slices.Grow(s.y[:0], len(f.x))[:len(f.x)]
+	s.y[0] = int(br.read(bits))
+	s.y[1] = int(br.read(bits))
+	offset := 2
+	for _, class := range f.partitionClass {
+		cbits := f.classSubclasses[class]
+		cval := 0
+		if cbits > 0 {
+			if cval = d.codebooks[f.classMasterbook[class]].decode(br); cval < 0 {
+				return false
+			}
+		}
+		for j := 0; j < f.classDimensions[class]; j++ {
+			s.y[offset+j] = 0
+			if book := f.subclassBooks[class][cval&(1<<cbits-1)]; book >= 0 {
+				if s.y[offset+j] = d.codebooks[book].decode(br); s.y[offset+j] < 0 {
+					return false
+				}
+			}
+			cval >>= cbits
+		}
+		offset += f.classDimensions[class]
+	}
+	return !br.eop
+}
+
+func renderPoint(x0, y0, x1, y1, x int) int {
+	dy := y1 - y0
+	adx := x1 - x0
+	off := abs(dy) * (x - x0) / adx
+	if dy < 0 {
+		return y0 - off
+	}
+	return y0 + off
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func floor1Value(y int) float32 {
+	return floor1InverseDB[max(0, min(255, y))]
+}
+
+func renderLine(x0, y0, x1, y1 int, out []float32) {
+	dy := y1 - y0
+	adx := x1 - x0
+	base := dy / adx
+	sy := base + 1
+	if dy < 0 {
+		sy = base - 1
+	}
+	ady := abs(dy) - abs(base)*adx
+	y, err := y0, 0
+	if x0 < len(out) {
+		out[x0] *= floor1Value(y)
+	}
+	for x := x0 + 1; x < min(x1, len(out)); x++ {
+		err += ady
+		if err >= adx {
+			err -= adx
+			y += sy
+		} else {
+			y += base
+		}
+		out[x] *= floor1Value(y)
+	}
+}
+
+func (f *floor1) apply(s *floorState, _ int, out []float32) {
+	rng := floor1Range[f.multiplier-1]
+	var finalY [65]int
+	var step2 [65]bool
+	finalY[0], finalY[1] = s.y[0], s.y[1]
+	step2[0], step2[1] = true, true
+	for i := 2; i < len(f.x); i++ {
+		low, high := f.low[i], f.high[i]
+		predicted := renderPoint(f.x[low], finalY[low], f.x[high], finalY[high], f.x[i])
+		val := s.y[i]
+		highroom := rng - predicted
+		lowroom := predicted
+		room := min(highroom, lowroom) * 2
+		switch {
+		case val == 0:
+			finalY[i] = predicted
+			continue
+		case val >= room:
+			if highroom > lowroom {
+				finalY[i] = val - lowroom + predicted
+			} else {
+				finalY[i] = predicted - val + highroom - 1
+			}
+		case val%2 == 1:
+			finalY[i] = predicted - (val+1)/2
+		default:
+			finalY[i] = predicted + val/2
+		}
+		step2[low], step2[high], step2[i] = true, true, true
+	}
+
+	lx, ly := 0, finalY[f.sorted[0]]*f.multiplier
+	hx, hy := 0, 0
+	for _, i := range f.sorted[1:] {
+		if step2[i] {
+			hx, hy = f.x[i], finalY[i]*f.multiplier
+			renderLine(lx, ly, hx, hy, out)
+			lx, ly = hx, hy
+		}
+	}
+	if hx < len(out) {
+		renderLine(hx, hy, len(out), hy, out)
+	}
+}
+
+type floor0 struct {
+	order           int
+	barkMapSize     int
+	amplitudeBits   uint
+	amplitudeOffset int
+	books           []int
+	// maps are the bark maps for short and long blocks
+	maps [2][]int
+}
+
+func (d *Decoder) readFloor0(br *bitReader) (floor, error) {
+	f := &floor0{order: int(br.read(8))}
+	rate := float64(br.read(16))
+	f.barkMapSize = int(br.read(16))
+	f.amplitudeBits = uint(br.read(6))
+	f.amplitudeOffset = int(br.read(8))
+	f.books = make([]int, br.read(4)+1)
+	for i := range f.books {
+		b, err := d.book(int(br.read(8)), true)
+		if err != nil {
+			return nil, err
+		}
+		f.books[i] = b
+	}
+	if br.eop {
+		return nil, debug.Errorf("Truncated floor")
+	}
+	if f.order == 0 || rate == 0 || f.barkMapSize == 0 || f.amplitudeBits == 0 {
+		return nil, debug.Errorf("Invalid floor0 parameters")
+	}
+
+	bark := func(x float64) float64 {
+		return 13.1*math.Atan(.00074*x) + 2.24*math.Atan(.0000000185*x*x) + .0001*x
+	}
+	for flag, blocksize := range d.blocksize {
+		n := blocksize / 2
+		m := make([]int, n)
+		for i := range m {
+			v := int(math.Floor(bark(rate*float64(i)/float64(2*n)) * float64(f.barkMapSize) / bark(.5*rate)))
+			m[i] = min(f.barkMapSize-1, v)
+		}
+		f.maps[flag] = m
+	}
+	return f, nil
+}
+
+func (f *floor0) decode(d *Decoder, br *bitReader, s *floorState) bool {
+	s.amplitude = int(br.read(f.amplitudeBits))
+	if s.amplitude == 0 {
+		return false
+	}
+	bookNumber := int(br.read(ilog(len(f.books))))
+	if bookNumber >= len(f.books) {
+		return false
+	}
+	book := d.codebooks[f.books[bookNumber]]
+	s.coefficients = s.coefficients[:0]
+	last := float32(0)
+	for len(s.coefficients) < f.order {
+		v := book.decodeVQ(br)
+		if v == nil {
+			return false
+		}
+		for _, c := range v {
+			s.coefficients = append(s.coefficients, c+last)
+		}
+		last = s.coefficients[len(s.coefficients)-1]
+	}
+	return !br.eop
+}
+
+func (f *floor0) apply(s *floorState, blockflag int, out []float32) {
+	m := f.maps[blockflag]
+	for i := 0; i < len(out); {
+		w := math.Cos(math.Pi * float64(m[i]) / float64(f.barkMapSize))
+		var p, q float64
+		if f.order%2 == 1 {
+			p, q = 1-w*w, 0.25
+		} else {
+			p, q = (1-w)/2, (1+w)/2
+		}
+		for k := 0; k < f.order; k++ {
+			t := math.Cos(float64(s.coefficients[k])) - w
+			if k%2 == 1 {
+				p *= 4 * t * t
+			} else {
+				q *= 4 * t * t
+			}
+		}
+		v := float32(math.Exp(0.11512925 * (float64(s.amplitude*f.amplitudeOffset)/(float64(int(1)<<f.amplitudeBits-1)*math.Sqrt(p+q)) - float64(f.amplitudeOffset))))
+		for cond := m[i]; i < len(out) && m[i] == cond; i++ {
+			out[i] *= v
+		}
+	}
+}
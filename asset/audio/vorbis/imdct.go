@@ -0,0 +1,112 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+/*
+imdct computes the unnormalized inverse MDCT of n/2 coefficients into n
+samples
+
+	y[i] = sum(X[k] * cos(pi/(n/2) * (i + 1/2 + n/4) * (k + 1/2)))
+
+through a DCT-IV of size n/2, which is in turn computed with an n/4 point
+complex FFT.
+*/
+type imdct struct {
+	n       int
+	pre     []complex128
+	post    []complex128
+	twiddle []complex128
+	bitrev  []int
+	fft     []complex128
+	z       []float32
+	// slope is the rising half of the window for overlaps of n/2
+	slope []float32
+}
+
+func newIMDCT(n int) *imdct {
+	m := n / 2
+	q := n / 4
+	t := &imdct{
+		n:       n,
+		pre:     make([]complex128, q),
+		post:    make([]complex128, q),
+		twiddle: make([]complex128, q/2),
+		bitrev:  make([]int, q),
+		fft:     make([]complex128, q),
+		z:       make([]float32, m),
+		slope:   make([]float32, m),
+	}
+	for k := 0; k < q; k++ {
+		t.pre[k] = cmplx.Exp(complex(0, -math.Pi*float64(k)/float64(m)))
+		t.post[k] = cmplx.Exp(complex(0, -math.Pi*(float64(k)+0.25)/float64(m)))
+		t.bitrev[k] = int(bits.Reverse(uint(k)) >> (bits.UintSize - bits.Len(uint(q-1))))
+	}
+	for k := range t.twiddle {
+		t.twiddle[k] = cmplx.Exp(complex(0, -2*math.Pi*float64(k)/float64(q)))
+	}
+	for i := range t.slope {
+		s := math.Sin((float64(i) + 0.5) / float64(m) * math.Pi / 2)
+		t.slope[i] = float32(math.Sin(math.Pi / 2 * s * s))
+	}
+	return t
+}
+
+func (t *imdct) transform(in, out []float32) {
+	m := t.n / 2
+	q := t.n / 4
+
+	// DCT-IV of in into z, the even and odd inputs are packed into one complex
+	// sequence so that z[2p] and z[m-1-2p] come out of the same FFT bin
+	for k := 0; k < q; k++ {
+		t.fft[t.bitrev[k]] = complex(float64(in[2*k]), float64(in[m-1-2*k])) * t.pre[k]
+	}
+	for size := 2; size <= q; size <<= 1 {
+		half := size / 2
+		step := q / size
+		for start := 0; start < q; start += size {
+			for j := 0; j < half; j++ {
+				a := t.fft[start+j]
+				b := t.fft[start+j+half] * t.twiddle[j*step]
+				t.fft[start+j] = a + b
+				t.fft[start+j+half] = a - b
+			}
+		}
+	}
+	for p := 0; p < q; p++ {
+		s := t.fft[p] * t.post[p]
+		t.z[2*p] = float32(real(s))
+		t.z[m-1-2*p] = float32(-imag(s))
+	}
+
+	// the IMDCT is the DCT-IV shifted by n/4 using its symmetries
+	for i := 0; i < t.n; i++ {
+		switch j := i + q; {
+		case j < m:
+			out[i] = t.z[j]
+		case j < 2*m:
+			out[i] = -t.z[2*m-1-j]
+		default:
+			out[i] = -t.z[j-2*m]
+		}
+	}
+}
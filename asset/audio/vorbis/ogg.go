@@ -0,0 +1,245 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"slices"
+)
+
+const (
+	oggHeaderSize = 27
+
+	oggFlagContinued = 0x01
+	oggFlagBOS       = 0x02
+	oggFlagEOS       = 0x04
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = (r << 1) ^ 0x04C11DB7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+func oggCRC(crc uint32, b []byte) uint32 {
+	for _, c := range b {
+		crc = (crc << 8) ^ oggCRCTable[byte(crc>>24)^c]
+	}
+	return crc
+}
+
+type oggPacket struct {
+	data []byte
+	// granule is the granule position of the page the packet ends on if it is
+	// the last packet to end on that page, -1 otherwise
+	granule int64
+	eos     bool
+}
+
+/*
+oggReader returns the packets of the first logical stream, pages of other
+streams and pages failing the CRC are skipped.
+*/
+type oggReader struct {
+	data    []byte
+	off     int
+	serial  uint32
+	started bool
+	done    bool
+	partial []byte
+	// true if partial is the start of a packet, false if it is lost
+	partialValid bool
+	packets      []oggPacket
+}
+
+/*
+page parses the page at off, returning its header fields, segment table and
+body or ok false if there is no valid page there.
+*/
+func (r *oggReader) page(off int) (flags byte, granule int64, serial uint32, lacing, body []byte, ok bool) {
+	d := r.data[off:]
+	if len(d) < oggHeaderSize || string(d[:4]) != "OggS" || d[4] != 0 {
+		return
+	}
+	segments := int(d[26])
+	if len(d) < oggHeaderSize+segments {
+		return
+	}
+	lacing = d[oggHeaderSize : oggHeaderSize+segments]
+	size := 0
+	for _, l := range lacing {
+		size += int(l)
+	}
+	end := oggHeaderSize + segments + size
+	if len(d) < end {
+		return
+	}
+
+	crc := oggCRC(0, d[:22])
+	crc = oggCRC(crc, []byte{0, 0, 0, 0})
+	crc = oggCRC(crc, d[26:end])
+	if crc != binary.LittleEndian.Uint32(d[22:]) {
+		return
+	}
+	return d[5], int64(binary.LittleEndian.Uint64(d[6:])), binary.LittleEndian.Uint32(d[14:]), lacing, d[oggHeaderSize+segments : end], true
+}
+
+func (r *oggReader) next() (oggPacket, error) {
+	for len(r.packets) == 0 {
+		if r.done {
+			return oggPacket{}, io.EOF
+		}
+		if err := r.readPage(); err != nil {
+			return oggPacket{}, err
+		}
+	}
+	p := r.packets[0]
+	r.packets = r.packets[1:]
+	return p, nil
+}
+
+func (r *oggReader) readPage() error {
+	flags, granule, serial, lacing, body, ok := r.page(r.off)
+	if !ok {
+		// resync on the next capture pattern
+		i := bytes.Index(r.data[min(len(r.data), r.off+1):], []byte("OggS"))
+		if i < 0 {
+			r.done = true
+			if !r.started {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		r.off += 1 + i
+		r.partial, r.partialValid = nil, false
+		return nil
+	}
+	r.off += oggHeaderSize + len(lacing) + len(body)
+
+	if !r.started {
+		if flags&oggFlagBOS == 0 {
+			return nil
+		}
+		r.started, r.serial = true, serial
+	} else if serial != r.serial {
+		return nil
+	}
+
+	if flags&oggFlagContinued == 0 {
+		r.partial, r.partialValid = nil, true
+	}
+	last := -1
+	start := len(r.packets)
+	for _, l := range lacing {
+		seg := body[:l]
+		body = body[l:]
+		if r.partialValid {
+			if r.partial == nil && l < 255 {
+				// the common case of a packet within a page is not copied
+				r.packets = append(r.packets, oggPacket{data: seg, granule: -1})
+			} else {
+				r.partial = append(r.partial, seg...)
+				if l < 255 {
+					r.packets = append(r.packets, oggPacket{data: r.partial, granule: -1})
+					r.partial = nil
+				}
+			}
+		}
+		if l < 255 {
+			r.partialValid = true
+			last = len(r.packets) - 1
+		}
+	}
+	if last >= start {
+		r.packets[last].granule = granule
+		if flags&oggFlagEOS != 0 {
+			r.packets[last].eos = true
+		}
+	}
+	if flags&oggFlagEOS != 0 {
+		r.done = true
+	}
+	return nil
+}
+
+/*
+clone returns a copy of r that does not share buffers with it.
+*/
+func (r *oggReader) clone() oggReader {
+	c := *r
+	c.partial = slices.Clone(r.partial)
+	c.packets = slices.Clone(r.packets)
+	return c
+}
+
+/*
+seekPage returns the offset of the page following the last page of the stream
+that ends before frame, or -1 if there is none.
+*/
+func (r *oggReader) seekPage(off int, frame int64) int {
+	found := -1
+	for off < len(r.data) {
+		flags, granule, serial, lacing, body, ok := r.page(off)
+		if !ok {
+			i := bytes.Index(r.data[off+1:], []byte("OggS"))
+			if i < 0 {
+				break
+			}
+			off += 1 + i
+			continue
+		}
+		off += oggHeaderSize + len(lacing) + len(body)
+		if serial != r.serial || granule < 0 {
+			continue
+		}
+		if granule > frame || flags&oggFlagEOS != 0 {
+			break
+		}
+		found = off
+	}
+	return found
+}
+
+/*
+lastGranule returns the granule position of the last page of the stream or -1
+without decoding anything.
+*/
+func (r *oggReader) lastGranule() int64 {
+	for end := len(r.data); end > 0; {
+		i := bytes.LastIndex(r.data[:end], []byte("OggS"))
+		if i < 0 {
+			break
+		}
+		if _, granule, serial, _, _, ok := r.page(i); ok && serial == r.serial && granule >= 0 {
+			return granule
+		}
+		end = i
+	}
+	return -1
+}
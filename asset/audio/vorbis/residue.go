@@ -0,0 +1,180 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"slices"
+
+	"goarrg.com/debug"
+)
+
+type residue struct {
+	typ             int
+	begin, end      int
+	partitionSize   int
+	classifications int
+	classbook       int
+	// books holds the codebook of each pass for every classification or -1
+	books [][8]int
+}
+
+func (d *Decoder) readResidue(br *bitReader) (*residue, error) {
+	r := &residue{typ: int(br.read(16))}
+	if r.typ > 2 {
+		return nil, debug.Errorf("Invalid type %d", r.typ)
+	}
+	r.begin = int(br.read(24))
+	r.end = int(br.read(24))
+	r.partitionSize = int(br.read(24)) + 1
+	r.classifications = int(br.read(6)) + 1
+	var err error
+	if r.classbook, err = d.book(int(br.read(8)), false); err != nil {
+		return nil, err
+	}
+	cascade := make([]uint32, r.classifications)
+	for i := range cascade {
+		cascade[i] = br.read(3)
+		if br.flag() {
+			cascade[i] |= br.read(5) << 3
+		}
+	}
+	r.books = make([][8]int, r.classifications)
+	for i := range r.books {
+		for j := range r.books[i] {
+			r.books[i][j] = -1
+			if cascade[i]&(1<<j) != 0 {
+				if r.books[i][j], err = d.book(int(br.read(8)), true); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if br.eop {
+		return nil, debug.Errorf("Truncated residue")
+	}
+	return r, nil
+}
+
+/*
+decode adds the residue of the channels to vectors, which are half a block
+long. Decoding stops silently at the end of the packet as the spec requires.
+*/
+func (r *residue) decode(d *Decoder, br *bitReader, vectors [][]float32, doNotDecode []bool) {
+	if r.typ != 2 {
+		r.decodeVectors(d, br, vectors, doNotDecode)
+		return
+	}
+
+	// type 2 is type 1 on the channels interleaved into one vector
+	if !slices.Contains(doNotDecode, false) {
+		return
+	}
+	n := len(vectors[0])
+	channels := len(vectors)
+	buf := slices.Grow(d.residueBuf[:0], n*channels)[:n*channels]
+	d.residueBuf = buf
+	clear(buf)
+	r.decodeVectors(d, br, [][]float32{buf}, []bool{false})
+	for i := 0; i < n; i++ {
+		for c, v := range vectors {
+			v[i] += buf[i*channels+c]
+		}
+	}
+}
+
+func (r *residue) decodeVectors(d *Decoder, br *bitReader, vectors [][]float32, doNotDecode []bool) {
+	size := len(vectors[0])
+	begin := min(r.begin, size)
+	end := min(r.end, size)
+	if end <= begin {
+		return
+	}
+	classbook := d.codebooks[r.classbook]
+	perCodeword := classbook.dimensions
+	partitions := (end - begin) / r.partitionSize
+
+	for len(d.classes) < len(vectors) {
+		d.classes = append(d.classes, nil)
+	}
+	for j := range vectors {
+		d.classes[j] = slices.Grow(d.classes[j][:0], partitions+perCodeword)[:partitions+perCodeword]
+	}
+
+	for pass := 0; pass < 8; pass++ {
+		for partition := 0; partition < partitions; {
+			if pass == 0 {
+				for j := range vectors {
+					if doNotDecode[j] {
+						continue
+					}
+					temp := classbook.decode(br)
+					if temp < 0 {
+						return
+					}
+					for i := perCodeword - 1; i >= 0; i-- {
+						d.classes[j][i+partition] = temp % r.classifications
+						temp /= r.classifications
+					}
+				}
+			}
+			for i := 0; i < perCodeword && partition < partitions; i, partition = i+1, partition+1 {
+				for j, v := range vectors {
+					if doNotDecode[j] {
+						continue
+					}
+					book := r.books[d.classes[j][partition]][pass]
+					if book < 0 {
+						continue
+					}
+					offset := begin + partition*r.partitionSize
+					if !r.decodePartition(d.codebooks[book], br, v[offset:offset+r.partitionSize]) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func (r *residue) decodePartition(book *codebook, br *bitReader, v []float32) bool {
+	if r.typ == 0 {
+		step := len(v) / book.dimensions
+		for i := 0; i < step; i++ {
+			e := book.decodeVQ(br)
+			if e == nil {
+				return false
+			}
+			for k, x := range e {
+				v[i+k*step] += x
+			}
+		}
+		return true
+	}
+	for i := 0; i < len(v); {
+		e := book.decodeVQ(br)
+		if e == nil {
+			return false
+		}
+		for _, x := range e {
+			if i < len(v) {
+				v[i] += x
+			}
+			i++
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"goarrg.com/debug"
+)
+
+type mapping struct {
+	magnitude []int
+	angle     []int
+	mux       []int
+	floors    []int
+	residues  []int
+}
+
+func (d *Decoder) parseSetup(b []byte) error {
+	br := &bitReader{data: b}
+
+	d.codebooks = make([]*codebook, br.read(8)+1)
+	for i := range d.codebooks {
+		c, err := readCodebook(br)
+		if err != nil {
+			return debug.ErrorWrapf(err, "Invalid codebook %d", i)
+		}
+		d.codebooks[i] = c
+	}
+
+	for i := br.read(6) + 1; i > 0; i-- {
+		if br.read(16) != 0 {
+			return debug.Errorf("Invalid time domain transform")
+		}
+	}
+
+	d.floors = make([]floor, br.read(6)+1)
+	for i := range d.floors {
+		var err error
+		switch t := br.read(16); t {
+		case 0:
+			d.floors[i], err = d.readFloor0(br)
+		case 1:
+			d.floors[i], err = d.readFloor1(br)
+		default:
+			err = debug.Errorf("Invalid type %d", t)
+		}
+		if err != nil {
+			return debug.ErrorWrapf(err, "Invalid floor %d", i)
+		}
+	}
+
+	d.residues = make([]*residue, br.read(6)+1)
+	for i := range d.residues {
+		r, err := d.readResidue(br)
+		if err != nil {
+			return debug.ErrorWrapf(err, "Invalid residue %d", i)
+		}
+		d.residues[i] = r
+	}
+
+	d.mappings = make([]*mapping, br.read(6)+1)
+	for i := range d.mappings {
+		m, err := d.readMapping(br)
+		if err != nil {
+			return debug.ErrorWrapf(err, "Invalid mapping %d", i)
+		}
+		d.mappings[i] = m
+	}
+
+	d.modes = make([]mode, br.read(6)+1)
+	for i := range d.modes {
+		m := mode{blockflag: br.flag()}
+		windowType, transformType := br.read(16), br.read(16)
+		m.mapping = int(br.read(8))
+		if windowType != 0 || transformType != 0 || m.mapping >= len(d.mappings) {
+			return debug.Errorf("Invalid mode %d", i)
+		}
+		d.modes[i] = m
+	}
+
+	if !br.flag() || br.eop {
+		return debug.Errorf("Truncated setup header")
+	}
+	return nil
+}
+
+func (d *Decoder) book(i int, vq bool) (int, error) {
+	if i >= len(d.codebooks) {
+		return 0, debug.Errorf("Invalid codebook %d", i)
+	}
+	if vq && d.codebooks[i].lookup == nil {
+		return 0, debug.Errorf("Codebook %d has no VQ lookup", i)
+	}
+	return i, nil
+}
+
+func (d *Decoder) readMapping(br *bitReader) (*mapping, error) {
+	if t := br.read(16); t != 0 {
+		return nil, debug.Errorf("Invalid type %d", t)
+	}
+	submaps := 1
+	if br.flag() {
+		submaps = int(br.read(4)) + 1
+	}
+	m := &mapping{mux: make([]int, d.channels)}
+	if br.flag() {
+		bits := ilog(d.channels - 1)
+		for i := br.read(8) + 1; i > 0; i-- {
+			magnitude, angle := int(br.read(bits)), int(br.read(bits))
+			if magnitude == angle || magnitude >= d.channels || angle >= d.channels {
+				return nil, debug.Errorf("Invalid channel coupling")
+			}
+			m.magnitude = append(m.magnitude, magnitude)
+			m.angle = append(m.angle, angle)
+		}
+	}
+	if br.read(2) != 0 {
+		return nil, debug.Errorf("Invalid reserved field")
+	}
+	if submaps > 1 {
+		for i := range m.mux {
+			m.mux[i] = int(br.read(4))
+			if m.mux[i] >= submaps {
+				return nil, debug.Errorf("Invalid submap %d", m.mux[i])
+			}
+		}
+	}
+	for i := 0; i < submaps; i++ {
+		br.read(8)
+		f, r := int(br.read(8)), int(br.read(8))
+		if f >= len(d.floors) || r >= len(d.residues) {
+			return nil, debug.Errorf("Invalid submap %d", i)
+		}
+		m.floors = append(m.floors, f)
+		m.residues = append(m.residues, r)
+	}
+	if br.eop {
+		return nil, debug.Errorf("Truncated mapping")
+	}
+	return m, nil
+}
@@ -0,0 +1,480 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package vorbis registers a pure Go Ogg Vorbis decoder with the audio package,
+import it for its side effects:
+
+	import _ "goarrg.com/asset/audio/vorbis"
+
+Only the first logical stream of a file is decoded. Decoder and LoadComments
+give access to the comment header, e.g. the LOOPSTART and LOOPLENGTH tags
+used to loop music. The loop and CHAPTERxxx markers are also read as
+audio.Metadata.
+*/
+package vorbis
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"goarrg.com/asset"
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+func init() {
+	audio.RegisterStreamFormat("OggS", decode, stream)
+	audio.RegisterMetadata("OggS", metadata)
+}
+
+func decode(a *asset.File) (audio.Spec, int, []float32, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return audio.Spec{}, 0, nil, debug.ErrorWrapf(err, "Failed to decode Vorbis")
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return audio.Spec{}, 0, nil, err
+	}
+	track := make([]float32, 0, max(0, d.Frames())*d.channels)
+	for {
+		samples, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return audio.Spec{}, 0, nil, err
+		}
+		track = append(track, samples...)
+	}
+	return d.Spec(), len(track), track, nil
+}
+
+func metadata(a *asset.File) (audio.Metadata, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return audio.Metadata{}, debug.ErrorWrapf(err, "Failed to read Vorbis metadata")
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return audio.Metadata{}, err
+	}
+	m := audio.Metadata{Markers: d.comments.Chapters(d.rate)}
+	if start, length, ok := d.comments.Loop(); ok {
+		m.LoopStart, m.LoopEnd = start, start+length
+	}
+	return m, nil
+}
+
+func stream(a *asset.File) (audio.StreamDecoder, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode Vorbis")
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+/*
+channelList maps the Vorbis channel order onto audio.Channel.
+*/
+func channelList(count int) []audio.Channel {
+	l, r, c := audio.ChannelLeft, audio.ChannelRight, audio.ChannelCenter
+	lfe := audio.ChannelLowFrequency
+	sl, sr := audio.ChannelSurroundLeft, audio.ChannelSurroundRight
+	bl, br := audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight
+	switch count {
+	case 1:
+		return audio.ChannelsMono()
+	case 2:
+		return []audio.Channel{l, r}
+	case 3:
+		return []audio.Channel{l, c, r}
+	case 4:
+		return []audio.Channel{l, r, bl, br}
+	case 5:
+		return []audio.Channel{l, c, r, sl, sr}
+	case 6:
+		return []audio.Channel{l, c, r, sl, sr, lfe}
+	case 7:
+		return []audio.Channel{l, c, r, sl, sr, audio.ChannelBackCenter, lfe}
+	case 8:
+		return []audio.Channel{l, c, r, sl, sr, bl, br, lfe}
+	}
+	channels := make([]audio.Channel, count)
+	for i := range channels {
+		channels[i] = audio.ChannelUnknown + audio.Channel(i)
+	}
+	return channels
+}
+
+/*
+Comments is the content of the comment header, field names are upper cased as
+they are case insensitive.
+*/
+type Comments struct {
+	Vendor string
+	Fields map[string][]string
+}
+
+/*
+Get returns the first value of the field or "".
+*/
+func (c *Comments) Get(name string) string {
+	if v := c.Fields[strings.ToUpper(name)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+/*
+Loop returns the loop region in frames from the LOOPSTART tag and either the
+LOOPLENGTH or LOOPEND tag, ok is false if the tags are missing or invalid.
+*/
+func (c *Comments) Loop() (start, length int, ok bool) {
+	start, err := strconv.Atoi(c.Get("LOOPSTART"))
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if v := c.Get("LOOPLENGTH"); v != "" {
+		length, err = strconv.Atoi(v)
+	} else {
+		var end int
+		end, err = strconv.Atoi(c.Get("LOOPEND"))
+		length = end - start
+	}
+	if err != nil || length <= 0 {
+		return 0, 0, false
+	}
+	return start, length, true
+}
+
+/*
+Chapters returns the markers of the CHAPTERxxx tags at frequency, which hold
+the time as HH:MM:SS.sss, labeled by the CHAPTERxxxNAME tags. Chapters are
+numbered from 000 or 001 without gaps.
+*/
+func (c *Comments) Chapters(frequency int) []audio.Marker {
+	var markers []audio.Marker
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("CHAPTER%03d", i)
+		v := c.Get(key)
+		if v == "" {
+			if i == 0 {
+				continue
+			}
+			break
+		}
+		seconds, ok := parseChapterTime(v)
+		if !ok {
+			continue
+		}
+		markers = append(markers, audio.Marker{
+			Frame: int(seconds*float64(frequency) + 0.5),
+			Label: c.Get(key + "NAME"),
+		})
+	}
+	return markers
+}
+
+/*
+parseChapterTime parses HH:MM:SS.sss into seconds.
+*/
+func parseChapterTime(v string) (float64, bool) {
+	parts := strings.Split(v, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	seconds := 0.0
+	for _, p := range parts {
+		f, err := strconv.ParseFloat(p, 64)
+		if err != nil || f < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + f
+	}
+	return seconds, true
+}
+
+/*
+LoadComments reads the comment header of an Ogg Vorbis file without decoding
+the audio.
+*/
+func LoadComments(file string) (*Comments, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load comments")
+	}
+	defer a.Close()
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load comments %q", file)
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load comments %q", file)
+	}
+	return d.Comments(), nil
+}
+
+type mode struct {
+	blockflag bool
+	mapping   int
+}
+
+/*
+Decoder decodes an Ogg Vorbis stream one packet at a time.
+*/
+type Decoder struct {
+	ogg oggReader
+	// start is the reader positioned at the first audio packet
+	start     oggReader
+	channels  int
+	rate      int
+	blocksize [2]int
+	comments  Comments
+
+	codebooks []*codebook
+	floors    []floor
+	residues  []*residue
+	mappings  []*mapping
+	modes     []mode
+
+	floorState  []floorState
+	floorUnused []bool
+	noResidue   []bool
+	spectrum    [][]float32
+	residueBuf  []float32
+	classes     [][]int
+	imdct       [2]*imdct
+	block       [][]float32
+	// prev holds the windowed right half of the previous block
+	prev  [][]float32
+	prevN int
+	out   []float32
+
+	frames int64
+	// decoded is the position of the next frame to decode, or -1 after a
+	// seek until a packet with a granule position is found
+	decoded int64
+	// pending holds the frames decoded after a seek while the position is
+	// unknown
+	pending []float32
+	// target is the frame to seek to, frames before it are dropped
+	target int64
+}
+
+/*
+NewDecoder parses the headers of the stream in data, which must stay valid
+while the decoder is in use.
+*/
+func NewDecoder(data []byte) (*Decoder, error) {
+	d := &Decoder{ogg: oggReader{data: data}}
+	for i, parse := range []func([]byte) error{d.parseIdentification, d.parseComments, d.parseSetup} {
+		p, err := d.ogg.next()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, debug.ErrorWrapf(err, "Failed to decode Vorbis header %d", i)
+		}
+		if len(p.data) < 7 || p.data[0] != byte(1+2*i) || string(p.data[1:7]) != "vorbis" {
+			if i == 0 {
+				return nil, debug.Errorf("Not a Vorbis stream")
+			}
+			return nil, debug.Errorf("Failed to decode Vorbis: missing header %d", i)
+		}
+		if err := parse(p.data[7:]); err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decode Vorbis header %d", i)
+		}
+	}
+	d.frames = d.ogg.lastGranule()
+	d.start = d.ogg.clone()
+
+	d.floorState = make([]floorState, d.channels)
+	d.floorUnused = make([]bool, d.channels)
+	d.noResidue = make([]bool, d.channels)
+	d.spectrum = make([][]float32, d.channels)
+	d.block = make([][]float32, d.channels)
+	d.prev = make([][]float32, d.channels)
+	for i := range d.spectrum {
+		d.spectrum[i] = make([]float32, d.blocksize[1]/2)
+		d.block[i] = make([]float32, d.blocksize[1])
+		d.prev[i] = make([]float32, d.blocksize[1]/2)
+	}
+	d.imdct[0] = newIMDCT(d.blocksize[0])
+	d.imdct[1] = newIMDCT(d.blocksize[1])
+	if d.blocksize[1] == d.blocksize[0] {
+		d.imdct[1] = d.imdct[0]
+	}
+	return d, nil
+}
+
+func (d *Decoder) parseIdentification(b []byte) error {
+	if len(b) < 23 {
+		return debug.Errorf("Truncated identification header")
+	}
+	le := binary.LittleEndian
+	if v := le.Uint32(b); v != 0 {
+		return debug.Errorf("Unsupported version %d", v)
+	}
+	d.channels = int(b[4])
+	d.rate = int(le.Uint32(b[5:]))
+	d.blocksize = [2]int{1 << (b[21] & 0x0F), 1 << (b[21] >> 4)}
+	if d.channels == 0 || d.rate == 0 {
+		return debug.Errorf("Invalid channel count %d or sample rate %d", d.channels, d.rate)
+	}
+	if d.blocksize[0] < 64 || d.blocksize[1] > 8192 || d.blocksize[0] > d.blocksize[1] || b[22]&1 == 0 {
+		return debug.Errorf("Invalid block sizes %v", d.blocksize)
+	}
+	return nil
+}
+
+func (d *Decoder) parseComments(b []byte) error {
+	le := binary.LittleEndian
+	str := func() (string, bool) {
+		if len(b) < 4 || uint64(len(b)-4) < uint64(le.Uint32(b)) {
+			return "", false
+		}
+		n := le.Uint32(b)
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+	vendor, ok := str()
+	if !ok || len(b) < 4 {
+		return debug.Errorf("Truncated comment header")
+	}
+	d.comments = Comments{Vendor: vendor, Fields: map[string][]string{}}
+	count := le.Uint32(b)
+	b = b[4:]
+	for i := uint32(0); i < count; i++ {
+		s, ok := str()
+		if !ok {
+			return debug.Errorf("Truncated comment header")
+		}
+		// fields without "=" are invalid and ignored
+		if k, v, ok := strings.Cut(s, "="); ok {
+			k = strings.ToUpper(k)
+			d.comments.Fields[k] = append(d.comments.Fields[k], v)
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) Spec() audio.Spec {
+	return audio.Spec{Channels: channelList(d.channels), Frequency: d.rate}
+}
+
+/*
+Comments returns the comment header, it must not be modified.
+*/
+func (d *Decoder) Comments() *Comments {
+	return &d.comments
+}
+
+/*
+Frames returns the length of the stream in frames from the granule position of
+the last page or -1 if it is unknown.
+*/
+func (d *Decoder) Frames() int {
+	return int(d.frames)
+}
+
+/*
+Decode returns the next interleaved frames, the slice is only valid until the
+next call. It returns io.EOF at the end of the stream, packets that fail to
+decode are skipped as the spec requires.
+*/
+func (d *Decoder) Decode() ([]float32, error) {
+	for {
+		p, err := d.ogg.next()
+		if err != nil {
+			return nil, err
+		}
+		n, ok := d.decodePacket(p.data)
+		if !ok {
+			continue
+		}
+		frames := d.overlap(n)
+		out := d.out[:frames*d.channels]
+		if d.decoded < 0 {
+			d.pending = append(d.pending, out...)
+			if p.granule < 0 {
+				continue
+			}
+			start := p.granule - int64(len(d.pending)/d.channels)
+			if p.eos || start > d.target {
+				// the position cannot be recovered from this page, which
+				// only happens with very large packets or short streams
+				d.rewind()
+				continue
+			}
+			d.decoded, out, frames = start, d.pending, len(d.pending)/d.channels
+		} else if p.eos && p.granule >= 0 && d.decoded+int64(frames) > p.granule {
+			// the last page trims the final block
+			frames = int(max(0, p.granule-d.decoded))
+		}
+
+		start := d.decoded
+		d.decoded += int64(frames)
+		if skip := min(int64(frames), d.target-start); skip > 0 {
+			out = out[skip*int64(d.channels):]
+			frames -= int(skip)
+		}
+		if frames == 0 {
+			continue
+		}
+		return out[:frames*d.channels], nil
+	}
+}
+
+/*
+Seek makes the next call to Decode start at frame. Decoding restarts at the
+page ending at least a long block before frame, as the first packet after it
+only primes the overlap, and the position is recovered from the granule
+position of the next page.
+*/
+func (d *Decoder) Seek(frame int) error {
+	if frame < 0 || (d.frames >= 0 && int64(frame) > d.frames) {
+		return debug.Errorf("Invalid seek to frame %d", frame)
+	}
+	d.target = int64(frame)
+	off := d.start.seekPage(d.start.off, d.target-int64(d.blocksize[1]/2))
+	if off < 0 {
+		d.rewind()
+		return nil
+	}
+	d.ogg = d.start.clone()
+	d.ogg.off, d.ogg.partial, d.ogg.partialValid, d.ogg.packets = off, nil, false, nil
+	d.prevN, d.decoded, d.pending = 0, -1, d.pending[:0]
+	return nil
+}
+
+/*
+rewind restarts decoding from the first audio packet.
+*/
+func (d *Decoder) rewind() {
+	d.ogg = d.start.clone()
+	d.prevN, d.decoded, d.pending = 0, 0, d.pending[:0]
+}
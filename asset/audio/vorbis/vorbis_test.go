@@ -0,0 +1,625 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vorbis
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/internal/testutil"
+)
+
+type bitWriter struct {
+	data []byte
+	n    uint
+}
+
+func (w *bitWriter) write(v uint32, bits uint) {
+	for i := uint(0); i < bits; i++ {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(v>>i&1) << (w.n % 8)
+		w.n++
+	}
+}
+
+// code writes a codeword, which is read one bit at a time starting at its MSB.
+func (w *bitWriter) code(code uint32, length uint) {
+	for i := int(length) - 1; i >= 0; i-- {
+		w.write(code>>i, 1)
+	}
+}
+
+func (w *bitWriter) header(t byte) {
+	w.write(uint32(t), 8)
+	for _, c := range []byte("vorbis") {
+		w.write(uint32(c), 8)
+	}
+}
+
+/*
+makeOgg muxes packets into pages of up to maxSegments segments, granules holds
+the granule position of each packet.
+*/
+func makeOgg(packets [][]byte, granules []int64, maxSegments int) []byte {
+	type segment struct {
+		data    []byte
+		first   bool
+		granule int64
+	}
+	segments := []segment{}
+	for j, p := range packets {
+		for i := 0; ; i += 255 {
+			l := min(255, len(p)-i)
+			g := int64(-1)
+			if l < 255 {
+				g = granules[j]
+			}
+			segments = append(segments, segment{p[i : i+l], i == 0, g})
+			if l < 255 {
+				break
+			}
+		}
+	}
+
+	out := []byte{}
+	le := binary.LittleEndian
+	for seq := 0; len(segments) > 0; seq++ {
+		n := min(maxSegments, len(segments))
+		page := segments[:n]
+		segments = segments[n:]
+
+		var flags byte
+		if !page[0].first {
+			flags |= oggFlagContinued
+		}
+		if seq == 0 {
+			flags |= oggFlagBOS
+		}
+		g := int64(-1)
+		for _, s := range page {
+			if s.granule >= 0 {
+				g = s.granule
+			}
+		}
+		if len(segments) == 0 {
+			flags |= oggFlagEOS
+		}
+		start := len(out)
+		out = append(out, "OggS\x00"...)
+		out = append(out, flags)
+		out = le.AppendUint64(out, uint64(g))
+		out = le.AppendUint32(out, 0x1234)
+		out = le.AppendUint32(out, uint32(seq))
+		out = le.AppendUint32(out, 0)
+		out = append(out, byte(n))
+		for _, s := range page {
+			out = append(out, byte(len(s.data)))
+		}
+		for _, s := range page {
+			out = append(out, s.data...)
+		}
+		le.PutUint32(out[start+22:], oggCRC(0, out[start:]))
+	}
+	return out
+}
+
+type testBlock struct {
+	long, prevLong, nextLong bool
+	// values are the codebook entries of the 32 interleaved residue values
+	values [32]int
+}
+
+const (
+	testBlocksizes = 0x86 // 64 and 256
+	testFloorY     = 200
+)
+
+func makeHeaders(comments []string) [][]byte {
+	id := bitWriter{}
+	id.header(1)
+	id.write(0, 32)
+	id.write(2, 8)
+	id.write(44100, 32)
+	id.write(0, 32)
+	id.write(128000, 32)
+	id.write(0, 32)
+	id.write(testBlocksizes, 8)
+	id.write(1, 1)
+
+	comment := bitWriter{}
+	comment.header(3)
+	str := func(s string) {
+		comment.write(uint32(len(s)), 32)
+		for _, c := range []byte(s) {
+			comment.write(uint32(c), 8)
+		}
+	}
+	str("goarrg test")
+	comment.write(uint32(len(comments)), 32)
+	for _, s := range comments {
+		str(s)
+	}
+	comment.write(1, 1)
+
+	setup := bitWriter{}
+	setup.header(5)
+	setup.write(1, 8)
+	// classbook: 2 entries of length 1
+	setup.write(0x564342, 24)
+	setup.write(1, 16)
+	setup.write(2, 24)
+	setup.write(0, 2)
+	setup.write(0, 5)
+	setup.write(0, 5)
+	setup.write(0, 4)
+	// VQ book: 4 entries of length 2 with the values -1, 0, 1 and 2
+	setup.write(0x564342, 24)
+	setup.write(1, 16)
+	setup.write(4, 24)
+	setup.write(0, 2)
+	for i := 0; i < 4; i++ {
+		setup.write(1, 5)
+	}
+	setup.write(1, 4)
+	setup.write(0x80000000|788<<21|1, 32)
+	setup.write(788<<21|1, 32)
+	setup.write(1, 4)
+	setup.write(0, 1)
+	for i := 0; i < 4; i++ {
+		setup.write(uint32(i), 2)
+	}
+	// time domain transforms
+	setup.write(0, 6)
+	setup.write(0, 16)
+	// floor1 with only the two end points
+	setup.write(0, 6)
+	setup.write(1, 16)
+	setup.write(0, 5)
+	setup.write(0, 2)
+	setup.write(7, 4)
+	// residue 2 over the first 32 interleaved values
+	setup.write(0, 6)
+	setup.write(2, 16)
+	setup.write(0, 24)
+	setup.write(32, 24)
+	setup.write(15, 24)
+	setup.write(0, 6)
+	setup.write(0, 8)
+	setup.write(1, 3)
+	setup.write(0, 1)
+	setup.write(1, 8)
+	// mapping coupling channel 1 onto channel 0
+	setup.write(0, 6)
+	setup.write(0, 16)
+	setup.write(0, 1)
+	setup.write(1, 1)
+	setup.write(0, 8)
+	setup.write(0, 1)
+	setup.write(1, 1)
+	setup.write(0, 2)
+	setup.write(0, 8)
+	setup.write(0, 8)
+	setup.write(0, 8)
+	// short and long modes
+	setup.write(1, 6)
+	for _, long := range []uint32{0, 1} {
+		setup.write(long, 1)
+		setup.write(0, 16)
+		setup.write(0, 16)
+		setup.write(0, 8)
+	}
+	setup.write(1, 1)
+
+	return [][]byte{id.data, comment.data, setup.data}
+}
+
+func boolBit(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func makeAudioPacket(b testBlock) []byte {
+	w := bitWriter{}
+	w.write(0, 1)
+	w.write(boolBit(b.long), 1)
+	if b.long {
+		w.write(boolBit(b.prevLong), 1)
+		w.write(boolBit(b.nextLong), 1)
+	}
+	for ch := 0; ch < 2; ch++ {
+		w.write(1, 1)
+		w.write(testFloorY, 8)
+		w.write(testFloorY, 8)
+	}
+	for partition := 0; partition < 2; partition++ {
+		w.code(0, 1)
+		for _, v := range b.values[partition*16 : partition*16+16] {
+			w.code(uint32(v), 2)
+		}
+	}
+	return w.data
+}
+
+func slowIMDCT(in []float64) []float64 {
+	n := len(in) * 2
+	out := make([]float64, n)
+	for i := range out {
+		for k, x := range in {
+			out[i] += x * math.Cos(math.Pi/float64(n/2)*(float64(i)+0.5+float64(n)/4)*(float64(k)+0.5))
+		}
+	}
+	return out
+}
+
+func windowValue(n, i, bs0 int, b testBlock) float64 {
+	slope := func(x, length int) float64 {
+		s := math.Sin((float64(x) + 0.5) / float64(length) * math.Pi / 2)
+		return math.Sin(math.Pi / 2 * s * s)
+	}
+	leftStart, leftN := 0, n/2
+	if b.long && !b.prevLong {
+		leftStart, leftN = n/4-bs0/4, bs0/2
+	}
+	rightStart, rightN := n/2, n/2
+	if b.long && !b.nextLong {
+		rightStart, rightN = n*3/4-bs0/4, bs0/2
+	}
+	switch {
+	case i < leftStart:
+		return 0
+	case i < leftStart+leftN:
+		return slope(i-leftStart, leftN)
+	case i < rightStart:
+		return 1
+	case i < rightStart+rightN:
+		return slope(rightStart+rightN-1-i, rightN)
+	}
+	return 0
+}
+
+/*
+reference decodes the test blocks straight from the spec's formulas, placing
+each windowed block at its absolute position.
+*/
+func reference(blocks []testBlock) [2][]float64 {
+	bs := [2]int{64, 256}
+	size := func(b testBlock) int { return bs[boolBit(b.long)] }
+	centers := blockCenters(blocks)
+	total := centers[len(centers)-1]
+	floor := math.Pow(10, float64(testFloorY-255)*140/256/20)
+
+	var out [2][]float64
+	out[0], out[1] = make([]float64, total), make([]float64, total)
+	for bi, b := range blocks {
+		n := size(b)
+		spectrum := [2][]float64{make([]float64, n/2), make([]float64, n/2)}
+		for k := 0; k < 16; k++ {
+			m, a := float64(b.values[2*k]-1), float64(b.values[2*k+1]-1)
+			switch {
+			case m > 0 && a > 0:
+				a = m - a
+			case m > 0:
+				m, a = m+a, m
+			case a > 0:
+				a = m + a
+			default:
+				m, a = m-a, m
+			}
+			spectrum[0][k], spectrum[1][k] = m*floor, a*floor
+		}
+		for ch := range spectrum {
+			y := slowIMDCT(spectrum[ch])
+			for i, v := range y {
+				if t := centers[bi] - n/2 + i; t >= 0 && t < total {
+					out[ch][t] += v * windowValue(n, i, bs[0], b)
+				}
+			}
+		}
+	}
+	return out
+}
+
+/*
+blockCenters returns the position of the center of each block's overlap with
+the previous block, which is where decoding the block ends.
+*/
+func blockCenters(blocks []testBlock) []int {
+	bs := [2]int{64, 256}
+	size := func(b testBlock) int { return bs[boolBit(b.long)] }
+	centers := []int{0}
+	for i := 1; i < len(blocks); i++ {
+		centers = append(centers, centers[i-1]+size(blocks[i-1])/4+size(blocks[i])/4)
+	}
+	return centers
+}
+
+func testStream(t *testing.T, comments []string) ([]byte, [2][]float64, int) {
+	t.Helper()
+	r := rand.New(rand.NewSource(1))
+	longs := []bool{true, true, false, false, true, true, false, true}
+	longs = append(longs, append(longs, longs...)...)
+	blocks := make([]testBlock, len(longs))
+	packets := makeHeaders(comments)
+	for i, long := range longs {
+		blocks[i] = testBlock{long: long, prevLong: i == 0 || longs[i-1], nextLong: i == len(longs)-1 || longs[i+1]}
+		for j := range blocks[i].values {
+			blocks[i].values[j] = r.Intn(4)
+		}
+		packets = append(packets, makeAudioPacket(blocks[i]))
+	}
+	want := reference(blocks)
+	// the last page trims the end of the stream
+	frames := len(want[0]) - 10
+	granules := []int64{-1, -1, -1}
+	for _, c := range blockCenters(blocks) {
+		granules = append(granules, int64(c))
+	}
+	granules[len(granules)-1] = int64(frames)
+	return makeOgg(packets, granules, 2), want, frames
+}
+
+func TestDecoder(t *testing.T) {
+	data, want, frames := testStream(t, []string{"TITLE=Test", "loopstart=100", "LOOPLENGTH=200", "title=Again", "invalid"})
+	d, err := NewDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec := d.Spec(); spec.Frequency != 44100 || !reflect.DeepEqual(spec.Channels, audio.ChannelsStereo()) {
+		t.Errorf("got spec %+v", spec)
+	}
+	if d.Frames() != frames {
+		t.Errorf("got %d frames want %d", d.Frames(), frames)
+	}
+
+	c := d.Comments()
+	if c.Vendor != "goarrg test" || c.Get("Title") != "Test" || !reflect.DeepEqual(c.Fields["TITLE"], []string{"Test", "Again"}) || len(c.Fields) != 3 {
+		t.Errorf("got comments %+v", c)
+	}
+	if start, length, ok := c.Loop(); start != 100 || length != 200 || !ok {
+		t.Errorf("got loop %d %d %v", start, length, ok)
+	}
+
+	got := []float32{}
+	for {
+		samples, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, samples...)
+	}
+	if len(got) != frames*2 {
+		t.Fatalf("got %d frames want %d", len(got)/2, frames)
+	}
+	for i := 0; i < frames; i++ {
+		for ch := 0; ch < 2; ch++ {
+			if math.Abs(float64(got[i*2+ch])-want[ch][i]) > 1e-5 {
+				t.Fatalf("frame %d channel %d: got %f want %f", i, ch, got[i*2+ch], want[ch][i])
+			}
+		}
+	}
+}
+
+func TestSeek(t *testing.T) {
+	data, want, frames := testStream(t, nil)
+	d, err := NewDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []int{700, 0, 1, 100, 333, 1000, frames - 5, frames, 500} {
+		if err := d.Seek(target); err != nil {
+			t.Fatal(err)
+		}
+		got := []float32{}
+		for {
+			samples, err := d.Decode()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, samples...)
+		}
+		if len(got) != (frames-target)*2 {
+			t.Fatalf("seek to %d: got %d frames want %d", target, len(got)/2, frames-target)
+		}
+		for i := 0; i < len(got); i++ {
+			if math.Abs(float64(got[i])-want[i%2][target+i/2]) > 1e-5 {
+				t.Fatalf("seek to %d: frame %d channel %d: got %f want %f", target, target+i/2, i%2, got[i], want[i%2][target+i/2])
+			}
+		}
+	}
+	if err := d.Seek(frames + 1); err == nil {
+		t.Errorf("seek past the end succeeded")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	data, want, frames := testStream(t, nil)
+	name := testutil.WriteFile(t, t.TempDir(), "test.ogg", data)
+	a, err := audio.Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.DurationSamples() != frames {
+		t.Errorf("got %d samples want %d", a.DurationSamples(), frames)
+	}
+	if v := a.Track().Channel(audio.ChannelRight)[frames/2]; math.Abs(float64(v)-want[1][frames/2]) > 1e-5 {
+		t.Errorf("got %f want %f", v, want[1][frames/2])
+	}
+	if _, err := LoadComments(name); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCorruptPage(t *testing.T) {
+	data, _, _ := testStream(t, nil)
+	// flip a byte in the body of the last page, its packets are dropped
+	data[len(data)-1] ^= 0xFF
+	d, err := NewDecoder(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := d.Decode(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := NewDecoder(makeOgg([][]byte{[]byte("OpusHead")}, []int64{0}, 1)); err == nil || !strings.Contains(err.Error(), "Not a Vorbis") {
+		t.Errorf("got %v", err)
+	}
+	if _, err := NewDecoder(data[:50]); err == nil {
+		t.Error("decoded truncated stream")
+	}
+}
+
+func TestIMDCT(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{64, 256, 2048} {
+		in := make([]float32, n/2)
+		in64 := make([]float64, n/2)
+		for i := range in {
+			in[i] = r.Float32()*2 - 1
+			in64[i] = float64(in[i])
+		}
+		out := make([]float32, n)
+		newIMDCT(n).transform(in, out)
+		for i, want := range slowIMDCT(in64) {
+			if math.Abs(float64(out[i])-want) > 1e-3 {
+				t.Fatalf("n %d: sample %d got %f want %f", n, i, out[i], want)
+			}
+		}
+	}
+}
+
+func TestCodebook(t *testing.T) {
+	// the example from the spec
+	c := &codebook{entries: 8, lengths: []uint8{2, 4, 4, 4, 4, 2, 3, 3}, single: -1}
+	if err := c.buildHuffman(); err != nil {
+		t.Fatal(err)
+	}
+	codes := []string{"00", "0100", "0101", "0110", "0111", "10", "110", "111"}
+	w := bitWriter{}
+	order := []int{7, 0, 5, 3, 6, 1, 4, 2}
+	for _, e := range order {
+		for _, b := range codes[e] {
+			w.write(uint32(b-'0'), 1)
+		}
+	}
+	br := &bitReader{data: w.data}
+	for _, e := range order {
+		if got := c.decode(br); got != e {
+			t.Fatalf("got %d want %d", got, e)
+		}
+	}
+
+	long := &codebook{entries: 14, lengths: []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 13}, single: -1}
+	if err := long.buildHuffman(); err != nil {
+		t.Fatal(err)
+	}
+	w = bitWriter{}
+	w.code(0x1FFF, 13)
+	w.code(0x1FFE, 13)
+	w.code(0x0FFE, 12)
+	br = &bitReader{data: w.data}
+	// the 2 bits padding the last byte decode as entry 0
+	for _, e := range []int{13, 12, 11, 0, 0} {
+		if got := long.decode(br); got != e {
+			t.Fatalf("got %d want %d", got, e)
+		}
+	}
+	if got := long.decode(br); got != -1 || !br.eop {
+		t.Errorf("got %d at end of packet", got)
+	}
+
+	over := &codebook{entries: 3, lengths: []uint8{1, 1, 1}, single: -1}
+	if err := over.buildHuffman(); err == nil {
+		t.Error("built overspecified codebook")
+	}
+
+	if v := lookup1Values(4, 1); v != 4 {
+		t.Errorf("lookup1Values(4, 1) = %d", v)
+	}
+	if v := lookup1Values(80, 4); v != 2 {
+		t.Errorf("lookup1Values(80, 4) = %d", v)
+	}
+	if v := lookup1Values(81, 4); v != 3 {
+		t.Errorf("lookup1Values(81, 4) = %d", v)
+	}
+	if v := float32Unpack(0x80000000 | 787<<21 | 3); v != -1.5 {
+		t.Errorf("float32Unpack = %f", v)
+	}
+}
+
+func TestComments(t *testing.T) {
+	c := &Comments{Fields: map[string][]string{"LOOPSTART": {"10"}, "LOOPEND": {"30"}}}
+	if start, length, ok := c.Loop(); start != 10 || length != 20 || !ok {
+		t.Errorf("got loop %d %d %v", start, length, ok)
+	}
+	c.Fields["LOOPEND"] = []string{"5"}
+	if _, _, ok := c.Loop(); ok {
+		t.Error("got loop ending before its start")
+	}
+
+	c = &Comments{Fields: map[string][]string{
+		"CHAPTER001":     {"00:00:01.500"},
+		"CHAPTER001NAME": {"Intro"},
+		"CHAPTER002":     {"01:02:03"},
+		"CHAPTER003":     {"bad"},
+		"CHAPTER004":     {"00:00:02"},
+		"CHAPTER006":     {"00:00:03"},
+	}}
+	want := []audio.Marker{{Frame: 1500, Label: "Intro"}, {Frame: 3723000}, {Frame: 2000}}
+	if got := c.Chapters(1000); !reflect.DeepEqual(got, want) {
+		t.Errorf("got chapters %+v want %+v", got, want)
+	}
+	if got := channelList(7)[5]; got != audio.ChannelBackCenter {
+		t.Errorf("got %v", got)
+	}
+	// same speakers as the other decoders, in Vorbis order
+	for _, count := range []int{6, 8} {
+		got, want := channelList(count), audio.Channels5Point1()
+		if count == 8 {
+			want = audio.Channels7Point1()
+		}
+		slices.Sort(got)
+		slices.Sort(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("channelList(%d) = %v want %v", count, got, want)
+		}
+	}
+}
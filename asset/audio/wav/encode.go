@@ -0,0 +1,204 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wav
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+	"slices"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+// headerSize is the size of everything before the samples
+const headerSize = 12 + 8 + 40 + 8 + 4 + 8
+
+// guidIEEEFloat is KSDATAFORMAT_SUBTYPE_IEEE_FLOAT
+var guidIEEEFloat = [16]byte{0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+/*
+Encoder writes 32 bit IEEE float WAV files with a WAVE_FORMAT_EXTENSIBLE
+channel mask, decoding them gives back the channels of the spec. Channels
+without a speaker position are written after the others. The sizes in the
+header are written by Close.
+*/
+type Encoder struct {
+	w    io.WriteSeeker
+	spec audio.Spec
+	// order is the spec's channels in the order they are written
+	order  []audio.Channel
+	mask   uint32
+	frames int
+	buf    []float32
+	bytes  []byte
+	err    error
+}
+
+/*
+NewEncoder writes the header for spec to w, which should be at the start of
+the file.
+*/
+func NewEncoder(w io.WriteSeeker, spec audio.Spec) (*Encoder, error) {
+	if len(spec.Channels) == 0 || spec.Frequency <= 0 {
+		return nil, debug.Errorf("Failed to encode WAV: invalid spec %+v", spec)
+	}
+	e := &Encoder{w: w, spec: spec}
+	e.order, e.mask = channelOrder(spec.Channels)
+	if err := e.writeHeader(); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to encode WAV")
+	}
+	return e, nil
+}
+
+/*
+Encode writes t, in the channels and frequency of spec, to w as a WAV file.
+*/
+func Encode(w io.WriteSeeker, spec audio.Spec, t audio.Track) error {
+	e, err := NewEncoder(w, spec)
+	if err != nil {
+		return err
+	}
+	if err := e.Write(t); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+/*
+channelOrder returns channels in WAV order and their speaker mask. Surrounds
+are written as the back speakers of 5.1 like channelList reads them.
+*/
+func channelOrder(channels []audio.Channel) ([]audio.Channel, uint32) {
+	backIsSurround := !slices.Contains(channels, audio.ChannelBackSurroundLeft) && !slices.Contains(channels, audio.ChannelBackSurroundRight) &&
+		(slices.Contains(channels, audio.ChannelLowFrequency) || slices.Contains(channels, audio.ChannelCenter))
+	speaker := func(c audio.Channel) int {
+		switch {
+		case len(channels) == 1:
+			return bits.TrailingZeros32(speakerFrontCenter)
+		case backIsSurround && c == audio.ChannelSurroundLeft:
+			return bits.TrailingZeros32(speakerBackLeft)
+		case backIsSurround && c == audio.ChannelSurroundRight:
+			return bits.TrailingZeros32(speakerBackRight)
+		}
+		if i := slices.Index(speakerChannels[:], c); i >= 0 {
+			return i
+		}
+		return -1
+	}
+
+	mask := uint32(0)
+	order := slices.Clone(channels)
+	slices.SortStableFunc(order, func(a, b audio.Channel) int {
+		sa, sb := speaker(a), speaker(b)
+		switch {
+		case sa == sb:
+			return 0
+		case sa < 0:
+			return 1
+		case sb < 0:
+			return -1
+		}
+		return sa - sb
+	})
+	for _, c := range order {
+		if s := speaker(c); s >= 0 {
+			mask |= 1 << s
+		}
+	}
+	return order, mask
+}
+
+func (e *Encoder) writeHeader() error {
+	le := binary.LittleEndian
+	channels := len(e.spec.Channels)
+	dataSize := uint32(e.frames * channels * 4)
+
+	h := make([]byte, 0, headerSize)
+	h = append(h, "RIFF"...)
+	h = le.AppendUint32(h, headerSize-8+dataSize)
+	h = append(h, "WAVE"...)
+
+	h = append(h, "fmt "...)
+	h = le.AppendUint32(h, 40)
+	h = le.AppendUint16(h, formatExtensible)
+	h = le.AppendUint16(h, uint16(channels))
+	h = le.AppendUint32(h, uint32(e.spec.Frequency))
+	h = le.AppendUint32(h, uint32(e.spec.Frequency*channels*4))
+	h = le.AppendUint16(h, uint16(channels*4))
+	h = le.AppendUint16(h, 32)
+	h = le.AppendUint16(h, 22)
+	h = le.AppendUint16(h, 32)
+	h = le.AppendUint32(h, e.mask)
+	h = append(h, guidIEEEFloat[:]...)
+
+	// non PCM formats need the length in frames
+	h = append(h, "fact"...)
+	h = le.AppendUint32(h, 4)
+	h = le.AppendUint32(h, uint32(e.frames))
+
+	h = append(h, "data"...)
+	h = le.AppendUint32(h, dataSize)
+	_, err := e.w.Write(h)
+	return err
+}
+
+/*
+Write appends the frames of t, which holds the channels of the spec. Missing
+channels are silent.
+*/
+func (e *Encoder) Write(t audio.Track) error {
+	if e.err != nil {
+		return e.err
+	}
+	channels := len(e.order)
+	if n := t.Frames() * channels; len(e.buf) < n {
+		e.buf = make([]float32, n)
+		e.bytes = make([]byte, n*4)
+	}
+	n := t.Interleave(e.buf, e.order) * channels
+	for i, s := range e.buf[:n] {
+		binary.LittleEndian.PutUint32(e.bytes[i*4:], math.Float32bits(s))
+	}
+	if _, err := e.w.Write(e.bytes[:n*4]); err != nil {
+		e.err = debug.ErrorWrapf(err, "Failed to encode WAV")
+		return e.err
+	}
+	e.frames += n / channels
+	return nil
+}
+
+/*
+Close writes the sizes into the header, it does not close the writer.
+*/
+func (e *Encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if _, err := e.w.Seek(0, io.SeekStart); err != nil {
+		return debug.ErrorWrapf(err, "Failed to encode WAV")
+	}
+	if err := e.writeHeader(); err != nil {
+		return debug.ErrorWrapf(err, "Failed to encode WAV")
+	}
+	if _, err := e.w.Seek(0, io.SeekEnd); err != nil {
+		return debug.ErrorWrapf(err, "Failed to encode WAV")
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"goarrg.com/asset"
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+func metadata(a *asset.File) (audio.Metadata, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return audio.Metadata{}, debug.ErrorWrapf(err, "Failed to read WAV metadata")
+	}
+	return parseMetadata(data), nil
+}
+
+/*
+parseMetadata reads the first loop of the "smpl" chunk and the cue points of
+the "cue " chunk, labeled by the "labl" and "ltxt" chunks of a "LIST" "adtl"
+chunk. Chunks that do not parse are skipped.
+*/
+func parseMetadata(data []byte) audio.Metadata {
+	le := binary.LittleEndian
+	var m audio.Metadata
+	if len(data) < 12 {
+		return m
+	}
+
+	cues := map[uint32]*audio.Marker{}
+	var order []uint32
+	labels := map[uint32]string{}
+	lengths := map[uint32]int{}
+
+	forEachChunk(data[12:], func(id string, body []byte) {
+		switch id {
+		case "smpl":
+			if len(body) < 36+24 || le.Uint32(body[28:]) == 0 {
+				return
+			}
+			loop := body[36:]
+			// the end is the last frame played
+			m.LoopStart, m.LoopEnd = int(le.Uint32(loop[8:])), int(le.Uint32(loop[12:]))+1
+		case "cue ":
+			if len(body) < 4 {
+				return
+			}
+			count := int(le.Uint32(body))
+			for i := 0; i < count && 4+(i+1)*24 <= len(body); i++ {
+				cue := body[4+i*24:]
+				id := le.Uint32(cue)
+				if _, ok := cues[id]; !ok {
+					order = append(order, id)
+				}
+				cues[id] = &audio.Marker{Frame: int(le.Uint32(cue[20:]))}
+			}
+		case "LIST":
+			if len(body) < 4 || string(body[:4]) != "adtl" {
+				return
+			}
+			forEachChunk(body[4:], func(id string, body []byte) {
+				if len(body) < 4 {
+					return
+				}
+				cue := le.Uint32(body)
+				switch id {
+				case "labl":
+					labels[cue] = zstring(body[4:])
+				case "ltxt":
+					if len(body) >= 8 {
+						lengths[cue] = int(le.Uint32(body[4:]))
+					}
+					if _, ok := labels[cue]; !ok && len(body) > 20 {
+						labels[cue] = zstring(body[20:])
+					}
+				}
+			})
+		}
+	})
+
+	for _, id := range order {
+		mk := cues[id]
+		mk.Label, mk.Length = labels[id], lengths[id]
+		m.Markers = append(m.Markers, *mk)
+	}
+	return m
+}
+
+/*
+forEachChunk calls fn for every RIFF chunk in data, stopping at a truncated
+chunk.
+*/
+func forEachChunk(data []byte, fn func(id string, body []byte)) {
+	le := binary.LittleEndian
+	for len(data) >= 8 {
+		id, size := string(data[0:4]), int(le.Uint32(data[4:]))
+		data = data[8:]
+		if size > len(data) {
+			return
+		}
+		fn(id, data[:size])
+		data = data[min(len(data), size+(size&1)):]
+	}
+}
+
+/*
+zstring returns b up to the first null byte.
+*/
+func zstring(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
@@ -0,0 +1,547 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package wav registers a pure Go WAV decoder with the audio package, import it
+for its side effects:
+
+	import _ "goarrg.com/asset/audio/wav"
+
+8 bit unsigned, 16/24/32 bit signed PCM, 32/64 bit IEEE float, A-law, μ-law and
+IMA ADPCM are supported, along with WAVE_FORMAT_EXTENSIBLE channel masks.
+
+The first loop of the "smpl" chunk and the cue points of the "cue " chunk,
+labeled by "labl" and "ltxt" chunks, are read as audio.Metadata.
+
+Encoder writes tracks back out as 32 bit IEEE float files.
+*/
+package wav
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+
+	"goarrg.com/asset"
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+// streamFrames is about the number of frames returned by Decoder.Decode
+const streamFrames = 4096
+
+const (
+	formatPCM        = 0x0001
+	formatIEEEFloat  = 0x0003
+	formatALaw       = 0x0006
+	formatMuLaw      = 0x0007
+	formatIMAADPCM   = 0x0011
+	formatExtensible = 0xFFFE
+)
+
+// WAVE_FORMAT_EXTENSIBLE speaker bits
+const (
+	speakerFrontLeft = 1 << iota
+	speakerFrontRight
+	speakerFrontCenter
+	speakerLowFrequency
+	speakerBackLeft
+	speakerBackRight
+	speakerFrontLeftOfCenter
+	speakerFrontRightOfCenter
+	speakerBackCenter
+	speakerSideLeft
+	speakerSideRight
+	speakerTopCenter
+	speakerTopFrontLeft
+	speakerTopFrontCenter
+	speakerTopFrontRight
+	speakerTopBackLeft
+	speakerTopBackCenter
+	speakerTopBackRight
+)
+
+var speakerChannels = [...]audio.Channel{
+	audio.ChannelLeft,
+	audio.ChannelRight,
+	audio.ChannelCenter,
+	audio.ChannelLowFrequency,
+	audio.ChannelBackSurroundLeft,
+	audio.ChannelBackSurroundRight,
+	audio.ChannelFrontLeftOfCenter,
+	audio.ChannelFrontRightOfCenter,
+	audio.ChannelBackCenter,
+	audio.ChannelSurroundLeft,
+	audio.ChannelSurroundRight,
+	audio.ChannelTopCenter,
+	audio.ChannelTopFrontLeft,
+	audio.ChannelTopFrontCenter,
+	audio.ChannelTopFrontRight,
+	audio.ChannelTopBackLeft,
+	audio.ChannelTopBackCenter,
+	audio.ChannelTopBackRight,
+}
+
+func init() {
+	audio.RegisterStreamFormat("RIFF????WAVE", decode, stream)
+	audio.RegisterMetadata("RIFF????WAVE", metadata)
+}
+
+type waveFormat struct {
+	tag             uint16
+	channels        int
+	frequency       int
+	blockAlign      int
+	bitsPerSample   int
+	channelMask     uint32
+	samplesPerBlock int
+}
+
+func decode(a *asset.File) (audio.Spec, int, []float32, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return audio.Spec{}, 0, nil, debug.ErrorWrapf(err, "Failed to decode WAV")
+	}
+	spec, samples, track, err := decodeWAV(data)
+	return spec, samples, track, debug.ErrorWrapf(err, "Failed to decode WAV")
+}
+
+func stream(a *asset.File) (audio.StreamDecoder, error) {
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode WAV")
+	}
+	d, err := NewDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func decodeWAV(data []byte) (audio.Spec, int, []float32, error) {
+	format, samples, err := parseWAV(data)
+	if err != nil {
+		return audio.Spec{}, 0, nil, err
+	}
+	track, err := decodeSamples(format, samples)
+	if err != nil {
+		return audio.Spec{}, 0, nil, err
+	}
+	return format.spec(), len(track), track, nil
+}
+
+/*
+parseWAV returns the format and sample data of the file in data.
+*/
+func parseWAV(data []byte) (*waveFormat, []byte, error) {
+	le := binary.LittleEndian
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, nil, debug.Errorf("Not a WAV file")
+	}
+	// some writers put the file size instead of the RIFF size or nothing at
+	// all when streaming, so the RIFF size is only used to limit the parsing
+	if riffSize := int(le.Uint32(data[4:])); riffSize >= 4 && 8+riffSize < len(data) {
+		data = data[:8+riffSize]
+	}
+
+	var format *waveFormat
+	var samples []byte
+	for chunks := data[12:]; len(chunks) >= 8; {
+		id, size := string(chunks[0:4]), int(le.Uint32(chunks[4:]))
+		chunks = chunks[8:]
+		if size > len(chunks) {
+			if id != "data" {
+				return nil, nil, debug.Errorf("Truncated %q chunk", id)
+			}
+			// truncated files are played up to where they stop
+			size = len(chunks)
+		}
+		body := chunks[:size]
+		chunks = chunks[min(len(chunks), size+(size&1)):]
+
+		switch id {
+		case "fmt ":
+			f, err := parseFormat(body)
+			if err != nil {
+				return nil, nil, err
+			}
+			format = f
+		case "data":
+			samples = body
+		}
+		if format != nil && samples != nil {
+			break
+		}
+	}
+	if format == nil {
+		return nil, nil, debug.Errorf("Missing \"fmt \" chunk")
+	}
+	if samples == nil {
+		return nil, nil, debug.Errorf("Missing \"data\" chunk")
+	}
+	return format, samples, nil
+}
+
+/*
+Decoder decodes a WAV file a few blocks at a time.
+*/
+type Decoder struct {
+	format  *waveFormat
+	samples []byte
+	// block is the index of the next block to decode and skip the number of
+	// its frames to drop after a seek
+	block int
+	skip  int
+}
+
+/*
+NewDecoder parses the headers of the file in data, which must stay valid while
+the decoder is in use.
+*/
+func NewDecoder(data []byte) (*Decoder, error) {
+	format, samples, err := parseWAV(data)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode WAV")
+	}
+	// decoding nothing still validates the format
+	if _, err := decodeSamples(format, nil); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode WAV")
+	}
+	return &Decoder{format: format, samples: samples}, nil
+}
+
+func (d *Decoder) Spec() audio.Spec {
+	return d.format.spec()
+}
+
+func (d *Decoder) Frames() int {
+	return len(d.samples) / d.format.blockAlign * d.format.blockFrames()
+}
+
+/*
+Decode returns the next interleaved frames, the slice is only valid until the
+next call. It returns io.EOF at the end of the file.
+*/
+func (d *Decoder) Decode() ([]float32, error) {
+	blocks := len(d.samples) / d.format.blockAlign
+	if d.block >= blocks {
+		return nil, io.EOF
+	}
+	count := min(blocks-d.block, max(1, streamFrames/d.format.blockFrames()))
+	b := d.samples[d.block*d.format.blockAlign : (d.block+count)*d.format.blockAlign]
+	track, err := decodeSamples(d.format, b)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode WAV")
+	}
+	d.block += count
+	track = track[d.skip*d.format.channels:]
+	d.skip = 0
+	return track, nil
+}
+
+func (d *Decoder) Seek(frame int) error {
+	if frame < 0 || frame > d.Frames() {
+		return debug.Errorf("Invalid seek to frame %d", frame)
+	}
+	d.block, d.skip = frame/d.format.blockFrames(), frame%d.format.blockFrames()
+	return nil
+}
+
+func parseFormat(b []byte) (*waveFormat, error) {
+	le := binary.LittleEndian
+	if len(b) < 16 {
+		return nil, debug.Errorf("Invalid \"fmt \" chunk size %d", len(b))
+	}
+	f := &waveFormat{
+		tag:           le.Uint16(b[0:]),
+		channels:      int(le.Uint16(b[2:])),
+		frequency:     int(le.Uint32(b[4:])),
+		blockAlign:    int(le.Uint16(b[12:])),
+		bitsPerSample: int(le.Uint16(b[14:])),
+	}
+	if f.channels == 0 || f.frequency == 0 || f.blockAlign == 0 {
+		return nil, debug.Errorf("Invalid format, %d channels at %dHz with block align %d", f.channels, f.frequency, f.blockAlign)
+	}
+
+	var extra []byte
+	if len(b) >= 18 {
+		extra = b[18:min(len(b), 18+int(le.Uint16(b[16:])))]
+	}
+	switch f.tag {
+	case formatExtensible:
+		if len(extra) < 22 {
+			return nil, debug.Errorf("Invalid WAVE_FORMAT_EXTENSIBLE size %d", len(extra))
+		}
+		// valid bits per sample in extra[0:2] is ignored, samples are left
+		// justified in their container so scaling by the container size is
+		// already correct
+		f.channelMask = le.Uint32(extra[2:])
+		// the sub format GUID starts with the format tag
+		f.tag = le.Uint16(extra[6:])
+	case formatIMAADPCM:
+		if len(extra) >= 2 {
+			f.samplesPerBlock = int(le.Uint16(extra))
+		}
+	}
+	return f, nil
+}
+
+func (f *waveFormat) spec() audio.Spec {
+	return audio.Spec{Channels: channelList(f.channels, f.channelMask), Frequency: f.frequency}
+}
+
+/*
+blockFrames returns the number of frames in a block of f.blockAlign bytes.
+*/
+func (f *waveFormat) blockFrames() int {
+	if f.tag != formatIMAADPCM {
+		return 1
+	}
+	perBlock := 1 + ((f.blockAlign-4*f.channels)*2)/f.channels
+	if f.samplesPerBlock > 0 {
+		perBlock = f.samplesPerBlock
+	}
+	return perBlock
+}
+
+/*
+channelList maps the channel mask to audio.Channel, files without a mask get
+the usual layout for their channel count. Like audio.Channels5Point1, back
+speakers are treated as surround speakers unless there are also side speakers.
+*/
+func channelList(count int, mask uint32) []audio.Channel {
+	if mask == 0 {
+		switch count {
+		case 1:
+			return audio.ChannelsMono()
+		case 2:
+			return audio.ChannelsStereo()
+		case 3:
+			mask = speakerFrontLeft | speakerFrontRight | speakerFrontCenter
+		case 4:
+			mask = speakerFrontLeft | speakerFrontRight | speakerBackLeft | speakerBackRight
+		case 5:
+			mask = speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerBackLeft | speakerBackRight
+		case 6:
+			mask = speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackLeft | speakerBackRight
+		case 7:
+			mask = speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackCenter | speakerSideLeft | speakerSideRight
+		case 8:
+			mask = speakerFrontLeft | speakerFrontRight | speakerFrontCenter | speakerLowFrequency | speakerBackLeft | speakerBackRight | speakerSideLeft | speakerSideRight
+		}
+	}
+	if count == 1 && mask == speakerFrontCenter {
+		return audio.ChannelsMono()
+	}
+
+	backIsSurround := mask&(speakerSideLeft|speakerSideRight) == 0 && mask&(speakerLowFrequency|speakerFrontCenter) != 0
+	channels := make([]audio.Channel, 0, count)
+	for m := mask; m != 0 && len(channels) < count; m &= m - 1 {
+		bit := bits.TrailingZeros32(m)
+		if bit >= len(speakerChannels) {
+			break
+		}
+		c := speakerChannels[bit]
+		if backIsSurround {
+			switch 1 << bit {
+			case speakerBackLeft:
+				c = audio.ChannelSurroundLeft
+			case speakerBackRight:
+				c = audio.ChannelSurroundRight
+			}
+		}
+		channels = append(channels, c)
+	}
+	// channels beyond the mask have no position
+	for i := audio.ChannelUnknown; len(channels) < count; i++ {
+		channels = append(channels, i)
+	}
+	return channels
+}
+
+func decodeSamples(f *waveFormat, b []byte) ([]float32, error) {
+	le := binary.LittleEndian
+	container := f.blockAlign / f.channels
+	if f.tag != formatIMAADPCM && (container == 0 || f.blockAlign%f.channels != 0) {
+		return nil, debug.Errorf("Invalid block align %d for %d channels", f.blockAlign, f.channels)
+	}
+	b = b[:len(b)-(len(b)%f.blockAlign)]
+
+	switch f.tag {
+	case formatPCM:
+		track := make([]float32, len(b)/container)
+		switch container {
+		case 1:
+			for i, s := range b {
+				track[i] = float32(int(s)-128) / 128
+			}
+		case 2:
+			for i := range track {
+				track[i] = float32(int16(le.Uint16(b[i*2:]))) / (1 << 15)
+			}
+		case 3:
+			for i := range track {
+				s := int32(uint32(b[i*3])<<8|uint32(b[i*3+1])<<16|uint32(b[i*3+2])<<24) >> 8
+				track[i] = float32(s) / (1 << 23)
+			}
+		case 4:
+			for i := range track {
+				track[i] = float32(float64(int32(le.Uint32(b[i*4:]))) / (1 << 31))
+			}
+		default:
+			return nil, debug.Errorf("Unsupported PCM sample size %d", container*8)
+		}
+		return track, nil
+
+	case formatIEEEFloat:
+		track := make([]float32, len(b)/container)
+		switch container {
+		case 4:
+			for i := range track {
+				track[i] = math.Float32frombits(le.Uint32(b[i*4:]))
+			}
+		case 8:
+			for i := range track {
+				track[i] = float32(math.Float64frombits(le.Uint64(b[i*8:])))
+			}
+		default:
+			return nil, debug.Errorf("Unsupported float sample size %d", container*8)
+		}
+		return track, nil
+
+	case formatALaw, formatMuLaw:
+		if container != 1 {
+			return nil, debug.Errorf("Unsupported G.711 sample size %d", container*8)
+		}
+		expand := decodeALaw
+		if f.tag == formatMuLaw {
+			expand = decodeMuLaw
+		}
+		track := make([]float32, len(b))
+		for i, s := range b {
+			track[i] = float32(expand(s)) / (1 << 15)
+		}
+		return track, nil
+
+	case formatIMAADPCM:
+		return decodeIMAADPCM(f, b)
+	}
+	return nil, debug.Errorf("Unsupported format 0x%04X", f.tag)
+}
+
+func decodeALaw(s byte) int16 {
+	s ^= 0x55
+	exponent := int16(s>>4) & 0x07
+	mantissa := int16(s & 0x0F)
+	v := (mantissa << 4) + 8
+	if exponent != 0 {
+		v = (v + 0x100) << (exponent - 1)
+	}
+	if s&0x80 == 0 {
+		return -v
+	}
+	return v
+}
+
+func decodeMuLaw(s byte) int16 {
+	s = ^s
+	exponent := int16(s>>4) & 0x07
+	mantissa := int16(s & 0x0F)
+	v := (((mantissa << 3) + 0x84) << exponent) - 0x84
+	if s&0x80 != 0 {
+		return -v
+	}
+	return v
+}
+
+var imaIndexTable = [16]int{-1, -1, -1, -1, 2, 4, 6, 8, -1, -1, -1, -1, 2, 4, 6, 8}
+
+var imaStepTable = [89]int{
+	7, 8, 9, 10, 11, 12, 13, 14, 16, 17, 19, 21, 23, 25, 28, 31, 34, 37, 41, 45,
+	50, 55, 60, 66, 73, 80, 88, 97, 107, 118, 130, 143, 157, 173, 190, 209, 230,
+	253, 279, 307, 337, 371, 408, 449, 494, 544, 598, 658, 724, 796, 876, 963,
+	1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066, 2272, 2499, 2749, 3024, 3327,
+	3660, 4026, 4428, 4871, 5358, 5894, 6484, 7132, 7845, 8630, 9493, 10442, 11487,
+	12635, 13899, 15289, 16818, 18500, 20350, 22385, 24623, 27086, 29794, 32767,
+}
+
+/*
+decodeIMAADPCM decodes Microsoft IMA ADPCM, each block starts with a 4 byte
+header per channel holding the first sample and step index followed by groups
+of 4 bytes, 8 samples, per channel.
+*/
+func decodeIMAADPCM(f *waveFormat, b []byte) ([]float32, error) {
+	le := binary.LittleEndian
+	if f.bitsPerSample != 4 {
+		return nil, debug.Errorf("Unsupported IMA ADPCM sample size %d", f.bitsPerSample)
+	}
+	header := 4 * f.channels
+	if f.blockAlign <= header || (f.blockAlign-header)%header != 0 {
+		return nil, debug.Errorf("Invalid IMA ADPCM block align %d for %d channels", f.blockAlign, f.channels)
+	}
+	perBlock := 1 + ((f.blockAlign-header)*2)/f.channels
+	if f.samplesPerBlock > 0 {
+		if f.samplesPerBlock > perBlock {
+			return nil, debug.Errorf("Invalid IMA ADPCM samples per block %d", f.samplesPerBlock)
+		}
+		perBlock = f.samplesPerBlock
+	}
+
+	blocks := len(b) / f.blockAlign
+	track := make([]float32, blocks*perBlock*f.channels)
+	for block := 0; block < blocks; block++ {
+		in := b[block*f.blockAlign : (block+1)*f.blockAlign]
+		out := track[block*perBlock*f.channels:]
+		for c := 0; c < f.channels; c++ {
+			predictor := int(int16(le.Uint16(in[c*4:])))
+			index := min(int(in[c*4+2]), len(imaStepTable)-1)
+			out[c] = float32(predictor) / (1 << 15)
+
+			for i := 1; i < perBlock; i++ {
+				// nibbles of a channel are in groups of 4 bytes
+				n := i - 1
+				byteOffset := header + ((n/8)*4*f.channels + c*4 + (n%8)/2)
+				nibble := int(in[byteOffset])
+				if n%2 == 1 {
+					nibble >>= 4
+				}
+				nibble &= 0x0F
+
+				step := imaStepTable[index]
+				diff := step >> 3
+				if nibble&1 != 0 {
+					diff += step >> 2
+				}
+				if nibble&2 != 0 {
+					diff += step >> 1
+				}
+				if nibble&4 != 0 {
+					diff += step
+				}
+				if nibble&8 != 0 {
+					predictor -= diff
+				} else {
+					predictor += diff
+				}
+				predictor = max(-32768, min(32767, predictor))
+				index = max(0, min(len(imaStepTable)-1, index+imaIndexTable[nibble]))
+				out[i*f.channels+c] = float32(predictor) / (1 << 15)
+			}
+		}
+	}
+	return track, nil
+}
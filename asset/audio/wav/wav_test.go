@@ -0,0 +1,404 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wav
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/internal/testutil"
+)
+
+type testFormat struct {
+	tag        uint16
+	channels   int
+	frequency  int
+	blockAlign int
+	bits       int
+	mask       uint32
+	extensible bool
+	extra      []byte
+}
+
+func makeWAV(f testFormat, data []byte) []byte {
+	le := binary.LittleEndian
+	fmtChunk := le.AppendUint16(nil, f.tag)
+	if f.extensible {
+		fmtChunk = le.AppendUint16(nil, formatExtensible)
+	}
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(f.channels))
+	fmtChunk = le.AppendUint32(fmtChunk, uint32(f.frequency))
+	fmtChunk = le.AppendUint32(fmtChunk, uint32(f.frequency*f.blockAlign))
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(f.blockAlign))
+	fmtChunk = le.AppendUint16(fmtChunk, uint16(f.bits))
+	switch {
+	case f.extensible:
+		fmtChunk = le.AppendUint16(fmtChunk, 22)
+		fmtChunk = le.AppendUint16(fmtChunk, uint16(f.bits))
+		fmtChunk = le.AppendUint32(fmtChunk, f.mask)
+		fmtChunk = le.AppendUint16(fmtChunk, f.tag)
+		fmtChunk = append(fmtChunk, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71)
+	case f.extra != nil:
+		fmtChunk = le.AppendUint16(fmtChunk, uint16(len(f.extra)))
+		fmtChunk = append(fmtChunk, f.extra...)
+	}
+
+	out := []byte("RIFF\x00\x00\x00\x00WAVE")
+	// an unknown odd sized chunk to check padding is skipped
+	out = append(out, "junk\x03\x00\x00\x00abc\x00"...)
+	out = append(out, "fmt "...)
+	out = le.AppendUint32(out, uint32(len(fmtChunk)))
+	out = append(out, fmtChunk...)
+	out = append(out, "data"...)
+	out = le.AppendUint32(out, uint32(len(data)))
+	out = append(out, data...)
+	le.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	le := binary.LittleEndian
+	tests := []struct {
+		name   string
+		format testFormat
+		data   []byte
+		want   []float32
+	}{
+		{
+			"pcm8", testFormat{tag: formatPCM, channels: 1, frequency: 8000, blockAlign: 1, bits: 8},
+			[]byte{0, 128, 192},
+			[]float32{-1, 0, 0.5},
+		},
+		{
+			"pcm16", testFormat{tag: formatPCM, channels: 2, frequency: 44100, blockAlign: 4, bits: 16},
+			le.AppendUint16(le.AppendUint16(nil, 0x8000), 0x4000),
+			[]float32{-1, 0.5},
+		},
+		{
+			"pcm24", testFormat{tag: formatPCM, channels: 1, frequency: 48000, blockAlign: 3, bits: 24},
+			[]byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xC0},
+			[]float32{0.5, -0.5},
+		},
+		{
+			"pcm32", testFormat{tag: formatPCM, channels: 1, frequency: 48000, blockAlign: 4, bits: 32},
+			le.AppendUint32(nil, 1<<30),
+			[]float32{0.5},
+		},
+		{
+			"pcm24in32", testFormat{tag: formatPCM, channels: 1, frequency: 48000, blockAlign: 4, bits: 32, extensible: true, mask: speakerFrontCenter},
+			le.AppendUint32(nil, 0xC0000000),
+			[]float32{-0.5},
+		},
+		{
+			"float32", testFormat{tag: formatIEEEFloat, channels: 1, frequency: 48000, blockAlign: 4, bits: 32},
+			le.AppendUint32(nil, math.Float32bits(0.25)),
+			[]float32{0.25},
+		},
+		{
+			"float64", testFormat{tag: formatIEEEFloat, channels: 1, frequency: 48000, blockAlign: 8, bits: 64},
+			le.AppendUint64(nil, math.Float64bits(-0.75)),
+			[]float32{-0.75},
+		},
+		{
+			"alaw", testFormat{tag: formatALaw, channels: 1, frequency: 8000, blockAlign: 1, bits: 8},
+			[]byte{0xD5, 0x55, 0xAA},
+			[]float32{8.0 / 32768, -8.0 / 32768, 32256.0 / 32768},
+		},
+		{
+			"mulaw", testFormat{tag: formatMuLaw, channels: 1, frequency: 8000, blockAlign: 1, bits: 8},
+			[]byte{0xFF, 0x80, 0x00},
+			[]float32{0, 32124.0 / 32768, -32124.0 / 32768},
+		},
+		{
+			"imaadpcm", testFormat{tag: formatIMAADPCM, channels: 1, frequency: 22050, blockAlign: 8, bits: 4, extra: []byte{9, 0}},
+			[]byte{0, 0, 0, 0, 0x77, 0, 0, 0},
+			[]float32{0, 11.0 / 32768, 41.0 / 32768, 45.0 / 32768, 48.0 / 32768, 51.0 / 32768, 54.0 / 32768, 56.0 / 32768, 58.0 / 32768},
+		},
+	}
+	for _, test := range tests {
+		spec, samples, track, err := decodeWAV(makeWAV(test.format, test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if spec.Frequency != test.format.frequency || len(spec.Channels) != test.format.channels {
+			t.Errorf("%s: got spec %+v", test.name, spec)
+		}
+		if samples != len(test.want) || !reflect.DeepEqual(track, test.want) {
+			t.Errorf("%s: got %d samples %v want %v", test.name, samples, track, test.want)
+		}
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	le := binary.LittleEndian
+	pcm := []byte{}
+	for i := 0; i < 10000; i++ {
+		pcm = le.AppendUint16(pcm, uint16(i*7))
+	}
+	adpcm := []byte{}
+	for i := 0; i < 1000; i++ {
+		adpcm = append(adpcm, 0, byte(i), 0, 0, 0x77, byte(i), 0x1F, 0x80)
+	}
+	tests := []struct {
+		name   string
+		format testFormat
+		data   []byte
+	}{
+		{"pcm16", testFormat{tag: formatPCM, channels: 2, frequency: 44100, blockAlign: 4, bits: 16}, pcm},
+		{"imaadpcm", testFormat{tag: formatIMAADPCM, channels: 1, frequency: 22050, blockAlign: 8, bits: 4, extra: []byte{9, 0}}, adpcm},
+	}
+	for _, test := range tests {
+		data := makeWAV(test.format, test.data)
+		_, _, want, err := decodeWAV(data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		d, err := NewDecoder(data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if d.Frames()*test.format.channels != len(want) {
+			t.Errorf("%s: got %d frames want %d", test.name, d.Frames(), len(want)/test.format.channels)
+		}
+		for _, seek := range []int{0, 4, 4999, d.Frames()} {
+			if err := d.Seek(seek); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			got := []float32{}
+			for {
+				samples, err := d.Decode()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", test.name, err)
+				}
+				got = append(got, samples...)
+			}
+			if !reflect.DeepEqual(got, want[seek*test.format.channels:]) {
+				t.Errorf("%s: seek to %d: got %d samples want %d", test.name, seek, len(got), len(want)-seek*test.format.channels)
+			}
+		}
+		if err := d.Seek(d.Frames() + 1); err == nil {
+			t.Errorf("%s: seek past the end succeeded", test.name)
+		}
+	}
+}
+
+func TestChannelMask(t *testing.T) {
+	tests := []struct {
+		count int
+		mask  uint32
+		want  []audio.Channel
+	}{
+		{1, 0, audio.ChannelsMono()},
+		{1, speakerFrontCenter, audio.ChannelsMono()},
+		{2, 0, audio.ChannelsStereo()},
+		{6, 0, audio.Channels5Point1()},
+		{6, 0x3F, audio.Channels5Point1()},
+		{6, 0x60F, audio.Channels5Point1()},
+		{8, 0x63F, audio.Channels7Point1()},
+		{8, 0, audio.Channels7Point1()},
+		{4, 0x33, []audio.Channel{audio.ChannelLeft, audio.ChannelRight, audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight}},
+		{7, 0x70F, []audio.Channel{audio.ChannelLeft, audio.ChannelRight, audio.ChannelCenter, audio.ChannelLowFrequency, audio.ChannelBackCenter, audio.ChannelSurroundLeft, audio.ChannelSurroundRight}},
+		{4, speakerFrontLeft | speakerFrontRight | speakerTopCenter, []audio.Channel{audio.ChannelLeft, audio.ChannelRight, audio.ChannelTopCenter, audio.ChannelUnknown}},
+	}
+	for _, test := range tests {
+		if got := channelList(test.count, test.mask); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("channelList(%d, 0x%X) = %v want %v", test.count, test.mask, got, test.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	le := binary.LittleEndian
+	data := []byte{}
+	for _, s := range []uint16{0x4000, 0xC000, 0x2000, 0xE000} {
+		data = le.AppendUint16(data, s)
+	}
+	name := testutil.WriteFile(t, t.TempDir(), "test.wav", makeWAV(testFormat{tag: formatPCM, channels: 2, frequency: 44100, blockAlign: 4, bits: 16}, data))
+
+	a, err := audio.Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.DurationSamples() != 2 {
+		t.Errorf("got %d samples", a.DurationSamples())
+	}
+	want := [][]float32{{0.5, 0.25}, {-0.5, -0.25}}
+	if !reflect.DeepEqual(a.Track().Samples(), want) {
+		t.Errorf("got %v want %v", a.Track(), want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	valid := testFormat{tag: formatPCM, channels: 1, frequency: 8000, blockAlign: 2, bits: 16}
+	tests := map[string][]byte{
+		"not riff":    []byte("RIFX\x00\x00\x00\x00WAVE"),
+		"no data":     makeWAV(valid, nil)[:len(makeWAV(valid, nil))-8],
+		"unsupported": makeWAV(testFormat{tag: 0x0002, channels: 1, frequency: 8000, blockAlign: 2, bits: 4}, []byte{0, 0}),
+		"pcm40":       makeWAV(testFormat{tag: formatPCM, channels: 1, frequency: 8000, blockAlign: 5, bits: 40}, make([]byte, 5)),
+		"no channels": makeWAV(testFormat{tag: formatPCM, frequency: 8000, blockAlign: 2, bits: 16}, []byte{0, 0}),
+	}
+	for name, data := range tests {
+		if _, _, _, err := decodeWAV(data); err == nil {
+			t.Errorf("%s: decoded", name)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, channels := range [][]audio.Channel{
+		audio.ChannelsMono(),
+		audio.ChannelsStereo(),
+		{audio.ChannelRight, audio.ChannelLeft},
+		audio.Channels5Point1(),
+		audio.Channels7Point1(),
+		{audio.ChannelLeft, audio.ChannelRight, audio.ChannelSurroundLeft, audio.ChannelSurroundRight},
+		{audio.ChannelLeft, audio.ChannelRight, audio.ChannelBackSurroundLeft, audio.ChannelBackSurroundRight},
+	} {
+		track := audio.NewTrack(channels, 3)
+		for i, s := range track.Samples() {
+			for j := range s {
+				s[j] = float32(i) + float32(j)/4
+			}
+		}
+		name := filepath.Join(t.TempDir(), "test.wav")
+		f, err := os.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := NewEncoder(f, audio.Spec{Channels: channels, Frequency: 44100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// written in two parts
+		first := make([][]float32, len(channels))
+		second := make([][]float32, len(channels))
+		for i, s := range track.Samples() {
+			first[i], second[i] = s[:1], s[1:]
+		}
+		if err := e.Write(audio.TrackOf(channels, first)); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Write(audio.TrackOf(channels, second)); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+
+		a, err := audio.Load(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.DurationSamples() != 3 || a.Spec().Frequency != 44100 || len(a.Spec().Channels) != len(channels) {
+			t.Fatalf("%v: got spec %+v with %d frames", channels, a.Spec(), a.DurationSamples())
+		}
+		for _, c := range channels {
+			if !reflect.DeepEqual(a.Track().Channel(c), track.Channel(c)) {
+				t.Errorf("%v: channel %v got %v want %v", channels, c, a.Track().Channel(c), track.Channel(c))
+			}
+		}
+	}
+
+	if _, err := NewEncoder(nil, audio.Spec{}); err == nil {
+		t.Error("expected an error for an empty spec")
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	le := binary.LittleEndian
+	data := make([]byte, 100*2)
+	for i := range 100 {
+		le.PutUint16(data[i*2:], uint16(int16(8000*math.Sin(float64(i)))))
+	}
+	out := makeWAV(testFormat{tag: formatPCM, channels: 1, frequency: 8000, blockAlign: 2, bits: 16}, data)
+	chunk := func(id string, body []byte) {
+		out = append(out, id...)
+		out = le.AppendUint32(out, uint32(len(body)))
+		out = append(out, body...)
+		if len(body)&1 != 0 {
+			out = append(out, 0)
+		}
+	}
+
+	smpl := make([]byte, 36+24)
+	le.PutUint32(smpl[28:], 1)
+	le.PutUint32(smpl[36+8:], 10)
+	le.PutUint32(smpl[36+12:], 59)
+	chunk("smpl", smpl)
+
+	cue := le.AppendUint32(nil, 3)
+	for _, c := range [][2]uint32{{1, 40}, {2, 5}, {3, 1000}} {
+		point := make([]byte, 24)
+		le.PutUint32(point, c[0])
+		le.PutUint32(point[20:], c[1])
+		cue = append(cue, point...)
+	}
+	chunk("cue ", cue)
+
+	adtl := []byte("adtl")
+	adtl = append(adtl, "labl\x08\x00\x00\x00\x01\x00\x00\x00hit\x00"...)
+	ltxt := le.AppendUint32(nil, 2)
+	ltxt = le.AppendUint32(ltxt, 20)
+	ltxt = append(ltxt, make([]byte, 12)...)
+	ltxt = append(ltxt, "intro\x00"...)
+	adtl = append(adtl, "ltxt"...)
+	adtl = le.AppendUint32(adtl, uint32(len(ltxt)))
+	adtl = append(adtl, ltxt...)
+	chunk("LIST", adtl)
+	le.PutUint32(out[4:], uint32(len(out)-8))
+
+	name := testutil.WriteFile(t, t.TempDir(), "test.wav", out)
+	a, err := audio.Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := audio.MetadataOf(a)
+	if m.LoopStart != 10 || m.LoopEnd != 60 {
+		t.Errorf("got loop %d %d", m.LoopStart, m.LoopEnd)
+	}
+	// the cue past the end is dropped
+	want := []audio.Marker{{Frame: 5, Length: 20, Label: "intro"}, {Frame: 40, Label: "hit"}}
+	if !reflect.DeepEqual(m.Markers, want) {
+		t.Errorf("got markers %+v want %+v", m.Markers, want)
+	}
+	if m.Loudness == 0 || math.IsInf(m.Loudness, 0) {
+		t.Errorf("got loudness %f", m.Loudness)
+	}
+
+	s, err := audio.LoadStream(name, audio.StreamConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if m := audio.MetadataOf(s); m.LoopEnd != 60 || len(m.Markers) != 2 || m.Loudness != 0 {
+		t.Errorf("got stream metadata %+v", m)
+	}
+}
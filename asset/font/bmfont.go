@@ -0,0 +1,218 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"strconv"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+)
+
+var magicBMFont = []byte{'B', 'M', 'F', 3}
+
+/*
+LoadBMFont loads a BMFont descriptor in either the text or binary format, page
+paths are resolved relative to the descriptor.
+*/
+func LoadBMFont(file string) (*Font, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load BMFont")
+	}
+	defer a.Close()
+
+	data, err := a.View(0, a.Size())
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load BMFont %q", file)
+	}
+
+	f := &Font{Glyphs: map[rune]Glyph{}, Kerning: map[KerningPair]float32{}}
+	if bytes.HasPrefix(data, magicBMFont) {
+		err = f.parseBMFontBinary(data[len(magicBMFont):])
+	} else {
+		err = f.parseBMFontText(data)
+	}
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load BMFont %q", file)
+	}
+
+	dir := filepath.Dir(file)
+	for i, p := range f.Pages {
+		f.Pages[i] = filepath.Join(dir, filepath.FromSlash(p))
+	}
+	return f, nil
+}
+
+func (f *Font) addBMFontChar(id, x, y, w, h, xOffset, yOffset, xAdvance, page int, base float32) error {
+	if page < 0 || page >= len(f.Pages) {
+		return debug.Errorf("Char %d: Invalid page %d", id, page)
+	}
+	f.Glyphs[rune(id)] = Glyph{
+		Rect:    gmath.Rect[int]{X: x, Y: y, W: w, H: h},
+		Page:    page,
+		Offset:  gmath.Vector2f[float32]{X: float32(xOffset), Y: float32(yOffset) - base},
+		Advance: float32(xAdvance),
+	}
+	return nil
+}
+
+func (f *Font) parseBMFontText(data []byte) error {
+	type pending struct {
+		line   int
+		values map[string]int
+	}
+	chars := []pending{}
+
+	for n := 1; len(data) > 0; n++ {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		tag, rest, _ := bytes.Cut(line, []byte{' '})
+		strs := map[string]string{}
+		ints := map[string]int{}
+		for rest = bytes.TrimLeft(rest, " \t"); len(rest) > 0; rest = bytes.TrimLeft(rest, " \t") {
+			key, value, ok := bytes.Cut(rest, []byte{'='})
+			if !ok {
+				return debug.Errorf("Line %d: Expected key=value", n)
+			}
+			if len(value) > 0 && value[0] == '"' {
+				end := bytes.IndexByte(value[1:], '"')
+				if end < 0 {
+					return debug.Errorf("Line %d: Unterminated string", n)
+				}
+				strs[string(key)], rest = string(value[1:1+end]), value[2+end:]
+				continue
+			}
+			end := bytes.IndexAny(value, " \t")
+			if end < 0 {
+				end = len(value)
+			}
+			v := string(value[:end])
+			strs[string(key)], rest = v, value[end:]
+			// lists such as padding=1,1,1,1 are not needed
+			if i, err := strconv.Atoi(v); err == nil {
+				ints[string(key)] = i
+			}
+		}
+
+		switch string(tag) {
+		case "info":
+			f.Name = strs["face"]
+			f.Size = float32(max(ints["size"], -ints["size"]))
+		case "common":
+			f.LineHeight = float32(ints["lineHeight"])
+			f.Ascent = float32(ints["base"])
+			f.Descent = f.LineHeight - f.Ascent
+		case "page":
+			id := ints["id"]
+			if id < 0 || id > 255 {
+				return debug.Errorf("Line %d: Invalid page id %d", n, id)
+			}
+			for len(f.Pages) <= id {
+				f.Pages = append(f.Pages, "")
+			}
+			f.Pages[id] = strs["file"]
+		case "char":
+			// pages may come after chars in hand written files
+			chars = append(chars, pending{n, ints})
+		case "kerning":
+			f.Kerning[KerningPair{rune(ints["first"]), rune(ints["second"])}] = float32(ints["amount"])
+		}
+	}
+
+	for _, c := range chars {
+		v := c.values
+		err := f.addBMFontChar(v["id"], v["x"], v["y"], v["width"], v["height"], v["xoffset"], v["yoffset"], v["xadvance"], v["page"], f.Ascent)
+		if err != nil {
+			return debug.ErrorWrapf(err, "Line %d", c.line)
+		}
+	}
+
+	return nil
+}
+
+func (f *Font) parseBMFontBinary(data []byte) error {
+	le := binary.LittleEndian
+
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return debug.Errorf("Block header truncated")
+		}
+		kind, size := data[0], int(le.Uint32(data[1:]))
+		if size > len(data)-5 {
+			return debug.Errorf("Block %d truncated", kind)
+		}
+		block := data[5 : 5+size]
+		data = data[5+size:]
+
+		switch kind {
+		case 1:
+			if len(block) < 14 {
+				return debug.Errorf("Info block truncated")
+			}
+			size := int(int16(le.Uint16(block)))
+			f.Size = float32(max(size, -size))
+			name, _, _ := bytes.Cut(block[14:], []byte{0})
+			f.Name = string(name)
+		case 2:
+			if len(block) < 15 {
+				return debug.Errorf("Common block truncated")
+			}
+			f.LineHeight = float32(le.Uint16(block))
+			f.Ascent = float32(le.Uint16(block[2:]))
+			f.Descent = f.LineHeight - f.Ascent
+		case 3:
+			for _, p := range bytes.Split(bytes.TrimRight(block, "\x00"), []byte{0}) {
+				f.Pages = append(f.Pages, string(p))
+			}
+		case 4:
+			if len(block)%20 != 0 {
+				return debug.Errorf("Chars block has invalid size %d", len(block))
+			}
+			for c := block; len(c) > 0; c = c[20:] {
+				u16 := func(off int) int { return int(le.Uint16(c[off:])) }
+				i16 := func(off int) int { return int(int16(le.Uint16(c[off:]))) }
+				if err := f.addBMFontChar(int(le.Uint32(c)), u16(4), u16(6), u16(8), u16(10), i16(12), i16(14), i16(16), int(c[18]), f.Ascent); err != nil {
+					return err
+				}
+			}
+		case 5:
+			if len(block)%10 != 0 {
+				return debug.Errorf("Kerning block has invalid size %d", len(block))
+			}
+			for k := block; len(k) > 0; k = k[10:] {
+				pair := KerningPair{rune(le.Uint32(k)), rune(le.Uint32(k[4:]))}
+				f.Kerning[pair] = float32(int16(le.Uint16(k[8:])))
+			}
+		}
+	}
+
+	return nil
+}
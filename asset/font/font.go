@@ -0,0 +1,109 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package font loads bitmap fonts, either from BMFont descriptors or by
+rasterising TrueType/OpenType fonts into glyph atlases. All metrics are in
+pixels at the font's Size with +Y pointing down.
+*/
+package font
+
+import (
+	"goarrg.com/asset/image"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+)
+
+type Glyph struct {
+	// Rect is the glyph's bitmap within its atlas page, it is empty for
+	// glyphs without a bitmap such as space.
+	Rect gmath.Rect[int]
+	Page int
+	// Offset is from the pen position on the baseline to the top left of
+	// the glyph's bitmap.
+	Offset gmath.Vector2f[float32]
+	// Advance is how far the pen moves after the glyph, excluding kerning.
+	Advance float32
+}
+
+type KerningPair struct {
+	First  rune
+	Second rune
+}
+
+type Font struct {
+	Name string
+	// Size is the pixel size of an em.
+	Size       float32
+	LineHeight float32
+	// Ascent is the distance from the top of a line to the baseline and
+	// Descent from the baseline to the bottom, both positive.
+	Ascent  float32
+	Descent float32
+
+	Glyphs  map[rune]Glyph
+	Kerning map[KerningPair]float32
+
+	// Pages holds the paths of the atlas pages for BMFonts, Images holds the
+	// pages themselves and is nil for BMFonts until LoadPages is called.
+	Pages  []string
+	Images []*image.Image
+
+	// SDFSpread is non zero for signed distance field atlases, it is the
+	// distance in pixels at Size between the edge and where the field
+	// reaches 0 or 1. The edge is at 0.5.
+	SDFSpread float32
+}
+
+/*
+Glyph returns r's glyph, falling back to U+FFFD and then '?' if the font does
+not have it.
+*/
+func (f *Font) Glyph(r rune) (Glyph, bool) {
+	for _, c := range []rune{r, 0xFFFD, '?'} {
+		if g, ok := f.Glyphs[c]; ok {
+			return g, c == r
+		}
+	}
+	return Glyph{}, false
+}
+
+/*
+Kern returns the adjustment to the advance between a and b.
+*/
+func (f *Font) Kern(a, b rune) float32 {
+	return f.Kerning[KerningPair{a, b}]
+}
+
+/*
+LoadPages loads the atlas pages listed in Pages into Images, it does nothing if
+Images is already set.
+*/
+func (f *Font) LoadPages() error {
+	if f.Images != nil {
+		return nil
+	}
+	images := make([]*image.Image, len(f.Pages))
+	for i, p := range f.Pages {
+		img, err := image.Load(p)
+		if err != nil {
+			return debug.ErrorWrapf(err, "Failed to load font page %d", i)
+		}
+		images[i] = img
+	}
+	f.Images = images
+	return nil
+}
@@ -0,0 +1,167 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+
+	"goarrg.com/asset/image"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+	"goarrg.com/internal/testutil"
+)
+
+const testBMFont = `info face="Test Font" size=-16 bold=0 italic=0 padding=1,1,1,1 spacing=1,1
+common lineHeight=20 base=15 scaleW=64 scaleH=64 pages=1 packed=0
+page id=0 file="pages/test_0.png"
+chars count=2
+char id=65 x=1 y=2 width=9 height=11 xoffset=-1 yoffset=4 xadvance=10 page=0 chnl=15
+char id=32 x=0 y=0 width=0 height=0 xoffset=0 yoffset=0 xadvance=4 page=0 chnl=15
+kernings count=1
+kerning first=65 second=65 amount=-2
+`
+
+func testBMFontBinary() []byte {
+	le := binary.LittleEndian
+	b := []byte{'B', 'M', 'F', 3}
+	block := func(kind byte, data []byte) {
+		b = append(b, kind)
+		b = le.AppendUint32(b, uint32(len(data)))
+		b = append(b, data...)
+	}
+
+	info := le.AppendUint16(nil, uint16(0x10000-16))
+	info = append(info, make([]byte, 12)...)
+	block(1, append(info, "Test Font\x00"...))
+
+	common := le.AppendUint16(nil, 20)
+	common = le.AppendUint16(common, 15)
+	block(2, append(common, make([]byte, 11)...))
+
+	block(3, []byte("pages/test_0.png\x00"))
+
+	chars := []byte{}
+	for _, c := range [][9]int{{65, 1, 2, 9, 11, -1, 4, 10, 0}, {32, 0, 0, 0, 0, 0, 0, 4, 0}} {
+		chars = le.AppendUint32(chars, uint32(c[0]))
+		for _, v := range c[1:8] {
+			chars = le.AppendUint16(chars, uint16(v))
+		}
+		chars = append(chars, byte(c[8]), 15)
+	}
+	block(4, chars)
+
+	kerning := le.AppendUint32(nil, 65)
+	kerning = le.AppendUint32(kerning, 65)
+	block(5, le.AppendUint16(kerning, uint16(0x10000-2)))
+
+	return b
+}
+
+func TestBMFont(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"text.fnt":   []byte(testBMFont),
+		"binary.fnt": testBMFontBinary(),
+	} {
+		f, err := LoadBMFont(testutil.WriteFile(t, dir, name, data))
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		if f.Name != "Test Font" || f.Size != 16 || f.LineHeight != 20 || f.Ascent != 15 || f.Descent != 5 {
+			t.Fatalf("%s: Unexpected metrics: %+v", name, f)
+		}
+		if len(f.Pages) != 1 || f.Pages[0] != filepath.Join(dir, "pages", "test_0.png") {
+			t.Fatalf("%s: Unexpected pages: %v", name, f.Pages)
+		}
+		g, ok := f.Glyph('A')
+		want := Glyph{
+			Rect:    gmath.Rect[int]{X: 1, Y: 2, W: 9, H: 11},
+			Offset:  gmath.Vector2f[float32]{X: -1, Y: -11},
+			Advance: 10,
+		}
+		if !ok || g != want {
+			t.Fatalf("%s: Unexpected glyph: %+v", name, g)
+		}
+		if _, ok := f.Glyph('B'); ok {
+			t.Fatalf("%s: Found missing glyph", name)
+		}
+		if k := f.Kern('A', 'A'); k != -2 {
+			t.Fatalf("%s: Unexpected kerning: %v", name, k)
+		}
+	}
+
+	if _, err := LoadBMFont(testutil.WriteFile(t, dir, "bad.fnt", []byte("page id=0 file=\"a.png\"\nchar id=65 page=1\n"))); err == nil {
+		t.Fatal("Loaded char with invalid page")
+	}
+}
+
+func TestTrueType(t *testing.T) {
+	for _, sdf := range []bool{false, true} {
+		f, err := ParseTrueType(goregular.TTF, AtlasConfig{Size: 24, Runes: []rune("AVo ."), PageSize: 64, SDF: sdf})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Size != 24 || f.Ascent <= 0 || f.Descent <= 0 || f.LineHeight < f.Ascent+f.Descent {
+			t.Fatalf("Unexpected metrics: %+v", f)
+		}
+		if len(f.Glyphs) != 5 || len(f.Images) == 0 {
+			t.Fatalf("Unexpected glyphs %d or pages %d", len(f.Glyphs), len(f.Images))
+		}
+		for pair, k := range f.Kerning {
+			if k == 0 {
+				t.Fatalf("Stored zero kerning for %q", pair)
+			}
+		}
+
+		if g := f.Glyphs[' ']; g.Rect.W != 0 || g.Advance <= 0 {
+			t.Fatalf("Unexpected space glyph: %+v", g)
+		}
+
+		a := f.Glyphs['A']
+		if a.Offset.Y >= 0 || a.Offset.Y+float32(a.Rect.H) <= 0 || a.Rect.W == 0 {
+			t.Fatalf("Glyph does not sit on the baseline: %+v", a)
+		}
+		page, ok := image.Pixels[color.UNorm[uint8]](f.Images[a.Page])
+		if !ok {
+			t.Fatal("Unexpected page pixel type")
+		}
+		if f.Images[a.Page].Spec().Width > 64 {
+			t.Fatalf("Page larger than PageSize: %+v", f.Images[a.Page].Spec())
+		}
+
+		// the edges of a glyph's rect are padding, the middle of the bottom
+		// row inside the padding is between the A's legs.
+		edge := page.At(a.Rect.X, a.Rect.Y)
+		baseline := int(-a.Offset.Y) - 1
+		leg := page.At(a.Rect.X+int(-a.Offset.X)+1, a.Rect.Y+baseline)
+		gap := page.At(a.Rect.X+(a.Rect.W/2), a.Rect.Y+baseline)
+		if sdf {
+			if f.SDFSpread != 4 || edge.A != 0 || leg.A <= 127 || gap.A >= 127 {
+				t.Fatalf("Unexpected SDF values: edge %v leg %v gap %v", edge, leg, gap)
+			}
+		} else if f.SDFSpread != 0 || edge.A != 0 || leg.A == 0 || gap.A != 0 || leg.R != 255 {
+			t.Fatalf("Unexpected coverage: edge %v leg %v gap %v", edge, leg, gap)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package font
+
+import (
+	"errors"
+	stdimage "image"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+
+	"goarrg.com/asset"
+	"goarrg.com/asset/image"
+	"goarrg.com/asset/image/atlas"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+type AtlasConfig struct {
+	// Size is the pixel size of an em, defaults to 32.
+	Size float32
+	// Runes to rasterise, defaults to printable ASCII. Kerning is looked up
+	// for every pair so very large sets are slow to build.
+	Runes []rune
+	// PageSize is the maximum width and height of an atlas page, glyphs that
+	// don't fit spill into more pages. Defaults to 1024.
+	PageSize int
+	// Padding is the number of empty pixels around each glyph, defaults to
+	// 1 or ceil(SDFSpread)+1 for SDF atlases.
+	Padding int
+	// SDF produces a signed distance field instead of coverage.
+	SDF bool
+	// SDFSpread is the distance in pixels the field covers on either side of
+	// the edge, defaults to 4.
+	SDFSpread float32
+}
+
+/*
+sdfOversample is how much larger glyphs are rasterised before computing their
+distance fields.
+*/
+const sdfOversample = 4
+
+/*
+LoadTrueType loads a TrueType or OpenType font and rasterises it into atlas
+pages.
+*/
+func LoadTrueType(file string, cfg AtlasConfig) (*Font, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load font")
+	}
+	defer a.Close()
+
+	data, err := a.View(0, a.Size())
+	if err == nil {
+		var f *Font
+		if f, err = ParseTrueType(data, cfg); err == nil {
+			return f, nil
+		}
+	}
+	return nil, debug.ErrorWrapf(err, "Failed to load font %q", file)
+}
+
+/*
+ParseTrueType rasterises a TrueType or OpenType font held in data, data is only
+used during the call.
+*/
+func ParseTrueType(data []byte, cfg AtlasConfig) (*Font, error) {
+	if cfg.Size <= 0 {
+		cfg.Size = 32
+	}
+	if cfg.Runes == nil {
+		for r := rune(' '); r <= '~'; r++ {
+			cfg.Runes = append(cfg.Runes, r)
+		}
+	}
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 1024
+	}
+	if cfg.SDF && cfg.SDFSpread <= 0 {
+		cfg.SDFSpread = 4
+	}
+	if cfg.Padding <= 0 {
+		cfg.Padding = 1
+		if cfg.SDF {
+			cfg.Padding = int(math.Ceil(float64(cfg.SDFSpread))) + 1
+		}
+	}
+
+	sf, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to parse font")
+	}
+
+	buf := &sfnt.Buffer{}
+	ppem := fixed.Int26_6(cfg.Size * 64)
+	toFloat := func(v fixed.Int26_6) float32 { return float32(v) / 64 }
+
+	metrics, err := sf.Metrics(buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to read font metrics")
+	}
+	f := &Font{
+		Size:       cfg.Size,
+		LineHeight: toFloat(metrics.Height),
+		Ascent:     toFloat(metrics.Ascent),
+		Descent:    toFloat(metrics.Descent),
+		Glyphs:     map[rune]Glyph{},
+		Kerning:    map[KerningPair]float32{},
+	}
+	if cfg.SDF {
+		f.SDFSpread = cfg.SDFSpread
+	}
+	if name, err := sf.Name(buf, sfnt.NameIDFull); err == nil {
+		f.Name = name
+	}
+
+	type bitmap struct {
+		r      rune
+		pixels []uint8
+		w, h   int
+	}
+	bitmaps := []bitmap{}
+	indices := map[rune]sfnt.GlyphIndex{}
+
+	for _, r := range cfg.Runes {
+		idx, err := sf.GlyphIndex(buf, r)
+		if err != nil || (idx == 0 && r != 0xFFFD) {
+			continue
+		}
+		indices[r] = idx
+
+		bounds, advance, err := sf.GlyphBounds(buf, idx, ppem, font.HintingNone)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to read glyph %q", r)
+		}
+		x0, y0 := bounds.Min.X.Floor()-cfg.Padding, bounds.Min.Y.Floor()-cfg.Padding
+		x1, y1 := bounds.Max.X.Ceil()+cfg.Padding, bounds.Max.Y.Ceil()+cfg.Padding
+		g := Glyph{Advance: toFloat(advance), Offset: gmath.Vector2f[float32]{X: float32(x0), Y: float32(y0)}}
+
+		if bounds.Empty() {
+			f.Glyphs[r] = g
+			continue
+		}
+
+		pixels, err := rasterise(sf, buf, idx, cfg, x0, y0, x1-x0, y1-y0)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to rasterise glyph %q", r)
+		}
+		f.Glyphs[r] = g
+		bitmaps = append(bitmaps, bitmap{r, pixels, x1 - x0, y1 - y0})
+	}
+
+	for a, ia := range indices {
+		for b, ib := range indices {
+			k, err := sf.Kern(buf, ia, ib, ppem, font.HintingNone)
+			if errors.Is(err, sfnt.ErrNotFound) {
+				break
+			}
+			if err == nil && k != 0 {
+				f.Kerning[KerningPair{a, b}] = toFloat(k)
+			}
+		}
+	}
+
+	// pack everything into one page, splitting in half until each part fits
+	var pack func(b []bitmap) error
+	pack = func(b []bitmap) error {
+		if len(b) == 0 {
+			return nil
+		}
+		sizes := make([]gmath.Extent2i[int], len(b))
+		for i, bm := range b {
+			sizes[i] = gmath.Extent2i[int]{X: bm.w, Y: bm.h}
+		}
+		rects, size, err := atlas.Pack(sizes, atlas.Config{MaxSize: cfg.PageSize, PowerOfTwo: true})
+		if err != nil {
+			if len(b) == 1 {
+				return debug.ErrorWrapf(err, "Glyph %q does not fit in a page", b[0].r)
+			}
+			if err := pack(b[:len(b)/2]); err != nil {
+				return err
+			}
+			return pack(b[len(b)/2:])
+		}
+
+		page := image.NewBuffer[color.UNorm[uint8]](size.X, size.Y)
+		for i, bm := range b {
+			r := rects[i]
+			for y := range bm.h {
+				for x := range bm.w {
+					v := bm.pixels[(y*bm.w)+x]
+					if cfg.SDF {
+						page.Set(r.X+x, r.Y+y, color.UNorm[uint8]{R: v, G: v, B: v, A: v})
+					} else {
+						page.Set(r.X+x, r.Y+y, color.UNorm[uint8]{R: 255, G: 255, B: 255, A: v})
+					}
+				}
+			}
+			g := f.Glyphs[bm.r]
+			g.Rect, g.Page = r, len(f.Images)
+			f.Glyphs[bm.r] = g
+		}
+		f.Images = append(f.Images, image.New(image.Spec{Alpha: true}, page))
+		return nil
+	}
+	if err := pack(bitmaps); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to build atlas")
+	}
+
+	return f, nil
+}
+
+/*
+rasterise draws glyph idx into a w x h bitmap whose top left is at (x0, y0)
+relative to the pen position.
+*/
+func rasterise(sf *sfnt.Font, buf *sfnt.Buffer, idx sfnt.GlyphIndex, cfg AtlasConfig, x0, y0, w, h int) ([]uint8, error) {
+	scale := 1
+	if cfg.SDF {
+		scale = sdfOversample
+	}
+
+	segments, err := sf.LoadGlyph(buf, idx, fixed.Int26_6(cfg.Size*64*float32(scale)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rw, rh := w*scale, h*scale
+	ox, oy := float32(x0*scale), float32(y0*scale)
+	r := vector.NewRasterizer(rw, rh)
+	r.DrawOp = draw.Src
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return (float32(p.X) / 64) - ox, (float32(p.Y) / 64) - oy
+	}
+	for _, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			r.MoveTo(pt(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			r.LineTo(pt(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := pt(s.Args[0])
+			x2, y2 := pt(s.Args[1])
+			r.QuadTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := pt(s.Args[0])
+			x2, y2 := pt(s.Args[1])
+			x3, y3 := pt(s.Args[2])
+			r.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	r.ClosePath()
+
+	coverage := stdimage.NewAlpha(stdimage.Rect(0, 0, rw, rh))
+	r.Draw(coverage, coverage.Bounds(), stdimage.Opaque, stdimage.Point{})
+
+	if !cfg.SDF {
+		return coverage.Pix, nil
+	}
+	return distanceField(coverage.Pix, rw, rh, scale, cfg.SDFSpread), nil
+}
+
+/*
+distanceField downsamples an oversampled coverage bitmap into a signed distance
+field, 0.5 is the edge and spread pixels of the output map to the full range.
+*/
+func distanceField(coverage []uint8, w, h, scale int, spread float32) []uint8 {
+	inside := make([]float64, len(coverage))
+	outside := make([]float64, len(coverage))
+	for i, c := range coverage {
+		if c >= 128 {
+			outside[i] = math.Inf(1)
+		} else {
+			inside[i] = math.Inf(1)
+		}
+	}
+	// inside holds the distance to the nearest inside pixel and outside to
+	// the nearest outside pixel
+	edt(inside, w, h)
+	edt(outside, w, h)
+
+	ow, oh := w/scale, h/scale
+	out := make([]uint8, ow*oh)
+	for y := range oh {
+		for x := range ow {
+			i := (((y * scale) + (scale / 2)) * w) + (x * scale) + (scale / 2)
+			// positive inside
+			d := (math.Sqrt(outside[i]) - math.Sqrt(inside[i])) / float64(scale)
+			v := 0.5 + (d / (2 * float64(spread)))
+			out[(y*ow)+x] = uint8(math.Round(min(1, max(0, v)) * 255))
+		}
+	}
+	return out
+}
+
+/*
+edt replaces every value of f, which is 0 or +Inf, with the squared euclidean
+distance to the nearest 0 using Felzenszwalb and Huttenlocher's algorithm.
+*/
+func edt(f []float64, w, h int) {
+	n := max(w, h)
+	d := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+	line := make([]float64, n)
+
+	pass := func(get func(int) float64, set func(int, float64), n int) {
+		for i := range n {
+			line[i] = get(i)
+		}
+		k := 0
+		v[0], z[0], z[1] = 0, math.Inf(-1), math.Inf(1)
+		for q := 1; q < n; q++ {
+			if math.IsInf(line[q], 1) {
+				continue
+			}
+			for {
+				p := v[k]
+				s := ((line[q] + float64(q*q)) - (line[p] + float64(p*p))) / float64((2*q)-(2*p))
+				if math.IsInf(line[p], 1) {
+					s = math.Inf(-1)
+				}
+				if s <= z[k] && k > 0 {
+					k--
+					continue
+				}
+				if s <= z[k] {
+					// the only parabola so far is at infinity, replace it
+					v[k], z[k], z[k+1] = q, math.Inf(-1), math.Inf(1)
+					break
+				}
+				k++
+				v[k], z[k], z[k+1] = q, s, math.Inf(1)
+				break
+			}
+		}
+		k = 0
+		for q := range n {
+			for z[k+1] < float64(q) {
+				k++
+			}
+			dq := float64(q - v[k])
+			d[q] = (dq * dq) + line[v[k]]
+		}
+		for i := range n {
+			set(i, d[i])
+		}
+	}
+
+	for x := range w {
+		pass(func(i int) float64 { return f[(i*w)+x] }, func(i int, val float64) { f[(i*w)+x] = val }, h)
+	}
+	for y := range h {
+		row := f[y*w : (y+1)*w]
+		pass(func(i int) float64 { return row[i] }, func(i int, val float64) { row[i] = val }, w)
+	}
+}
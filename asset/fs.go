@@ -37,3 +37,14 @@ func (f *FileSystem) Open(name string) (fs.File, error) {
 func DirFS(dir string) *FileSystem {
 	return &FileSystem{dir: dir}
 }
+
+/*
+OpenWindowed is like Open but returns a WindowedFile, it is needed for files
+too large to be mapped in one go.
+*/
+func (f *FileSystem) OpenWindowed(name string, cfg WindowConfig) (*WindowedFile, error) {
+	if !fs.ValidPath(name) {
+		return nil, fs.ErrInvalid
+	}
+	return LoadWindowed(filepath.Join(f.dir, name), cfg)
+}
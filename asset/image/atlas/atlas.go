@@ -0,0 +1,190 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package atlas packs sprites into a single image and describes where each one
+ended up with a Metadata file.
+*/
+package atlas
+
+import (
+	"encoding/json"
+	"io"
+	"math/bits"
+	"slices"
+
+	"goarrg.com/asset"
+	"goarrg.com/asset/image"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+)
+
+type Config struct {
+	// MaxSize is the largest width or height the atlas may grow to,
+	// defaults to 4096.
+	MaxSize int
+	// Padding is the number of pixels around every sprite, it is filled by
+	// extending the sprite's edges so filtering doesn't pick up neighbours.
+	Padding int
+	// PowerOfTwo rounds the atlas's height up to a power of two, the width
+	// always is one.
+	PowerOfTwo bool
+}
+
+type Sprite struct {
+	Name  string
+	Image *image.Image
+}
+
+/*
+Metadata describes an atlas, every sprite's Rect is in pixels with the origin at
+the top left and does not include padding.
+*/
+type Metadata struct {
+	Width   int                        `json:"width"`
+	Height  int                        `json:"height"`
+	Sprites map[string]gmath.Rect[int] `json:"sprites"`
+}
+
+/*
+Pack places rectangles of the given sizes without overlap and returns their
+positions along with the size of the atlas needed to hold them. sizes should
+include any padding.
+*/
+func Pack(sizes []gmath.Extent2i[int], cfg Config) ([]gmath.Rect[int], gmath.Extent2i[int], error) {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 4096
+	}
+
+	// shelf packing tallest first, trying every power of two width and keeping
+	// the one with the smallest area
+	order := make([]int, len(sizes))
+	area := 0
+	minWidth := 1
+	for i, s := range sizes {
+		if s.X <= 0 || s.Y <= 0 {
+			return nil, gmath.Extent2i[int]{}, debug.Errorf("Invalid size %dx%d at %d", s.X, s.Y, i)
+		}
+		order[i] = i
+		area += s.X * s.Y
+		minWidth = max(minWidth, s.X)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if sizes[a].Y != sizes[b].Y {
+			return sizes[b].Y - sizes[a].Y
+		}
+		return sizes[b].X - sizes[a].X
+	})
+
+	var best []gmath.Rect[int]
+	bestSize := gmath.Extent2i[int]{}
+
+	for width := 1 << bits.Len(uint(minWidth-1)); width <= cfg.MaxSize; width *= 2 {
+		rects := make([]gmath.Rect[int], len(sizes))
+		x, y, shelf := 0, 0, 0
+		for _, i := range order {
+			if x+sizes[i].X > width {
+				x, y, shelf = 0, y+shelf, 0
+			}
+			rects[i] = gmath.Rect[int]{X: x, Y: y, W: sizes[i].X, H: sizes[i].Y}
+			x += sizes[i].X
+			shelf = max(shelf, sizes[i].Y)
+		}
+
+		height := max(1, y+shelf)
+		if cfg.PowerOfTwo {
+			height = 1 << bits.Len(uint(height-1))
+		}
+		if height > cfg.MaxSize {
+			continue
+		}
+		if best == nil || width*height < bestSize.X*bestSize.Y {
+			best = rects
+			bestSize = gmath.Extent2i[int]{X: width, Y: height}
+		}
+		if width*height == area {
+			break
+		}
+	}
+
+	if best == nil {
+		return nil, gmath.Extent2i[int]{}, debug.Errorf("Sprites do not fit in %dx%d", cfg.MaxSize, cfg.MaxSize)
+	}
+	return best, bestSize, nil
+}
+
+/*
+Build packs sprites into a single image stored as P, sprites are converted to
+P as needed.
+*/
+func Build[P image.Pixel](sprites []Sprite, cfg Config) (*image.Buffer[P], Metadata, error) {
+	sizes := make([]gmath.Extent2i[int], len(sprites))
+	names := make(map[string]struct{}, len(sprites))
+	for i, s := range sprites {
+		if _, ok := names[s.Name]; ok {
+			return nil, Metadata{}, debug.ErrorWrapf(debug.Errorf("Duplicate sprite %q", s.Name), "Failed to build atlas")
+		}
+		names[s.Name] = struct{}{}
+		spec := s.Image.Spec()
+		sizes[i] = gmath.Extent2i[int]{X: spec.Width + (2 * cfg.Padding), Y: spec.Height + (2 * cfg.Padding)}
+	}
+
+	rects, size, err := Pack(sizes, cfg)
+	if err != nil {
+		return nil, Metadata{}, debug.ErrorWrapf(err, "Failed to build atlas")
+	}
+
+	out := image.NewBuffer[P](size.X, size.Y)
+	meta := Metadata{Width: size.X, Height: size.Y, Sprites: make(map[string]gmath.Rect[int], len(sprites))}
+
+	for i, s := range sprites {
+		src := image.Convert[P](s.Image)
+		r := rects[i]
+		for y := range r.H {
+			sy := min(src.Height-1, max(0, y-cfg.Padding))
+			for x := range r.W {
+				sx := min(src.Width-1, max(0, x-cfg.Padding))
+				out.Set(r.X+x, r.Y+y, src.At(sx, sy))
+			}
+		}
+		meta.Sprites[s.Name] = gmath.Rect[int]{X: r.X + cfg.Padding, Y: r.Y + cfg.Padding, W: src.Width, H: src.Height}
+	}
+
+	return out, meta, nil
+}
+
+func (m Metadata) Encode(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "\t")
+	return debug.ErrorWrapf(e.Encode(m), "Failed to encode atlas metadata")
+}
+
+/*
+LoadMetadata loads a metadata file written by Metadata.Encode.
+*/
+func LoadMetadata(file string) (Metadata, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return Metadata{}, debug.ErrorWrapf(err, "Failed to load atlas metadata")
+	}
+	defer a.Close()
+
+	m := Metadata{}
+	if err := json.NewDecoder(a).Decode(&m); err != nil {
+		return Metadata{}, debug.ErrorWrapf(err, "Failed to load atlas metadata %q", file)
+	}
+	return m, nil
+}
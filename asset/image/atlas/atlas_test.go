@@ -0,0 +1,100 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package atlas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"goarrg.com/asset/image"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+func TestPack(t *testing.T) {
+	sizes := []gmath.Extent2i[int]{}
+	for i := range 20 {
+		sizes = append(sizes, gmath.Extent2i[int]{X: 3 + (i * 7 % 13), Y: 2 + (i * 5 % 11)})
+	}
+
+	rects, size, err := Pack(sizes, Config{MaxSize: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range rects {
+		if a.W != sizes[i].X || a.H != sizes[i].Y || a.X < 0 || a.Y < 0 || a.X+a.W > size.X || a.Y+a.H > size.Y {
+			t.Fatalf("[%d] %+v out of bounds %+v", i, a, size)
+		}
+		for j, b := range rects[:i] {
+			if a.X < b.X+b.W && b.X < a.X+a.W && a.Y < b.Y+b.H && b.Y < a.Y+a.H {
+				t.Fatalf("[%d] %+v overlaps [%d] %+v", i, a, j, b)
+			}
+		}
+	}
+
+	if _, _, err := Pack([]gmath.Extent2i[int]{{X: 65, Y: 1}}, Config{MaxSize: 64}); err == nil {
+		t.Fatal("Expected sprite too large")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	sprites := []Sprite{}
+	for i := range 3 {
+		b := image.NewBuffer[color.SRGB[uint8]](i+1, 2)
+		for j := range b.Pix {
+			b.Pix[j] = color.SRGB[uint8]{R: uint8(i), G: uint8(j), A: 255}
+		}
+		sprites = append(sprites, Sprite{Name: fmt.Sprint("sprite", i), Image: image.New(image.Spec{}, b)})
+	}
+
+	buf, m, err := Build[color.SRGB[uint8]](sprites, Config{Padding: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range sprites {
+		r := m.Sprites[s.Name]
+		src, _ := image.Pixels[color.SRGB[uint8]](s.Image)
+		if r.W != src.Width || r.H != src.Height {
+			t.Fatalf("%s: Wrong rect %+v", s.Name, r)
+		}
+		for y := -1; y <= r.H; y++ {
+			for x := -1; x <= r.W; x++ {
+				want := src.At(min(r.W-1, max(0, x)), min(r.H-1, max(0, y)))
+				if got := buf.At(r.X+x, r.Y+y); got != want {
+					t.Fatalf("[%d] [%d, %d] %+v != %+v", i, x, y, got, want)
+				}
+			}
+		}
+	}
+
+	b := bytes.Buffer{}
+	if err := m.Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	decoded := Metadata{}
+	if err := json.Unmarshal(b.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(decoded) != fmt.Sprint(m) {
+		t.Fatalf("%+v != %+v", decoded, m)
+	}
+}
@@ -0,0 +1,382 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"math/bits"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+const (
+	bmpCompressionRGB            = 0
+	bmpCompressionRLE8           = 1
+	bmpCompressionRLE4           = 2
+	bmpCompressionBitfields      = 3
+	bmpCompressionAlphaBitfields = 6
+
+	bmpColorSpaceCalibrated = 0
+)
+
+type bmpMask struct {
+	mask  uint32
+	shift int
+	max   uint32
+}
+
+func newBMPMask(m uint32) bmpMask {
+	if m == 0 {
+		return bmpMask{}
+	}
+	shift := bits.TrailingZeros32(m)
+	return bmpMask{mask: m, shift: shift, max: m >> shift}
+}
+
+func (m bmpMask) extract(v uint32) uint8 {
+	if m.mask == 0 {
+		return 0
+	}
+	return uint8((uint64((v&m.mask)>>m.shift) * 255) / uint64(m.max))
+}
+
+func decodeBMP(a *asset.File) (*Image, error) {
+	start, err := a.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+	}
+
+	readAt := func(off int64, n int) ([]byte, error) {
+		b := make([]byte, n)
+		if _, err := a.ReadAt(b, start+off); err != nil {
+			return nil, debug.Errorf("File truncated")
+		}
+		return b, nil
+	}
+
+	header, err := readAt(0, 18)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+	}
+	dataOffset := int64(binary.LittleEndian.Uint32(header[10:]))
+	dibSize := int(binary.LittleEndian.Uint32(header[14:]))
+
+	// BITMAPV5HEADER is the largest header there is
+	if (dibSize != 12 && dibSize < 40) || dibSize > 124 || int64(dibSize) > int64(a.Size())-start-14 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Unknown header size %d", dibSize), "Failed to decode BMP")
+	}
+
+	dib, err := readAt(14, dibSize)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+	}
+
+	var width, height, bpp, compression, paletteSize, paletteEntrySize int
+	var masks [4]uint32
+	paletteOffset := int64(14 + dibSize)
+	spec := Spec{}
+
+	if dibSize == 12 {
+		width = int(binary.LittleEndian.Uint16(dib[4:]))
+		height = int(binary.LittleEndian.Uint16(dib[6:]))
+		bpp = int(binary.LittleEndian.Uint16(dib[10:]))
+		paletteEntrySize = 3
+	} else {
+		width = int(int32(binary.LittleEndian.Uint32(dib[4:])))
+		height = int(int32(binary.LittleEndian.Uint32(dib[8:])))
+		bpp = int(binary.LittleEndian.Uint16(dib[14:]))
+		compression = int(binary.LittleEndian.Uint32(dib[16:]))
+		paletteSize = int(binary.LittleEndian.Uint32(dib[32:]))
+		paletteEntrySize = 4
+
+		switch {
+		case dibSize >= 56:
+			masks = [4]uint32{
+				binary.LittleEndian.Uint32(dib[40:]), binary.LittleEndian.Uint32(dib[44:]),
+				binary.LittleEndian.Uint32(dib[48:]), binary.LittleEndian.Uint32(dib[52:]),
+			}
+		case dibSize >= 52:
+			masks = [4]uint32{
+				binary.LittleEndian.Uint32(dib[40:]), binary.LittleEndian.Uint32(dib[44:]),
+				binary.LittleEndian.Uint32(dib[48:]),
+			}
+		case compression == bmpCompressionBitfields || compression == bmpCompressionAlphaBitfields:
+			n := 3
+			if compression == bmpCompressionAlphaBitfields {
+				n = 4
+			}
+			m, err := readAt(paletteOffset, n*4)
+			if err != nil {
+				return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+			}
+			for i := range n {
+				masks[i] = binary.LittleEndian.Uint32(m[i*4:])
+			}
+			paletteOffset += int64(n * 4)
+		}
+
+		if dibSize >= 108 && binary.LittleEndian.Uint32(dib[56:]) == bmpColorSpaceCalibrated {
+			// 16.16 fixed point
+			spec.Gamma = float64(binary.LittleEndian.Uint32(dib[96:])) / 65536
+		}
+	}
+
+	topDown := height < 0
+	if topDown {
+		height = -height
+	}
+	if width <= 0 || height <= 0 || width > math.MaxInt32 || height > math.MaxInt32 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid dimensions %dx%d", width, height), "Failed to decode BMP")
+	}
+
+	var palette []color.SRGB[uint8]
+	if bpp <= 8 {
+		if paletteSize == 0 || paletteSize > 1<<bpp {
+			paletteSize = 1 << bpp
+		}
+		p, err := readAt(paletteOffset, paletteSize*paletteEntrySize)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+		}
+		palette = make([]color.SRGB[uint8], paletteSize)
+		for i := range palette {
+			e := p[i*paletteEntrySize:]
+			palette[i] = color.SRGB[uint8]{R: e[2], G: e[1], B: e[0], A: 255}
+		}
+	}
+
+	switch compression {
+	case bmpCompressionRGB:
+		switch bpp {
+		case 16:
+			masks = [4]uint32{0x7C00, 0x03E0, 0x001F}
+		case 24, 32:
+			masks = [4]uint32{0xFF0000, 0x00FF00, 0x0000FF}
+		}
+	case bmpCompressionBitfields, bmpCompressionAlphaBitfields:
+		if bpp != 16 && bpp != 32 {
+			return nil, debug.ErrorWrapf(debug.Errorf("Invalid bit depth %d for bitfields", bpp), "Failed to decode BMP")
+		}
+	case bmpCompressionRLE8, bmpCompressionRLE4:
+	default:
+		return nil, debug.ErrorWrapf(debug.Errorf("Unsupported compression %d", compression), "Failed to decode BMP")
+	}
+
+	spec.Alpha = masks[3] != 0
+
+	// RLE can end the bitmap early so only the pixel count can be checked
+	available := max(0, int64(a.Size())-start-dataOffset)
+	if compression == bmpCompressionRLE8 || compression == bmpCompressionRLE4 {
+		available = -1
+	}
+	if err := checkSize(width, height, bpp, available); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+	}
+
+	img, err := decodeBMPPixels(a, start+dataOffset, width, height, bpp, compression, topDown, masks, palette)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode BMP")
+	}
+
+	// BMP is assumed to be sRGB unless it is calibrated with a gamma of 1
+	if spec.Gamma != 0 && math.Abs(spec.Gamma-1) < 0.01 {
+		linear := NewBuffer[color.UNorm[uint8]](img.Width, img.Height)
+		for i, p := range img.Pix {
+			linear.Pix[i] = color.UNorm[uint8](p)
+		}
+		return New(spec, linear), nil
+	}
+
+	return New(spec, img), nil
+}
+
+func decodeBMPPixels(a *asset.File, offset int64, width, height, bpp, compression int, topDown bool,
+	masks [4]uint32, palette []color.SRGB[uint8],
+) (*Buffer[color.SRGB[uint8]], error) {
+	b := NewBuffer[color.SRGB[uint8]](width, height)
+	row := func(y int) int {
+		if topDown {
+			return y
+		}
+		return height - 1 - y
+	}
+	lookup := func(i int) (color.SRGB[uint8], error) {
+		if i >= len(palette) {
+			return color.SRGB[uint8]{}, debug.Errorf("Palette index %d out of range", i)
+		}
+		return palette[i], nil
+	}
+
+	if compression == bmpCompressionRLE8 || compression == bmpCompressionRLE4 {
+		if _, err := a.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		indices, err := decodeBMPRLE(a, width, height, compression == bmpCompressionRLE4)
+		if err != nil {
+			return nil, err
+		}
+		for y := range height {
+			for x := range width {
+				p, err := lookup(int(indices[(y*width)+x]))
+				if err != nil {
+					return nil, err
+				}
+				b.Set(x, row(y), p)
+			}
+		}
+		return b, nil
+	}
+
+	m := [4]bmpMask{newBMPMask(masks[0]), newBMPMask(masks[1]), newBMPMask(masks[2]), newBMPMask(masks[3])}
+	stride := (((width * bpp) + 31) / 32) * 4
+	data := make([]byte, stride)
+
+	for y := range height {
+		if _, err := a.ReadAt(data, offset+int64(y*stride)); err != nil {
+			return nil, debug.Errorf("Pixel data truncated")
+		}
+
+		for x := range width {
+			var p color.SRGB[uint8]
+			var err error
+
+			switch bpp {
+			case 1, 2, 4:
+				bit := x * bpp
+				p, err = lookup(int(data[bit/8]>>(8-bpp-(bit%8))) & ((1 << bpp) - 1))
+			case 8:
+				p, err = lookup(int(data[x]))
+			case 16, 24, 32:
+				var v uint32
+				switch bpp {
+				case 16:
+					v = uint32(binary.LittleEndian.Uint16(data[x*2:]))
+				case 24:
+					v = uint32(data[x*3]) | uint32(data[(x*3)+1])<<8 | uint32(data[(x*3)+2])<<16
+				case 32:
+					v = binary.LittleEndian.Uint32(data[x*4:])
+				}
+				p = color.SRGB[uint8]{R: m[0].extract(v), G: m[1].extract(v), B: m[2].extract(v), A: 255}
+				if m[3].mask != 0 {
+					p.A = m[3].extract(v)
+				}
+			default:
+				return nil, debug.Errorf("Unsupported bit depth %d", bpp)
+			}
+
+			if err != nil {
+				return nil, err
+			}
+			b.Set(x, row(y), p)
+		}
+	}
+
+	return b, nil
+}
+
+/*
+decodeBMPRLE returns the palette indices in file order, that is bottom up.
+*/
+func decodeBMPRLE(a *asset.File, width, height int, rle4 bool) ([]uint8, error) {
+	indices := make([]uint8, width*height)
+	x, y := 0, 0
+	put := func(i uint8) {
+		if x < width && y < height {
+			indices[(y*width)+x] = i
+		}
+		x++
+	}
+	next := func() (uint8, error) {
+		b, err := a.ReadByte()
+		if err != nil {
+			return 0, debug.Errorf("RLE data truncated")
+		}
+		return b, nil
+	}
+
+	for y < height {
+		n, err := next()
+		if err != nil {
+			return nil, err
+		}
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		if n > 0 {
+			for i := range int(n) {
+				if !rle4 {
+					put(v)
+				} else if i%2 == 0 {
+					put(v >> 4)
+				} else {
+					put(v & 0xF)
+				}
+			}
+			continue
+		}
+
+		switch v {
+		case 0:
+			x, y = 0, y+1
+		case 1:
+			return indices, nil
+		case 2:
+			dx, err := next()
+			if err != nil {
+				return nil, err
+			}
+			dy, err := next()
+			if err != nil {
+				return nil, err
+			}
+			x, y = x+int(dx), y+int(dy)
+		default:
+			// absolute mode, runs are padded to 16 bits
+			size := int(v)
+			if rle4 {
+				size = (size + 1) / 2
+			}
+			for i := range size {
+				b, err := next()
+				if err != nil {
+					return nil, err
+				}
+				if !rle4 {
+					put(b)
+					continue
+				}
+				put(b >> 4)
+				if (i*2)+1 < int(v) {
+					put(b & 0xF)
+				}
+			}
+			if size%2 != 0 {
+				if _, err := next(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return indices, nil
+}
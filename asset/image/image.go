@@ -0,0 +1,324 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+type ColorSpace int
+
+const (
+	ColorSpaceLinear ColorSpace = iota
+	ColorSpaceSRGB
+)
+
+type Spec struct {
+	Width  int
+	Height int
+	// BitDepth is the size in bits of each component of the decoded pixels,
+	// not of the source file.
+	BitDepth   int
+	ColorSpace ColorSpace
+	// Gamma is the encoding gamma stored in the file, e.g. 0.45455 for 1/2.2,
+	// or 0 if the file does not specify one.
+	Gamma float64
+	// Alpha is true if the source had an alpha channel or transparency,
+	// decoded pixels always have 4 components regardless.
+	Alpha bool
+	// Premultiplied is true if the color components have been multiplied by
+	// alpha, decoders never set it.
+	Premultiplied bool
+}
+
+/*
+Pixel is the set of pixel formats images are decoded to. The color type tells
+you the color space, UNorm is linear while SRGB is not.
+*/
+type Pixel interface {
+	color.UNorm[uint8] | color.SRGB[uint8] |
+		color.UNorm[uint16] | color.SRGB[uint16] |
+		color.UNorm[float32]
+}
+
+/*
+Buffer is a tightly packed row major image with the origin at the top left.
+*/
+type Buffer[P Pixel] struct {
+	Width  int
+	Height int
+	Pix    []P
+}
+
+func NewBuffer[P Pixel](width, height int) *Buffer[P] {
+	return &Buffer[P]{
+		Width:  width,
+		Height: height,
+		Pix:    make([]P, width*height),
+	}
+}
+
+func (b *Buffer[P]) At(x, y int) P {
+	return b.Pix[(y*b.Width)+x]
+}
+
+func (b *Buffer[P]) Set(x, y int, p P) {
+	b.Pix[(y*b.Width)+x] = p
+}
+
+/*
+Image is a decoded image, Spec describes the format of the buffer that
+Pixels will return without conversion.
+*/
+type Image struct {
+	spec Spec
+	buf  any
+}
+
+// maxPixels limits the images decoders allocate, files claiming more are
+// rejected before anything is allocated
+const maxPixels = 1 << 28
+
+/*
+checkSize returns an error if a width x height image has more than maxPixels
+or needs more than available bytes at bits per pixel, with available < 0 only
+the pixel count is checked.
+*/
+func checkSize(width, height, bits int, available int64) error {
+	if width <= 0 || height <= 0 || width > maxPixels/height {
+		return debug.Errorf("Invalid dimensions %dx%d", width, height)
+	}
+	if available >= 0 && (int64(width)*int64(height)*int64(bits)+7)/8 > available {
+		return debug.Errorf("Dimensions %dx%d need more data than the file has", width, height)
+	}
+	return nil
+}
+
+/*
+New wraps buf into an Image, spec's Width, Height, BitDepth and ColorSpace are
+taken from buf. It is meant for use by decoders.
+*/
+func New[P Pixel](spec Spec, buf *Buffer[P]) *Image {
+	spec.Width = buf.Width
+	spec.Height = buf.Height
+
+	switch any(*new(P)).(type) {
+	case color.UNorm[uint8]:
+		spec.BitDepth, spec.ColorSpace = 8, ColorSpaceLinear
+	case color.SRGB[uint8]:
+		spec.BitDepth, spec.ColorSpace = 8, ColorSpaceSRGB
+	case color.UNorm[uint16]:
+		spec.BitDepth, spec.ColorSpace = 16, ColorSpaceLinear
+	case color.SRGB[uint16]:
+		spec.BitDepth, spec.ColorSpace = 16, ColorSpaceSRGB
+	case color.UNorm[float32]:
+		spec.BitDepth, spec.ColorSpace = 32, ColorSpaceLinear
+	}
+
+	return &Image{spec: spec, buf: buf}
+}
+
+func (i *Image) Spec() Spec {
+	return i.spec
+}
+
+/*
+Pixels returns the image's buffer if it is stored as P, it does not convert.
+*/
+func Pixels[P Pixel](i *Image) (*Buffer[P], bool) {
+	b, ok := i.buf.(*Buffer[P])
+	return b, ok
+}
+
+/*
+Convert returns the image's buffer as P, converting between color spaces and
+component sizes if needed. If no conversion is needed the returned buffer is the
+image's own buffer and not a copy.
+*/
+func Convert[P Pixel](i *Image) *Buffer[P] {
+	if b, ok := Pixels[P](i); ok {
+		return b
+	}
+
+	out := NewBuffer[P](i.spec.Width, i.spec.Height)
+	switch in := i.buf.(type) {
+	case *Buffer[color.UNorm[uint8]]:
+		convertBuffer(out, in)
+	case *Buffer[color.SRGB[uint8]]:
+		convertBuffer(out, in)
+	case *Buffer[color.UNorm[uint16]]:
+		convertBuffer(out, in)
+	case *Buffer[color.SRGB[uint16]]:
+		convertBuffer(out, in)
+	case *Buffer[color.UNorm[float32]]:
+		convertBuffer(out, in)
+	default:
+		panic(fmt.Sprintf("Unknown buffer type: %T", i.buf))
+	}
+	return out
+}
+
+func convertBuffer[P, Q Pixel](out *Buffer[P], in *Buffer[Q]) {
+	for i, p := range in.Pix {
+		out.Pix[i] = fromUNorm64[P](toUNorm64(p))
+	}
+}
+
+func toUNorm64[P Pixel](p P) color.UNorm[float64] {
+	switch p := any(p).(type) {
+	case color.UNorm[uint8]:
+		return color.Convert[color.UNorm[float64], color.UNorm[uint8], float64, uint8](p)
+	case color.SRGB[uint8]:
+		return color.Convert[color.UNorm[float64], color.SRGB[uint8], float64, uint8](p)
+	case color.UNorm[uint16]:
+		return color.Convert[color.UNorm[float64], color.UNorm[uint16], float64, uint16](p)
+	case color.SRGB[uint16]:
+		return color.Convert[color.UNorm[float64], color.SRGB[uint16], float64, uint16](p)
+	case color.UNorm[float32]:
+		return color.Convert[color.UNorm[float64], color.UNorm[float32], float64, float32](p)
+	default:
+		panic(fmt.Sprintf("Unknown pixel type: %T", p))
+	}
+}
+
+func fromUNorm64[P Pixel](c color.UNorm[float64]) P {
+	// color.Convert truncates when converting to integers, quantize here
+	// instead so that round trips are lossless
+	q := func(in float64, scale float64) float64 {
+		return math.Floor((min(1, max(0, in)) * scale) + 0.5)
+	}
+	switch any(*new(P)).(type) {
+	case color.UNorm[uint8]:
+		return any(color.UNorm[uint8]{R: uint8(q(c.R, 255)), G: uint8(q(c.G, 255)), B: uint8(q(c.B, 255)), A: uint8(q(c.A, 255))}).(P)
+	case color.SRGB[uint8]:
+		s := color.Convert[color.SRGB[float64], color.UNorm[float64], float64, float64](c)
+		return any(color.SRGB[uint8]{R: uint8(q(s.R, 255)), G: uint8(q(s.G, 255)), B: uint8(q(s.B, 255)), A: uint8(q(s.A, 255))}).(P)
+	case color.UNorm[uint16]:
+		return any(color.UNorm[uint16]{R: uint16(q(c.R, 65535)), G: uint16(q(c.G, 65535)), B: uint16(q(c.B, 65535)), A: uint16(q(c.A, 65535))}).(P)
+	case color.SRGB[uint16]:
+		s := color.Convert[color.SRGB[float64], color.UNorm[float64], float64, float64](c)
+		return any(color.SRGB[uint16]{R: uint16(q(s.R, 65535)), G: uint16(q(s.G, 65535)), B: uint16(q(s.B, 65535)), A: uint16(q(s.A, 65535))}).(P)
+	case color.UNorm[float32]:
+		return any(color.Convert[color.UNorm[float32], color.UNorm[float64], float32, float64](c)).(P)
+	default:
+		panic(fmt.Sprintf("Unknown pixel type: %T", *new(P)))
+	}
+}
+
+type format struct {
+	magic  []byte
+	decode func(*asset.File) (*Image, error)
+}
+
+var (
+	mtx     = sync.Mutex{}
+	formats = atomic.Value{}
+)
+
+func init() {
+	RegisterFormat("\x89PNG\r\n\x1a\n", decodePNG)
+	RegisterFormat("\xff\xd8\xff", decodeJPEG)
+	RegisterFormat("BM", decodeBMP)
+	RegisterFormat("", decodeTGA)
+}
+
+/*
+RegisterFormat registers a decoder for files starting with magic, '?' matches
+any byte. Formats without a magic number such as TGA can be registered with an
+empty magic, they are only tried in registration order after every format with a
+magic failed to match. Decoders must not retain the *asset.File.
+*/
+func RegisterFormat(magic string, decode func(*asset.File) (*Image, error)) {
+	mtx.Lock()
+	f, _ := formats.Load().([]format)
+	formats.Store(append(f, format{[]byte(magic), decode}))
+	mtx.Unlock()
+}
+
+func Load(file string) (*Image, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load image")
+	}
+	defer a.Close()
+
+	img, err := Decode(a)
+	return img, debug.ErrorWrapf(err, "Failed to load image %q", file)
+}
+
+/*
+Decode decodes a from its current position.
+*/
+func Decode(a *asset.File) (*Image, error) {
+	formats, _ := formats.Load().([]format)
+	var fallback []format
+
+formats:
+	for _, f := range formats {
+		if len(f.magic) == 0 {
+			fallback = append(fallback, f)
+			continue
+		}
+
+		if a.Len() < len(f.magic) {
+			continue
+		}
+
+		magic, err := a.Peek(len(f.magic))
+
+		if errors.Is(err, io.EOF) {
+			return nil, debug.ErrorWrapf(err, "Failed to decode image")
+		}
+
+		for i, b := range f.magic {
+			if b != magic[i] && b != '?' {
+				continue formats
+			}
+		}
+
+		return f.decode(a)
+	}
+
+	if len(fallback) > 0 {
+		start, err := a.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decode image")
+		}
+		for _, f := range fallback {
+			img, decodeErr := f.decode(a)
+			if decodeErr == nil {
+				return img, nil
+			}
+			err = decodeErr
+			if _, err := a.Seek(start, io.SeekStart); err != nil {
+				return nil, debug.ErrorWrapf(err, "Failed to decode image")
+			}
+		}
+		return nil, debug.ErrorWrapf(err, "Failed to decode image, unknown format")
+	}
+
+	return nil, debug.Errorf("Failed to decode image, unknown format")
+}
@@ -0,0 +1,345 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	stdimage "image"
+	stdcolor "image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"goarrg.com/gmath/color"
+	"goarrg.com/internal/testutil"
+)
+
+func TestPNG(t *testing.T) {
+	const w, h = 7, 5
+
+	encode := func(t *testing.T, img stdimage.Image) string {
+		t.Helper()
+		b := bytes.Buffer{}
+		if err := png.Encode(&b, img); err != nil {
+			t.Fatal(err)
+		}
+		return testutil.WriteFile(t, t.TempDir(), "test.png", b.Bytes())
+	}
+
+	check8 := func(t *testing.T, img *Image, want func(x, y int) color.SRGB[uint8]) {
+		t.Helper()
+		buf, ok := Pixels[color.SRGB[uint8]](img)
+		if !ok {
+			t.Fatalf("Wrong pixel format: %+v", img.Spec())
+		}
+		for y := range h {
+			for x := range w {
+				if got := buf.At(x, y); got != want(x, y) {
+					t.Fatalf("[%d, %d] %+v != %+v", x, y, got, want(x, y))
+				}
+			}
+		}
+	}
+
+	t.Run("NRGBA", func(t *testing.T) {
+		src := stdimage.NewNRGBA(stdimage.Rect(0, 0, w, h))
+		for y := range h {
+			for x := range w {
+				src.SetNRGBA(x, y, stdcolor.NRGBA{uint8(x * 30), uint8(y * 50), uint8(x * y), uint8(255 - x)})
+			}
+		}
+		img, err := Load(encode(t, src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := img.Spec(); s.Width != w || s.Height != h || !s.Alpha || s.BitDepth != 8 || s.ColorSpace != ColorSpaceSRGB {
+			t.Fatalf("Wrong spec: %+v", s)
+		}
+		check8(t, img, func(x, y int) color.SRGB[uint8] {
+			return color.SRGB[uint8]{R: uint8(x * 30), G: uint8(y * 50), B: uint8(x * y), A: uint8(255 - x)}
+		})
+	})
+
+	t.Run("Gray", func(t *testing.T) {
+		src := stdimage.NewGray(stdimage.Rect(0, 0, w, h))
+		for y := range h {
+			for x := range w {
+				src.SetGray(x, y, stdcolor.Gray{uint8((x * 11) + y)})
+			}
+		}
+		img, err := Load(encode(t, src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check8(t, img, func(x, y int) color.SRGB[uint8] {
+			v := uint8((x * 11) + y)
+			return color.SRGB[uint8]{R: v, G: v, B: v, A: 255}
+		})
+	})
+
+	t.Run("Paletted", func(t *testing.T) {
+		palette := stdcolor.Palette{
+			stdcolor.NRGBA{0, 0, 0, 0},
+			stdcolor.NRGBA{255, 0, 0, 255},
+			stdcolor.NRGBA{0, 255, 0, 255},
+		}
+		src := stdimage.NewPaletted(stdimage.Rect(0, 0, w, h), palette)
+		for y := range h {
+			for x := range w {
+				src.SetColorIndex(x, y, uint8((x+y)%3))
+			}
+		}
+		img, err := Load(encode(t, src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check8(t, img, func(x, y int) color.SRGB[uint8] {
+			c := palette[(x+y)%3].(stdcolor.NRGBA)
+			return color.SRGB[uint8]{R: c.R, G: c.G, B: c.B, A: c.A}
+		})
+	})
+
+	t.Run("NRGBA64", func(t *testing.T) {
+		src := stdimage.NewNRGBA64(stdimage.Rect(0, 0, w, h))
+		for y := range h {
+			for x := range w {
+				src.SetNRGBA64(x, y, stdcolor.NRGBA64{uint16(x * 9000), uint16(y * 13000), 1234, 65535})
+			}
+		}
+		img, err := Load(encode(t, src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, ok := Pixels[color.SRGB[uint16]](img)
+		if !ok {
+			t.Fatalf("Wrong pixel format: %+v", img.Spec())
+		}
+		for y := range h {
+			for x := range w {
+				want := color.SRGB[uint16]{R: uint16(x * 9000), G: uint16(y * 13000), B: 1234, A: 65535}
+				if got := buf.At(x, y); got != want {
+					t.Fatalf("[%d, %d] %+v != %+v", x, y, got, want)
+				}
+			}
+		}
+	})
+}
+
+func TestBMP(t *testing.T) {
+	// 3x2 24 bit bottom up, rows padded to 12 bytes
+	data := []byte{'B', 'M', 0, 0, 0, 0, 0, 0, 0, 0, 54, 0, 0, 0}
+	dib := make([]byte, 40)
+	binary.LittleEndian.PutUint32(dib[0:], 40)
+	binary.LittleEndian.PutUint32(dib[4:], 3)
+	binary.LittleEndian.PutUint32(dib[8:], 2)
+	binary.LittleEndian.PutUint16(dib[12:], 1)
+	binary.LittleEndian.PutUint16(dib[14:], 24)
+	data = append(data, dib...)
+	data = append(data,
+		// bottom row, BGR
+		0, 0, 255, 0, 255, 0, 255, 0, 0, 0, 0, 0,
+		// top row
+		10, 20, 30, 40, 50, 60, 70, 80, 90, 0, 0, 0,
+	)
+
+	img, err := Load(testutil.WriteFile(t, t.TempDir(), "test.bmp", data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, ok := Pixels[color.SRGB[uint8]](img)
+	if !ok {
+		t.Fatalf("Wrong pixel format: %+v", img.Spec())
+	}
+	want := []color.SRGB[uint8]{
+		{R: 30, G: 20, B: 10, A: 255}, {R: 60, G: 50, B: 40, A: 255}, {R: 90, G: 80, B: 70, A: 255},
+		{R: 255, G: 0, B: 0, A: 255}, {R: 0, G: 255, B: 0, A: 255}, {R: 0, G: 0, B: 255, A: 255},
+	}
+	for i, c := range want {
+		if buf.Pix[i] != c {
+			t.Fatalf("[%d] %+v != %+v", i, buf.Pix[i], c)
+		}
+	}
+}
+
+func TestTGA(t *testing.T) {
+	// 3x2 RLE 32 bit top left origin with 8 alpha bits
+	data := []byte{0, 0, 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 2, 0, 32, 0x28}
+	data = append(data,
+		// run of 4 pixels
+		0x83, 1, 2, 3, 4,
+		// 2 raw pixels
+		0x01, 5, 6, 7, 8, 9, 10, 11, 12,
+	)
+
+	img, err := Load(testutil.WriteFile(t, t.TempDir(), "test.tga", data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !img.Spec().Alpha {
+		t.Fatalf("Wrong spec: %+v", img.Spec())
+	}
+	buf, ok := Pixels[color.SRGB[uint8]](img)
+	if !ok {
+		t.Fatalf("Wrong pixel format: %+v", img.Spec())
+	}
+	want := []color.SRGB[uint8]{
+		{R: 3, G: 2, B: 1, A: 4}, {R: 3, G: 2, B: 1, A: 4}, {R: 3, G: 2, B: 1, A: 4},
+		{R: 3, G: 2, B: 1, A: 4}, {R: 7, G: 6, B: 5, A: 8}, {R: 11, G: 10, B: 9, A: 12},
+	}
+	for i, c := range want {
+		if buf.Pix[i] != c {
+			t.Fatalf("[%d] %+v != %+v", i, buf.Pix[i], c)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	b := NewBuffer[color.SRGB[uint8]](256, 1)
+	for i := range b.Pix {
+		b.Pix[i] = color.SRGB[uint8]{R: uint8(i), G: uint8(255 - i), B: uint8(i / 2), A: uint8(i)}
+	}
+	img := New(Spec{}, b)
+
+	linear := New(Spec{}, Convert[color.UNorm[float32]](img))
+	back := Convert[color.SRGB[uint8]](linear)
+	for i := range b.Pix {
+		if back.Pix[i] != b.Pix[i] {
+			t.Fatalf("[%d] %+v != %+v", i, back.Pix[i], b.Pix[i])
+		}
+	}
+}
+
+func TestMips(t *testing.T) {
+	// a 4x2 checkerboard of sRGB black and white averages to linear 0.5, not
+	// sRGB 128
+	b := NewBuffer[color.SRGB[uint8]](4, 2)
+	for i := range b.Pix {
+		v := uint8(255 * (((i % 4) + (i / 4)) % 2))
+		b.Pix[i] = color.SRGB[uint8]{R: v, G: v, B: v, A: 255}
+	}
+
+	levels := GenerateMips(New(Spec{}, b), FilterBox)
+	if len(levels) != 3 {
+		t.Fatalf("Wrong level count %d", len(levels))
+	}
+	for i, want := range [][2]int{{4, 2}, {2, 1}, {1, 1}} {
+		if s := levels[i].Spec(); s.Width != want[0] || s.Height != want[1] || s.ColorSpace != ColorSpaceSRGB || s.BitDepth != 8 {
+			t.Fatalf("[%d] Wrong spec: %+v", i, s)
+		}
+	}
+	for _, p := range Convert[color.SRGB[uint8]](levels[2]).Pix {
+		if p != (color.SRGB[uint8]{R: 188, G: 188, B: 188, A: 255}) {
+			t.Fatalf("Not gamma correct: %+v", p)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	// transparent pixels must not bleed their color into opaque ones
+	b := NewBuffer[color.SRGB[uint8]](8, 1)
+	for i := range b.Pix {
+		if i%2 == 0 {
+			b.Pix[i] = color.SRGB[uint8]{R: 255, A: 255}
+		} else {
+			b.Pix[i] = color.SRGB[uint8]{B: 255}
+		}
+	}
+
+	for _, f := range []Filter{FilterBox, FilterLanczos3} {
+		out := Convert[color.SRGB[uint8]](Resize(New(Spec{}, b), 3, 1, f))
+		for i, p := range out.Pix {
+			if p.R != 255 || p.B != 0 || p.A == 0 {
+				t.Fatalf("%s [%d] Color bleed: %+v", f, i, p)
+			}
+		}
+	}
+
+	pre := Convert[color.SRGB[uint8]](Premultiply(New(Spec{}, b)))
+	if pre.Pix[1] != (color.SRGB[uint8]{}) || pre.Pix[0] != b.Pix[0] {
+		t.Fatalf("Wrong premultiply: %+v", pre.Pix[:2])
+	}
+}
+
+func TestOversized(t *testing.T) {
+	bmp := func(width, height int32) []byte {
+		data := []byte{'B', 'M', 0, 0, 0, 0, 0, 0, 0, 0, 54, 0, 0, 0}
+		dib := make([]byte, 40)
+		binary.LittleEndian.PutUint32(dib[0:], 40)
+		binary.LittleEndian.PutUint32(dib[4:], uint32(width))
+		binary.LittleEndian.PutUint32(dib[8:], uint32(height))
+		binary.LittleEndian.PutUint16(dib[12:], 1)
+		binary.LittleEndian.PutUint16(dib[14:], 24)
+		return append(append(data, dib...), make([]byte, 24)...)
+	}
+	pngFile := func(width, height uint32) []byte {
+		data := []byte("\x89PNG\r\n\x1a\n")
+		chunk := func(id string, body []byte) {
+			data = binary.BigEndian.AppendUint32(data, uint32(len(body)))
+			start := len(data)
+			data = append(append(data, id...), body...)
+			data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data[start:]))
+		}
+		ihdr := binary.BigEndian.AppendUint32(nil, width)
+		ihdr = binary.BigEndian.AppendUint32(ihdr, height)
+		chunk("IHDR", append(ihdr, 8, 6, 0, 0, 0))
+		chunk("IDAT", []byte{0x78, 0x9c, 0x03, 0x00, 0x00, 0x00, 0x00, 0x01})
+		chunk("IEND", nil)
+		return data
+	}
+	tga := func(imageType byte, width, height uint16) []byte {
+		data := []byte{0, 0, imageType, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 32, 0x28}
+		binary.LittleEndian.PutUint16(data[12:], width)
+		binary.LittleEndian.PutUint16(data[14:], height)
+		return append(data, 0xFF, 1, 2, 3, 4)
+	}
+
+	header := bmp(1, 1)[:64]
+	binary.LittleEndian.PutUint32(header[14:], 0xff000028)
+	// a true color image with a color map of 0 bit entries
+	cmap := tga(2, 1, 1)
+	cmap[1], cmap[5], cmap[7] = 1, 1, 0
+	jpg := bytes.Buffer{}
+	if err := jpeg.Encode(&jpg, stdimage.NewGray(stdimage.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	sof := bytes.Index(jpg.Bytes(), []byte{0xFF, 0xC0})
+	binary.BigEndian.PutUint32(jpg.Bytes()[sof+5:], 0xFFFFFFFF)
+
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"pixels.bmp", bmp(524289, 524290)},
+		{"data.bmp", bmp(1000, -1000)},
+		{"pixels.png", pngFile(1<<20, 1<<20)},
+		{"data.png", pngFile(10000, 10000)},
+		{"data.tga", tga(2, 1000, 1000)},
+		{"rle.tga", tga(10, 1000, 1000)},
+		{"header.bmp", header},
+		{"cmap.tga", cmap},
+		{"pixels.jpg", jpg.Bytes()},
+	} {
+		if _, err := Load(testutil.WriteFile(t, t.TempDir(), f.name, f.data)); err == nil {
+			t.Errorf("%s: decoded", f.name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	stdimage "image"
+	stdcolor "image/color"
+	"image/jpeg"
+	"io"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+/*
+decodeJPEG uses the standard library's entropy decoder and IDCT, only the
+color conversion is done here so that the YCbCr planes are written straight
+into the output buffer without going through image.Image's At.
+*/
+func decodeJPEG(a *asset.File) (*Image, error) {
+	start, err := a.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode JPEG")
+	}
+	cfg, err := jpeg.DecodeConfig(a)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode JPEG")
+	}
+	if err := checkSize(cfg.Width, cfg.Height, 0, -1); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode JPEG")
+	}
+	if _, err := a.Seek(start, io.SeekStart); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode JPEG")
+	}
+
+	src, err := jpeg.Decode(a)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode JPEG")
+	}
+
+	r := src.Bounds()
+	b := NewBuffer[color.SRGB[uint8]](r.Dx(), r.Dy())
+
+	switch src := src.(type) {
+	case *stdimage.YCbCr:
+		for y := 0; y < b.Height; y++ {
+			for x := 0; x < b.Width; x++ {
+				yi := src.YOffset(r.Min.X+x, r.Min.Y+y)
+				ci := src.COffset(r.Min.X+x, r.Min.Y+y)
+				cr, cg, cb := stdcolor.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+				b.Pix[(y*b.Width)+x] = color.SRGB[uint8]{R: cr, G: cg, B: cb, A: 255}
+			}
+		}
+
+	case *stdimage.Gray:
+		for y := 0; y < b.Height; y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < b.Width; x++ {
+				v := row[x]
+				b.Pix[(y*b.Width)+x] = color.SRGB[uint8]{R: v, G: v, B: v, A: 255}
+			}
+		}
+
+	case *stdimage.CMYK:
+		for y := 0; y < b.Height; y++ {
+			row := src.Pix[y*src.Stride:]
+			for x := 0; x < b.Width; x++ {
+				p := row[x*4:]
+				cr, cg, cb := stdcolor.CMYKToRGB(p[0], p[1], p[2], p[3])
+				b.Pix[(y*b.Width)+x] = color.SRGB[uint8]{R: cr, G: cg, B: cb, A: 255}
+			}
+		}
+
+	default:
+		for y := 0; y < b.Height; y++ {
+			for x := 0; x < b.Width; x++ {
+				c := stdcolor.NRGBAModel.Convert(src.At(r.Min.X+x, r.Min.Y+y)).(stdcolor.NRGBA)
+				b.Pix[(y*b.Width)+x] = color.SRGB[uint8]{R: c.R, G: c.G, B: c.B, A: c.A}
+			}
+		}
+	}
+
+	return New(Spec{}, b), nil
+}
@@ -0,0 +1,413 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+// maxDeflateRatio is the most deflate can compress data
+const maxDeflateRatio = 1032
+
+const (
+	pngColorGray      = 0
+	pngColorRGB       = 2
+	pngColorPalette   = 3
+	pngColorGrayAlpha = 4
+	pngColorRGBA      = 6
+)
+
+type pngDecoder struct {
+	width     int
+	height    int
+	depth     int
+	colorType int
+	interlace bool
+	channels  int
+
+	palette [][4]uint16
+	trns    []byte
+	gamma   float64
+	srgb    bool
+	idat    bytes.Buffer
+}
+
+type (
+	pixel8 interface {
+		color.UNorm[uint8] | color.SRGB[uint8]
+	}
+	pixel16 interface {
+		color.UNorm[uint16] | color.SRGB[uint16]
+	}
+)
+
+func sink8[P pixel8](b *Buffer[P]) func(int, [4]uint16) {
+	return func(i int, c [4]uint16) {
+		b.Pix[i] = P(color.UNorm[uint8]{R: uint8(c[0]), G: uint8(c[1]), B: uint8(c[2]), A: uint8(c[3])})
+	}
+}
+
+func sink16[P pixel16](b *Buffer[P]) func(int, [4]uint16) {
+	return func(i int, c [4]uint16) {
+		b.Pix[i] = P(color.UNorm[uint16]{R: c[0], G: c[1], B: c[2], A: c[3]})
+	}
+}
+
+func decodePNG(a *asset.File) (*Image, error) {
+	d := pngDecoder{}
+
+	if _, err := a.Discard(8); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode PNG")
+	}
+
+	header := [8]byte{}
+	seenHeader := false
+
+chunks:
+	for {
+		if _, err := io.ReadFull(a, header[:]); err != nil {
+			return nil, debug.ErrorWrapf(debug.Errorf("Missing IEND chunk"), "Failed to decode PNG")
+		}
+
+		length := int(binary.BigEndian.Uint32(header[:4]))
+		chunk := string(header[4:])
+
+		if length > a.Len()-4 {
+			return nil, debug.ErrorWrapf(debug.Errorf("Chunk %q truncated", chunk), "Failed to decode PNG")
+		}
+
+		data := make([]byte, length+4)
+		if _, err := io.ReadFull(a, data); err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decode PNG")
+		}
+		data, crc := data[:length], binary.BigEndian.Uint32(data[length:])
+
+		h := crc32.NewIEEE()
+		h.Write(header[4:])
+		h.Write(data)
+		if h.Sum32() != crc {
+			return nil, debug.ErrorWrapf(debug.Errorf("Chunk %q CRC mismatch", chunk), "Failed to decode PNG")
+		}
+
+		if !seenHeader && chunk != "IHDR" {
+			return nil, debug.ErrorWrapf(debug.Errorf("First chunk is not IHDR"), "Failed to decode PNG")
+		}
+
+		switch chunk {
+		case "IHDR":
+			if err := d.parseHeader(data); err != nil {
+				return nil, debug.ErrorWrapf(err, "Failed to decode PNG")
+			}
+			seenHeader = true
+
+		case "PLTE":
+			if len(data)%3 != 0 || len(data) > 256*3 {
+				return nil, debug.ErrorWrapf(debug.Errorf("Invalid PLTE size %d", len(data)), "Failed to decode PNG")
+			}
+			d.palette = make([][4]uint16, len(data)/3)
+			for i := range d.palette {
+				d.palette[i] = [4]uint16{uint16(data[i*3]), uint16(data[(i*3)+1]), uint16(data[(i*3)+2]), 255}
+			}
+
+		case "tRNS":
+			d.trns = data
+
+		case "gAMA":
+			if len(data) == 4 {
+				d.gamma = float64(binary.BigEndian.Uint32(data)) / 100000
+			}
+
+		case "sRGB", "iCCP":
+			// there is no color management here, ICC profiles are assumed to
+			// be some form of sRGB as they practically always are
+			d.srgb = true
+
+		case "IDAT":
+			d.idat.Write(data)
+
+		case "IEND":
+			break chunks
+
+		default:
+			// bit 5 of the first byte is the ancillary bit
+			if chunk[0]&0x20 == 0 {
+				return nil, debug.ErrorWrapf(debug.Errorf("Unknown critical chunk %q", chunk), "Failed to decode PNG")
+			}
+		}
+	}
+
+	if d.colorType == pngColorPalette && len(d.palette) == 0 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Missing PLTE chunk"), "Failed to decode PNG")
+	}
+
+	if d.colorType == pngColorPalette {
+		for i := 0; i < len(d.trns) && i < len(d.palette); i++ {
+			d.palette[i][3] = uint16(d.trns[i])
+		}
+	}
+
+	spec := Spec{
+		Gamma: d.gamma,
+		Alpha: d.colorType == pngColorGrayAlpha || d.colorType == pngColorRGBA || len(d.trns) > 0,
+	}
+
+	// without sRGB/iCCP the gamma decides, only an explicit gamma of 1.0
+	// is treated as linear, everything else is close enough to sRGB
+	linear := !d.srgb && d.gamma != 0 && math.Abs(d.gamma-1) < 0.01
+
+	// the inflated samples can only be so much larger than IDAT
+	if err := checkSize(d.width, d.height, d.channels*d.depth, int64(d.idat.Len())*maxDeflateRatio); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode PNG")
+	}
+
+	var img *Image
+	var put func(int, [4]uint16)
+
+	switch {
+	case d.depth == 16 && linear:
+		b := NewBuffer[color.UNorm[uint16]](d.width, d.height)
+		img, put = New(spec, b), sink16(b)
+	case d.depth == 16:
+		b := NewBuffer[color.SRGB[uint16]](d.width, d.height)
+		img, put = New(spec, b), sink16(b)
+	case linear:
+		b := NewBuffer[color.UNorm[uint8]](d.width, d.height)
+		img, put = New(spec, b), sink8(b)
+	default:
+		b := NewBuffer[color.SRGB[uint8]](d.width, d.height)
+		img, put = New(spec, b), sink8(b)
+	}
+
+	if err := d.decodeData(put); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode PNG")
+	}
+
+	return img, nil
+}
+
+func (d *pngDecoder) parseHeader(data []byte) error {
+	if len(data) != 13 {
+		return debug.Errorf("Invalid IHDR size %d", len(data))
+	}
+
+	d.width = int(binary.BigEndian.Uint32(data[0:]))
+	d.height = int(binary.BigEndian.Uint32(data[4:]))
+	d.depth = int(data[8])
+	d.colorType = int(data[9])
+	d.interlace = data[12] == 1
+
+	if err := checkSize(d.width, d.height, 0, -1); err != nil {
+		return err
+	}
+	if data[10] != 0 || data[11] != 0 || data[12] > 1 {
+		return debug.Errorf("Invalid compression, filter or interlace method")
+	}
+
+	validDepth := false
+	switch d.colorType {
+	case pngColorGray:
+		d.channels = 1
+		validDepth = d.depth == 1 || d.depth == 2 || d.depth == 4 || d.depth == 8 || d.depth == 16
+	case pngColorRGB:
+		d.channels = 3
+		validDepth = d.depth == 8 || d.depth == 16
+	case pngColorPalette:
+		d.channels = 1
+		validDepth = d.depth == 1 || d.depth == 2 || d.depth == 4 || d.depth == 8
+	case pngColorGrayAlpha:
+		d.channels = 2
+		validDepth = d.depth == 8 || d.depth == 16
+	case pngColorRGBA:
+		d.channels = 4
+		validDepth = d.depth == 8 || d.depth == 16
+	default:
+		return debug.Errorf("Invalid color type %d", d.colorType)
+	}
+
+	if !validDepth {
+		return debug.Errorf("Invalid bit depth %d for color type %d", d.depth, d.colorType)
+	}
+
+	return nil
+}
+
+func (d *pngDecoder) decodeData(put func(int, [4]uint16)) error {
+	z, err := zlib.NewReader(&d.idat)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+
+	type pass struct{ x, y, dx, dy int }
+	passes := []pass{{0, 0, 1, 1}}
+	if d.interlace {
+		passes = []pass{
+			{0, 0, 8, 8}, {4, 0, 8, 8}, {0, 4, 4, 8}, {2, 0, 4, 4},
+			{0, 2, 2, 4}, {1, 0, 2, 2}, {0, 1, 1, 2},
+		}
+	}
+
+	bpp := max(1, (d.channels*d.depth)/8)
+
+	for _, p := range passes {
+		w := (d.width - p.x + p.dx - 1) / p.dx
+		h := (d.height - p.y + p.dy - 1) / p.dy
+		if w <= 0 || h <= 0 {
+			continue
+		}
+
+		stride := ((w * d.channels * d.depth) + 7) / 8
+		// index 0 of both holds the filter type
+		prev := make([]byte, stride+1)
+		row := make([]byte, stride+1)
+
+		for y := 0; y < h; y++ {
+			if _, err := io.ReadFull(z, row); err != nil {
+				return debug.ErrorWrapf(err, "Failed to decompress image data")
+			}
+
+			cur, up := row[1:], prev[1:]
+			switch row[0] {
+			case 0:
+			case 1:
+				for i := bpp; i < stride; i++ {
+					cur[i] += cur[i-bpp]
+				}
+			case 2:
+				for i := range cur {
+					cur[i] += up[i]
+				}
+			case 3:
+				for i := range bpp {
+					cur[i] += up[i] / 2
+				}
+				for i := bpp; i < stride; i++ {
+					cur[i] += uint8((int(cur[i-bpp]) + int(up[i])) / 2)
+				}
+			case 4:
+				for i := range bpp {
+					cur[i] += up[i]
+				}
+				for i := bpp; i < stride; i++ {
+					cur[i] += paeth(cur[i-bpp], up[i], up[i-bpp])
+				}
+			default:
+				return debug.Errorf("Invalid filter type %d", row[0])
+			}
+
+			for x := 0; x < w; x++ {
+				c, err := d.pixel(cur, x)
+				if err != nil {
+					return err
+				}
+				put(((p.y+(y*p.dy))*d.width)+p.x+(x*p.dx), c)
+			}
+
+			prev, row = row, prev
+		}
+	}
+
+	return nil
+}
+
+func paeth(a, b, c uint8) uint8 {
+	p := int(a) + int(b) - int(c)
+	pa := abs(p - int(a))
+	pb := abs(p - int(b))
+	pc := abs(p - int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+/*
+pixel returns the x'th pixel of the unfiltered row with components scaled to
+8 bits if the depth is <= 8 or 16 bits otherwise.
+*/
+func (d *pngDecoder) pixel(row []byte, x int) ([4]uint16, error) {
+	sample := func(i int) uint16 {
+		switch d.depth {
+		case 16:
+			return binary.BigEndian.Uint16(row[((x*d.channels)+i)*2:])
+		case 8:
+			return uint16(row[(x*d.channels)+i])
+		default:
+			bit := x * d.depth
+			return uint16(row[bit/8]>>(8-d.depth-(bit%8))) & ((1 << d.depth) - 1)
+		}
+	}
+	maxValue := uint16(255)
+	if d.depth == 16 {
+		maxValue = 65535
+	}
+
+	switch d.colorType {
+	case pngColorGray:
+		v := sample(0)
+		a := maxValue
+		if len(d.trns) >= 2 && binary.BigEndian.Uint16(d.trns) == v {
+			a = 0
+		}
+		if d.depth < 8 {
+			v *= 255 / ((1 << d.depth) - 1)
+		}
+		return [4]uint16{v, v, v, a}, nil
+
+	case pngColorRGB:
+		r, g, b := sample(0), sample(1), sample(2)
+		a := maxValue
+		if len(d.trns) >= 6 && binary.BigEndian.Uint16(d.trns) == r &&
+			binary.BigEndian.Uint16(d.trns[2:]) == g && binary.BigEndian.Uint16(d.trns[4:]) == b {
+			a = 0
+		}
+		return [4]uint16{r, g, b, a}, nil
+
+	case pngColorPalette:
+		i := int(sample(0))
+		if i >= len(d.palette) {
+			return [4]uint16{}, debug.Errorf("Palette index %d out of range", i)
+		}
+		return d.palette[i], nil
+
+	case pngColorGrayAlpha:
+		v := sample(0)
+		return [4]uint16{v, v, v, sample(1)}, nil
+
+	default:
+		return [4]uint16{sample(0), sample(1), sample(2), sample(3)}, nil
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"math"
+
+	"goarrg.com/gmath/color"
+)
+
+/*
+Filter is the reconstruction filter used when resampling. All filtering is done
+on linear premultiplied values regardless of how the image is stored so sRGB
+images don't darken and transparent pixels don't bleed their color.
+*/
+type Filter int
+
+const (
+	FilterBox Filter = iota
+	FilterLanczos3
+)
+
+func (f Filter) String() string {
+	switch f {
+	case FilterBox:
+		return "Box"
+	case FilterLanczos3:
+		return "Lanczos3"
+	default:
+		return fmt.Sprintf("Filter(%d)", int(f))
+	}
+}
+
+func (f Filter) support() float64 {
+	switch f {
+	case FilterLanczos3:
+		return 3
+	default:
+		return 0.5
+	}
+}
+
+func (f Filter) eval(x float64) float64 {
+	switch f {
+	case FilterLanczos3:
+		if x == 0 {
+			return 1
+		}
+		if x <= -3 || x >= 3 {
+			return 0
+		}
+		px := math.Pi * x
+		return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+	default:
+		if x >= -0.5 && x < 0.5 {
+			return 1
+		}
+		return 0
+	}
+}
+
+/*
+Resize returns img resampled to width x height, the result is stored in the
+same pixel format as img.
+*/
+func Resize(img *Image, width, height int, filter Filter) *Image {
+	return fromLinear(img.spec, resample(toLinear(img), width, height, filter))
+}
+
+/*
+GenerateMips returns the full mip chain of img down to 1x1 with level 0 being
+img itself. Each level is filtered from the previous one without requantizing
+in between.
+*/
+func GenerateMips(img *Image, filter Filter) []*Image {
+	levels := []*Image{img}
+	buf := toLinear(img)
+
+	for buf.Width > 1 || buf.Height > 1 {
+		buf = resample(buf, max(1, buf.Width/2), max(1, buf.Height/2), filter)
+		levels = append(levels, fromLinear(img.spec, buf))
+	}
+
+	return levels
+}
+
+/*
+Premultiply returns img with its color components multiplied by alpha, or img
+itself if it is already premultiplied.
+*/
+func Premultiply(img *Image) *Image {
+	if img.spec.Premultiplied {
+		return img
+	}
+	spec := img.spec
+	spec.Premultiplied = true
+	return fromLinear(spec, toLinear(img))
+}
+
+/*
+toLinear returns a copy of img as linear premultiplied floats.
+*/
+func toLinear(img *Image) *Buffer[color.UNorm[float32]] {
+	in := Convert[color.UNorm[float32]](img)
+	out := &Buffer[color.UNorm[float32]]{
+		Width:  in.Width,
+		Height: in.Height,
+		Pix:    make([]color.UNorm[float32], len(in.Pix)),
+	}
+	copy(out.Pix, in.Pix)
+
+	if !img.spec.Premultiplied {
+		for i, p := range out.Pix {
+			out.Pix[i] = color.UNorm[float32]{R: p.R * p.A, G: p.G * p.A, B: p.B * p.A, A: p.A}
+		}
+	}
+
+	return out
+}
+
+/*
+fromLinear stores buf, which is linear premultiplied, in the pixel format and
+alpha mode described by spec. buf is not modified but may be referenced by the
+returned Image.
+*/
+func fromLinear(spec Spec, buf *Buffer[color.UNorm[float32]]) *Image {
+	if !spec.Premultiplied {
+		straight := NewBuffer[color.UNorm[float32]](buf.Width, buf.Height)
+		for i, p := range buf.Pix {
+			if p.A > 0 {
+				straight.Pix[i] = color.UNorm[float32]{R: min(1, p.R/p.A), G: min(1, p.G/p.A), B: min(1, p.B/p.A), A: p.A}
+			}
+		}
+		buf = straight
+	}
+
+	switch {
+	case spec.BitDepth == 8 && spec.ColorSpace == ColorSpaceSRGB:
+		return New(spec, convertNew[color.SRGB[uint8]](buf))
+	case spec.BitDepth == 8:
+		return New(spec, convertNew[color.UNorm[uint8]](buf))
+	case spec.BitDepth == 16 && spec.ColorSpace == ColorSpaceSRGB:
+		return New(spec, convertNew[color.SRGB[uint16]](buf))
+	case spec.BitDepth == 16:
+		return New(spec, convertNew[color.UNorm[uint16]](buf))
+	default:
+		return New(spec, buf)
+	}
+}
+
+func convertNew[P, Q Pixel](in *Buffer[Q]) *Buffer[P] {
+	out := NewBuffer[P](in.Width, in.Height)
+	convertBuffer(out, in)
+	return out
+}
+
+type contribution struct {
+	start   int
+	weights []float32
+}
+
+/*
+contributions returns for each output pixel the input pixels and their weights,
+when downsampling the filter is stretched to cover every input pixel.
+*/
+func contributions(in, out int, filter Filter) []contribution {
+	scale := float64(in) / float64(out)
+	stretch := max(1, scale)
+	support := filter.support() * stretch
+	c := make([]contribution, out)
+
+	for i := range c {
+		center := (float64(i) + 0.5) * scale
+		start := int(math.Floor(center - support))
+		end := int(math.Ceil(center + support))
+
+		weights := make([]float64, 0, end-start)
+		sum := 0.0
+		for j := start; j < end; j++ {
+			w := filter.eval((float64(j) + 0.5 - center) / stretch)
+			weights = append(weights, w)
+			sum += w
+		}
+
+		c[i] = contribution{start: start, weights: make([]float32, len(weights))}
+		for j, w := range weights {
+			c[i].weights[j] = float32(w / sum)
+		}
+	}
+
+	return c
+}
+
+func resample(in *Buffer[color.UNorm[float32]], width, height int, filter Filter) *Buffer[color.UNorm[float32]] {
+	apply := func(c contribution, size int, at func(int) color.UNorm[float32]) color.UNorm[float32] {
+		acc := color.UNorm[float32]{}
+		for k, w := range c.weights {
+			p := at(min(size-1, max(0, c.start+k)))
+			acc.R += p.R * w
+			acc.G += p.G * w
+			acc.B += p.B * w
+			acc.A += p.A * w
+		}
+		// lanczos rings, keep the result premultiplied and in range
+		acc.A = min(1, max(0, acc.A))
+		acc.R = min(acc.A, max(0, acc.R))
+		acc.G = min(acc.A, max(0, acc.G))
+		acc.B = min(acc.A, max(0, acc.B))
+		return acc
+	}
+
+	tmp := in
+	if width != in.Width {
+		tmp = NewBuffer[color.UNorm[float32]](width, in.Height)
+		cx := contributions(in.Width, width, filter)
+		for y := range in.Height {
+			row := in.Pix[y*in.Width : (y+1)*in.Width]
+			for x, c := range cx {
+				tmp.Pix[(y*width)+x] = apply(c, in.Width, func(i int) color.UNorm[float32] { return row[i] })
+			}
+		}
+	}
+
+	out := tmp
+	if height != tmp.Height {
+		out = NewBuffer[color.UNorm[float32]](width, height)
+		cy := contributions(tmp.Height, height, filter)
+		for y, c := range cy {
+			for x := range width {
+				out.Pix[(y*width)+x] = apply(c, tmp.Height, func(i int) color.UNorm[float32] { return tmp.Pix[(i*width)+x] })
+			}
+		}
+	}
+
+	if out == in {
+		out = &Buffer[color.UNorm[float32]]{Width: in.Width, Height: in.Height, Pix: append([]color.UNorm[float32](nil), in.Pix...)}
+	}
+
+	return out
+}
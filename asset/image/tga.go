@@ -0,0 +1,276 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+const (
+	tgaTypeColorMapped    = 1
+	tgaTypeTrueColor      = 2
+	tgaTypeGray           = 3
+	tgaTypeRLEColorMapped = 9
+	tgaTypeRLETrueColor   = 10
+	tgaTypeRLEGray        = 11
+
+	tgaFooterSize    = 26
+	tgaFooterMagic   = "TRUEVISION-XFILE.\x00"
+	tgaExtensionSize = 495
+)
+
+type tgaHeader struct {
+	idLength     uint8
+	colorMapType uint8
+	imageType    uint8
+	cmFirst      uint16
+	cmLength     uint16
+	cmEntrySize  uint8
+	width        uint16
+	height       uint16
+	depth        uint8
+	descriptor   uint8
+}
+
+func (h *tgaHeader) validate() error {
+	if h.colorMapType > 1 {
+		return debug.Errorf("Invalid color map type %d", h.colorMapType)
+	}
+	if h.width == 0 || h.height == 0 {
+		return debug.Errorf("Invalid dimensions %dx%d", h.width, h.height)
+	}
+	// the color map is read for every image type that has one
+	if h.colorMapType == 1 && h.cmEntrySize != 15 && h.cmEntrySize != 16 && h.cmEntrySize != 24 && h.cmEntrySize != 32 {
+		return debug.Errorf("Invalid color map entry size %d", h.cmEntrySize)
+	}
+
+	switch h.imageType {
+	case tgaTypeColorMapped, tgaTypeRLEColorMapped:
+		if h.colorMapType != 1 || h.cmLength == 0 {
+			return debug.Errorf("Missing color map")
+		}
+		if h.depth != 8 && h.depth != 16 {
+			return debug.Errorf("Invalid bit depth %d for color mapped image", h.depth)
+		}
+	case tgaTypeTrueColor, tgaTypeRLETrueColor:
+		if h.depth != 15 && h.depth != 16 && h.depth != 24 && h.depth != 32 {
+			return debug.Errorf("Invalid bit depth %d for true color image", h.depth)
+		}
+	case tgaTypeGray, tgaTypeRLEGray:
+		if h.depth != 8 && h.depth != 16 {
+			return debug.Errorf("Invalid bit depth %d for grayscale image", h.depth)
+		}
+	default:
+		return debug.Errorf("Invalid image type %d", h.imageType)
+	}
+
+	if h.descriptor&0xC0 != 0 {
+		return debug.Errorf("Invalid image descriptor %#x", h.descriptor)
+	}
+
+	return nil
+}
+
+func decodeTGA(a *asset.File) (*Image, error) {
+	raw := [18]byte{}
+	if _, err := io.ReadFull(a, raw[:]); err != nil {
+		return nil, debug.ErrorWrapf(debug.Errorf("File truncated"), "Failed to decode TGA")
+	}
+
+	h := tgaHeader{
+		idLength:     raw[0],
+		colorMapType: raw[1],
+		imageType:    raw[2],
+		cmFirst:      binary.LittleEndian.Uint16(raw[3:]),
+		cmLength:     binary.LittleEndian.Uint16(raw[5:]),
+		cmEntrySize:  raw[7],
+		width:        binary.LittleEndian.Uint16(raw[12:]),
+		height:       binary.LittleEndian.Uint16(raw[14:]),
+		depth:        raw[16],
+		descriptor:   raw[17],
+	}
+
+	if err := h.validate(); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode TGA")
+	}
+
+	spec := Spec{}
+	alphaBits := h.descriptor & 0xF
+	ignoreAlpha := false
+
+	// TGA 2.0 files have a footer pointing at an extension area that holds
+	// the gamma and whether the alpha channel is actually alpha
+	if a.Size() >= 18+tgaFooterSize {
+		footer := make([]byte, tgaFooterSize)
+		if _, err := a.ReadAt(footer, int64(a.Size()-tgaFooterSize)); err == nil && bytes.Equal(footer[8:], []byte(tgaFooterMagic)) {
+			if offset := int64(binary.LittleEndian.Uint32(footer)); offset > 0 {
+				ext := make([]byte, tgaExtensionSize)
+				if _, err := a.ReadAt(ext, offset); err == nil && binary.LittleEndian.Uint16(ext) >= tgaExtensionSize {
+					num, den := binary.LittleEndian.Uint16(ext[478:]), binary.LittleEndian.Uint16(ext[480:])
+					if num != 0 && den != 0 {
+						// the file stores the display gamma, e.g. 2.2
+						spec.Gamma = float64(den) / float64(num)
+					}
+					ignoreAlpha = ext[494] < 3
+				}
+			}
+		}
+	}
+
+	if _, err := a.Discard(int64(h.idLength)); err != nil {
+		return nil, debug.ErrorWrapf(debug.Errorf("File truncated"), "Failed to decode TGA")
+	}
+
+	var colorMap []color.SRGB[uint8]
+	if h.colorMapType == 1 {
+		entrySize := (int(h.cmEntrySize) + 7) / 8
+		data := make([]byte, int(h.cmLength)*entrySize)
+		if _, err := io.ReadFull(a, data); err != nil {
+			return nil, debug.ErrorWrapf(debug.Errorf("Color map truncated"), "Failed to decode TGA")
+		}
+		colorMap = make([]color.SRGB[uint8], h.cmLength)
+		for i := range colorMap {
+			colorMap[i] = tgaColor(data[i*entrySize:], h.cmEntrySize, alphaBits > 0 && !ignoreAlpha)
+		}
+	}
+
+	// 16 bit grayscale is gray + alpha
+	grayAlpha := (h.imageType == tgaTypeGray || h.imageType == tgaTypeRLEGray) && h.depth == 16
+	hasAlpha := !ignoreAlpha && (alphaBits > 0 || grayAlpha)
+	spec.Alpha = hasAlpha
+
+	pixelSize := (int(h.depth) + 7) / 8
+	width, height := int(h.width), int(h.height)
+	// an RLE packet is a byte and a pixel for up to 128 pixels, so the data
+	// covers at most 128 times as many packets as it has room for
+	bits, available := pixelSize*8, int64(a.Len())
+	if h.imageType >= tgaTypeRLEColorMapped {
+		bits, available = (pixelSize+1)*8, available*128
+	}
+	if err := checkSize(width, height, bits, available); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode TGA")
+	}
+	b := NewBuffer[color.SRGB[uint8]](width, height)
+
+	decode := func(p []byte) (color.SRGB[uint8], error) {
+		switch h.imageType {
+		case tgaTypeColorMapped, tgaTypeRLEColorMapped:
+			i := int(p[0])
+			if pixelSize == 2 {
+				i = int(binary.LittleEndian.Uint16(p))
+			}
+			i -= int(h.cmFirst)
+			if i < 0 || i >= len(colorMap) {
+				return color.SRGB[uint8]{}, debug.Errorf("Color map index %d out of range", i+int(h.cmFirst))
+			}
+			return colorMap[i], nil
+		case tgaTypeGray, tgaTypeRLEGray:
+			alpha := uint8(255)
+			if grayAlpha && hasAlpha {
+				alpha = p[1]
+			}
+			return color.SRGB[uint8]{R: p[0], G: p[0], B: p[0], A: alpha}, nil
+		default:
+			return tgaColor(p, h.depth, hasAlpha), nil
+		}
+	}
+
+	rightToLeft := h.descriptor&0x10 != 0
+	topToBottom := h.descriptor&0x20 != 0
+	put := func(i int, c color.SRGB[uint8]) {
+		x, y := i%width, i/width
+		if rightToLeft {
+			x = width - 1 - x
+		}
+		if !topToBottom {
+			y = height - 1 - y
+		}
+		b.Set(x, y, c)
+	}
+
+	p := make([]byte, pixelSize)
+	total := width * height
+
+	for i := 0; i < total; {
+		count, repeat := 1, false
+
+		if h.imageType >= tgaTypeRLEColorMapped {
+			packet, err := a.ReadByte()
+			if err != nil {
+				return nil, debug.ErrorWrapf(debug.Errorf("Pixel data truncated"), "Failed to decode TGA")
+			}
+			count, repeat = int(packet&0x7F)+1, packet&0x80 != 0
+		}
+
+		var c color.SRGB[uint8]
+		for j := 0; j < count && i < total; j++ {
+			if j == 0 || !repeat {
+				if _, err := io.ReadFull(a, p); err != nil {
+					return nil, debug.ErrorWrapf(debug.Errorf("Pixel data truncated"), "Failed to decode TGA")
+				}
+				var err error
+				if c, err = decode(p); err != nil {
+					return nil, debug.ErrorWrapf(err, "Failed to decode TGA")
+				}
+			}
+			put(i, c)
+			i++
+		}
+	}
+
+	if spec.Gamma != 0 && math.Abs(spec.Gamma-1) < 0.01 {
+		linear := NewBuffer[color.UNorm[uint8]](width, height)
+		for i, p := range b.Pix {
+			linear.Pix[i] = color.UNorm[uint8](p)
+		}
+		return New(spec, linear), nil
+	}
+
+	return New(spec, b), nil
+}
+
+func tgaColor(p []byte, depth uint8, alpha bool) color.SRGB[uint8] {
+	switch depth {
+	case 15, 16:
+		v := binary.LittleEndian.Uint16(p)
+		c := color.SRGB[uint8]{
+			R: uint8((uint32((v>>10)&0x1F) * 255) / 31),
+			G: uint8((uint32((v>>5)&0x1F) * 255) / 31),
+			B: uint8((uint32(v&0x1F) * 255) / 31),
+			A: 255,
+		}
+		if alpha && depth == 16 && v&0x8000 == 0 {
+			c.A = 0
+		}
+		return c
+	case 24:
+		return color.SRGB[uint8]{R: p[2], G: p[1], B: p[0], A: 255}
+	default:
+		c := color.SRGB[uint8]{R: p[2], G: p[1], B: p[0], A: 255}
+		if alpha {
+			c.A = p[3]
+		}
+		return c
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asset
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"goarrg.com/debug"
+)
+
+/*
+Manifest lists the size and SHA-256 of every file of an install, keyed by slash
+separated paths relative to the install directory.
+*/
+type Manifest struct {
+	Files map[string]ManifestEntry
+}
+
+type ManifestEntry struct {
+	Size   int64
+	SHA256 string
+}
+
+type VerifyMode int
+
+const (
+	// VerifySize only compares sizes, which catches truncated files without reading them.
+	VerifySize VerifyMode = iota
+	// VerifyHash compares sizes and SHA-256 hashes.
+	VerifyHash
+)
+
+type VerifyReason int
+
+const (
+	VerifyMissing VerifyReason = iota
+	VerifyUnlisted
+	VerifySizeMismatch
+	VerifyHashMismatch
+)
+
+func (r VerifyReason) String() string {
+	switch r {
+	case VerifyMissing:
+		return "missing"
+	case VerifyUnlisted:
+		return "not in manifest"
+	case VerifySizeMismatch:
+		return "size mismatch"
+	case VerifyHashMismatch:
+		return "SHA-256 mismatch"
+	}
+	return "unknown"
+}
+
+/*
+VerifyError is returned, possibly joined with others, for every file that
+doesn't match the manifest. Use errors.As to retrieve it.
+*/
+type VerifyError struct {
+	Name   string
+	Reason VerifyReason
+	Want   ManifestEntry
+	Got    ManifestEntry
+}
+
+func (e *VerifyError) Error() string {
+	switch e.Reason {
+	case VerifySizeMismatch:
+		return fmt.Sprintf("%q: %s, Got: %d Want: %d", e.Name, e.Reason, e.Got.Size, e.Want.Size)
+	case VerifyHashMismatch:
+		return fmt.Sprintf("%q: %s, Got: %q Want: %q", e.Name, e.Reason, e.Got.SHA256, e.Want.SHA256)
+	}
+	return fmt.Sprintf("%q: %s", e.Name, e.Reason)
+}
+
+/*
+GenerateManifest hashes every file under dir, ignore is passed paths relative
+to dir as with toolchain.ScanDirModTime and should at least skip the manifest
+itself.
+*/
+func GenerateManifest(dir string, ignore func(string) bool) (*Manifest, error) {
+	m := &Manifest{Files: map[string]ManifestEntry{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if ignore != nil && ignore(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.ContainsAny(rel, "\r\n") {
+			return debug.Errorf("Unsupported file name %q", rel)
+		}
+		e, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		m.Files[filepath.ToSlash(rel)] = e
+		return nil
+	})
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to generate manifest for %q", dir)
+	}
+	return m, nil
+}
+
+/*
+Encode writes the manifest as one "sha256 size path" line per file, sorted by
+path.
+*/
+func (m *Manifest) Encode(w io.Writer) error {
+	names := make([]string, 0, len(m.Files))
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		e := m.Files[name]
+		if _, err := fmt.Fprintf(bw, "%s %d %s\n", e.SHA256, e.Size, name); err != nil {
+			return debug.ErrorWrapf(err, "Failed to encode manifest")
+		}
+	}
+	return debug.ErrorWrapf(bw.Flush(), "Failed to encode manifest")
+}
+
+func DecodeManifest(r io.Reader) (*Manifest, error) {
+	m := &Manifest{Files: map[string]ManifestEntry{}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if scanner.Text() == "" {
+			continue
+		}
+		// the path is last so that it may contain spaces
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) != 3 || len(fields[0]) != sha256.Size*2 {
+			return nil, debug.Errorf("Failed to decode manifest: invalid line %d", line)
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decode manifest: invalid line %d", line)
+		}
+		if !fs.ValidPath(fields[2]) {
+			return nil, debug.Errorf("Failed to decode manifest: invalid path %q", fields[2])
+		}
+		m.Files[fields[2]] = ManifestEntry{Size: size, SHA256: strings.ToLower(fields[0])}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to decode manifest")
+	}
+	return m, nil
+}
+
+func LoadManifest(name string) (*Manifest, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load manifest")
+	}
+	defer f.Close()
+	m, err := DecodeManifest(f)
+	return m, debug.ErrorWrapf(err, "Failed to load manifest %q", name)
+}
+
+/*
+Verify checks every file in the manifest against dir and returns the mismatches
+joined together, files in dir that are not in the manifest are not reported.
+*/
+func (m *Manifest) Verify(dir string, mode VerifyMode) error {
+	errs := []error{}
+	for name, want := range m.Files {
+		if err := verifyFile(filepath.Join(dir, filepath.FromSlash(name)), name, want, mode, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+/*
+verifyFile checks the file at path against want, data if not nil is the already
+mapped content of the file and avoids reading it again.
+*/
+func verifyFile(path, name string, want ManifestEntry, mode VerifyMode, data []byte) error {
+	var got ManifestEntry
+	if data != nil {
+		got.Size = int64(len(data))
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return &VerifyError{Name: name, Reason: VerifyMissing, Want: want}
+			}
+			return err
+		}
+		got.Size = info.Size()
+	}
+	if got.Size != want.Size {
+		return &VerifyError{Name: name, Reason: VerifySizeMismatch, Want: want, Got: got}
+	}
+	if mode == VerifySize {
+		return nil
+	}
+
+	if data != nil {
+		sum := sha256.Sum256(data)
+		got.SHA256 = hex.EncodeToString(sum[:])
+	} else {
+		e, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		got.SHA256 = e.SHA256
+	}
+	if got.SHA256 != want.SHA256 {
+		return &VerifyError{Name: name, Reason: VerifyHashMismatch, Want: want, Got: got}
+	}
+	return nil
+}
+
+func hashFile(path string) (ManifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+type activeManifest struct {
+	dir      string
+	manifest *Manifest
+	mode     VerifyMode
+	verified sync.Map
+}
+
+var loadManifest atomic.Pointer[activeManifest]
+
+/*
+SetManifest makes Load and LoadWindowed verify files under dir against m the
+first time each is loaded, files under dir that are not in m fail to load.
+Passing a nil manifest disables verification.
+*/
+func SetManifest(dir string, m *Manifest, mode VerifyMode) error {
+	if m == nil {
+		loadManifest.Store(nil)
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to set manifest")
+	}
+	loadManifest.Store(&activeManifest{dir: abs, manifest: m, mode: mode})
+	return nil
+}
+
+/*
+verifyLoad is called by load with the mapped content of the file or by
+LoadWindowed with nil.
+*/
+func verifyLoad(path string, data []byte) error {
+	active := loadManifest.Load()
+	if active == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(active.dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// outside of the install, e.g. user data
+		return nil
+	}
+	name := filepath.ToSlash(rel)
+	if _, ok := active.verified.Load(name); ok {
+		return nil
+	}
+
+	want, ok := active.manifest.Files[name]
+	if !ok {
+		return &VerifyError{Name: name, Reason: VerifyUnlisted}
+	}
+	if err := verifyFile(abs, name, want, active.mode, data); err != nil {
+		return err
+	}
+	active.verified.Store(name, struct{}{})
+	return nil
+}
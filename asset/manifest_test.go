@@ -0,0 +1,174 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asset
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"goarrg.com/internal/testutil"
+)
+
+func writeManifestTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"a.txt":          "hello",
+		"sub/b file.bin": "world!",
+		"manifest.txt":   "ignored",
+	} {
+		testutil.WriteFile(t, dir, name, []byte(data))
+	}
+	return dir
+}
+
+func verifyReasons(err error) map[string]VerifyReason {
+	reasons := map[string]VerifyReason{}
+	if err == nil {
+		return reasons
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var v *VerifyError
+		if errors.As(err, &v) {
+			reasons[v.Name] = v.Reason
+		} else {
+			reasons[err.Error()] = -1
+		}
+	}
+	return reasons
+}
+
+func TestManifest(t *testing.T) {
+	dir := writeManifestTree(t)
+	m, err := GenerateManifest(dir, func(p string) bool { return p == "manifest.txt" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ManifestEntry{
+		"a.txt":          {Size: 5, SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		"sub/b file.bin": {Size: 6, SHA256: "711e9609339e92b03ddc0a211827dba421f38f9ed8b9d806e1ffdd8c15ffa03d"},
+	}
+	if !reflect.DeepEqual(m.Files, want) {
+		t.Fatalf("got %v want %v", m.Files, want)
+	}
+
+	buf := bytes.Buffer{}
+	if err := m.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, m) {
+		t.Fatalf("round trip got %v want %v", decoded, m)
+	}
+	if _, err := DecodeManifest(bytes.NewBufferString("abc 1 a\n")); err == nil {
+		t.Error("decoded invalid manifest")
+	}
+
+	if err := m.Verify(dir, VerifyHash); err != nil {
+		t.Fatal(err)
+	}
+
+	// same size, different content is only caught by hashing
+	testutil.WriteFile(t, dir, "a.txt", []byte("HELLO"))
+	if err := os.Remove(filepath.Join(dir, "sub", "b file.bin")); err != nil {
+		t.Fatal(err)
+	}
+	if got := verifyReasons(m.Verify(dir, VerifySize)); !reflect.DeepEqual(got, map[string]VerifyReason{"sub/b file.bin": VerifyMissing}) {
+		t.Errorf("VerifySize: %v", got)
+	}
+	if got := verifyReasons(m.Verify(dir, VerifyHash)); !reflect.DeepEqual(got, map[string]VerifyReason{"a.txt": VerifyHashMismatch, "sub/b file.bin": VerifyMissing}) {
+		t.Errorf("VerifyHash: %v", got)
+	}
+	testutil.WriteFile(t, dir, "a.txt", []byte("hi"))
+	if got := verifyReasons(m.Verify(dir, VerifySize)); got["a.txt"] != VerifySizeMismatch {
+		t.Errorf("VerifySize: %v", got)
+	}
+}
+
+func TestManifestLoad(t *testing.T) {
+	dir := writeManifestTree(t)
+	m, err := GenerateManifest(dir, func(p string) bool { return p == "manifest.txt" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetManifest(dir, m, VerifyHash); err != nil {
+		t.Fatal(err)
+	}
+	defer SetManifest("", nil, VerifySize)
+
+	// loads racing each other all verify the file, one mapping is cached
+	files := make([]*File, 8)
+	errs := make([]error, len(files))
+	wg := sync.WaitGroup{}
+	for i := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			files[i], errs[i] = Load(filepath.Join(dir, "a.txt"))
+		}()
+	}
+	wg.Wait()
+	for i, f := range files {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if f.mmap.refs != files[0].mmap.refs {
+			t.Error("Loads were not shared")
+		}
+	}
+	for _, f := range files {
+		f.Close()
+	}
+
+	_, err = Load(filepath.Join(dir, "manifest.txt"))
+	if got := verifyReasons(err); got["manifest.txt"] != VerifyUnlisted {
+		t.Errorf("unlisted: %v", got)
+	}
+
+	testutil.WriteFile(t, dir, "sub/b file.bin", []byte("WORLD!"))
+	_, err = DirFS(dir).Open("sub/b file.bin")
+	if got := verifyReasons(err); got["sub/b file.bin"] != VerifyHashMismatch {
+		t.Errorf("tampered: %v", got)
+	}
+	_, err = LoadWindowed(filepath.Join(dir, "sub", "b file.bin"), WindowConfig{})
+	if got := verifyReasons(err); got["sub/b file.bin"] != VerifyHashMismatch {
+		t.Errorf("tampered windowed: %v", got)
+	}
+
+	// files outside of the install are not verified
+	outside := testutil.WriteFile(t, t.TempDir(), "save", []byte("save"))
+	f, err := Load(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mesh
+
+import (
+	"encoding/binary"
+	"math"
+	"unsafe"
+
+	"goarrg.com/debug"
+)
+
+/*
+ComponentType is the OpenGL enum glTF uses for accessor components.
+*/
+type ComponentType int
+
+const (
+	ComponentTypeInt8    ComponentType = 5120
+	ComponentTypeUint8   ComponentType = 5121
+	ComponentTypeInt16   ComponentType = 5122
+	ComponentTypeUint16  ComponentType = 5123
+	ComponentTypeUint32  ComponentType = 5125
+	ComponentTypeFloat32 ComponentType = 5126
+)
+
+func (c ComponentType) Size() int {
+	switch c {
+	case ComponentTypeInt8, ComponentTypeUint8:
+		return 1
+	case ComponentTypeInt16, ComponentTypeUint16:
+		return 2
+	case ComponentTypeUint32, ComponentTypeFloat32:
+		return 4
+	default:
+		return 0
+	}
+}
+
+type Type int
+
+const (
+	TypeScalar Type = iota
+	TypeVec2
+	TypeVec3
+	TypeVec4
+	TypeMat2
+	TypeMat3
+	TypeMat4
+)
+
+/*
+Components returns the number of components of each element.
+*/
+func (t Type) Components() int {
+	return [...]int{1, 2, 3, 4, 4, 9, 16}[t]
+}
+
+func (t Type) columns() int {
+	return [...]int{1, 1, 1, 1, 2, 3, 4}[t]
+}
+
+/*
+Accessor is a typed view of a buffer. Data starts at the first element and
+Stride is the number of bytes between elements, which may be larger than
+ElementSize for interleaved data. Data aliases the mapped file when possible and
+is read only.
+*/
+type Accessor struct {
+	ComponentType ComponentType
+	Type          Type
+	Normalized    bool
+	Count         int
+	Stride        int
+	Data          []byte
+	Min           []float64
+	Max           []float64
+}
+
+/*
+columnStride is the size of a matrix column, glTF aligns columns to 4 bytes.
+*/
+func (a *Accessor) columnStride() int {
+	rows := a.Type.Components() / a.Type.columns()
+	size := rows * a.ComponentType.Size()
+	if a.Type >= TypeMat2 {
+		size = (size + 3) &^ 3
+	}
+	return size
+}
+
+/*
+ElementSize is the size in bytes of a single element including any matrix
+column padding.
+*/
+func (a *Accessor) ElementSize() int {
+	return a.columnStride() * a.Type.columns()
+}
+
+func (a *Accessor) element(i int) []byte {
+	return a.Data[i*a.Stride : (i*a.Stride)+a.ElementSize()]
+}
+
+/*
+View returns the accessor's elements as T, which must have the same size as an
+element, e.g. gmath.Vector3f[float32] for a float VEC3 accessor. T's layout is
+not otherwise checked. The returned slice aliases the accessor's data when it is
+tightly packed and suitably aligned and is then read only, otherwise it is a
+copy.
+*/
+func View[T any](a *Accessor) ([]T, error) {
+	size := int(unsafe.Sizeof(*new(T)))
+	if size != a.ElementSize() {
+		return nil, debug.Errorf("Size mismatch, %T is %d bytes but elements are %d bytes", *new(T), size, a.ElementSize())
+	}
+	if a.Count == 0 {
+		return nil, nil
+	}
+	if a.Stride == size && uintptr(unsafe.Pointer(&a.Data[0]))%unsafe.Alignof(*new(T)) == 0 {
+		return unsafe.Slice((*T)(unsafe.Pointer(&a.Data[0])), a.Count), nil
+	}
+
+	out := make([]T, a.Count)
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(&out[0])), a.Count*size)
+	for i := range a.Count {
+		copy(dst[i*size:], a.element(i))
+	}
+	return out, nil
+}
+
+/*
+Float32s returns every component of every element converted to float32,
+normalized integers are mapped to [0, 1] or [-1, 1].
+*/
+func (a *Accessor) Float32s() []float32 {
+	n := a.Type.Components()
+	rows := n / a.Type.columns()
+	size := a.ComponentType.Size()
+	out := make([]float32, 0, a.Count*n)
+
+	for i := range a.Count {
+		e := a.element(i)
+		for c := range n {
+			b := e[((c/rows)*a.columnStride())+((c%rows)*size):]
+			var v float32
+			switch a.ComponentType {
+			case ComponentTypeInt8:
+				v = float32(int8(b[0]))
+				if a.Normalized {
+					v = max(v/127, -1)
+				}
+			case ComponentTypeUint8:
+				v = float32(b[0])
+				if a.Normalized {
+					v /= 255
+				}
+			case ComponentTypeInt16:
+				v = float32(int16(binary.LittleEndian.Uint16(b)))
+				if a.Normalized {
+					v = max(v/32767, -1)
+				}
+			case ComponentTypeUint16:
+				v = float32(binary.LittleEndian.Uint16(b))
+				if a.Normalized {
+					v /= 65535
+				}
+			case ComponentTypeUint32:
+				v = float32(binary.LittleEndian.Uint32(b))
+			case ComponentTypeFloat32:
+				v = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			}
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+/*
+Uint32s returns every component of every element converted to uint32, it is
+meant for indices and joints and returns nil for float or signed accessors.
+*/
+func (a *Accessor) Uint32s() []uint32 {
+	n := a.Type.Components()
+	rows := n / a.Type.columns()
+	size := a.ComponentType.Size()
+	out := make([]uint32, 0, a.Count*n)
+
+	for i := range a.Count {
+		e := a.element(i)
+		for c := range n {
+			b := e[((c/rows)*a.columnStride())+((c%rows)*size):]
+			switch a.ComponentType {
+			case ComponentTypeUint8:
+				out = append(out, uint32(b[0]))
+			case ComponentTypeUint16:
+				out = append(out, uint32(binary.LittleEndian.Uint16(b)))
+			case ComponentTypeUint32:
+				out = append(out, binary.LittleEndian.Uint32(b))
+			default:
+				return nil
+			}
+		}
+	}
+
+	return out
+}
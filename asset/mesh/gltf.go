@@ -0,0 +1,898 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mesh
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io/fs"
+	"math"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+const (
+	glbMagic     = 0x46546C67
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+type gltfTextureInfo struct {
+	Index    *int     `json:"index"`
+	TexCoord int      `json:"texCoord"`
+	Scale    *float32 `json:"scale"`
+	Strength *float32 `json:"strength"`
+}
+
+type gltfAccessorRef struct {
+	BufferView    int           `json:"bufferView"`
+	ByteOffset    int           `json:"byteOffset"`
+	ComponentType ComponentType `json:"componentType"`
+}
+
+type gltfDocument struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Name  string `json:"name"`
+		Nodes []int  `json:"nodes"`
+	} `json:"scenes"`
+	Nodes []struct {
+		Name        string      `json:"name"`
+		Children    []int       `json:"children"`
+		Matrix      []float32   `json:"matrix"`
+		Translation *[3]float32 `json:"translation"`
+		Rotation    *[4]float32 `json:"rotation"`
+		Scale       *[3]float32 `json:"scale"`
+		Mesh        *int        `json:"mesh"`
+		Skin        *int        `json:"skin"`
+		Weights     []float32   `json:"weights"`
+	} `json:"nodes"`
+
+	Buffers []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+	Accessors []struct {
+		BufferView    *int          `json:"bufferView"`
+		ByteOffset    int           `json:"byteOffset"`
+		ComponentType ComponentType `json:"componentType"`
+		Normalized    bool          `json:"normalized"`
+		Count         int           `json:"count"`
+		Type          string        `json:"type"`
+		Min           []float64     `json:"min"`
+		Max           []float64     `json:"max"`
+		Sparse        *struct {
+			Count   int             `json:"count"`
+			Indices gltfAccessorRef `json:"indices"`
+			Values  gltfAccessorRef `json:"values"`
+		} `json:"sparse"`
+	} `json:"accessors"`
+
+	Meshes []struct {
+		Name       string `json:"name"`
+		Primitives []struct {
+			Attributes map[string]int   `json:"attributes"`
+			Indices    *int             `json:"indices"`
+			Material   *int             `json:"material"`
+			Mode       *Mode            `json:"mode"`
+			Targets    []map[string]int `json:"targets"`
+		} `json:"primitives"`
+		Weights []float32 `json:"weights"`
+	} `json:"meshes"`
+
+	Materials []struct {
+		Name                 string `json:"name"`
+		PBRMetallicRoughness struct {
+			BaseColorFactor          *[4]float32     `json:"baseColorFactor"`
+			BaseColorTexture         gltfTextureInfo `json:"baseColorTexture"`
+			MetallicFactor           *float32        `json:"metallicFactor"`
+			RoughnessFactor          *float32        `json:"roughnessFactor"`
+			MetallicRoughnessTexture gltfTextureInfo `json:"metallicRoughnessTexture"`
+		} `json:"pbrMetallicRoughness"`
+		NormalTexture    gltfTextureInfo `json:"normalTexture"`
+		OcclusionTexture gltfTextureInfo `json:"occlusionTexture"`
+		EmissiveTexture  gltfTextureInfo `json:"emissiveTexture"`
+		EmissiveFactor   [3]float32      `json:"emissiveFactor"`
+		AlphaMode        string          `json:"alphaMode"`
+		AlphaCutoff      *float32        `json:"alphaCutoff"`
+		DoubleSided      bool            `json:"doubleSided"`
+	} `json:"materials"`
+	Textures []struct {
+		Name    string `json:"name"`
+		Source  *int   `json:"source"`
+		Sampler *int   `json:"sampler"`
+	} `json:"textures"`
+	Samplers []struct {
+		MagFilter int  `json:"magFilter"`
+		MinFilter int  `json:"minFilter"`
+		WrapS     *int `json:"wrapS"`
+		WrapT     *int `json:"wrapT"`
+	} `json:"samplers"`
+	Images []struct {
+		Name       string `json:"name"`
+		URI        string `json:"uri"`
+		MimeType   string `json:"mimeType"`
+		BufferView *int   `json:"bufferView"`
+	} `json:"images"`
+
+	Skins []struct {
+		Name                string `json:"name"`
+		InverseBindMatrices *int   `json:"inverseBindMatrices"`
+		Skeleton            *int   `json:"skeleton"`
+		Joints              []int  `json:"joints"`
+	} `json:"skins"`
+	Animations []struct {
+		Name     string `json:"name"`
+		Channels []struct {
+			Sampler int `json:"sampler"`
+			Target  struct {
+				Node *int   `json:"node"`
+				Path string `json:"path"`
+			} `json:"target"`
+		} `json:"channels"`
+		Samplers []struct {
+			Input         int    `json:"input"`
+			Output        int    `json:"output"`
+			Interpolation string `json:"interpolation"`
+		} `json:"samplers"`
+	} `json:"animations"`
+}
+
+/*
+Load loads a .gltf or .glb file, external files are resolved relative to its
+directory.
+*/
+func Load(file string) (*Model, error) {
+	return LoadFS(asset.DirFS(filepath.Dir(file)), filepath.Base(file))
+}
+
+/*
+LoadFS loads a .gltf or .glb file from fsys, external buffers and images are
+resolved relative to name within fsys. Files opened as *asset.File, such as
+those from asset.FileSystem, are referenced without copying.
+*/
+func LoadFS(fsys fs.FS, name string) (*Model, error) {
+	m := &Model{DefaultScene: -1}
+	l := gltfLoader{fsys: fsys, dir: path.Dir(name), model: m}
+
+	data, err := l.open(name)
+	if err != nil {
+		m.Close()
+		return nil, debug.ErrorWrapf(err, "Failed to load glTF")
+	}
+
+	if err := l.load(data); err != nil {
+		m.Close()
+		return nil, debug.ErrorWrapf(err, "Failed to load glTF %q", name)
+	}
+	return m, nil
+}
+
+type gltfLoader struct {
+	fsys    fs.FS
+	dir     string
+	model   *Model
+	doc     gltfDocument
+	bin     []byte
+	buffers [][]byte
+}
+
+/*
+open returns the contents of name, any *asset.File is kept open until the model
+is closed.
+*/
+func (l *gltfLoader) open(name string) ([]byte, error) {
+	data, a, err := openFS(l.fsys, name)
+	if a != nil {
+		l.model.files = append(l.model.files, a)
+	}
+	return data, err
+}
+
+func (l *gltfLoader) load(data []byte) error {
+	jsonData := data
+
+	if len(data) >= 12 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if v := binary.LittleEndian.Uint32(data[4:]); v != 2 {
+			return debug.Errorf("Unsupported GLB version %d", v)
+		}
+		jsonData = nil
+		length := min(len(data), int(binary.LittleEndian.Uint32(data[8:])))
+		for off := 12; off+8 <= length; {
+			size := int(binary.LittleEndian.Uint32(data[off:]))
+			kind := binary.LittleEndian.Uint32(data[off+4:])
+			if size > length-off-8 {
+				return debug.Errorf("GLB chunk at %d truncated", off)
+			}
+			chunk := data[off+8 : off+8+size : off+8+size]
+			switch {
+			case kind == glbChunkJSON && jsonData == nil:
+				jsonData = chunk
+			case kind == glbChunkBIN && l.bin == nil:
+				l.bin = chunk
+			}
+			off += 8 + ((size + 3) &^ 3)
+		}
+		if jsonData == nil {
+			return debug.Errorf("GLB has no JSON chunk")
+		}
+	}
+
+	if err := json.Unmarshal(jsonData, &l.doc); err != nil {
+		return debug.ErrorWrapf(err, "Failed to parse JSON")
+	}
+	if !strings.HasPrefix(l.doc.Asset.Version, "2.") {
+		return debug.Errorf("Unsupported glTF version %q", l.doc.Asset.Version)
+	}
+	if len(l.doc.ExtensionsRequired) > 0 {
+		return debug.Errorf("Unsupported required extensions %v", l.doc.ExtensionsRequired)
+	}
+
+	for _, step := range []func() error{
+		l.loadBuffers, l.loadAccessors, l.loadImages, l.loadMaterials,
+		l.loadMeshes, l.loadNodes, l.loadSkins, l.loadAnimations,
+	} {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+index checks that i is a valid index for a slice of length n and returns -1 for
+nil.
+*/
+func index(i *int, n int, what string) (int, error) {
+	if i == nil {
+		return -1, nil
+	}
+	if *i < 0 || *i >= n {
+		return -1, debug.Errorf("Invalid %s index %d", what, *i)
+	}
+	return *i, nil
+}
+
+func (l *gltfLoader) loadBuffers() error {
+	l.buffers = make([][]byte, len(l.doc.Buffers))
+
+	for i, b := range l.doc.Buffers {
+		var data []byte
+		switch {
+		case b.URI == "":
+			if i != 0 || l.bin == nil {
+				return debug.Errorf("Buffer %d has no data", i)
+			}
+			data = l.bin
+		case strings.HasPrefix(b.URI, "data:"):
+			_, encoded, ok := strings.Cut(b.URI, ";base64,")
+			if !ok {
+				return debug.Errorf("Buffer %d: Unsupported data URI", i)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Buffer %d: Failed to decode data URI", i)
+			}
+			data = decoded
+		default:
+			file, err := l.resolve(b.URI)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Buffer %d", i)
+			}
+			data, err = l.open(file)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Buffer %d: Failed to open %q", i, file)
+			}
+		}
+
+		if len(data) < b.ByteLength {
+			return debug.Errorf("Buffer %d truncated, got %d bytes want %d", i, len(data), b.ByteLength)
+		}
+		l.buffers[i] = data[:b.ByteLength:b.ByteLength]
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) resolve(uri string) (string, error) {
+	u, err := url.PathUnescape(uri)
+	if err != nil {
+		return "", debug.ErrorWrapf(err, "Invalid URI %q", uri)
+	}
+	file := path.Join(l.dir, u)
+	if !fs.ValidPath(file) {
+		return "", debug.Errorf("Invalid URI %q", uri)
+	}
+	return file, nil
+}
+
+/*
+bufferView returns the bytes of view starting at offset and its stride.
+*/
+func (l *gltfLoader) bufferView(view, offset int) ([]byte, int, error) {
+	if view < 0 || view >= len(l.doc.BufferViews) {
+		return nil, 0, debug.Errorf("Invalid buffer view index %d", view)
+	}
+	v := l.doc.BufferViews[view]
+	if v.Buffer < 0 || v.Buffer >= len(l.buffers) {
+		return nil, 0, debug.Errorf("Buffer view %d: Invalid buffer index %d", view, v.Buffer)
+	}
+	b := l.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(b)-v.ByteLength {
+		return nil, 0, debug.Errorf("Buffer view %d out of range", view)
+	}
+	b = b[v.ByteOffset : v.ByteOffset+v.ByteLength]
+	if offset < 0 || offset > len(b) {
+		return nil, 0, debug.Errorf("Buffer view %d: Offset %d out of range", view, offset)
+	}
+	return b[offset:], v.ByteStride, nil
+}
+
+func (l *gltfLoader) loadAccessors() error {
+	types := map[string]Type{
+		"SCALAR": TypeScalar, "VEC2": TypeVec2, "VEC3": TypeVec3, "VEC4": TypeVec4,
+		"MAT2": TypeMat2, "MAT3": TypeMat3, "MAT4": TypeMat4,
+	}
+	l.model.Accessors = make([]Accessor, len(l.doc.Accessors))
+
+	for i, src := range l.doc.Accessors {
+		t, ok := types[src.Type]
+		if !ok {
+			return debug.Errorf("Accessor %d: Invalid type %q", i, src.Type)
+		}
+		if src.ComponentType.Size() == 0 {
+			return debug.Errorf("Accessor %d: Invalid component type %d", i, src.ComponentType)
+		}
+		if src.Count < 0 {
+			return debug.Errorf("Accessor %d: Invalid count %d", i, src.Count)
+		}
+
+		a := &l.model.Accessors[i]
+		*a = Accessor{
+			ComponentType: src.ComponentType,
+			Type:          t,
+			Normalized:    src.Normalized,
+			Count:         src.Count,
+			Min:           src.Min,
+			Max:           src.Max,
+		}
+		size := a.ElementSize()
+		a.Stride = size
+
+		if src.BufferView != nil {
+			data, stride, err := l.bufferView(*src.BufferView, src.ByteOffset)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Accessor %d", i)
+			}
+			if stride != 0 {
+				a.Stride = stride
+			}
+			if a.Stride < size {
+				return debug.Errorf("Accessor %d: Stride %d smaller than element size %d", i, a.Stride, size)
+			}
+			if n := (a.Stride * max(0, a.Count-1)) + size; a.Count > 0 && n > len(data) {
+				return debug.Errorf("Accessor %d truncated, needs %d bytes has %d", i, n, len(data))
+			}
+			if a.Count > 0 {
+				a.Data = data[:(a.Stride*(a.Count-1))+size]
+			}
+		} else {
+			a.Data = make([]byte, size*a.Count)
+		}
+
+		if src.Sparse != nil {
+			if err := l.applySparse(a, src.Sparse.Count, src.Sparse.Indices, src.Sparse.Values); err != nil {
+				return debug.ErrorWrapf(err, "Accessor %d", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+applySparse replaces a's data with a tightly packed copy with the sparse values
+written over it.
+*/
+func (l *gltfLoader) applySparse(a *Accessor, count int, indices, values gltfAccessorRef) error {
+	if count < 0 || count > a.Count {
+		return debug.Errorf("Invalid sparse count %d", count)
+	}
+	size := a.ElementSize()
+	dense := make([]byte, size*a.Count)
+	for i := range a.Count {
+		copy(dense[i*size:], a.element(i))
+	}
+
+	idx := Accessor{ComponentType: indices.ComponentType, Type: TypeScalar, Count: count, Stride: indices.ComponentType.Size()}
+	data, _, err := l.bufferView(indices.BufferView, indices.ByteOffset)
+	if err != nil || idx.Stride == 0 || len(data) < count*idx.Stride {
+		return debug.Errorf("Invalid sparse indices")
+	}
+	idx.Data = data
+	vals, _, err := l.bufferView(values.BufferView, values.ByteOffset)
+	if err != nil || len(vals) < count*size {
+		return debug.Errorf("Invalid sparse values")
+	}
+
+	for i, n := range idx.Uint32s() {
+		if int(n) >= a.Count {
+			return debug.Errorf("Sparse index %d out of range", n)
+		}
+		copy(dense[int(n)*size:], vals[i*size:(i+1)*size])
+	}
+
+	a.Data, a.Stride = dense, size
+	return nil
+}
+
+func (l *gltfLoader) accessor(i int) (*Accessor, error) {
+	if i < 0 || i >= len(l.model.Accessors) {
+		return nil, debug.Errorf("Invalid accessor index %d", i)
+	}
+	return &l.model.Accessors[i], nil
+}
+
+func (l *gltfLoader) loadImages() error {
+	l.model.Images = make([]Image, len(l.doc.Images))
+	for i, src := range l.doc.Images {
+		img := Image{Name: src.Name, MimeType: src.MimeType}
+		switch {
+		case src.BufferView != nil:
+			data, _, err := l.bufferView(*src.BufferView, 0)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Image %d", i)
+			}
+			img.Data = data
+		case strings.HasPrefix(src.URI, "data:"):
+			header, encoded, ok := strings.Cut(src.URI, ";base64,")
+			if !ok {
+				return debug.Errorf("Image %d: Unsupported data URI", i)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Image %d: Failed to decode data URI", i)
+			}
+			img.Data = decoded
+			if img.MimeType == "" {
+				img.MimeType = strings.TrimPrefix(header, "data:")
+			}
+		default:
+			uri, err := l.resolve(src.URI)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Image %d", i)
+			}
+			img.URI = uri
+		}
+		l.model.Images[i] = img
+	}
+
+	l.model.Textures = make([]Texture, len(l.doc.Textures))
+	for i, src := range l.doc.Textures {
+		img, err := index(src.Source, len(l.model.Images), "image")
+		if err != nil {
+			return debug.ErrorWrapf(err, "Texture %d", i)
+		}
+		sampler, err := index(src.Sampler, len(l.doc.Samplers), "sampler")
+		if err != nil {
+			return debug.ErrorWrapf(err, "Texture %d", i)
+		}
+
+		// glTF defaults to repeat
+		t := Texture{Name: src.Name, Image: img, Sampler: Sampler{WrapS: 10497, WrapT: 10497}}
+		if sampler >= 0 {
+			s := l.doc.Samplers[sampler]
+			t.Sampler.MagFilter, t.Sampler.MinFilter = s.MagFilter, s.MinFilter
+			if s.WrapS != nil {
+				t.Sampler.WrapS = *s.WrapS
+			}
+			if s.WrapT != nil {
+				t.Sampler.WrapT = *s.WrapT
+			}
+		}
+		l.model.Textures[i] = t
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) textureRef(info gltfTextureInfo) (TextureRef, error) {
+	i, err := index(info.Index, len(l.model.Textures), "texture")
+	if err != nil {
+		return TextureRef{}, err
+	}
+	r := TextureRef{Texture: i, TexCoord: info.TexCoord, Scale: 1}
+	if info.Scale != nil {
+		r.Scale = *info.Scale
+	}
+	if info.Strength != nil {
+		r.Scale = *info.Strength
+	}
+	return r, nil
+}
+
+func (l *gltfLoader) loadMaterials() error {
+	l.model.Materials = make([]Material, len(l.doc.Materials))
+
+	for i, src := range l.doc.Materials {
+		m := Material{
+			Name:            src.Name,
+			BaseColorFactor: color.UNorm[float32]{R: 1, G: 1, B: 1, A: 1},
+			MetallicFactor:  1,
+			RoughnessFactor: 1,
+			EmissiveFactor:  gmath.Vector3f[float32]{X: src.EmissiveFactor[0], Y: src.EmissiveFactor[1], Z: src.EmissiveFactor[2]},
+			AlphaCutoff:     0.5,
+			DoubleSided:     src.DoubleSided,
+		}
+		pbr := src.PBRMetallicRoughness
+		if f := pbr.BaseColorFactor; f != nil {
+			m.BaseColorFactor = color.UNorm[float32]{R: f[0], G: f[1], B: f[2], A: f[3]}
+		}
+		if pbr.MetallicFactor != nil {
+			m.MetallicFactor = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			m.RoughnessFactor = *pbr.RoughnessFactor
+		}
+		if src.AlphaCutoff != nil {
+			m.AlphaCutoff = *src.AlphaCutoff
+		}
+
+		switch src.AlphaMode {
+		case "", "OPAQUE":
+			m.AlphaMode = AlphaModeOpaque
+		case "MASK":
+			m.AlphaMode = AlphaModeMask
+		case "BLEND":
+			m.AlphaMode = AlphaModeBlend
+		default:
+			return debug.Errorf("Material %d: Invalid alpha mode %q", i, src.AlphaMode)
+		}
+
+		for _, t := range []struct {
+			dst *TextureRef
+			src gltfTextureInfo
+		}{
+			{&m.BaseColorTexture, pbr.BaseColorTexture},
+			{&m.MetallicRoughnessTexture, pbr.MetallicRoughnessTexture},
+			{&m.NormalTexture, src.NormalTexture},
+			{&m.OcclusionTexture, src.OcclusionTexture},
+			{&m.EmissiveTexture, src.EmissiveTexture},
+		} {
+			r, err := l.textureRef(t.src)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Material %d", i)
+			}
+			*t.dst = r
+		}
+
+		l.model.Materials[i] = m
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) attributes(src map[string]int) (map[string]*Accessor, error) {
+	out := make(map[string]*Accessor, len(src))
+	for name, i := range src {
+		a, err := l.accessor(i)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Attribute %s", name)
+		}
+		out[name] = a
+	}
+	return out, nil
+}
+
+func (l *gltfLoader) loadMeshes() error {
+	l.model.Meshes = make([]Mesh, len(l.doc.Meshes))
+
+	for i, src := range l.doc.Meshes {
+		m := Mesh{Name: src.Name, Weights: src.Weights, Primitives: make([]Primitive, len(src.Primitives))}
+
+		for j, p := range src.Primitives {
+			out := &m.Primitives[j]
+			out.Mode = ModeTriangles
+			if p.Mode != nil {
+				if *p.Mode < ModePoints || *p.Mode > ModeTriangleFan {
+					return debug.Errorf("Mesh %d primitive %d: Invalid mode %d", i, j, *p.Mode)
+				}
+				out.Mode = *p.Mode
+			}
+
+			var err error
+			if out.Attributes, err = l.attributes(p.Attributes); err != nil {
+				return debug.ErrorWrapf(err, "Mesh %d primitive %d", i, j)
+			}
+			if p.Indices != nil {
+				if out.Indices, err = l.accessor(*p.Indices); err != nil {
+					return debug.ErrorWrapf(err, "Mesh %d primitive %d", i, j)
+				}
+			}
+			if out.Material, err = index(p.Material, len(l.model.Materials), "material"); err != nil {
+				return debug.ErrorWrapf(err, "Mesh %d primitive %d", i, j)
+			}
+			for _, t := range p.Targets {
+				target, err := l.attributes(t)
+				if err != nil {
+					return debug.ErrorWrapf(err, "Mesh %d primitive %d target", i, j)
+				}
+				out.Targets = append(out.Targets, target)
+			}
+		}
+
+		l.model.Meshes[i] = m
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadNodes() error {
+	l.model.Nodes = make([]Node, len(l.doc.Nodes))
+	for i := range l.model.Nodes {
+		l.model.Nodes[i].Parent = -1
+	}
+
+	for i, src := range l.doc.Nodes {
+		n := &l.model.Nodes[i]
+		n.Name = src.Name
+		n.Children = src.Children
+		n.Weights = src.Weights
+		n.Transform = gmath.Transform[float32]{
+			Rot:   gmath.Quaternion[float32]{W: 1},
+			Scale: gmath.Vector3f[float32]{X: 1, Y: 1, Z: 1},
+		}
+
+		var err error
+		if n.Mesh, err = index(src.Mesh, len(l.model.Meshes), "mesh"); err != nil {
+			return debug.ErrorWrapf(err, "Node %d", i)
+		}
+		if n.Skin, err = index(src.Skin, len(l.doc.Skins), "skin"); err != nil {
+			return debug.ErrorWrapf(err, "Node %d", i)
+		}
+
+		switch {
+		case len(src.Matrix) == 16:
+			n.Transform = decompose(src.Matrix)
+		case len(src.Matrix) != 0:
+			return debug.Errorf("Node %d: Invalid matrix", i)
+		}
+		if t := src.Translation; t != nil {
+			n.Transform.Pos = gmath.Point3f[float32]{X: t[0], Y: t[1], Z: t[2]}
+		}
+		if r := src.Rotation; r != nil {
+			n.Transform.Rot = gmath.Quaternion[float32]{X: r[0], Y: r[1], Z: r[2], W: r[3]}
+		}
+		if s := src.Scale; s != nil {
+			n.Transform.Scale = gmath.Vector3f[float32]{X: s[0], Y: s[1], Z: s[2]}
+		}
+
+		for _, c := range src.Children {
+			if c < 0 || c >= len(l.model.Nodes) || c == i {
+				return debug.Errorf("Node %d: Invalid child index %d", i, c)
+			}
+			if p := l.model.Nodes[c].Parent; p >= 0 {
+				return debug.Errorf("Node %d: Child %d already has parent %d", i, c, p)
+			}
+			l.model.Nodes[c].Parent = i
+		}
+	}
+
+	// with every node having at most one parent a cycle can only exist with
+	// no root, walk up from each node to find them
+	for i := range l.model.Nodes {
+		n := i
+		for steps := 0; l.model.Nodes[n].Parent >= 0; steps++ {
+			if steps > len(l.model.Nodes) {
+				return debug.Errorf("Node %d is part of a cycle", i)
+			}
+			n = l.model.Nodes[n].Parent
+		}
+	}
+
+	l.model.Scenes = make([]Scene, len(l.doc.Scenes))
+	for i, src := range l.doc.Scenes {
+		for _, n := range src.Nodes {
+			if n < 0 || n >= len(l.model.Nodes) || l.model.Nodes[n].Parent >= 0 {
+				return debug.Errorf("Scene %d: Invalid root node %d", i, n)
+			}
+		}
+		l.model.Scenes[i] = Scene{Name: src.Name, Nodes: src.Nodes}
+	}
+
+	var err error
+	if l.model.DefaultScene, err = index(l.doc.Scene, len(l.model.Scenes), "scene"); err != nil {
+		return err
+	}
+	if l.model.DefaultScene < 0 && len(l.model.Scenes) > 0 {
+		l.model.DefaultScene = 0
+	}
+
+	return nil
+}
+
+/*
+decompose splits a column major affine matrix into a Transform, it assumes the
+matrix has no shear.
+*/
+func decompose(m []float32) gmath.Transform[float32] {
+	col := func(c int) gmath.Vector3f[float32] {
+		return gmath.Vector3f[float32]{X: m[c*4], Y: m[(c*4)+1], Z: m[(c*4)+2]}
+	}
+	length := func(v gmath.Vector3f[float32]) float32 {
+		return float32(math.Sqrt(float64((v.X * v.X) + (v.Y * v.Y) + (v.Z * v.Z))))
+	}
+
+	c0, c1, c2 := col(0), col(1), col(2)
+	s := gmath.Vector3f[float32]{X: length(c0), Y: length(c1), Z: length(c2)}
+	// a negative determinant means a mirror, put it on X
+	if (c0.X*((c1.Y*c2.Z)-(c1.Z*c2.Y)))-(c1.X*((c0.Y*c2.Z)-(c0.Z*c2.Y)))+(c2.X*((c0.Y*c1.Z)-(c0.Z*c1.Y))) < 0 {
+		s.X = -s.X
+	}
+
+	// r[row][col]
+	r := [3][3]float64{}
+	for c, v := range []gmath.Vector3f[float32]{c0, c1, c2} {
+		scale := []float32{s.X, s.Y, s.Z}[c]
+		if scale == 0 {
+			continue
+		}
+		r[0][c], r[1][c], r[2][c] = float64(v.X/scale), float64(v.Y/scale), float64(v.Z/scale)
+	}
+
+	var x, y, z, w float64
+	switch trace := r[0][0] + r[1][1] + r[2][2]; {
+	case trace > 0:
+		k := math.Sqrt(trace+1) * 2
+		w, x, y, z = 0.25*k, (r[2][1]-r[1][2])/k, (r[0][2]-r[2][0])/k, (r[1][0]-r[0][1])/k
+	case r[0][0] > r[1][1] && r[0][0] > r[2][2]:
+		k := math.Sqrt(1+r[0][0]-r[1][1]-r[2][2]) * 2
+		w, x, y, z = (r[2][1]-r[1][2])/k, 0.25*k, (r[0][1]+r[1][0])/k, (r[0][2]+r[2][0])/k
+	case r[1][1] > r[2][2]:
+		k := math.Sqrt(1+r[1][1]-r[0][0]-r[2][2]) * 2
+		w, x, y, z = (r[0][2]-r[2][0])/k, (r[0][1]+r[1][0])/k, 0.25*k, (r[1][2]+r[2][1])/k
+	default:
+		k := math.Sqrt(1+r[2][2]-r[0][0]-r[1][1]) * 2
+		w, x, y, z = (r[1][0]-r[0][1])/k, (r[0][2]+r[2][0])/k, (r[1][2]+r[2][1])/k, 0.25*k
+	}
+
+	return gmath.Transform[float32]{
+		Pos:   gmath.Point3f[float32]{X: m[12], Y: m[13], Z: m[14]},
+		Rot:   gmath.Quaternion[float32]{X: float32(x), Y: float32(y), Z: float32(z), W: float32(w)}.Normalize(),
+		Scale: s,
+	}
+}
+
+func (l *gltfLoader) loadSkins() error {
+	l.model.Skins = make([]Skin, len(l.doc.Skins))
+
+	for i, src := range l.doc.Skins {
+		s := Skin{Name: src.Name, Joints: src.Joints, InverseBindMatrices: make([]gmath.Matrix4x4f[float32], len(src.Joints))}
+		for _, j := range src.Joints {
+			if j < 0 || j >= len(l.model.Nodes) {
+				return debug.Errorf("Skin %d: Invalid joint index %d", i, j)
+			}
+		}
+
+		var err error
+		if s.Skeleton, err = index(src.Skeleton, len(l.model.Nodes), "skeleton"); err != nil {
+			return debug.ErrorWrapf(err, "Skin %d", i)
+		}
+
+		if src.InverseBindMatrices == nil {
+			for j := range s.InverseBindMatrices {
+				s.InverseBindMatrices[j] = gmath.Matrix4x4f[float32]{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+			}
+		} else {
+			a, err := l.accessor(*src.InverseBindMatrices)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Skin %d", i)
+			}
+			if a.Type != TypeMat4 || a.ComponentType != ComponentTypeFloat32 || a.Count < len(src.Joints) {
+				return debug.Errorf("Skin %d: Invalid inverse bind matrices", i)
+			}
+			// glTF is column major
+			v := a.Float32s()
+			for j := range s.InverseBindMatrices {
+				for c := range 4 {
+					for r := range 4 {
+						s.InverseBindMatrices[j][r][c] = v[(j*16)+(c*4)+r]
+					}
+				}
+			}
+		}
+
+		l.model.Skins[i] = s
+	}
+
+	return nil
+}
+
+func (l *gltfLoader) loadAnimations() error {
+	paths := map[string]Path{
+		"translation": PathTranslation, "rotation": PathRotation, "scale": PathScale, "weights": PathWeights,
+	}
+	interpolations := map[string]Interpolation{
+		"": InterpolationLinear, "LINEAR": InterpolationLinear, "STEP": InterpolationStep, "CUBICSPLINE": InterpolationCubicSpline,
+	}
+	l.model.Animations = make([]Animation, len(l.doc.Animations))
+
+	for i, src := range l.doc.Animations {
+		a := Animation{Name: src.Name, Samplers: make([]AnimationSampler, len(src.Samplers))}
+
+		for j, s := range src.Samplers {
+			interpolation, ok := interpolations[s.Interpolation]
+			if !ok {
+				return debug.Errorf("Animation %d sampler %d: Invalid interpolation %q", i, j, s.Interpolation)
+			}
+			input, err := l.accessor(s.Input)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Animation %d sampler %d", i, j)
+			}
+			output, err := l.accessor(s.Output)
+			if err != nil {
+				return debug.ErrorWrapf(err, "Animation %d sampler %d", i, j)
+			}
+			if input.Type != TypeScalar || input.ComponentType != ComponentTypeFloat32 {
+				return debug.Errorf("Animation %d sampler %d: Input must be float scalars", i, j)
+			}
+			a.Samplers[j] = AnimationSampler{Input: input, Output: output, Interpolation: interpolation}
+		}
+
+		for j, c := range src.Channels {
+			path, ok := paths[c.Target.Path]
+			if !ok {
+				// extensions may target other properties, skip what we don't know
+				continue
+			}
+			if c.Sampler < 0 || c.Sampler >= len(a.Samplers) {
+				return debug.Errorf("Animation %d channel %d: Invalid sampler index %d", i, j, c.Sampler)
+			}
+			node, err := index(c.Target.Node, len(l.model.Nodes), "node")
+			if err != nil {
+				return debug.ErrorWrapf(err, "Animation %d channel %d", i, j)
+			}
+			if node < 0 {
+				continue
+			}
+			a.Channels = append(a.Channels, Channel{Sampler: c.Sampler, Node: node, Path: path})
+		}
+
+		l.model.Animations[i] = a
+	}
+
+	return nil
+}
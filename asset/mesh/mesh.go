@@ -0,0 +1,248 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package mesh loads meshes and scenes. Vertex data is exposed through Accessors
+which point into the mapped files whenever the layout allows it, so a Model must
+be closed once its data is no longer needed.
+*/
+package mesh
+
+import (
+	"io"
+	"io/fs"
+
+	"goarrg.com/asset"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+/*
+Model is a loaded glTF document. Every index in it is into the matching slice
+of the Model and is -1 when not set.
+*/
+type Model struct {
+	Nodes  []Node
+	Scenes []Scene
+	// DefaultScene is the scene to display, if the file doesn't specify
+	// one it is 0 if there are any scenes.
+	DefaultScene int
+
+	Meshes     []Mesh
+	Materials  []Material
+	Textures   []Texture
+	Images     []Image
+	Skins      []Skin
+	Animations []Animation
+	Accessors  []Accessor
+
+	files []*asset.File
+}
+
+type Scene struct {
+	Name  string
+	Nodes []int
+}
+
+type Node struct {
+	Name     string
+	Parent   int
+	Children []int
+	// Transform is relative to the parent, nodes specified with a matrix are
+	// decomposed.
+	Transform gmath.Transform[float32]
+
+	Mesh int
+	Skin int
+	// Weights are the default morph target weights, overriding the mesh's.
+	Weights []float32
+}
+
+type Mesh struct {
+	Name       string
+	Primitives []Primitive
+	Weights    []float32
+}
+
+type Mode int
+
+const (
+	ModePoints Mode = iota
+	ModeLines
+	ModeLineLoop
+	ModeLineStrip
+	ModeTriangles
+	ModeTriangleStrip
+	ModeTriangleFan
+)
+
+type Primitive struct {
+	Mode Mode
+	// Attributes is keyed by the glTF semantic, e.g. "POSITION" or
+	// "TEXCOORD_0".
+	Attributes map[string]*Accessor
+	// Indices is nil for non indexed geometry.
+	Indices  *Accessor
+	Material int
+	Targets  []map[string]*Accessor
+}
+
+type AlphaMode int
+
+const (
+	AlphaModeOpaque AlphaMode = iota
+	AlphaModeMask
+	AlphaModeBlend
+)
+
+type TextureRef struct {
+	Texture  int
+	TexCoord int
+	// Scale is the normal texture's scale or the occlusion texture's
+	// strength, it is 1 for other textures.
+	Scale float32
+}
+
+/*
+Material is a glTF metallic-roughness material.
+*/
+type Material struct {
+	Name string
+
+	BaseColorFactor          color.UNorm[float32]
+	BaseColorTexture         TextureRef
+	MetallicFactor           float32
+	RoughnessFactor          float32
+	MetallicRoughnessTexture TextureRef
+
+	NormalTexture    TextureRef
+	OcclusionTexture TextureRef
+	EmissiveFactor   gmath.Vector3f[float32]
+	EmissiveTexture  TextureRef
+
+	AlphaMode   AlphaMode
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+/*
+Sampler holds the OpenGL enums used by glTF, 0 means unspecified for the
+filters.
+*/
+type Sampler struct {
+	MagFilter int
+	MinFilter int
+	WrapS     int
+	WrapT     int
+}
+
+type Texture struct {
+	Name    string
+	Image   int
+	Sampler Sampler
+}
+
+/*
+Image is either a URI relative to the root of the file system the model was
+loaded from, or Data which aliases the model's buffers.
+*/
+type Image struct {
+	Name     string
+	URI      string
+	MimeType string
+	Data     []byte
+}
+
+type Skin struct {
+	Name     string
+	Joints   []int
+	Skeleton int
+	// InverseBindMatrices has one matrix per joint, identity if the file
+	// doesn't specify them.
+	InverseBindMatrices []gmath.Matrix4x4f[float32]
+}
+
+type Path int
+
+const (
+	PathTranslation Path = iota
+	PathRotation
+	PathScale
+	PathWeights
+)
+
+type Interpolation int
+
+const (
+	InterpolationLinear Interpolation = iota
+	InterpolationStep
+	InterpolationCubicSpline
+)
+
+type AnimationSampler struct {
+	// Input holds the keyframe times in seconds.
+	Input *Accessor
+	// Output holds the values, for cubic spline each keyframe has an in
+	// tangent, value and out tangent.
+	Output        *Accessor
+	Interpolation Interpolation
+}
+
+type Channel struct {
+	Sampler int
+	Node    int
+	Path    Path
+}
+
+type Animation struct {
+	Name     string
+	Channels []Channel
+	Samplers []AnimationSampler
+}
+
+/*
+Close releases the files backing the model, any slice returned from an Accessor
+must not be used afterwards.
+*/
+func (m *Model) Close() error {
+	var err error
+	for _, f := range m.files {
+		if e := f.Close(); e != nil {
+			err = e
+		}
+	}
+	m.files = nil
+	return err
+}
+
+/*
+openFS returns the contents of name, without copying if fsys returns an
+*asset.File in which case it is also returned and the data is only valid until
+it is closed.
+*/
+func openFS(fsys fs.FS, name string) ([]byte, *asset.File, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a, ok := f.(*asset.File); ok {
+		data, err := a.View(0, a.Size())
+		return data, a, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	return data, nil, err
+}
@@ -0,0 +1,255 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mesh
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"goarrg.com/gmath"
+	"goarrg.com/internal/testutil"
+)
+
+/*
+testBuffer holds a triangle: 3 float VEC3 positions, 3 uint16 indices padded
+to 4 bytes, and interleaved float VEC2 uvs with a byte of padding each.
+*/
+func testBuffer() []byte {
+	b := []byte{}
+	for _, f := range []float32{0, 0, 0, 1, 0, 0, 0, 1, 0} {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	for _, i := range []uint16{0, 1, 2, 0} {
+		b = binary.LittleEndian.AppendUint16(b, i)
+	}
+	for _, f := range []float32{0, 0, 1, 0, 0, 1} {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+		if len(b)%12 == 4 {
+			b = append(b, 0, 0, 0, 0)
+		}
+	}
+	return b
+}
+
+const testJSON = `{
+	"asset": {"version": "2.0"},
+	"scene": 0,
+	"scenes": [{"nodes": [0]}],
+	"nodes": [
+		{"name": "root", "children": [1], "translation": [1, 2, 3]},
+		{"name": "child", "mesh": 0, "skin": 0, "matrix": [2,0,0,0, 0,2,0,0, 0,0,2,0, 4,5,6,1]}
+	],
+	"buffers": [{BUFFER "byteLength": 68}],
+	"bufferViews": [
+		{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+		{"buffer": 0, "byteOffset": 36, "byteLength": 8},
+		{"buffer": 0, "byteOffset": 44, "byteLength": 24, "byteStride": 12}
+	],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+		{"bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		{"bufferView": 2, "componentType": 5126, "count": 2, "type": "VEC2"},
+		{"componentType": 5126, "count": 2, "type": "SCALAR"}
+	],
+	"meshes": [{"primitives": [{"attributes": {"POSITION": 0, "TEXCOORD_0": 2}, "indices": 1, "material": 0}]}],
+	"materials": [{"pbrMetallicRoughness": {"baseColorFactor": [1, 0, 0, 1]}, "alphaMode": "MASK"}],
+	"skins": [{"joints": [0, 1]}],
+	"animations": [{
+		"samplers": [{"input": 3, "output": 0, "interpolation": "STEP"}],
+		"channels": [{"sampler": 0, "target": {"node": 1, "path": "translation"}}]
+	}]
+}`
+
+func checkModel(t *testing.T, m *Model) {
+	t.Helper()
+
+	if m.DefaultScene != 0 || len(m.Scenes[0].Nodes) != 1 || m.Nodes[1].Parent != 0 || m.Nodes[0].Parent != -1 {
+		t.Fatalf("Wrong hierarchy: %+v %+v", m.Scenes, m.Nodes)
+	}
+	if tr := m.Nodes[0].Transform; tr.Pos != (gmath.Point3f[float32]{X: 1, Y: 2, Z: 3}) || tr.Scale != (gmath.Vector3f[float32]{X: 1, Y: 1, Z: 1}) {
+		t.Fatalf("Wrong transform: %+v", tr)
+	}
+	if tr := m.Nodes[1].Transform; tr.Pos != (gmath.Point3f[float32]{X: 4, Y: 5, Z: 6}) ||
+		tr.Scale != (gmath.Vector3f[float32]{X: 2, Y: 2, Z: 2}) || tr.Rot != (gmath.Quaternion[float32]{W: 1}) {
+		t.Fatalf("Wrong decomposed transform: %+v", tr)
+	}
+
+	p := m.Meshes[0].Primitives[0]
+	if p.Mode != ModeTriangles || p.Material != 0 || m.Materials[0].AlphaMode != AlphaModeMask || m.Materials[0].BaseColorFactor.G != 0 {
+		t.Fatalf("Wrong primitive: %+v %+v", p, m.Materials)
+	}
+	pos, err := View[gmath.Vector3f[float32]](p.Attributes["POSITION"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos[1] != (gmath.Vector3f[float32]{X: 1}) || pos[2] != (gmath.Vector3f[float32]{Y: 1}) {
+		t.Fatalf("Wrong positions: %+v", pos)
+	}
+	if unsafe.SliceData(pos) != (*gmath.Vector3f[float32])(unsafe.Pointer(&p.Attributes["POSITION"].Data[0])) {
+		t.Fatal("Positions were copied")
+	}
+	if idx := p.Indices.Uint32s(); len(idx) != 3 || idx[2] != 2 {
+		t.Fatalf("Wrong indices: %v", idx)
+	}
+	// interleaved so a copy
+	uv, err := View[gmath.Vector2f[float32]](p.Attributes["TEXCOORD_0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uv[1] != (gmath.Vector2f[float32]{X: 1}) {
+		t.Fatalf("Wrong uvs: %+v", uv)
+	}
+	if _, err := View[gmath.Vector2f[float32]](p.Attributes["POSITION"]); err == nil {
+		t.Fatal("Expected size mismatch")
+	}
+
+	if s := m.Skins[0]; len(s.Joints) != 2 || s.InverseBindMatrices[1][3][3] != 1 {
+		t.Fatalf("Wrong skin: %+v", s)
+	}
+	if a := m.Animations[0]; len(a.Channels) != 1 || a.Channels[0].Node != 1 || a.Samplers[0].Interpolation != InterpolationStep {
+		t.Fatalf("Wrong animation: %+v", a)
+	}
+}
+
+func TestGLTF(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, dir, "test buffer.bin", testBuffer())
+	name := testutil.WriteFile(t, dir, "test.gltf", []byte(strings.Replace(testJSON, "BUFFER", `"uri": "test%20buffer.bin",`, 1)))
+
+	m, err := Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	checkModel(t, m)
+
+	uri := `"uri": "data:application/octet-stream;base64,` + base64.StdEncoding.EncodeToString(testBuffer()) + `",`
+	m2, err := Load(testutil.WriteFile(t, dir, "embedded.gltf", []byte(strings.Replace(testJSON, "BUFFER", uri, 1))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m2.Close()
+	checkModel(t, m2)
+
+	bad := strings.Replace(testJSON, "BUFFER", uri, 1)
+	bad = strings.Replace(bad, `"children": [1]`, `"children": [1, 0]`, 1)
+	if _, err := Load(testutil.WriteFile(t, dir, "bad.gltf", []byte(bad))); err == nil {
+		t.Fatal("Expected invalid hierarchy")
+	}
+
+	for _, count := range []int{-1, 3} {
+		sparse := fmt.Sprintf(`"sparse": {"count": %d, "indices": {"bufferView": 1, "componentType": 5123}, "values": {"bufferView": 0}}`, count)
+		bad = strings.Replace(testJSON, "BUFFER", uri, 1)
+		bad = strings.Replace(bad, `{"componentType": 5126, "count": 2, "type": "SCALAR"}`, `{"componentType": 5126, "count": 2, "type": "SCALAR", `+sparse+`}`, 1)
+		if _, err := Load(testutil.WriteFile(t, dir, "sparse.gltf", []byte(bad))); err == nil {
+			t.Fatalf("Expected invalid sparse count %d", count)
+		}
+	}
+}
+
+func TestGLB(t *testing.T) {
+	js := []byte(strings.Replace(testJSON, "BUFFER", "", 1))
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+	bin := testBuffer()
+
+	glb := binary.LittleEndian.AppendUint32(nil, glbMagic)
+	glb = binary.LittleEndian.AppendUint32(glb, 2)
+	glb = binary.LittleEndian.AppendUint32(glb, uint32(12+8+len(js)+8+len(bin)))
+	glb = binary.LittleEndian.AppendUint32(glb, uint32(len(js)))
+	glb = binary.LittleEndian.AppendUint32(glb, glbChunkJSON)
+	glb = append(glb, js...)
+	glb = binary.LittleEndian.AppendUint32(glb, uint32(len(bin)))
+	glb = binary.LittleEndian.AppendUint32(glb, glbChunkBIN)
+	glb = append(glb, bin...)
+
+	m, err := Load(testutil.WriteFile(t, t.TempDir(), "test.glb", glb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	checkModel(t, m)
+}
+
+func TestOBJ(t *testing.T) {
+	dir := t.TempDir()
+	testutil.WriteFile(t, dir, "test.mtl", []byte(`
+newmtl red
+Kd 1 0 0
+d 0.5
+map_Kd -bm 1 textures/red.png
+newmtl green
+Kd 0 1 0
+`))
+	name := testutil.WriteFile(t, dir, "test.obj", []byte(`# a quad and a triangle sharing an edge
+mtllib test.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+v 2 0.5 0
+vt 0 0
+vt 1 1
+o quad
+usemtl red
+f 1/1 2 3/2 \
+  4
+usemtl green
+f -4 -1 -3
+`))
+
+	m, err := LoadOBJ(name, OBJConfig{GenerateNormals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Indices) != 9 || len(m.Vertices) != 6 {
+		t.Fatalf("Wrong counts: %d indices %d vertices", len(m.Indices), len(m.Vertices))
+	}
+	if len(m.Groups) != 2 || m.Groups[0] != (OBJGroup{Name: "quad", Material: 0, Offset: 0, Count: 6}) ||
+		m.Groups[1] != (OBJGroup{Name: "quad", Material: 1, Offset: 6, Count: 3}) {
+		t.Fatalf("Wrong groups: %+v", m.Groups)
+	}
+	if mat := m.Materials[0]; mat.Name != "red" || mat.Opacity != 0.5 || mat.DiffuseMap != "textures/red.png" || mat.Diffuse.Y != 0 {
+		t.Fatalf("Wrong material: %+v", mat)
+	}
+	if !m.HasNormals || !m.HasTexCoords {
+		t.Fatal("Wrong flags")
+	}
+	for i, v := range m.Vertices {
+		if v.Normal != (gmath.Vector3f[float32]{Z: 1}) {
+			t.Fatalf("[%d] Wrong normal: %+v", i, v.Normal)
+		}
+	}
+	if v := m.Vertices[m.Indices[2]]; v.TexCoord != (gmath.Vector2f[float32]{X: 1, Y: 1}) {
+		t.Fatalf("Wrong texcoord: %+v", v)
+	}
+
+	bad := testutil.WriteFile(t, dir, "bad.obj", []byte("v 0 0 0\nv 1 0 0\n\nf 1 2 3\n"))
+	if _, err := LoadOBJ(bad, OBJConfig{}); err == nil || !strings.Contains(err.Error(), "Line 4") {
+		t.Fatalf("Expected error on line 4, got: %v", err)
+	}
+}
@@ -0,0 +1,506 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mesh
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+)
+
+type OBJConfig struct {
+	// GenerateNormals computes smooth area weighted normals for vertices that
+	// have none in the file.
+	GenerateNormals bool
+}
+
+type Vertex struct {
+	Position gmath.Vector3f[float32]
+	Normal   gmath.Vector3f[float32]
+	TexCoord gmath.Vector2f[float32]
+}
+
+/*
+OBJGroup is a range of OBJMesh.Indices sharing a group and material, Material
+indexes OBJMesh.Materials or is -1.
+*/
+type OBJGroup struct {
+	Name     string
+	Material int
+	Offset   int
+	Count    int
+}
+
+type OBJMaterial struct {
+	Name string
+
+	Ambient   gmath.Vector3f[float32]
+	Diffuse   gmath.Vector3f[float32]
+	Specular  gmath.Vector3f[float32]
+	Emissive  gmath.Vector3f[float32]
+	Shininess float32
+	Opacity   float32
+	IOR       float32
+	Illum     int
+
+	// Maps are relative to the root of the file system the OBJ was loaded
+	// from, options such as -bm are ignored.
+	AmbientMap  string
+	DiffuseMap  string
+	SpecularMap string
+	EmissiveMap string
+	AlphaMap    string
+	NormalMap   string
+}
+
+/*
+OBJMesh is an indexed triangle list, vertices sharing the same position, normal
+and texture coordinate in the file are merged.
+*/
+type OBJMesh struct {
+	Vertices []Vertex
+	Indices  []uint32
+	Groups   []OBJGroup
+
+	Materials []OBJMaterial
+
+	// HasNormals and HasTexCoords are false if no face in the file referenced
+	// them, GenerateNormals sets HasNormals.
+	HasNormals   bool
+	HasTexCoords bool
+}
+
+/*
+LoadOBJ loads an OBJ file along with its material libraries which are resolved
+relative to its directory.
+*/
+func LoadOBJ(file string, cfg OBJConfig) (*OBJMesh, error) {
+	return LoadOBJFS(asset.DirFS(filepath.Dir(file)), filepath.Base(file), cfg)
+}
+
+/*
+LoadOBJFS is like LoadOBJ but resolves every file within fsys.
+*/
+func LoadOBJFS(fsys fs.FS, name string, cfg OBJConfig) (*OBJMesh, error) {
+	data, a, err := openFS(fsys, name)
+	if a != nil {
+		defer a.Close()
+	}
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load OBJ")
+	}
+
+	p := objParser{fsys: fsys, dir: path.Dir(name), cfg: cfg, mesh: &OBJMesh{}, vertices: map[objKey]uint32{}, material: -1}
+	if err := p.parse(data); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load OBJ %q", name)
+	}
+	return p.mesh, nil
+}
+
+/*
+objKey is a face vertex, indices are 0 based and -1 when missing.
+*/
+type objKey struct {
+	p, t, n int32
+}
+
+type objParser struct {
+	fsys fs.FS
+	dir  string
+	cfg  OBJConfig
+	mesh *OBJMesh
+
+	positions []gmath.Vector3f[float32]
+	texCoords []gmath.Vector2f[float32]
+	normals   []gmath.Vector3f[float32]
+
+	vertices map[objKey]uint32
+	keys     []objKey
+	group    string
+	material int
+	face     []uint32
+}
+
+/*
+objLines calls fn with each line of data with comments, trailing whitespace and
+line continuations handled. Lines alias data unless they were continued.
+*/
+func objLines(data []byte, fn func(line int, fields [][]byte) error) error {
+	var joined []byte
+	fields := make([][]byte, 0, 16)
+	startLine := 0
+
+	for n := 1; len(data) > 0; n++ {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		line = bytes.TrimRight(line, " \t\r")
+
+		if bytes.HasSuffix(line, []byte{'\\'}) {
+			if joined == nil {
+				startLine = n
+			}
+			joined = append(joined, line[:len(line)-1]...)
+			joined = append(joined, ' ')
+			continue
+		}
+		lineNumber := n
+		if joined != nil {
+			line = append(joined, line...)
+			joined, lineNumber = nil, startLine
+		}
+
+		if i := bytes.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields = fields[:0]
+		for len(line) > 0 {
+			line = bytes.TrimLeft(line, " \t")
+			end := bytes.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			if end > 0 {
+				fields = append(fields, line[:end])
+			}
+			line = line[end:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if err := fn(lineNumber, fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFloats(dst []float32, fields [][]byte, required int) error {
+	if len(fields) < required {
+		return debug.Errorf("Expected %d values got %d", required, len(fields))
+	}
+	for i := range min(len(dst), len(fields)) {
+		v, err := strconv.ParseFloat(string(fields[i]), 32)
+		if err != nil {
+			return debug.Errorf("Invalid number %q", fields[i])
+		}
+		dst[i] = float32(v)
+	}
+	return nil
+}
+
+func (p *objParser) parse(data []byte) error {
+	err := objLines(data, func(line int, fields [][]byte) error {
+		var err error
+		v := [3]float32{}
+
+		switch string(fields[0]) {
+		case "v":
+			if err = parseFloats(v[:], fields[1:], 3); err == nil {
+				p.positions = append(p.positions, gmath.Vector3f[float32]{X: v[0], Y: v[1], Z: v[2]})
+			}
+		case "vt":
+			if err = parseFloats(v[:2], fields[1:], 1); err == nil {
+				p.texCoords = append(p.texCoords, gmath.Vector2f[float32]{X: v[0], Y: v[1]})
+			}
+		case "vn":
+			if err = parseFloats(v[:], fields[1:], 3); err == nil {
+				p.normals = append(p.normals, gmath.Vector3f[float32]{X: v[0], Y: v[1], Z: v[2]})
+			}
+		case "f":
+			err = p.parseFace(fields[1:])
+		case "o", "g":
+			p.group = ""
+			if len(fields) > 1 {
+				p.group = string(bytes.Join(fields[1:], []byte{' '}))
+			}
+			p.startGroup()
+		case "usemtl":
+			if len(fields) < 2 {
+				return debug.Errorf("Line %d: usemtl without a name", line)
+			}
+			p.material = -1
+			for i, m := range p.mesh.Materials {
+				if m.Name == string(fields[1]) {
+					p.material = i
+				}
+			}
+			if p.material < 0 {
+				return debug.Errorf("Line %d: Unknown material %q", line, fields[1])
+			}
+			p.startGroup()
+		case "mtllib":
+			for _, lib := range fields[1:] {
+				if err = p.loadMTL(path.Join(p.dir, string(lib))); err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			return debug.ErrorWrapf(err, "Line %d", line)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.endGroup()
+	if p.cfg.GenerateNormals && len(p.mesh.Indices) > 0 {
+		p.generateNormals()
+	}
+	return nil
+}
+
+func (p *objParser) startGroup() {
+	p.endGroup()
+	p.mesh.Groups = append(p.mesh.Groups, OBJGroup{Name: p.group, Material: p.material, Offset: len(p.mesh.Indices)})
+}
+
+/*
+endGroup finalizes the current group, dropping it if it has no faces.
+*/
+func (p *objParser) endGroup() {
+	if len(p.mesh.Groups) == 0 {
+		return
+	}
+	g := &p.mesh.Groups[len(p.mesh.Groups)-1]
+	g.Count = len(p.mesh.Indices) - g.Offset
+	if g.Count == 0 {
+		p.mesh.Groups = p.mesh.Groups[:len(p.mesh.Groups)-1]
+	}
+}
+
+/*
+objIndex converts a 1 based or negative relative index to a 0 based index.
+*/
+func objIndex(field []byte, count int) (int32, error) {
+	i, err := strconv.ParseInt(string(field), 10, 32)
+	if err != nil {
+		return 0, debug.Errorf("Invalid index %q", field)
+	}
+	if i < 0 {
+		i += int64(count)
+	} else {
+		i--
+	}
+	if i < 0 || i >= int64(count) {
+		return 0, debug.Errorf("Index %s out of range [1, %d]", field, count)
+	}
+	return int32(i), nil
+}
+
+func (p *objParser) parseFace(fields [][]byte) error {
+	if len(fields) < 3 {
+		return debug.Errorf("Face needs at least 3 vertices, got %d", len(fields))
+	}
+	if len(p.mesh.Groups) == 0 {
+		p.startGroup()
+	}
+
+	p.face = p.face[:0]
+	for _, f := range fields {
+		k := objKey{-1, -1, -1}
+		parts := [3][]byte{f}
+		if i := bytes.IndexByte(f, '/'); i >= 0 {
+			parts[0], parts[1] = f[:i], f[i+1:]
+			if i := bytes.IndexByte(parts[1], '/'); i >= 0 {
+				parts[1], parts[2] = parts[1][:i], parts[1][i+1:]
+			}
+		}
+
+		var err error
+		if k.p, err = objIndex(parts[0], len(p.positions)); err != nil {
+			return err
+		}
+		if len(parts[1]) > 0 {
+			if k.t, err = objIndex(parts[1], len(p.texCoords)); err != nil {
+				return err
+			}
+			p.mesh.HasTexCoords = true
+		}
+		if len(parts[2]) > 0 {
+			if k.n, err = objIndex(parts[2], len(p.normals)); err != nil {
+				return err
+			}
+			p.mesh.HasNormals = true
+		}
+
+		i, ok := p.vertices[k]
+		if !ok {
+			i = uint32(len(p.mesh.Vertices))
+			v := Vertex{Position: p.positions[k.p]}
+			if k.t >= 0 {
+				v.TexCoord = p.texCoords[k.t]
+			}
+			if k.n >= 0 {
+				v.Normal = p.normals[k.n]
+			}
+			p.mesh.Vertices = append(p.mesh.Vertices, v)
+			p.keys = append(p.keys, k)
+			p.vertices[k] = i
+		}
+		p.face = append(p.face, i)
+	}
+
+	// fan triangulation, OBJ polygons are expected to be convex
+	for i := 2; i < len(p.face); i++ {
+		p.mesh.Indices = append(p.mesh.Indices, p.face[0], p.face[i-1], p.face[i])
+	}
+	return nil
+}
+
+/*
+generateNormals fills in the normals of vertices that have none, accumulating
+face normals per position so vertices split by texture coordinates still share
+the same normal.
+*/
+func (p *objParser) generateNormals() {
+	sums := make([]gmath.Vector3f[float32], len(p.positions))
+	missing := false
+	for _, k := range p.keys {
+		missing = missing || k.n < 0
+	}
+	if !missing {
+		return
+	}
+
+	v := p.mesh.Vertices
+	for i := 0; i+2 < len(p.mesh.Indices); i += 3 {
+		a, b, c := p.mesh.Indices[i], p.mesh.Indices[i+1], p.mesh.Indices[i+2]
+		// the cross product's length is twice the area which weights it
+		n := v[b].Position.Subtract(v[a].Position).Cross(v[c].Position.Subtract(v[a].Position))
+		for _, j := range []uint32{a, b, c} {
+			sums[p.keys[j].p] = sums[p.keys[j].p].Add(n)
+		}
+	}
+
+	for i, k := range p.keys {
+		if k.n < 0 {
+			p.mesh.Vertices[i].Normal = sums[k.p].Normalize()
+		}
+	}
+	p.mesh.HasNormals = true
+}
+
+func (p *objParser) loadMTL(name string) error {
+	data, a, err := openFS(p.fsys, name)
+	if a != nil {
+		defer a.Close()
+	}
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to load MTL")
+	}
+
+	dir := path.Dir(name)
+	var m *OBJMaterial
+	err = objLines(data, func(line int, fields [][]byte) error {
+		key := string(fields[0])
+		if key == "newmtl" {
+			if len(fields) < 2 {
+				return debug.Errorf("Line %d: newmtl without a name", line)
+			}
+			p.mesh.Materials = append(p.mesh.Materials, OBJMaterial{
+				Name: string(fields[1]), Diffuse: gmath.Vector3f[float32]{X: 1, Y: 1, Z: 1}, Opacity: 1, IOR: 1,
+			})
+			m = &p.mesh.Materials[len(p.mesh.Materials)-1]
+			return nil
+		}
+		if m == nil {
+			return debug.Errorf("Line %d: %s before newmtl", line, key)
+		}
+
+		var err error
+		v := [3]float32{}
+		vec := func(dst *gmath.Vector3f[float32]) {
+			if err = parseFloats(v[:], fields[1:], 1); err == nil {
+				if len(fields) < 4 {
+					v[1], v[2] = v[0], v[0]
+				}
+				*dst = gmath.Vector3f[float32]{X: v[0], Y: v[1], Z: v[2]}
+			}
+		}
+		scalar := func(dst *float32) {
+			if err = parseFloats(v[:1], fields[1:], 1); err == nil {
+				*dst = v[0]
+			}
+		}
+		texture := func(dst *string) {
+			if len(fields) < 2 {
+				err = debug.Errorf("%s without a file", key)
+				return
+			}
+			*dst = path.Join(dir, filepath.ToSlash(string(fields[len(fields)-1])))
+		}
+
+		switch key {
+		case "Ka":
+			vec(&m.Ambient)
+		case "Kd":
+			vec(&m.Diffuse)
+		case "Ks":
+			vec(&m.Specular)
+		case "Ke":
+			vec(&m.Emissive)
+		case "Ns":
+			scalar(&m.Shininess)
+		case "d":
+			scalar(&m.Opacity)
+		case "Tr":
+			scalar(&m.Opacity)
+			m.Opacity = 1 - m.Opacity
+		case "Ni":
+			scalar(&m.IOR)
+		case "illum":
+			scalar(&v[0])
+			m.Illum = int(v[0])
+		case "map_Ka":
+			texture(&m.AmbientMap)
+		case "map_Kd":
+			texture(&m.DiffuseMap)
+		case "map_Ks":
+			texture(&m.SpecularMap)
+		case "map_Ke":
+			texture(&m.EmissiveMap)
+		case "map_d":
+			texture(&m.AlphaMap)
+		case "map_Bump", "map_bump", "bump", "norm":
+			texture(&m.NormalMap)
+		}
+
+		if err != nil {
+			return debug.ErrorWrapf(err, "Line %d", line)
+		}
+		return nil
+	})
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to parse MTL %q", name)
+	}
+	return nil
+}
@@ -63,3 +63,60 @@ func (f *sysLinux) close() {
 	}
 	unix.Close(f.fd)
 }
+
+type sysLinuxFile struct {
+	fd int
+}
+
+type sysLinuxRegion struct {
+	addr uintptr
+	data []byte
+}
+
+func allocationGranularity() int {
+	return unix.Getpagesize()
+}
+
+func openFile(name string) (sysFile, error) {
+	fd, err := unix.Open(name, unix.O_RDONLY, 0)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to open file")
+	}
+	return &sysLinuxFile{fd}, nil
+}
+
+func (f *sysLinuxFile) mapRegion(offset int64, size int) (sysRegion, error) {
+	data, err := unix.Mmap(f.fd, offset, size, unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to map file region")
+	}
+
+	return &sysLinuxRegion{
+		uintptr(unsafe.Pointer(unsafe.SliceData(data))),
+		data,
+	}, nil
+}
+
+func (f *sysLinuxFile) close() {
+	unix.Close(f.fd)
+}
+
+func (r *sysLinuxRegion) bytes() []byte {
+	return r.data
+}
+
+func (r *sysLinuxRegion) uintptr() uintptr {
+	return r.addr
+}
+
+func (r *sysLinuxRegion) prefetch(offset, size int) {
+	// offset is not guaranteed to be page aligned, madvise requires it to be
+	align := offset % unix.Getpagesize()
+	_ = unix.Madvise(r.data[offset-align:offset+size], unix.MADV_WILLNEED)
+}
+
+func (r *sysLinuxRegion) close() {
+	if err := debug.ErrorWrapf(unix.Munmap(r.data), "Failed to unmap file region"); err != nil {
+		panic(err)
+	}
+}
@@ -21,7 +21,10 @@ package asset
 
 import (
 	"bytes"
+	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -41,3 +44,61 @@ func TestMMAP(t *testing.T) {
 		t.Fatal("got != want")
 	}
 }
+
+func TestWindowed(t *testing.T) {
+	g := allocationGranularity()
+	want := make([]byte, (g*5)+123)
+	rand.Read(want)
+
+	name := filepath.Join(t.TempDir(), "windowed")
+	if err := os.WriteFile(name, want, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := LoadWindowed(name, WindowConfig{WindowSize: g, ReadAhead: g / 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Size() != int64(len(want)) {
+		t.Fatalf("Size: %d != %d", f.Size(), len(want))
+	}
+
+	t.Run("ReadAt", func(t *testing.T) {
+		for _, r := range [][2]int{
+			{0, 16},
+			{g - 8, 16},
+			{g + (g / 2) - 8, 16},
+			{g / 3, g * 3},
+			{len(want) - 16, 16},
+		} {
+			got := make([]byte, r[1])
+			if _, err := f.ReadAt(got, int64(r[0])); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want[r[0]:r[0]+r[1]]) {
+				t.Fatalf("[%d, %d) got != want", r[0], r[0]+r[1])
+			}
+		}
+
+		got := make([]byte, 32)
+		n, err := f.ReadAt(got, int64(len(want)-16))
+		if n != 16 || err != io.EOF { //nolint:errorlint
+			t.Fatalf("Expected short read with io.EOF, got %d %v", n, err)
+		}
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatal("got != want")
+		}
+	})
+}
@@ -86,3 +86,84 @@ func (f *sysWindows) close() {
 		}
 	}
 }
+
+type sysWindowsFile struct {
+	fh windows.Handle
+	mh windows.Handle
+}
+
+type sysWindowsRegion struct {
+	addr uintptr
+	data []byte
+}
+
+// windows has used a 64KiB allocation granularity on every platform it has ever supported
+func allocationGranularity() int {
+	return 64 << 10
+}
+
+func openFile(name string) (sysFile, error) {
+	var err error
+	f := sysWindowsFile{fh: windows.InvalidHandle, mh: windows.InvalidHandle}
+
+	f.fh, err = windows.CreateFile(windows.StringToUTF16Ptr(name), windows.GENERIC_READ, windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil,
+		windows.OPEN_EXISTING, 0, 0)
+	if f.fh == windows.InvalidHandle {
+		return nil, debug.ErrorWrapf(err, "Failed to open file")
+	}
+
+	f.mh, err = windows.CreateFileMapping(f.fh, nil, windows.PAGE_READONLY, 0, 0, nil)
+	if f.mh == 0 {
+		f.mh = windows.InvalidHandle
+		f.close()
+		return nil, debug.ErrorWrapf(err, "Failed to open file")
+	}
+
+	return &f, nil
+}
+
+func (f *sysWindowsFile) mapRegion(offset int64, size int) (sysRegion, error) {
+	addr, err := windows.MapViewOfFile(f.mh, windows.FILE_MAP_READ, uint32(offset>>32), uint32(offset), uintptr(size))
+	if addr == 0 {
+		return nil, debug.ErrorWrapf(err, "Failed to map file region")
+	}
+
+	// addr is reinterpreted rather than converted from uintptr, which go vet flags
+	return &sysWindowsRegion{
+		addr,
+		unsafe.Slice(*(**byte)(unsafe.Pointer(&addr)), size),
+	}, nil
+}
+
+func (f *sysWindowsFile) close() {
+	if f.mh != windows.InvalidHandle {
+		if err := windows.CloseHandle(f.mh); err != nil {
+			panic(err)
+		}
+	}
+
+	if f.fh != windows.InvalidHandle {
+		if err := windows.CloseHandle(f.fh); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (r *sysWindowsRegion) bytes() []byte {
+	return r.data
+}
+
+func (r *sysWindowsRegion) uintptr() uintptr {
+	return r.addr
+}
+
+// PrefetchVirtualMemory is not exposed by x/sys/windows, the memory manager's own
+// read ahead is relied on instead.
+func (r *sysWindowsRegion) prefetch(offset, size int) {
+}
+
+func (r *sysWindowsRegion) close() {
+	if err := windows.UnmapViewOfFile(r.addr); err != nil {
+		panic(err)
+	}
+}
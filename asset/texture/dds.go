@@ -0,0 +1,247 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+)
+
+const (
+	ddsHeaderSize     = 128
+	ddsDX10HeaderSize = 20
+
+	ddsFlagMipMapCount = 0x20000
+
+	ddsPixelFlagAlphaPixels = 0x1
+	ddsPixelFlagFourCC      = 0x4
+	ddsPixelFlagRGB         = 0x40
+	ddsPixelFlagLuminance   = 0x20000
+
+	ddsCaps2CubeMap = 0x200
+	ddsCaps2Volume  = 0x200000
+
+	ddsDX10DimensionTexture3D = 4
+	ddsDX10MiscTextureCube    = 0x4
+
+	dxgiFormatB8G8R8X8UNorm     = 88
+	dxgiFormatB8G8R8X8UNormSRGB = 93
+)
+
+func fourCC(s string) uint32 {
+	return binary.LittleEndian.Uint32([]byte(s))
+}
+
+/*
+ddsLegacyFormat maps a pre DX10 pixel format to a Format, it only covers the
+formats commonly written by tools.
+*/
+func ddsLegacyFormat(pf []byte) Format {
+	u32 := func(off int) uint32 {
+		return binary.LittleEndian.Uint32(pf[off:])
+	}
+	flags, cc, bitCount := u32(4), u32(8), u32(12)
+	r, g, b, a := u32(16), u32(20), u32(24), u32(28)
+
+	if flags&ddsPixelFlagFourCC != 0 {
+		switch cc {
+		case fourCC("DXT1"):
+			return FormatBC1RGBAUNorm
+		case fourCC("DXT2"), fourCC("DXT3"):
+			return FormatBC2UNorm
+		case fourCC("DXT4"), fourCC("DXT5"):
+			return FormatBC3UNorm
+		case fourCC("ATI1"), fourCC("BC4U"):
+			return FormatBC4UNorm
+		case fourCC("BC4S"):
+			return FormatBC4SNorm
+		case fourCC("ATI2"), fourCC("BC5U"):
+			return FormatBC5UNorm
+		case fourCC("BC5S"):
+			return FormatBC5SNorm
+		// D3DFORMAT values stored in place of a four character code
+		case 36:
+			return FormatR16G16B16A16UNorm
+		case 111:
+			return FormatR16SFloat
+		case 112:
+			return FormatR16G16SFloat
+		case 113:
+			return FormatR16G16B16A16SFloat
+		case 114:
+			return FormatR32SFloat
+		case 115:
+			return FormatR32G32SFloat
+		case 116:
+			return FormatR32G32B32A32SFloat
+		}
+		return FormatUndefined
+	}
+
+	switch {
+	case flags&ddsPixelFlagRGB != 0 && bitCount == 32:
+		switch {
+		case r == 0xFF && g == 0xFF00 && b == 0xFF0000:
+			return FormatR8G8B8A8UNorm
+		case r == 0xFF0000 && g == 0xFF00 && b == 0xFF:
+			return FormatB8G8R8A8UNorm
+		case r == 0x3FF && g == 0xFFC00 && b == 0x3FF00000:
+			return FormatA2B10G10R10UNorm
+		case r == 0xFFFF && g == 0xFFFF0000 && b == 0 && a == 0:
+			return FormatR16G16UNorm
+		}
+	case flags&ddsPixelFlagRGB != 0 && bitCount == 24:
+		if r == 0xFF0000 && g == 0xFF00 && b == 0xFF {
+			return FormatB8G8R8UNorm
+		}
+	case flags&ddsPixelFlagLuminance != 0 && bitCount == 8 && flags&ddsPixelFlagAlphaPixels == 0:
+		return FormatR8UNorm
+	case flags&ddsPixelFlagLuminance != 0 && bitCount == 16 && flags&ddsPixelFlagAlphaPixels != 0:
+		return FormatR8G8UNorm
+	case flags&ddsPixelFlagLuminance != 0 && bitCount == 16:
+		return FormatR16UNorm
+	}
+
+	return FormatUndefined
+}
+
+/*
+ParseDDS parses f as a DDS file, the returned Texture references f and f must
+not be closed while it is in use.
+*/
+func ParseDDS(f *asset.File, cfg Config) (*Texture, error) {
+	header, err := f.View(0, ddsHeaderSize)
+	if err != nil || !bytes.Equal(header[:len(magicDDS)], magicDDS) {
+		return nil, debug.ErrorWrapf(debug.Errorf("Not a DDS file"), "Failed to parse DDS")
+	}
+
+	u32 := func(b []byte, off int) int {
+		return int(binary.LittleEndian.Uint32(b[off:]))
+	}
+
+	if size := u32(header, 4); size != 124 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid header size %d", size), "Failed to parse DDS")
+	}
+
+	flags := u32(header, 8)
+	caps2 := u32(header, 112)
+	pf := header[76 : 76+32]
+
+	t := Texture{
+		Width:  u32(header, 16),
+		Height: max(1, u32(header, 12)),
+		Depth:  1,
+		Levels: 1,
+		Layers: 1,
+		Faces:  1,
+		file:   f,
+	}
+
+	if flags&ddsFlagMipMapCount != 0 {
+		t.Levels = max(1, u32(header, 28))
+	}
+	if caps2&ddsCaps2Volume != 0 {
+		t.Depth = max(1, u32(header, 24))
+	}
+	if caps2&ddsCaps2CubeMap != 0 {
+		t.Faces = 6
+	}
+
+	dataOffset := ddsHeaderSize
+	if binary.LittleEndian.Uint32(pf[4:])&ddsPixelFlagFourCC != 0 && binary.LittleEndian.Uint32(pf[8:]) == fourCC("DX10") {
+		dx10, err := f.View(ddsHeaderSize, ddsDX10HeaderSize)
+		if err != nil {
+			return nil, debug.ErrorWrapf(debug.Errorf("DX10 header truncated"), "Failed to parse DDS")
+		}
+		dataOffset += ddsDX10HeaderSize
+
+		t.DXGIFormat = uint32(u32(dx10, 0))
+		switch t.DXGIFormat {
+		case dxgiFormatB8G8R8X8UNorm:
+			t.Format = FormatB8G8R8A8UNorm
+		case dxgiFormatB8G8R8X8UNormSRGB:
+			t.Format = FormatB8G8R8A8SRGB
+		default:
+			t.Format = FormatFromDXGI(t.DXGIFormat)
+		}
+
+		t.Layers = max(1, u32(dx10, 12))
+		if u32(dx10, 8)&ddsDX10MiscTextureCube != 0 {
+			t.Faces = 6
+		} else if u32(dx10, 4) != ddsDX10DimensionTexture3D {
+			t.Depth = 1
+		}
+	} else {
+		t.Format = ddsLegacyFormat(pf)
+		t.DXGIFormat = t.Format.DXGIFormat()
+	}
+	t.VkFormat = t.Format.VkFormat()
+
+	if t.Width == 0 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid width 0"), "Failed to parse DDS")
+	}
+	if t.Format == FormatUndefined {
+		// unlike KTX2 there is no level index, without knowing the format
+		// there is no way to find the images
+		return nil, debug.ErrorWrapf(debug.Errorf("Unsupported pixel format"), "Failed to parse DDS")
+	}
+	if maxLevels := bits.Len(uint(max(t.Width, t.Height, t.Depth))); t.Levels > maxLevels {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid level count %d for %dx%dx%d", t.Levels, t.Width, t.Height, t.Depth), "Failed to parse DDS")
+	}
+	if cfg.Validate && t.Faces == 6 && t.Width != t.Height {
+		return nil, debug.ErrorWrapf(debug.Errorf("Cube map faces must be square, got %dx%d", t.Width, t.Height), "Failed to validate DDS")
+	}
+
+	// every image takes at least a byte, which bounds the layer count before
+	// anything is allocated for it
+	if available := f.Size() - dataOffset; t.Layers > available/(t.Levels*t.Faces) {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid layer count %d for %d bytes of data", t.Layers, max(0, available)), "Failed to parse DDS")
+	}
+
+	// each layer/face holds its full mip chain
+	t.images = make([]Region, t.Levels*t.Layers*t.Faces)
+	offset := dataOffset
+	for layer := range t.Layers {
+		for face := range t.Faces {
+			for level := range t.Levels {
+				r := Region{Offset: offset, Size: t.imageSize(level)}
+				if cfg.Validate {
+					if err := t.validateRegion(r, 0, "Failed to validate DDS image [%d, %d, %d]", level, layer, face); err != nil {
+						return nil, err
+					}
+				}
+				t.images[(((level*t.Layers)+layer)*t.Faces)+face] = r
+				offset += r.Size
+			}
+		}
+	}
+
+	if cfg.Validate && offset != f.Size() {
+		return nil, debug.ErrorWrapf(debug.Errorf("Size mismatch, %d bytes of trailing data", f.Size()-offset), "Failed to validate DDS")
+	}
+
+	if t.Layers*t.Faces == 1 {
+		t.levels = make([]Region, t.Levels)
+		copy(t.levels, t.images)
+	}
+
+	return &t, nil
+}
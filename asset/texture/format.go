@@ -0,0 +1,279 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package texture
+
+import "fmt"
+
+/*
+Format is the container independent pixel format, use VkFormat or DXGIFormat
+to get the value a graphics API expects.
+*/
+type Format uint32
+
+const (
+	FormatUndefined Format = iota
+
+	FormatR8UNorm
+	FormatR8SRGB
+	FormatR8G8UNorm
+	FormatR8G8SRGB
+	FormatR8G8B8UNorm
+	FormatR8G8B8SRGB
+	FormatB8G8R8UNorm
+	FormatR8G8B8A8UNorm
+	FormatR8G8B8A8SNorm
+	FormatR8G8B8A8SRGB
+	FormatB8G8R8A8UNorm
+	FormatB8G8R8A8SRGB
+	FormatA2B10G10R10UNorm
+	FormatR16UNorm
+	FormatR16SFloat
+	FormatR16G16UNorm
+	FormatR16G16SFloat
+	FormatR16G16B16A16UNorm
+	FormatR16G16B16A16SFloat
+	FormatR32SFloat
+	FormatR32G32SFloat
+	FormatR32G32B32SFloat
+	FormatR32G32B32A32SFloat
+	FormatB10G11R11UFloat
+	FormatE5B9G9R9UFloat
+
+	FormatBC1RGBUNorm
+	FormatBC1RGBSRGB
+	FormatBC1RGBAUNorm
+	FormatBC1RGBASRGB
+	FormatBC2UNorm
+	FormatBC2SRGB
+	FormatBC3UNorm
+	FormatBC3SRGB
+	FormatBC4UNorm
+	FormatBC4SNorm
+	FormatBC5UNorm
+	FormatBC5SNorm
+	FormatBC6HUFloat
+	FormatBC6HSFloat
+	FormatBC7UNorm
+	FormatBC7SRGB
+
+	FormatETC2R8G8B8UNorm
+	FormatETC2R8G8B8SRGB
+	FormatETC2R8G8B8A1UNorm
+	FormatETC2R8G8B8A1SRGB
+	FormatETC2R8G8B8A8UNorm
+	FormatETC2R8G8B8A8SRGB
+	FormatEACR11UNorm
+	FormatEACR11SNorm
+	FormatEACR11G11UNorm
+	FormatEACR11G11SNorm
+
+	FormatASTC4x4UNorm
+	FormatASTC4x4SRGB
+	FormatASTC5x4UNorm
+	FormatASTC5x4SRGB
+	FormatASTC5x5UNorm
+	FormatASTC5x5SRGB
+	FormatASTC6x5UNorm
+	FormatASTC6x5SRGB
+	FormatASTC6x6UNorm
+	FormatASTC6x6SRGB
+	FormatASTC8x5UNorm
+	FormatASTC8x5SRGB
+	FormatASTC8x6UNorm
+	FormatASTC8x6SRGB
+	FormatASTC8x8UNorm
+	FormatASTC8x8SRGB
+	FormatASTC10x5UNorm
+	FormatASTC10x5SRGB
+	FormatASTC10x6UNorm
+	FormatASTC10x6SRGB
+	FormatASTC10x8UNorm
+	FormatASTC10x8SRGB
+	FormatASTC10x10UNorm
+	FormatASTC10x10SRGB
+	FormatASTC12x10UNorm
+	FormatASTC12x10SRGB
+	FormatASTC12x12UNorm
+	FormatASTC12x12SRGB
+
+	formatCount
+)
+
+/*
+FormatInfo describes the memory layout of a format. Uncompressed formats are
+treated as having 1x1 blocks.
+*/
+type FormatInfo struct {
+	Name        string
+	BlockWidth  int
+	BlockHeight int
+	BlockSize   int
+	Compressed  bool
+	SRGB        bool
+	VkFormat    uint32
+	DXGIFormat  uint32
+}
+
+var formatInfo = [formatCount]FormatInfo{
+	FormatUndefined: {Name: "Undefined"},
+
+	FormatR8UNorm:            {"R8UNorm", 1, 1, 1, false, false, 9, 61},
+	FormatR8SRGB:             {"R8SRGB", 1, 1, 1, false, true, 15, 0},
+	FormatR8G8UNorm:          {"R8G8UNorm", 1, 1, 2, false, false, 16, 49},
+	FormatR8G8SRGB:           {"R8G8SRGB", 1, 1, 2, false, true, 22, 0},
+	FormatR8G8B8UNorm:        {"R8G8B8UNorm", 1, 1, 3, false, false, 23, 0},
+	FormatR8G8B8SRGB:         {"R8G8B8SRGB", 1, 1, 3, false, true, 29, 0},
+	FormatB8G8R8UNorm:        {"B8G8R8UNorm", 1, 1, 3, false, false, 30, 0},
+	FormatR8G8B8A8UNorm:      {"R8G8B8A8UNorm", 1, 1, 4, false, false, 37, 28},
+	FormatR8G8B8A8SNorm:      {"R8G8B8A8SNorm", 1, 1, 4, false, false, 38, 31},
+	FormatR8G8B8A8SRGB:       {"R8G8B8A8SRGB", 1, 1, 4, false, true, 43, 29},
+	FormatB8G8R8A8UNorm:      {"B8G8R8A8UNorm", 1, 1, 4, false, false, 44, 87},
+	FormatB8G8R8A8SRGB:       {"B8G8R8A8SRGB", 1, 1, 4, false, true, 50, 91},
+	FormatA2B10G10R10UNorm:   {"A2B10G10R10UNorm", 1, 1, 4, false, false, 64, 24},
+	FormatR16UNorm:           {"R16UNorm", 1, 1, 2, false, false, 70, 56},
+	FormatR16SFloat:          {"R16SFloat", 1, 1, 2, false, false, 76, 54},
+	FormatR16G16UNorm:        {"R16G16UNorm", 1, 1, 4, false, false, 77, 35},
+	FormatR16G16SFloat:       {"R16G16SFloat", 1, 1, 4, false, false, 83, 34},
+	FormatR16G16B16A16UNorm:  {"R16G16B16A16UNorm", 1, 1, 8, false, false, 91, 11},
+	FormatR16G16B16A16SFloat: {"R16G16B16A16SFloat", 1, 1, 8, false, false, 97, 10},
+	FormatR32SFloat:          {"R32SFloat", 1, 1, 4, false, false, 100, 41},
+	FormatR32G32SFloat:       {"R32G32SFloat", 1, 1, 8, false, false, 103, 16},
+	FormatR32G32B32SFloat:    {"R32G32B32SFloat", 1, 1, 12, false, false, 106, 6},
+	FormatR32G32B32A32SFloat: {"R32G32B32A32SFloat", 1, 1, 16, false, false, 109, 2},
+	FormatB10G11R11UFloat:    {"B10G11R11UFloat", 1, 1, 4, false, false, 122, 26},
+	FormatE5B9G9R9UFloat:     {"E5B9G9R9UFloat", 1, 1, 4, false, false, 123, 67},
+
+	FormatBC1RGBUNorm:  {"BC1RGBUNorm", 4, 4, 8, true, false, 131, 0},
+	FormatBC1RGBSRGB:   {"BC1RGBSRGB", 4, 4, 8, true, true, 132, 0},
+	FormatBC1RGBAUNorm: {"BC1RGBAUNorm", 4, 4, 8, true, false, 133, 71},
+	FormatBC1RGBASRGB:  {"BC1RGBASRGB", 4, 4, 8, true, true, 134, 72},
+	FormatBC2UNorm:     {"BC2UNorm", 4, 4, 16, true, false, 135, 74},
+	FormatBC2SRGB:      {"BC2SRGB", 4, 4, 16, true, true, 136, 75},
+	FormatBC3UNorm:     {"BC3UNorm", 4, 4, 16, true, false, 137, 77},
+	FormatBC3SRGB:      {"BC3SRGB", 4, 4, 16, true, true, 138, 78},
+	FormatBC4UNorm:     {"BC4UNorm", 4, 4, 8, true, false, 139, 80},
+	FormatBC4SNorm:     {"BC4SNorm", 4, 4, 8, true, false, 140, 81},
+	FormatBC5UNorm:     {"BC5UNorm", 4, 4, 16, true, false, 141, 83},
+	FormatBC5SNorm:     {"BC5SNorm", 4, 4, 16, true, false, 142, 84},
+	FormatBC6HUFloat:   {"BC6HUFloat", 4, 4, 16, true, false, 143, 95},
+	FormatBC6HSFloat:   {"BC6HSFloat", 4, 4, 16, true, false, 144, 96},
+	FormatBC7UNorm:     {"BC7UNorm", 4, 4, 16, true, false, 145, 98},
+	FormatBC7SRGB:      {"BC7SRGB", 4, 4, 16, true, true, 146, 99},
+
+	FormatETC2R8G8B8UNorm:   {"ETC2R8G8B8UNorm", 4, 4, 8, true, false, 147, 0},
+	FormatETC2R8G8B8SRGB:    {"ETC2R8G8B8SRGB", 4, 4, 8, true, true, 148, 0},
+	FormatETC2R8G8B8A1UNorm: {"ETC2R8G8B8A1UNorm", 4, 4, 8, true, false, 149, 0},
+	FormatETC2R8G8B8A1SRGB:  {"ETC2R8G8B8A1SRGB", 4, 4, 8, true, true, 150, 0},
+	FormatETC2R8G8B8A8UNorm: {"ETC2R8G8B8A8UNorm", 4, 4, 16, true, false, 151, 0},
+	FormatETC2R8G8B8A8SRGB:  {"ETC2R8G8B8A8SRGB", 4, 4, 16, true, true, 152, 0},
+	FormatEACR11UNorm:       {"EACR11UNorm", 4, 4, 8, true, false, 153, 0},
+	FormatEACR11SNorm:       {"EACR11SNorm", 4, 4, 8, true, false, 154, 0},
+	FormatEACR11G11UNorm:    {"EACR11G11UNorm", 4, 4, 16, true, false, 155, 0},
+	FormatEACR11G11SNorm:    {"EACR11G11SNorm", 4, 4, 16, true, false, 156, 0},
+
+	FormatASTC4x4UNorm:   {"ASTC4x4UNorm", 4, 4, 16, true, false, 157, 0},
+	FormatASTC4x4SRGB:    {"ASTC4x4SRGB", 4, 4, 16, true, true, 158, 0},
+	FormatASTC5x4UNorm:   {"ASTC5x4UNorm", 5, 4, 16, true, false, 159, 0},
+	FormatASTC5x4SRGB:    {"ASTC5x4SRGB", 5, 4, 16, true, true, 160, 0},
+	FormatASTC5x5UNorm:   {"ASTC5x5UNorm", 5, 5, 16, true, false, 161, 0},
+	FormatASTC5x5SRGB:    {"ASTC5x5SRGB", 5, 5, 16, true, true, 162, 0},
+	FormatASTC6x5UNorm:   {"ASTC6x5UNorm", 6, 5, 16, true, false, 163, 0},
+	FormatASTC6x5SRGB:    {"ASTC6x5SRGB", 6, 5, 16, true, true, 164, 0},
+	FormatASTC6x6UNorm:   {"ASTC6x6UNorm", 6, 6, 16, true, false, 165, 0},
+	FormatASTC6x6SRGB:    {"ASTC6x6SRGB", 6, 6, 16, true, true, 166, 0},
+	FormatASTC8x5UNorm:   {"ASTC8x5UNorm", 8, 5, 16, true, false, 167, 0},
+	FormatASTC8x5SRGB:    {"ASTC8x5SRGB", 8, 5, 16, true, true, 168, 0},
+	FormatASTC8x6UNorm:   {"ASTC8x6UNorm", 8, 6, 16, true, false, 169, 0},
+	FormatASTC8x6SRGB:    {"ASTC8x6SRGB", 8, 6, 16, true, true, 170, 0},
+	FormatASTC8x8UNorm:   {"ASTC8x8UNorm", 8, 8, 16, true, false, 171, 0},
+	FormatASTC8x8SRGB:    {"ASTC8x8SRGB", 8, 8, 16, true, true, 172, 0},
+	FormatASTC10x5UNorm:  {"ASTC10x5UNorm", 10, 5, 16, true, false, 173, 0},
+	FormatASTC10x5SRGB:   {"ASTC10x5SRGB", 10, 5, 16, true, true, 174, 0},
+	FormatASTC10x6UNorm:  {"ASTC10x6UNorm", 10, 6, 16, true, false, 175, 0},
+	FormatASTC10x6SRGB:   {"ASTC10x6SRGB", 10, 6, 16, true, true, 176, 0},
+	FormatASTC10x8UNorm:  {"ASTC10x8UNorm", 10, 8, 16, true, false, 177, 0},
+	FormatASTC10x8SRGB:   {"ASTC10x8SRGB", 10, 8, 16, true, true, 178, 0},
+	FormatASTC10x10UNorm: {"ASTC10x10UNorm", 10, 10, 16, true, false, 179, 0},
+	FormatASTC10x10SRGB:  {"ASTC10x10SRGB", 10, 10, 16, true, true, 180, 0},
+	FormatASTC12x10UNorm: {"ASTC12x10UNorm", 12, 10, 16, true, false, 181, 0},
+	FormatASTC12x10SRGB:  {"ASTC12x10SRGB", 12, 10, 16, true, true, 182, 0},
+	FormatASTC12x12UNorm: {"ASTC12x12UNorm", 12, 12, 16, true, false, 183, 0},
+	FormatASTC12x12SRGB:  {"ASTC12x12SRGB", 12, 12, 16, true, true, 184, 0},
+}
+
+/*
+Info returns the layout of f, the zero value is returned for unknown formats.
+*/
+func (f Format) Info() FormatInfo {
+	if f >= formatCount {
+		return FormatInfo{}
+	}
+	return formatInfo[f]
+}
+
+func (f Format) VkFormat() uint32 {
+	return f.Info().VkFormat
+}
+
+func (f Format) DXGIFormat() uint32 {
+	return f.Info().DXGIFormat
+}
+
+/*
+ImageSize returns the size in bytes of a single 2D image of f, or 0 if f is
+unknown.
+*/
+func (f Format) ImageSize(width, height int) int {
+	i := f.Info()
+	if i.BlockSize == 0 {
+		return 0
+	}
+	bw := (width + i.BlockWidth - 1) / i.BlockWidth
+	bh := (height + i.BlockHeight - 1) / i.BlockHeight
+	return bw * bh * i.BlockSize
+}
+
+func FormatFromVk(vk uint32) Format {
+	if vk == 0 {
+		return FormatUndefined
+	}
+	for f := range formatInfo {
+		if formatInfo[f].VkFormat == vk {
+			return Format(f)
+		}
+	}
+	return FormatUndefined
+}
+
+func FormatFromDXGI(dxgi uint32) Format {
+	if dxgi == 0 {
+		return FormatUndefined
+	}
+	for f := range formatInfo {
+		if formatInfo[f].DXGIFormat == dxgi {
+			return Format(f)
+		}
+	}
+	return FormatUndefined
+}
+
+func (f Format) String() string {
+	if i := f.Info(); i.Name != "" {
+		return i.Name
+	}
+	return fmt.Sprintf("Format(%d)", uint32(f))
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/bits"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+)
+
+const (
+	ktx2HeaderSize     = 80
+	ktx2LevelIndexSize = 24
+)
+
+/*
+ParseKTX2 parses f as a KTX2 file, the returned Texture references f and f
+must not be closed while it is in use.
+*/
+func ParseKTX2(f *asset.File, cfg Config) (*Texture, error) {
+	header, err := f.View(0, ktx2HeaderSize)
+	if err != nil || !bytes.Equal(header[:len(magicKTX2)], magicKTX2) {
+		return nil, debug.ErrorWrapf(debug.Errorf("Not a KTX2 file"), "Failed to parse KTX2")
+	}
+
+	u32 := func(off int) int {
+		return int(binary.LittleEndian.Uint32(header[off:]))
+	}
+	u64 := func(b []byte, off int) (int, error) {
+		v := binary.LittleEndian.Uint64(b[off:])
+		if v > math.MaxInt32 && bits.UintSize == 32 {
+			return 0, debug.Errorf("Offset %d too large", v)
+		}
+		return int(v), nil
+	}
+
+	vkFormat := uint32(u32(12))
+	t := Texture{
+		Format:           FormatFromVk(vkFormat),
+		VkFormat:         vkFormat,
+		Width:            u32(20),
+		Height:           max(1, u32(24)),
+		Depth:            max(1, u32(28)),
+		Layers:           max(1, u32(32)),
+		Faces:            u32(36),
+		Levels:           max(1, u32(40)),
+		Supercompression: uint32(u32(44)),
+		DXGIFormat:       FormatFromVk(vkFormat).DXGIFormat(),
+		file:             f,
+	}
+
+	if t.Width == 0 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid width 0"), "Failed to parse KTX2")
+	}
+	if t.Faces != 1 && t.Faces != 6 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid face count %d", t.Faces), "Failed to parse KTX2")
+	}
+	if maxLevels := bits.Len(uint(max(t.Width, t.Height, t.Depth))); t.Levels > maxLevels {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid level count %d for %dx%dx%d", t.Levels, t.Width, t.Height, t.Depth), "Failed to parse KTX2")
+	}
+
+	if cfg.Validate {
+		if t.Faces == 6 && (t.Width != t.Height || t.Depth != 1) {
+			return nil, debug.ErrorWrapf(debug.Errorf("Cube map faces must be square and 2D, got %dx%dx%d", t.Width, t.Height, t.Depth),
+				"Failed to validate KTX2")
+		}
+		if t.Format == FormatUndefined && vkFormat != 0 {
+			return nil, debug.ErrorWrapf(debug.Errorf("Unknown VkFormat %d", vkFormat), "Failed to validate KTX2")
+		}
+	}
+
+	if kvdOffset, kvdLength := u32(56), u32(60); kvdLength > 0 {
+		kvd, err := f.View(kvdOffset, kvdLength)
+		if err != nil {
+			return nil, debug.ErrorWrapf(debug.Errorf("Key/value data truncated"), "Failed to parse KTX2")
+		}
+		t.KeyValues = map[string][]byte{}
+		for len(kvd) >= 4 {
+			n := int(binary.LittleEndian.Uint32(kvd))
+			if n > len(kvd)-4 {
+				return nil, debug.ErrorWrapf(debug.Errorf("Key/value pair truncated"), "Failed to parse KTX2")
+			}
+			kv := kvd[4 : 4+n]
+			if i := bytes.IndexByte(kv, 0); i >= 0 {
+				t.KeyValues[string(kv[:i])] = kv[i+1:]
+			}
+			kvd = kvd[min(len(kvd), (4+n+3)&^3):]
+		}
+	}
+
+	index, err := f.View(ktx2HeaderSize, t.Levels*ktx2LevelIndexSize)
+	if err != nil {
+		return nil, debug.ErrorWrapf(debug.Errorf("Level index truncated"), "Failed to parse KTX2")
+	}
+
+	// every image takes at least a byte after the level index, which bounds
+	// the layer count before anything is allocated for it
+	if available := f.Size() - ktx2HeaderSize - len(index); t.Layers > available/(t.Levels*t.Faces) {
+		return nil, debug.ErrorWrapf(debug.Errorf("Invalid layer count %d for %d bytes of data", t.Layers, max(0, available)), "Failed to parse KTX2")
+	}
+
+	t.levels = make([]Region, t.Levels)
+	if t.Supercompression == 0 {
+		t.images = make([]Region, t.Levels*t.Layers*t.Faces)
+	}
+
+	for level := range t.Levels {
+		e := index[level*ktx2LevelIndexSize:]
+		offset, err := u64(e, 0)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to parse KTX2")
+		}
+		length, err := u64(e, 8)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to parse KTX2")
+		}
+		uncompressedLength, err := u64(e, 16)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to parse KTX2")
+		}
+
+		t.levels[level] = Region{Offset: offset, Size: length}
+
+		if cfg.Validate {
+			want := 0
+			if t.Supercompression == 0 {
+				want = t.imageSize(level) * t.Layers * t.Faces
+				if uncompressedLength != length {
+					return nil, debug.ErrorWrapf(debug.Errorf("Size mismatch, uncompressed size %d != size %d", uncompressedLength, length),
+						"Failed to validate KTX2 level %d", level)
+				}
+			}
+			if err := t.validateRegion(t.levels[level], want, "Failed to validate KTX2 level %d", level); err != nil {
+				return nil, err
+			}
+		}
+
+		if t.images == nil {
+			continue
+		}
+
+		// each level holds every layer, then face, then depth slice
+		count := t.Layers * t.Faces
+		if length%count != 0 {
+			return nil, debug.ErrorWrapf(debug.Errorf("Level size %d is not a multiple of %d images", length, count),
+				"Failed to parse KTX2 level %d", level)
+		}
+		size := length / count
+		for i := range count {
+			t.images[(level*count)+i] = Region{Offset: offset + (i * size), Size: size}
+		}
+	}
+
+	return &t, nil
+}
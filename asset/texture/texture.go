@@ -0,0 +1,201 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package texture parses GPU texture containers without copying their data,
+every image is described by its offset into the mapped *asset.File so it can be
+uploaded directly from File.Uintptr()+Offset.
+*/
+package texture
+
+import (
+	"bytes"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+)
+
+type Config struct {
+	// Validate enables checking that every image's size matches what its
+	// format and dimensions require and that no image extends past the end of
+	// the file. Without it only what is needed to locate the images is read.
+	Validate bool
+}
+
+/*
+Region is a range of bytes within the file, Offset is from the start of the
+file.
+*/
+type Region struct {
+	Offset int
+	Size   int
+}
+
+type Texture struct {
+	// Format is FormatUndefined if the container's format is not one known
+	// to this package, VkFormat or DXGIFormat holds the raw value.
+	Format     Format
+	VkFormat   uint32
+	DXGIFormat uint32
+
+	Width  int
+	Height int
+	// Depth is 1 for non 3D textures.
+	Depth int
+
+	Levels int
+	// Layers is 1 for non array textures.
+	Layers int
+	// Faces is 6 for cube maps and 1 otherwise.
+	Faces int
+
+	// Supercompression is the KTX2 supercompression scheme. When non zero
+	// the images can not be addressed individually and only Level is valid,
+	// the data must be inflated first.
+	Supercompression uint32
+
+	// KeyValues holds the KTX2 key/value data, the values alias the file.
+	KeyValues map[string][]byte
+
+	file   *asset.File
+	levels []Region
+	// images is indexed by [level][layer][face] and holds every depth slice
+	images []Region
+}
+
+var (
+	magicKTX2 = []byte{0xAB, 'K', 'T', 'X', ' ', '2', '0', 0xBB, '\r', '\n', 0x1A, '\n'}
+	magicDDS  = []byte("DDS ")
+)
+
+/*
+Load opens file and parses it as either KTX2 or DDS depending on its magic
+number. The returned Texture owns the file and must be closed.
+*/
+func Load(file string, cfg Config) (*Texture, error) {
+	a, err := asset.Load(file)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load texture")
+	}
+
+	var t *Texture
+	magic, _ := a.View(0, min(a.Size(), len(magicKTX2)))
+
+	switch {
+	case bytes.HasPrefix(magic, magicKTX2):
+		t, err = ParseKTX2(a, cfg)
+	case bytes.HasPrefix(magic, magicDDS):
+		t, err = ParseDDS(a, cfg)
+	default:
+		err = debug.Errorf("Unknown format")
+	}
+
+	if err != nil {
+		a.Close()
+		return nil, debug.ErrorWrapf(err, "Failed to load texture %q", file)
+	}
+	return t, nil
+}
+
+/*
+File returns the file the texture's data lives in.
+*/
+func (t *Texture) File() *asset.File {
+	return t.file
+}
+
+func (t *Texture) Close() error {
+	return t.file.Close()
+}
+
+/*
+LevelSize returns the size in texels of the given mip level.
+*/
+func (t *Texture) LevelSize(level int) (width, height, depth int) {
+	return max(1, t.Width>>level), max(1, t.Height>>level), max(1, t.Depth>>level)
+}
+
+/*
+Level returns the region holding every image of the given mip level. For DDS
+files the images of a level are not contiguous unless there is only a single
+layer and face, Image must be used instead.
+*/
+func (t *Texture) Level(level int) (Region, error) {
+	if level < 0 || level >= t.Levels {
+		return Region{}, debug.Errorf("Level %d out of range [0, %d)", level, t.Levels)
+	}
+	if t.levels == nil {
+		return Region{}, debug.Errorf("Level data is not contiguous")
+	}
+	return t.levels[level], nil
+}
+
+/*
+Image returns the region holding the image for the given level, layer and face,
+including every depth slice of 3D textures.
+*/
+func (t *Texture) Image(level, layer, face int) (Region, error) {
+	if level < 0 || level >= t.Levels || layer < 0 || layer >= t.Layers || face < 0 || face >= t.Faces {
+		return Region{}, debug.Errorf("Image [%d, %d, %d] out of range [%d, %d, %d]", level, layer, face, t.Levels, t.Layers, t.Faces)
+	}
+	if t.images == nil {
+		return Region{}, debug.Errorf("Images are supercompressed")
+	}
+	return t.images[(((level*t.Layers)+layer)*t.Faces)+face], nil
+}
+
+/*
+Bytes returns r's bytes without copying, see asset.File.View.
+*/
+func (t *Texture) Bytes(r Region) ([]byte, error) {
+	b, err := t.file.View(r.Offset, r.Size)
+	return b, debug.ErrorWrapf(err, "Region [%d, %d) out of range", r.Offset, r.Offset+r.Size)
+}
+
+/*
+ImageBytes is a convenience function combining Image and Bytes.
+*/
+func (t *Texture) ImageBytes(level, layer, face int) ([]byte, error) {
+	r, err := t.Image(level, layer, face)
+	if err != nil {
+		return nil, err
+	}
+	return t.Bytes(r)
+}
+
+/*
+imageSize returns the expected size of a single image including all depth
+slices, or 0 if the format is unknown.
+*/
+func (t *Texture) imageSize(level int) int {
+	w, h, d := t.LevelSize(level)
+	return t.Format.ImageSize(w, h) * d
+}
+
+/*
+validateRegion checks that r is within the file and when known that it has the
+expected size.
+*/
+func (t *Texture) validateRegion(r Region, want int, what string, args ...any) error {
+	if r.Offset < 0 || r.Size < 0 || r.Offset > t.file.Size()-r.Size {
+		return debug.ErrorWrapf(debug.Errorf("Truncated, [%d, %d) is past the end of the file at %d", r.Offset, r.Offset+r.Size, t.file.Size()),
+			what, args...)
+	}
+	if want > 0 && r.Size != want {
+		return debug.ErrorWrapf(debug.Errorf("Size mismatch, got %d want %d", r.Size, want), what, args...)
+	}
+	return nil
+}
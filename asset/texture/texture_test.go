@@ -0,0 +1,213 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package texture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"goarrg.com/internal/testutil"
+)
+
+/*
+makeKTX2 builds a 4x4 R8G8B8A8 array texture with 2 layers and a full mip
+chain, every byte of an image is set to (level*16)+layer.
+*/
+func makeKTX2(levelSizes []int) []byte {
+	const layers = 2
+	header := make([]byte, ktx2HeaderSize)
+	copy(header, magicKTX2)
+	le := binary.LittleEndian
+	le.PutUint32(header[12:], FormatR8G8B8A8UNorm.VkFormat())
+	le.PutUint32(header[16:], 1)
+	le.PutUint32(header[20:], 4)
+	le.PutUint32(header[24:], 4)
+	le.PutUint32(header[32:], layers)
+	le.PutUint32(header[36:], 1)
+	le.PutUint32(header[40:], uint32(len(levelSizes)))
+
+	kv := []byte("KTXorientation\x00rd\x00")
+	kvOffset := ktx2HeaderSize + (len(levelSizes) * ktx2LevelIndexSize)
+	le.PutUint32(header[56:], uint32(kvOffset))
+	le.PutUint32(header[60:], uint32(4+len(kv)+1))
+
+	index := make([]byte, len(levelSizes)*ktx2LevelIndexSize)
+	kvd := le.AppendUint32(nil, uint32(len(kv)))
+	kvd = append(kvd, kv...)
+	kvd = append(kvd, 0)
+
+	data := []byte{}
+	offset := kvOffset + len(kvd)
+	for level, size := range levelSizes {
+		e := index[level*ktx2LevelIndexSize:]
+		le.PutUint64(e[0:], uint64(offset+len(data)))
+		le.PutUint64(e[8:], uint64(size*layers))
+		le.PutUint64(e[16:], uint64(size*layers))
+		for layer := range layers {
+			data = append(data, bytes.Repeat([]byte{byte((level * 16) + layer)}, size)...)
+		}
+	}
+
+	return append(append(append(header, index...), kvd...), data...)
+}
+
+func TestKTX2(t *testing.T) {
+	tex, err := Load(testutil.WriteFile(t, t.TempDir(), "test.ktx2", makeKTX2([]int{64, 16, 4})), Config{Validate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tex.Close()
+
+	if tex.Format != FormatR8G8B8A8UNorm || tex.Width != 4 || tex.Height != 4 || tex.Depth != 1 ||
+		tex.Levels != 3 || tex.Layers != 2 || tex.Faces != 1 {
+		t.Fatalf("Wrong texture: %+v", tex)
+	}
+	if v := string(tex.KeyValues["KTXorientation"]); v != "rd\x00" {
+		t.Fatalf("Wrong KTXorientation: %q", v)
+	}
+
+	for level := range tex.Levels {
+		for layer := range tex.Layers {
+			b, err := tex.ImageBytes(level, layer, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := bytes.Repeat([]byte{byte((level * 16) + layer)}, tex.imageSize(level)); !bytes.Equal(b, want) {
+				t.Fatalf("[%d, %d] %v != %v", level, layer, b, want)
+			}
+		}
+	}
+
+	// a level size that doesn't match the format is only caught when validating
+	bad := testutil.WriteFile(t, t.TempDir(), "bad.ktx2", makeKTX2([]int{64, 12}))
+	if _, err := Load(bad, Config{Validate: true}); err == nil {
+		t.Fatal("Expected size mismatch")
+	}
+	if tex, err := Load(bad, Config{}); err != nil {
+		t.Fatal(err)
+	} else {
+		tex.Close()
+	}
+
+	// header counts the data can not hold are rejected before allocating
+	for _, c := range []struct {
+		offset int
+		value  uint32
+	}{{32, 0x7fffffff}, {36, 2}, {36, 0x7fffffff}} {
+		data := makeKTX2([]int{64, 16, 4})
+		binary.LittleEndian.PutUint32(data[c.offset:], c.value)
+		if _, err := Load(testutil.WriteFile(t, t.TempDir(), "huge.ktx2", data), Config{}); err == nil {
+			t.Errorf("Loaded header with %d at %d", c.value, c.offset)
+		}
+	}
+}
+
+func TestDDS(t *testing.T) {
+	// 8x8 DXT1 with 4 mips: 32, 8, 8 and 8 bytes
+	header := make([]byte, ddsHeaderSize)
+	copy(header, magicDDS)
+	le := binary.LittleEndian
+	le.PutUint32(header[4:], 124)
+	le.PutUint32(header[8:], ddsFlagMipMapCount)
+	le.PutUint32(header[12:], 8)
+	le.PutUint32(header[16:], 8)
+	le.PutUint32(header[28:], 4)
+	le.PutUint32(header[76:], 32)
+	le.PutUint32(header[80:], ddsPixelFlagFourCC)
+	copy(header[84:], "DXT1")
+
+	data := header
+	sizes := []int{32, 8, 8, 8}
+	for level, size := range sizes {
+		data = append(data, bytes.Repeat([]byte{byte(level + 1)}, size)...)
+	}
+
+	tex, err := Load(testutil.WriteFile(t, t.TempDir(), "test.dds", data), Config{Validate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tex.Close()
+
+	if tex.Format != FormatBC1RGBAUNorm || tex.Width != 8 || tex.Height != 8 || tex.Levels != 4 || tex.Layers != 1 || tex.Faces != 1 {
+		t.Fatalf("Wrong texture: %+v", tex)
+	}
+	offset := ddsHeaderSize
+	for level, size := range sizes {
+		r, err := tex.Level(level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != (Region{Offset: offset, Size: size}) {
+			t.Fatalf("[%d] %+v != %+v", level, r, Region{Offset: offset, Size: size})
+		}
+		b, err := tex.Bytes(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, bytes.Repeat([]byte{byte(level + 1)}, size)) {
+			t.Fatalf("[%d] Wrong data %v", level, b)
+		}
+		offset += size
+	}
+
+	if _, err := Load(testutil.WriteFile(t, t.TempDir(), "truncated.dds", data[:len(data)-1]), Config{Validate: true}); err == nil {
+		t.Fatal("Expected truncated level")
+	}
+
+	// an array size the data can not hold is rejected before allocating
+	huge := append([]byte(nil), header...)
+	copy(huge[84:], "DX10")
+	dx10 := make([]byte, ddsDX10HeaderSize)
+	le.PutUint32(dx10[0:], 71)
+	le.PutUint32(dx10[4:], 3)
+	le.PutUint32(dx10[12:], 0x7fffffff)
+	huge = append(append(huge, dx10...), data[ddsHeaderSize:]...)
+	if _, err := Load(testutil.WriteFile(t, t.TempDir(), "huge.dds", huge), Config{}); err == nil {
+		t.Fatal("Loaded array size larger than the file")
+	}
+}
+
+func TestWriteKTX2(t *testing.T) {
+	levels := [][]byte{bytes.Repeat([]byte{1}, 4*2*4), bytes.Repeat([]byte{2}, 2*1*4), bytes.Repeat([]byte{3}, 4)}
+	b := bytes.Buffer{}
+	if err := WriteKTX2(&b, FormatR8G8B8A8SRGB, 4, 2, false, levels); err != nil {
+		t.Fatal(err)
+	}
+
+	tex, err := Load(testutil.WriteFile(t, t.TempDir(), "test.ktx2", b.Bytes()), Config{Validate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tex.Close()
+
+	if tex.Format != FormatR8G8B8A8SRGB || tex.Width != 4 || tex.Height != 2 || tex.Levels != 3 {
+		t.Fatalf("Wrong texture: %+v", tex)
+	}
+	for level, want := range levels {
+		got, err := tex.ImageBytes(level, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("[%d] %v != %v", level, got, want)
+		}
+	}
+}
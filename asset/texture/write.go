@@ -0,0 +1,179 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package texture
+
+import (
+	"encoding/binary"
+	"io"
+
+	"goarrg.com/asset/image"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+/*
+WriteKTX2Image writes levels as a 2D KTX2 file, levels[0] being the largest.
+Images with more than 8 bits per component are stored as 16 bit UNorm,
+everything else as 8 bit sRGB or UNorm depending on the color space.
+*/
+func WriteKTX2Image(w io.Writer, levels []*image.Image) error {
+	if len(levels) == 0 {
+		return debug.ErrorWrapf(debug.Errorf("No levels"), "Failed to write KTX2")
+	}
+
+	spec := levels[0].Spec()
+	format := FormatR8G8B8A8UNorm
+	data := make([][]byte, len(levels))
+	for i, l := range levels {
+		switch {
+		case spec.BitDepth > 8:
+			format = FormatR16G16B16A16UNorm
+			for _, p := range image.Convert[color.UNorm[uint16]](l).Pix {
+				data[i] = binary.LittleEndian.AppendUint16(data[i], p.R)
+				data[i] = binary.LittleEndian.AppendUint16(data[i], p.G)
+				data[i] = binary.LittleEndian.AppendUint16(data[i], p.B)
+				data[i] = binary.LittleEndian.AppendUint16(data[i], p.A)
+			}
+		case spec.ColorSpace == image.ColorSpaceSRGB:
+			format = FormatR8G8B8A8SRGB
+			for _, p := range image.Convert[color.SRGB[uint8]](l).Pix {
+				data[i] = append(data[i], p.R, p.G, p.B, p.A)
+			}
+		default:
+			for _, p := range image.Convert[color.UNorm[uint8]](l).Pix {
+				data[i] = append(data[i], p.R, p.G, p.B, p.A)
+			}
+		}
+	}
+	return WriteKTX2(w, format, spec.Width, spec.Height, spec.Premultiplied, data)
+}
+
+/*
+WriteKTX2 writes a 2D KTX2 file with the given mip levels, levels[0] being the
+largest. Only the 8 bit RGBA and 16 bit RGBA UNorm formats are supported.
+*/
+func WriteKTX2(w io.Writer, format Format, width, height int, premultiplied bool, levels [][]byte) error {
+	var bytesPerComponent int
+	switch format {
+	case FormatR8G8B8A8UNorm, FormatR8G8B8A8SRGB:
+		bytesPerComponent = 1
+	case FormatR16G16B16A16UNorm:
+		bytesPerComponent = 2
+	default:
+		return debug.ErrorWrapf(debug.Errorf("Unsupported format %s", format), "Failed to write KTX2")
+	}
+	if width <= 0 || height <= 0 || len(levels) == 0 {
+		return debug.ErrorWrapf(debug.Errorf("Invalid texture %dx%d with %d levels", width, height, len(levels)), "Failed to write KTX2")
+	}
+
+	le := binary.LittleEndian
+	blockSize := format.Info().BlockSize
+
+	// basic data format descriptor, one sample per component
+	dfd := le.AppendUint32(nil, 4+24+(16*4))
+	dfd = le.AppendUint32(dfd, 0)
+	dfd = le.AppendUint16(dfd, 2)
+	dfd = le.AppendUint16(dfd, 24+(16*4))
+	{
+		// RGBSDA, BT709
+		transfer, flags := byte(1), byte(0)
+		if format.Info().SRGB {
+			transfer = 2
+		}
+		if premultiplied {
+			flags = 1
+		}
+		dfd = append(dfd, 1, 1, transfer, flags)
+		dfd = append(dfd, 0, 0, 0, 0)
+		dfd = append(dfd, byte(blockSize), 0, 0, 0, 0, 0, 0, 0)
+	}
+	for i, channel := range []byte{0, 1, 2, 15} {
+		if channel == 15 && format.Info().SRGB {
+			// alpha is always linear
+			channel |= 0x10
+		}
+		bits := bytesPerComponent * 8
+		dfd = le.AppendUint16(dfd, uint16(i*bits))
+		dfd = append(dfd, byte(bits-1), channel, 0, 0, 0, 0)
+		dfd = le.AppendUint32(dfd, 0)
+		dfd = le.AppendUint32(dfd, uint32((1<<bits)-1))
+	}
+
+	header := make([]byte, ktx2HeaderSize+(len(levels)*ktx2LevelIndexSize))
+	copy(header, magicKTX2)
+	le.PutUint32(header[12:], format.VkFormat())
+	le.PutUint32(header[16:], uint32(bytesPerComponent))
+	le.PutUint32(header[20:], uint32(width))
+	le.PutUint32(header[24:], uint32(height))
+	le.PutUint32(header[36:], 1)
+	le.PutUint32(header[40:], uint32(len(levels)))
+	le.PutUint32(header[48:], uint32(len(header)))
+	le.PutUint32(header[52:], uint32(len(dfd)))
+
+	// levels are stored smallest first, each aligned to lcm(blockSize, 4)
+	align := blockSize
+	if align%4 != 0 {
+		align *= 4 / gcd(align, 4)
+	}
+	offset := len(header) + len(dfd)
+	offsets := make([]int, len(levels))
+	for level := len(levels) - 1; level >= 0; level-- {
+		lw, lh := max(1, width>>level), max(1, height>>level)
+		if want := format.ImageSize(lw, lh); len(levels[level]) != want {
+			return debug.ErrorWrapf(debug.Errorf("Size mismatch, got %d want %d", len(levels[level]), want), "Failed to write KTX2 level %d", level)
+		}
+		offset = (offset + align - 1) / align * align
+		offsets[level] = offset
+		offset += len(levels[level])
+	}
+	for level, data := range levels {
+		e := header[ktx2HeaderSize+(level*ktx2LevelIndexSize):]
+		le.PutUint64(e[0:], uint64(offsets[level]))
+		le.PutUint64(e[8:], uint64(len(data)))
+		le.PutUint64(e[16:], uint64(len(data)))
+	}
+
+	written := 0
+	write := func(b []byte) error {
+		n, err := w.Write(b)
+		written += n
+		return err
+	}
+	if err := write(header); err != nil {
+		return debug.ErrorWrapf(err, "Failed to write KTX2")
+	}
+	if err := write(dfd); err != nil {
+		return debug.ErrorWrapf(err, "Failed to write KTX2")
+	}
+	for level := len(levels) - 1; level >= 0; level-- {
+		if err := write(make([]byte, offsets[level]-written)); err != nil {
+			return debug.ErrorWrapf(err, "Failed to write KTX2")
+		}
+		if err := write(levels[level]); err != nil {
+			return debug.ErrorWrapf(err, "Failed to write KTX2")
+		}
+	}
+
+	return nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,615 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package tilemap loads Tiled maps in the .tmx and .tmj formats along with their
+.tsx and .tsj tilesets. Positions are in pixels with +Y pointing down as in
+Tiled, file paths are relative to the root of the file system the map was
+loaded from.
+*/
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+type Orientation int
+
+const (
+	OrientationOrthogonal Orientation = iota
+	OrientationIsometric
+	OrientationStaggered
+	OrientationHexagonal
+)
+
+type Map struct {
+	Orientation Orientation
+	// Width and Height are in tiles, for infinite maps they only cover the
+	// initial view and layers can extend past them.
+	Width      int
+	Height     int
+	TileWidth  int
+	TileHeight int
+	Infinite   bool
+
+	BackgroundColor color.SRGB[uint8]
+	Properties      Properties
+	// Tilesets are sorted by FirstGID.
+	Tilesets []*Tileset
+	// Layers are in draw order, bottom first.
+	Layers []Layer
+}
+
+type LayerType int
+
+const (
+	LayerTile LayerType = iota
+	LayerObject
+	LayerImage
+	LayerGroup
+)
+
+/*
+Layer is any of Tiled's layer types, only the fields matching Type are set.
+*/
+type Layer struct {
+	Type  LayerType
+	ID    int
+	Name  string
+	Class string
+	// Visible, Opacity and Offset are not combined with those of parent
+	// groups.
+	Visible    bool
+	Opacity    float32
+	Offset     gmath.Vector2f[float32]
+	Properties Properties
+
+	Tiles   *TileLayer
+	Objects []Object
+	Image   string
+	Layers  []Layer
+}
+
+/*
+Tile is a global tile ID with Tiled's flip flags in the high bits, 0 is empty.
+*/
+type Tile uint32
+
+const (
+	tileFlippedH           = 0x80000000
+	tileFlippedV           = 0x40000000
+	tileFlippedD           = 0x20000000
+	tileRotatedHex120      = 0x10000000
+	tileFlagsMask     Tile = tileFlippedH | tileFlippedV | tileFlippedD | tileRotatedHex120
+)
+
+func (t Tile) GID() uint32 {
+	return uint32(t &^ tileFlagsMask)
+}
+
+func (t Tile) FlippedHorizontally() bool {
+	return t&tileFlippedH != 0
+}
+
+func (t Tile) FlippedVertically() bool {
+	return t&tileFlippedV != 0
+}
+
+/*
+FlippedDiagonally reports whether x and y are swapped, applied before the
+other flips. For hexagonal maps it is a 60 degree rotation instead.
+*/
+func (t Tile) FlippedDiagonally() bool {
+	return t&tileFlippedD != 0
+}
+
+func (t Tile) RotatedHex120() bool {
+	return t&tileRotatedHex120 != 0
+}
+
+/*
+TileLayer holds the tiles of a layer in row major order, for infinite maps it
+covers the bounding box of all chunks.
+*/
+type TileLayer struct {
+	// X and Y are the map tile coordinates of Tiles[0].
+	X      int
+	Y      int
+	Width  int
+	Height int
+	Tiles  []Tile
+}
+
+/*
+At returns the tile at map tile coordinates x, y or 0 if it is outside the
+layer.
+*/
+func (l *TileLayer) At(x, y int) Tile {
+	x, y = x-l.X, y-l.Y
+	if x < 0 || y < 0 || x >= l.Width || y >= l.Height {
+		return 0
+	}
+	return l.Tiles[(y*l.Width)+x]
+}
+
+/*
+Grid returns a grid matching the layer with cellSize sized cells, cell 0, 0 is
+the layer's first tile and a world position is a Tiled pixel position scaled
+by cellSize/TileWidth horizontally and cellSize/TileHeight vertically. Z spans
+-cellSize/2 to cellSize/2. Layer.Offset is not applied.
+*/
+func (l *TileLayer) Grid(cellSize float32) gmath.Grid[float32, int32] {
+	g := gmath.Grid[float32, int32]{}
+	g.Init(gmath.Vector3i[int32]{X: int32(l.Width), Y: int32(l.Height), Z: 1}, cellSize)
+	g.Pos = gmath.Point3f[float32]{
+		X: (float32(l.X) * cellSize) - g.Bounds.Min.X,
+		Y: (float32(l.Y) * cellSize) - g.Bounds.Min.Y,
+	}
+	return g
+}
+
+/*
+Cell returns the tile at a cell of the layer's Grid, or 0 if it is outside the
+layer.
+*/
+func (l *TileLayer) Cell(c gmath.GridCell[int32]) Tile {
+	return l.At(l.X+int(c.X), l.Y+int(c.Y))
+}
+
+/*
+CellOf returns the Grid cell of map tile coordinates x, y.
+*/
+func (l *TileLayer) CellOf(x, y int) gmath.GridCell[int32] {
+	return gmath.GridCell[int32]{X: int32(x - l.X), Y: int32(y - l.Y)}
+}
+
+type ObjectShape int
+
+const (
+	ShapeRect ObjectShape = iota
+	ShapeEllipse
+	ShapePoint
+	ShapePolygon
+	ShapePolyline
+	ShapeText
+	// ShapeTile objects draw Tile stretched to Rect.
+	ShapeTile
+)
+
+type Object struct {
+	ID    int
+	Name  string
+	Class string
+	Shape ObjectShape
+	// Rect is the object's unrotated bounds from its top left corner, even
+	// for tile objects which Tiled positions from the bottom left. For
+	// polygons and polylines it is the bounding box of Points.
+	Rect gmath.Rect[float32]
+	// Rotation is in degrees clockwise around Tiled's object position, the
+	// top left of Rect or its bottom left for tile objects.
+	Rotation float32
+	// Points are the vertices of polygons and polylines in the same space
+	// as Rect, unrotated.
+	Points     []gmath.Point2f[float32]
+	Tile       Tile
+	Text       string
+	Visible    bool
+	Properties Properties
+}
+
+type Tileset struct {
+	FirstGID uint32
+	// Source is the path of the external tileset file, empty if it is
+	// embedded in the map.
+	Source     string
+	Name       string
+	Class      string
+	TileWidth  int
+	TileHeight int
+	Spacing    int
+	Margin     int
+	TileCount  int
+	Columns    int
+	Offset     gmath.Vector2f[float32]
+	// Image is empty for image collection tilesets, their images are in
+	// Tiles.
+	Image       string
+	ImageWidth  int
+	ImageHeight int
+	Properties  Properties
+	// Tiles holds the tiles that have extra data, keyed by local ID.
+	Tiles map[int]*TileInfo
+}
+
+type TileInfo struct {
+	ID          int
+	Class       string
+	Properties  Properties
+	Image       string
+	ImageWidth  int
+	ImageHeight int
+	Animation   []Frame
+	// Objects are the tile's collision shapes relative to its top left.
+	Objects []Object
+}
+
+type Frame struct {
+	TileID   int
+	Duration time.Duration
+}
+
+/*
+Rect returns the area of a tile within Image, for image collections it is the
+whole of the tile's image.
+*/
+func (t *Tileset) Rect(id int) gmath.Rect[int] {
+	if t.Image == "" || t.Columns <= 0 {
+		if info, ok := t.Tiles[id]; ok {
+			return gmath.Rect[int]{W: info.ImageWidth, H: info.ImageHeight}
+		}
+		return gmath.Rect[int]{}
+	}
+	return gmath.Rect[int]{
+		X: t.Margin + ((id % t.Columns) * (t.TileWidth + t.Spacing)),
+		Y: t.Margin + ((id / t.Columns) * (t.TileHeight + t.Spacing)),
+		W: t.TileWidth,
+		H: t.TileHeight,
+	}
+}
+
+/*
+Tileset returns the tileset of t and t's local ID within it, or nil if t is
+empty or not in any tileset.
+*/
+func (m *Map) Tileset(t Tile) (*Tileset, int) {
+	gid := t.GID()
+	if gid == 0 {
+		return nil, 0
+	}
+	for i := len(m.Tilesets) - 1; i >= 0; i-- {
+		if ts := m.Tilesets[i]; ts.FirstGID <= gid {
+			return ts, int(gid - ts.FirstGID)
+		}
+	}
+	return nil, 0
+}
+
+/*
+Properties maps names to values of type string, int, float64, bool,
+color.SRGB[uint8], File, ObjectRef or Properties for class properties.
+*/
+type Properties map[string]any
+
+/*
+File is a file property, relative to the root of the map's file system.
+*/
+type File string
+
+/*
+ObjectRef is an object property holding the referenced object's ID.
+*/
+type ObjectRef int
+
+func (p Properties) String(name string) (string, bool) {
+	v, ok := p[name].(string)
+	return v, ok
+}
+
+func (p Properties) Int(name string) (int, bool) {
+	v, ok := p[name].(int)
+	return v, ok
+}
+
+/*
+Float returns float and int properties as float64.
+*/
+func (p Properties) Float(name string) (float64, bool) {
+	switch v := p[name].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (p Properties) Bool(name string) (bool, bool) {
+	v, ok := p[name].(bool)
+	return v, ok
+}
+
+/*
+Load loads a .tmx or .tmj map, external tilesets and images are resolved
+relative to it.
+*/
+func Load(file string) (*Map, error) {
+	return LoadFS(asset.DirFS(filepath.Dir(file)), filepath.Base(file))
+}
+
+/*
+LoadFS loads a .tmx or .tmj map from fsys, the format is detected from the
+contents.
+*/
+func LoadFS(fsys fs.FS, name string) (*Map, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load map")
+	}
+
+	l := loader{fsys: fsys, dir: path.Dir(name), tilesets: map[string]*Tileset{}}
+	var m *Map
+	if isXML(data) {
+		m, err = l.loadTMX(data)
+	} else {
+		m, err = l.loadTMJ(data)
+	}
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load map %q", name)
+	}
+	return m, nil
+}
+
+type loader struct {
+	fsys fs.FS
+	dir  string
+	// tilesets caches external tilesets by path, without FirstGID.
+	tilesets map[string]*Tileset
+}
+
+func isXML(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n\xef\xbb\xbf"), []byte("<"))
+}
+
+/*
+resolve returns the path of p, relative to dir, within the file system.
+*/
+func resolve(dir, p string) string {
+	if p == "" {
+		return ""
+	}
+	return path.Join(dir, p)
+}
+
+/*
+externalTileset loads the tileset at source, relative to the map, and returns a
+copy of it with firstGID set.
+*/
+func (l *loader) externalTileset(firstGID uint32, source string) (*Tileset, error) {
+	name := resolve(l.dir, source)
+	ts, ok := l.tilesets[name]
+	if !ok {
+		data, err := fs.ReadFile(l.fsys, name)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to load tileset")
+		}
+		dir := path.Dir(name)
+		if isXML(data) {
+			ts, err = parseTSX(data, dir)
+		} else {
+			ts, err = parseTSJ(data, dir)
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to load tileset %q", name)
+		}
+		l.tilesets[name] = ts
+	}
+	c := *ts
+	c.FirstGID, c.Source = firstGID, name
+	return &c, nil
+}
+
+func sortTilesets(tilesets []*Tileset) {
+	slices.SortStableFunc(tilesets, func(a, b *Tileset) int {
+		return int(int64(a.FirstGID) - int64(b.FirstGID))
+	})
+}
+
+func parseOrientation(s string) (Orientation, error) {
+	switch s {
+	case "", "orthogonal":
+		return OrientationOrthogonal, nil
+	case "isometric":
+		return OrientationIsometric, nil
+	case "staggered":
+		return OrientationStaggered, nil
+	case "hexagonal":
+		return OrientationHexagonal, nil
+	}
+	return 0, debug.Errorf("Unknown orientation %q", s)
+}
+
+/*
+parseColor parses Tiled's #RRGGBB and #AARRGGBB colors, empty is transparent.
+*/
+func parseColor(s string) (color.SRGB[uint8], error) {
+	hex := strings.TrimPrefix(s, "#")
+	if hex == "" {
+		return color.SRGB[uint8]{}, nil
+	}
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return color.SRGB[uint8]{}, debug.Errorf("Invalid color %q", s)
+	}
+	return color.SRGB[uint8]{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: uint8(v >> 24)}, nil
+}
+
+/*
+propertyValue converts a property's string value to its type, dir is used to
+resolve file properties.
+*/
+func propertyValue(typ, value, dir string) (any, error) {
+	switch typ {
+	case "", "string":
+		return value, nil
+	case "int":
+		return strconv.Atoi(value)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "color":
+		return parseColor(value)
+	case "file":
+		return File(resolve(dir, value)), nil
+	case "object":
+		id, err := strconv.Atoi(value)
+		return ObjectRef(id), err
+	}
+	return nil, debug.Errorf("Unknown property type %q", typ)
+}
+
+/*
+decodeTiles decodes the tile data of a layer or chunk holding count tiles.
+*/
+func decodeTiles(encoding, compression, data string, count int) ([]Tile, error) {
+	tiles := make([]Tile, 0, count)
+
+	switch encoding {
+	case "csv":
+		for _, s := range strings.Split(data, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, debug.ErrorWrapf(err, "Invalid tile %q", s)
+			}
+			tiles = append(tiles, Tile(v))
+		}
+
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Invalid base64 tile data")
+		}
+		var r io.Reader
+		switch compression {
+		case "":
+		case "zlib":
+			r, err = zlib.NewReader(bytes.NewReader(raw))
+		case "gzip":
+			r, err = gzip.NewReader(bytes.NewReader(raw))
+		default:
+			return nil, debug.Errorf("Unsupported compression %q", compression)
+		}
+		if err == nil && r != nil {
+			raw, err = io.ReadAll(r)
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Failed to decompress tile data")
+		}
+		if len(raw) != count*4 {
+			return nil, debug.Errorf("Expected %d bytes of tile data, got %d", count*4, len(raw))
+		}
+		for i := 0; i < len(raw); i += 4 {
+			tiles = append(tiles, Tile(binary.LittleEndian.Uint32(raw[i:])))
+		}
+
+	default:
+		return nil, debug.Errorf("Unsupported encoding %q", encoding)
+	}
+
+	if len(tiles) != count {
+		return nil, debug.Errorf("Expected %d tiles, got %d", count, len(tiles))
+	}
+	return tiles, nil
+}
+
+type chunk struct {
+	x, y, width, height int
+	tiles               []Tile
+}
+
+/*
+newTileLayer merges chunks into a layer covering all of them.
+*/
+func newTileLayer(chunks []chunk) *TileLayer {
+	if len(chunks) == 0 {
+		return &TileLayer{}
+	}
+
+	minX, minY := chunks[0].x, chunks[0].y
+	maxX, maxY := minX+chunks[0].width, minY+chunks[0].height
+	for _, c := range chunks[1:] {
+		minX, minY = min(minX, c.x), min(minY, c.y)
+		maxX, maxY = max(maxX, c.x+c.width), max(maxY, c.y+c.height)
+	}
+
+	l := &TileLayer{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+	if len(chunks) == 1 {
+		l.Tiles = chunks[0].tiles
+		return l
+	}
+	l.Tiles = make([]Tile, l.Width*l.Height)
+	for _, c := range chunks {
+		for y := range c.height {
+			row := ((c.y - minY + y) * l.Width) + (c.x - minX)
+			copy(l.Tiles[row:row+c.width], c.tiles[y*c.width:(y+1)*c.width])
+		}
+	}
+	return l
+}
+
+/*
+newObject builds an Object from Tiled's position, size and shape, points are
+relative to x, y.
+*/
+func newObject(x, y, width, height float32, shape ObjectShape, points []gmath.Point2f[float32]) Object {
+	o := Object{Shape: shape, Rect: gmath.Rect[float32]{X: x, Y: y, W: width, H: height}}
+
+	switch shape {
+	case ShapeTile:
+		o.Rect.Y -= height
+	case ShapePoint:
+		o.Rect.W, o.Rect.H = 0, 0
+	case ShapePolygon, ShapePolyline:
+		o.Points = make([]gmath.Point2f[float32], len(points))
+		if len(points) == 0 {
+			o.Rect.W, o.Rect.H = 0, 0
+			break
+		}
+		minX, minY, maxX, maxY := points[0].X, points[0].Y, points[0].X, points[0].Y
+		for i, p := range points {
+			o.Points[i] = gmath.Point2f[float32]{X: x + p.X, Y: y + p.Y}
+			minX, minY = min(minX, p.X), min(minY, p.Y)
+			maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+		}
+		o.Rect = gmath.Rect[float32]{X: x + minX, Y: y + minY, W: maxX - minX, H: maxY - minY}
+	}
+
+	return o
+}
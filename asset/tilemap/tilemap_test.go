@@ -0,0 +1,341 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+	"goarrg.com/internal/testutil"
+)
+
+func encodeTiles(compression string, tiles ...uint32) string {
+	raw := []byte{}
+	for _, t := range tiles {
+		raw = binary.LittleEndian.AppendUint32(raw, t)
+	}
+	b := &bytes.Buffer{}
+	switch compression {
+	case "zlib":
+		w := zlib.NewWriter(b)
+		w.Write(raw)
+		w.Close()
+	case "gzip":
+		w := gzip.NewWriter(b)
+		w.Write(raw)
+		w.Close()
+	default:
+		b.Write(raw)
+	}
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="3" height="2" tilewidth="16" tileheight="16" infinite="0" backgroundcolor="#80ff0000">
+ <editorsettings><export target="a.tmj" format="json"/></editorsettings>
+ <properties>
+  <property name="name" value="test"/>
+  <property name="count" type="int" value="3"/>
+  <property name="speed" type="float" value="1.5"/>
+  <property name="on" type="bool" value="true"/>
+  <property name="tint" type="color" value="#ff00ff00"/>
+  <property name="next" type="file" value="next.tmx"/>
+  <property name="target" type="object" value="2"/>
+  <property name="desc">line1
+line2</property>
+  <property name="spawn" type="class" propertytype="Spawn">
+   <properties><property name="hp" type="int" value="5"/></properties>
+  </property>
+ </properties>
+ <tileset firstgid="5" source="tiles/terrain.tsx"/>
+ <tileset firstgid="1" name="embedded" tilewidth="16" tileheight="16" tilecount="4" columns="2">
+  <image source="img/e.png" width="32" height="32"/>
+ </tileset>
+ <layer id="1" name="ground" width="3" height="2">
+  <data encoding="csv">
+1,2,3,
+4,5,2147483654
+</data>
+ </layer>
+ <group id="2" name="group" offsetx="4" opacity="0.5">
+  <layer id="3" name="zlib" width="3" height="2" visible="0">
+   <data encoding="base64" compression="zlib">ZLIB</data>
+  </layer>
+  <objectgroup id="4" name="objects">
+   <object id="1" name="box" type="wall" x="8" y="16" width="32" height="16" rotation="90"/>
+   <object id="2" x="1" y="2"><point/></object>
+   <object id="3" x="10" y="10"><polygon points="0,0 10,-5 20,5"/></object>
+   <object id="4" gid="5" x="0" y="32" width="16" height="16"/>
+   <object id="5" x="0" y="0" width="50" height="10"><text wrap="1">hi</text></object>
+  </objectgroup>
+ </group>
+ <imagelayer id="5" name="bg"><image source="img/bg.png"/></imagelayer>
+</map>
+`
+
+const testTSX = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" name="terrain" tilewidth="16" tileheight="16" spacing="1" margin="2" tilecount="8" columns="4">
+ <image source="terrain.png" width="70" height="36"/>
+ <tile id="1" type="water">
+  <properties><property name="deep" type="bool" value="true"/></properties>
+  <objectgroup><object id="1" x="0" y="8" width="16" height="8"/></objectgroup>
+  <animation><frame tileid="1" duration="100"/><frame tileid="2" duration="200"/></animation>
+ </tile>
+</tileset>
+`
+
+const testTMJ = `{
+	"orientation": "isometric", "width": 4, "height": 4, "tilewidth": 32, "tileheight": 16, "infinite": true,
+	"properties": [
+		{"name": "count", "type": "int", "value": 3},
+		{"name": "name", "type": "string", "value": "test"},
+		{"name": "tint", "type": "color", "value": "#00ff00"},
+		{"name": "spawn", "type": "class", "propertytype": "Spawn", "value": {"hp": 5, "speed": 1.5, "inner": {"ok": true}}}
+	],
+	"tilesets": [{"firstgid": 1, "source": "terrain.tsj"}],
+	"layers": [
+		{"type": "tilelayer", "id": 1, "name": "chunks", "compression": "gzip", "chunks": [
+			{"x": -4, "y": -2, "width": 4, "height": 2, "data": "GZIP"},
+			{"x": 0, "y": 0, "width": 4, "height": 2, "data": [9, 10, 11, 12, 13, 14, 15, 16]}
+		]},
+		{"type": "tilelayer", "id": 2, "name": "plain", "width": 2, "height": 1, "data": [1, 2], "visible": false},
+		{"type": "objectgroup", "id": 3, "name": "objects", "objects": [
+			{"id": 1, "class": "path", "x": 5, "y": 5, "polyline": [{"x": 0, "y": 0}, {"x": -5, "y": 10}],
+				"properties": [{"name": "loop", "type": "bool", "value": true}]},
+			{"id": 2, "x": 1, "y": 1, "width": 4, "height": 2, "ellipse": true}
+		]}
+	]
+}`
+
+const testTSJ = `{
+	"name": "terrain", "tilewidth": 32, "tileheight": 16, "tilecount": 2, "columns": 0,
+	"tiles": [
+		{"id": 0, "image": "img/a.png", "imagewidth": 32, "imageheight": 20,
+			"animation": [{"tileid": 1, "duration": 50}]},
+		{"id": 1, "image": "img/b.png", "imagewidth": 32, "imageheight": 16}
+	]
+}`
+
+func TestTMX(t *testing.T) {
+	fsys := fstest.MapFS{
+		"maps/a.tmx":             {Data: []byte(strings.Replace(testTMX, "ZLIB", encodeTiles("zlib", 1, 0, 0, 0, 0, 0x40000002), 1))},
+		"maps/tiles/terrain.tsx": {Data: []byte(testTSX)},
+	}
+	m, err := LoadFS(fsys, "maps/a.tmx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Orientation != OrientationOrthogonal || m.Width != 3 || m.Height != 2 || m.TileWidth != 16 || m.Infinite {
+		t.Fatalf("Unexpected map: %+v", m)
+	}
+	if m.BackgroundColor != (color.SRGB[uint8]{R: 255, A: 128}) {
+		t.Fatalf("Unexpected background %v", m.BackgroundColor)
+	}
+
+	wantProps := Properties{
+		"name":   "test",
+		"count":  3,
+		"speed":  1.5,
+		"on":     true,
+		"tint":   color.SRGB[uint8]{G: 255, A: 255},
+		"next":   File("maps/next.tmx"),
+		"target": ObjectRef(2),
+		"desc":   "line1\nline2",
+		"spawn":  Properties{"hp": 5},
+	}
+	if !reflect.DeepEqual(m.Properties, wantProps) {
+		t.Fatalf("Got properties %v want %v", m.Properties, wantProps)
+	}
+	if v, ok := m.Properties.Float("count"); !ok || v != 3 {
+		t.Fatalf("Float(count) = %v, %v", v, ok)
+	}
+
+	if len(m.Tilesets) != 2 || m.Tilesets[0].Name != "embedded" || m.Tilesets[1].Name != "terrain" {
+		t.Fatalf("Unexpected tilesets: %+v", m.Tilesets)
+	}
+	embedded, terrain := m.Tilesets[0], m.Tilesets[1]
+	if embedded.Image != "maps/img/e.png" || embedded.Source != "" {
+		t.Fatalf("Unexpected embedded tileset: %+v", embedded)
+	}
+	if terrain.FirstGID != 5 || terrain.Source != "maps/tiles/terrain.tsx" || terrain.Image != "maps/tiles/terrain.png" {
+		t.Fatalf("Unexpected external tileset: %+v", terrain)
+	}
+	if r := terrain.Rect(5); r != (gmath.Rect[int]{X: 19, Y: 19, W: 16, H: 16}) {
+		t.Fatalf("Unexpected tile rect %v", r)
+	}
+	water := terrain.Tiles[1]
+	if water == nil || water.Class != "water" || water.Properties["deep"] != true ||
+		!reflect.DeepEqual(water.Animation, []Frame{{1, 100 * time.Millisecond}, {2, 200 * time.Millisecond}}) ||
+		len(water.Objects) != 1 || water.Objects[0].Rect != (gmath.Rect[float32]{Y: 8, W: 16, H: 8}) {
+		t.Fatalf("Unexpected tile info: %+v", water)
+	}
+
+	if len(m.Layers) != 3 {
+		t.Fatalf("Expected 3 layers, got %d", len(m.Layers))
+	}
+	ground := m.Layers[0]
+	if ground.Type != LayerTile || !ground.Visible || ground.Opacity != 1 ||
+		!reflect.DeepEqual(ground.Tiles, &TileLayer{Width: 3, Height: 2, Tiles: []Tile{1, 2, 3, 4, 5, 0x80000006}}) {
+		t.Fatalf("Unexpected ground layer: %+v %+v", ground, ground.Tiles)
+	}
+	flipped := ground.Tiles.At(2, 1)
+	if ts, id := m.Tileset(flipped); ts != terrain || id != 1 || !flipped.FlippedHorizontally() || flipped.FlippedVertically() {
+		t.Fatalf("Unexpected tile %x in %v %d", flipped, ts, id)
+	}
+	if ts, id := m.Tileset(ground.Tiles.At(1, 0)); ts != embedded || id != 1 {
+		t.Fatalf("Unexpected tileset %v %d", ts, id)
+	}
+
+	group := m.Layers[1]
+	if group.Type != LayerGroup || group.Opacity != 0.5 || group.Offset.X != 4 || len(group.Layers) != 2 {
+		t.Fatalf("Unexpected group: %+v", group)
+	}
+	zl := group.Layers[0]
+	if zl.Visible || !reflect.DeepEqual(zl.Tiles.Tiles, []Tile{1, 0, 0, 0, 0, 0x40000002}) {
+		t.Fatalf("Unexpected zlib layer: %+v %+v", zl, zl.Tiles)
+	}
+
+	objects := group.Layers[1].Objects
+	want := []Object{
+		{ID: 1, Name: "box", Class: "wall", Rect: gmath.Rect[float32]{X: 8, Y: 16, W: 32, H: 16}, Rotation: 90},
+		{ID: 2, Shape: ShapePoint, Rect: gmath.Rect[float32]{X: 1, Y: 2}},
+		{
+			ID: 3, Shape: ShapePolygon, Rect: gmath.Rect[float32]{X: 10, Y: 5, W: 20, H: 10},
+			Points: []gmath.Point2f[float32]{{X: 10, Y: 10}, {X: 20, Y: 5}, {X: 30, Y: 15}},
+		},
+		{ID: 4, Shape: ShapeTile, Tile: 5, Rect: gmath.Rect[float32]{X: 0, Y: 16, W: 16, H: 16}},
+		{ID: 5, Shape: ShapeText, Text: "hi", Rect: gmath.Rect[float32]{W: 50, H: 10}},
+	}
+	for i := range want {
+		want[i].Visible, want[i].Properties = true, Properties{}
+	}
+	if !reflect.DeepEqual(objects, want) {
+		t.Fatalf("Got objects:\n%+v\nwant:\n%+v", objects, want)
+	}
+
+	if bg := m.Layers[2]; bg.Type != LayerImage || bg.Image != "maps/img/bg.png" {
+		t.Fatalf("Unexpected image layer: %+v", bg)
+	}
+}
+
+func TestTMJ(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"maps/a.tmj":       strings.Replace(testTMJ, "GZIP", encodeTiles("gzip", 1, 2, 3, 4, 5, 6, 7, 8), 1),
+		"maps/terrain.tsj": testTSJ,
+	} {
+		testutil.WriteFile(t, dir, name, []byte(data))
+	}
+
+	m, err := Load(filepath.Join(dir, "maps", "a.tmj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Orientation != OrientationIsometric || !m.Infinite || m.TileWidth != 32 || m.TileHeight != 16 {
+		t.Fatalf("Unexpected map: %+v", m)
+	}
+	wantProps := Properties{
+		"count": 3,
+		"name":  "test",
+		"tint":  color.SRGB[uint8]{G: 255, A: 255},
+		"spawn": Properties{"hp": 5, "speed": 1.5, "inner": Properties{"ok": true}},
+	}
+	if !reflect.DeepEqual(m.Properties, wantProps) {
+		t.Fatalf("Got properties %v want %v", m.Properties, wantProps)
+	}
+
+	ts := m.Tilesets[0]
+	if ts.Source != "terrain.tsj" || ts.Tiles[0].Image != "img/a.png" || ts.Rect(0) != (gmath.Rect[int]{W: 32, H: 20}) ||
+		!reflect.DeepEqual(ts.Tiles[0].Animation, []Frame{{1, 50 * time.Millisecond}}) {
+		t.Fatalf("Unexpected tileset: %+v", ts)
+	}
+
+	chunks := m.Layers[0].Tiles
+	if chunks.X != -4 || chunks.Y != -2 || chunks.Width != 8 || chunks.Height != 4 {
+		t.Fatalf("Unexpected chunk bounds: %+v", chunks)
+	}
+	for _, test := range []struct {
+		x, y int
+		want Tile
+	}{{-4, -2, 1}, {-1, -1, 8}, {0, 0, 9}, {3, 1, 16}, {0, -1, 0}, {-1, 0, 0}, {4, 0, 0}} {
+		if got := chunks.At(test.x, test.y); got != test.want {
+			t.Errorf("At(%d, %d) = %d want %d", test.x, test.y, got, test.want)
+		}
+
+		// world positions are tile positions scaled by the cell size
+		g := chunks.Grid(2)
+		p := gmath.Point3f[float32]{X: (float32(test.x) * 2) + 0.5, Y: (float32(test.y) * 2) + 1.9}
+		cell := g.WorldPosToCell(p)
+		if cell != chunks.CellOf(test.x, test.y) || chunks.Cell(cell) != test.want {
+			t.Errorf("WorldPosToCell(%v) = %v want %v", p, cell, chunks.CellOf(test.x, test.y))
+		}
+		center := gmath.Point3f[float32]{X: (float32(test.x) * 2) + 1, Y: (float32(test.y) * 2) + 1}
+		if got := g.CellToWorldPos(cell); got != center {
+			t.Errorf("CellToWorldPos(%v) = %v want %v", cell, got, center)
+		}
+	}
+
+	if plain := m.Layers[1]; plain.Visible || !reflect.DeepEqual(plain.Tiles.Tiles, []Tile{1, 2}) {
+		t.Fatalf("Unexpected layer: %+v", plain)
+	}
+
+	objects := m.Layers[2].Objects
+	want := []Object{
+		{
+			ID: 1, Class: "path", Shape: ShapePolyline, Rect: gmath.Rect[float32]{X: 0, Y: 5, W: 5, H: 10},
+			Points: []gmath.Point2f[float32]{{X: 5, Y: 5}, {X: 0, Y: 15}}, Properties: Properties{"loop": true},
+		},
+		{ID: 2, Shape: ShapeEllipse, Rect: gmath.Rect[float32]{X: 1, Y: 1, W: 4, H: 2}, Properties: Properties{}},
+	}
+	for i := range want {
+		want[i].Visible = true
+	}
+	if !reflect.DeepEqual(objects, want) {
+		t.Fatalf("Got objects:\n%+v\nwant:\n%+v", objects, want)
+	}
+}
+
+func TestTilemapErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"count":       `{"layers": [{"type": "tilelayer", "width": 2, "height": 2, "data": [1, 2, 3]}]}`,
+		"compression": `<map><layer width="1" height="1"><data encoding="base64" compression="zstd">AAAAAA==</data></layer></map>`,
+		"property":    `<map><properties><property name="a" type="int" value="x"/></properties></map>`,
+		"template":    `<map><objectgroup><object id="1" template="a.tx"/></objectgroup></map>`,
+		"tileset":     `<map><tileset firstgid="1" source="missing.tsx"/></map>`,
+		"orientation": `{"orientation": "spherical"}`,
+	} {
+		if _, err := LoadFS(fstest.MapFS{"a": {Data: []byte(data)}}, "a"); err == nil {
+			t.Errorf("%s: Expected error", name)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tilemap
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+)
+
+type tmjProperty struct {
+	Name  string          `json:"name"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type tmjPoint struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+type tmjObject struct {
+	ID         int           `json:"id"`
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Class      string        `json:"class"`
+	X          float32       `json:"x"`
+	Y          float32       `json:"y"`
+	Width      float32       `json:"width"`
+	Height     float32       `json:"height"`
+	Rotation   float32       `json:"rotation"`
+	GID        uint32        `json:"gid"`
+	Visible    *bool         `json:"visible"`
+	Template   string        `json:"template"`
+	Properties []tmjProperty `json:"properties"`
+	Ellipse    bool          `json:"ellipse"`
+	Point      bool          `json:"point"`
+	Polygon    []tmjPoint    `json:"polygon"`
+	Polyline   []tmjPoint    `json:"polyline"`
+	Text       *struct {
+		Text string `json:"text"`
+	} `json:"text"`
+}
+
+type tmjChunk struct {
+	X      int             `json:"x"`
+	Y      int             `json:"y"`
+	Width  int             `json:"width"`
+	Height int             `json:"height"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type tmjLayer struct {
+	Type        string          `json:"type"`
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Class       string          `json:"class"`
+	Visible     *bool           `json:"visible"`
+	Opacity     *float32        `json:"opacity"`
+	OffsetX     float32         `json:"offsetx"`
+	OffsetY     float32         `json:"offsety"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Properties  []tmjProperty   `json:"properties"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Chunks      []tmjChunk      `json:"chunks"`
+	Objects     []tmjObject     `json:"objects"`
+	Image       string          `json:"image"`
+	Layers      []tmjLayer      `json:"layers"`
+}
+
+type tmjTile struct {
+	ID          int           `json:"id"`
+	Type        string        `json:"type"`
+	Class       string        `json:"class"`
+	Properties  []tmjProperty `json:"properties"`
+	Image       string        `json:"image"`
+	ImageWidth  int           `json:"imagewidth"`
+	ImageHeight int           `json:"imageheight"`
+	Animation   []struct {
+		TileID   int `json:"tileid"`
+		Duration int `json:"duration"`
+	} `json:"animation"`
+	ObjectGroup *tmjLayer `json:"objectgroup"`
+}
+
+type tmjTileset struct {
+	FirstGID    uint32        `json:"firstgid"`
+	Source      string        `json:"source"`
+	Name        string        `json:"name"`
+	Class       string        `json:"class"`
+	TileWidth   int           `json:"tilewidth"`
+	TileHeight  int           `json:"tileheight"`
+	Spacing     int           `json:"spacing"`
+	Margin      int           `json:"margin"`
+	TileCount   int           `json:"tilecount"`
+	Columns     int           `json:"columns"`
+	TileOffset  *tmjPoint     `json:"tileoffset"`
+	Image       string        `json:"image"`
+	ImageWidth  int           `json:"imagewidth"`
+	ImageHeight int           `json:"imageheight"`
+	Properties  []tmjProperty `json:"properties"`
+	Tiles       []tmjTile     `json:"tiles"`
+}
+
+type tmjMap struct {
+	Orientation     string        `json:"orientation"`
+	Width           int           `json:"width"`
+	Height          int           `json:"height"`
+	TileWidth       int           `json:"tilewidth"`
+	TileHeight      int           `json:"tileheight"`
+	Infinite        bool          `json:"infinite"`
+	BackgroundColor string        `json:"backgroundcolor"`
+	Properties      []tmjProperty `json:"properties"`
+	Tilesets        []tmjTileset  `json:"tilesets"`
+	Layers          []tmjLayer    `json:"layers"`
+}
+
+func (l *loader) loadTMJ(data []byte) (*Map, error) {
+	doc := tmjMap{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to parse TMJ")
+	}
+
+	orientation, err := parseOrientation(doc.Orientation)
+	if err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Orientation: orientation,
+		Width:       doc.Width,
+		Height:      doc.Height,
+		TileWidth:   doc.TileWidth,
+		TileHeight:  doc.TileHeight,
+		Infinite:    doc.Infinite,
+	}
+	if m.BackgroundColor, err = parseColor(doc.BackgroundColor); err != nil {
+		return nil, err
+	}
+	if m.Properties, err = parseTMJProperties(doc.Properties, l.dir); err != nil {
+		return nil, err
+	}
+
+	for _, t := range doc.Tilesets {
+		var ts *Tileset
+		if t.Source != "" {
+			ts, err = l.externalTileset(t.FirstGID, t.Source)
+		} else {
+			ts, err = t.parse(l.dir)
+			if ts != nil {
+				ts.FirstGID = t.FirstGID
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	sortTilesets(m.Tilesets)
+
+	if m.Layers, err = parseTMJLayers(doc.Layers, l.dir); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseTSJ(data []byte, dir string) (*Tileset, error) {
+	doc := tmjTileset{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to parse TSJ")
+	}
+	return doc.parse(dir)
+}
+
+/*
+jsonValue converts a value of a class property, which has no type information,
+to the closest property type.
+*/
+func jsonValue(raw json.RawMessage) (any, error) {
+	if bytes.HasPrefix(raw, []byte("{")) {
+		members := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &members); err != nil {
+			return nil, err
+		}
+		props := Properties{}
+		for name, m := range members {
+			v, err := jsonValue(m)
+			if err != nil {
+				return nil, debug.ErrorWrapf(err, "Member %q", name)
+			}
+			props[name] = v
+		}
+		return props, nil
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if f, ok := v.(float64); ok {
+		if i, err := strconv.Atoi(string(raw)); err == nil {
+			return i, nil
+		}
+		return f, nil
+	}
+	return v, nil
+}
+
+func parseTMJProperties(properties []tmjProperty, dir string) (Properties, error) {
+	props := Properties{}
+	for _, p := range properties {
+		var v any
+		var err error
+		switch {
+		case p.Type == "class":
+			v, err = jsonValue(p.Value)
+			if _, ok := v.(Properties); !ok && err == nil {
+				err = debug.Errorf("Expected an object")
+			}
+		case bytes.HasPrefix(p.Value, []byte(`"`)):
+			s := ""
+			if err = json.Unmarshal(p.Value, &s); err == nil {
+				v, err = propertyValue(p.Type, s, dir)
+			}
+		default:
+			v, err = propertyValue(p.Type, string(p.Value), dir)
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Property %q", p.Name)
+		}
+		props[p.Name] = v
+	}
+	return props, nil
+}
+
+func (t *tmjTileset) parse(dir string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:        t.Name,
+		Class:       t.Class,
+		TileWidth:   t.TileWidth,
+		TileHeight:  t.TileHeight,
+		Spacing:     t.Spacing,
+		Margin:      t.Margin,
+		TileCount:   t.TileCount,
+		Columns:     t.Columns,
+		Image:       resolve(dir, t.Image),
+		ImageWidth:  t.ImageWidth,
+		ImageHeight: t.ImageHeight,
+		Tiles:       map[int]*TileInfo{},
+	}
+	if t.TileOffset != nil {
+		ts.Offset = gmath.Vector2f[float32]{X: t.TileOffset.X, Y: t.TileOffset.Y}
+	}
+
+	var err error
+	if ts.Properties, err = parseTMJProperties(t.Properties, dir); err != nil {
+		return nil, err
+	}
+
+	for _, tile := range t.Tiles {
+		info := &TileInfo{
+			ID:          tile.ID,
+			Class:       tile.Class,
+			Image:       resolve(dir, tile.Image),
+			ImageWidth:  tile.ImageWidth,
+			ImageHeight: tile.ImageHeight,
+		}
+		if info.Class == "" {
+			info.Class = tile.Type
+		}
+		if info.Properties, err = parseTMJProperties(tile.Properties, dir); err != nil {
+			return nil, debug.ErrorWrapf(err, "Tile %d", tile.ID)
+		}
+		for _, f := range tile.Animation {
+			info.Animation = append(info.Animation, Frame{TileID: f.TileID, Duration: time.Duration(f.Duration) * time.Millisecond})
+		}
+		if tile.ObjectGroup != nil {
+			if info.Objects, err = parseTMJObjects(tile.ObjectGroup.Objects, dir); err != nil {
+				return nil, debug.ErrorWrapf(err, "Tile %d", tile.ID)
+			}
+		}
+		ts.Tiles[tile.ID] = info
+	}
+
+	return ts, nil
+}
+
+func parseTMJLayers(layers []tmjLayer, dir string) ([]Layer, error) {
+	out := []Layer{}
+	for _, t := range layers {
+		l := Layer{
+			ID:      t.ID,
+			Name:    t.Name,
+			Class:   t.Class,
+			Visible: t.Visible == nil || *t.Visible,
+			Opacity: 1,
+			Offset:  gmath.Vector2f[float32]{X: t.OffsetX, Y: t.OffsetY},
+		}
+		if t.Opacity != nil {
+			l.Opacity = *t.Opacity
+		}
+
+		var err error
+		switch t.Type {
+		case "tilelayer":
+			l.Type = LayerTile
+			l.Tiles, err = t.parseTiles()
+		case "objectgroup":
+			l.Type = LayerObject
+			l.Objects, err = parseTMJObjects(t.Objects, dir)
+		case "imagelayer":
+			l.Type = LayerImage
+			l.Image = resolve(dir, t.Image)
+		case "group":
+			l.Type = LayerGroup
+			l.Layers, err = parseTMJLayers(t.Layers, dir)
+		default:
+			err = debug.Errorf("Unknown layer type %q", t.Type)
+		}
+		if err == nil {
+			l.Properties, err = parseTMJProperties(t.Properties, dir)
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Layer %q", t.Name)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+/*
+decodeTMJTiles decodes data which is either an array of GIDs or a base64 string.
+*/
+func decodeTMJTiles(data json.RawMessage, compression string, count int) ([]Tile, error) {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		s := ""
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return decodeTiles("base64", compression, s, count)
+	}
+
+	tiles := []Tile{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tiles); err != nil {
+			return nil, err
+		}
+	}
+	if len(tiles) != count {
+		return nil, debug.Errorf("Expected %d tiles, got %d", count, len(tiles))
+	}
+	return tiles, nil
+}
+
+func (t *tmjLayer) parseTiles() (*TileLayer, error) {
+	if len(t.Chunks) == 0 {
+		tiles, err := decodeTMJTiles(t.Data, t.Compression, t.Width*t.Height)
+		if err != nil {
+			return nil, err
+		}
+		return newTileLayer([]chunk{{0, 0, t.Width, t.Height, tiles}}), nil
+	}
+
+	chunks := make([]chunk, len(t.Chunks))
+	for i, c := range t.Chunks {
+		tiles, err := decodeTMJTiles(c.Data, t.Compression, c.Width*c.Height)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Chunk %d, %d", c.X, c.Y)
+		}
+		chunks[i] = chunk{c.X, c.Y, c.Width, c.Height, tiles}
+	}
+	return newTileLayer(chunks), nil
+}
+
+func parseTMJObjects(objects []tmjObject, dir string) ([]Object, error) {
+	out := make([]Object, 0, len(objects))
+	for _, t := range objects {
+		if t.Template != "" {
+			return nil, debug.Errorf("Object %d: Templates are not supported", t.ID)
+		}
+
+		shape, points := ShapeRect, t.Polygon
+		switch {
+		case t.GID != 0:
+			shape = ShapeTile
+		case t.Ellipse:
+			shape = ShapeEllipse
+		case t.Point:
+			shape = ShapePoint
+		case t.Polygon != nil:
+			shape = ShapePolygon
+		case t.Polyline != nil:
+			shape, points = ShapePolyline, t.Polyline
+		case t.Text != nil:
+			shape = ShapeText
+		}
+		gpoints := make([]gmath.Point2f[float32], len(points))
+		for i, p := range points {
+			gpoints[i] = gmath.Point2f[float32]{X: p.X, Y: p.Y}
+		}
+
+		o := newObject(t.X, t.Y, t.Width, t.Height, shape, gpoints)
+		o.ID, o.Name, o.Class = t.ID, t.Name, t.Class
+		if o.Class == "" {
+			o.Class = t.Type
+		}
+		o.Rotation, o.Tile = t.Rotation, Tile(t.GID)
+		o.Visible = t.Visible == nil || *t.Visible
+		if t.Text != nil {
+			o.Text = t.Text.Text
+		}
+
+		var err error
+		if o.Properties, err = parseTMJProperties(t.Properties, dir); err != nil {
+			return nil, debug.ErrorWrapf(err, "Object %d", t.ID)
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
@@ -0,0 +1,444 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tilemap
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+)
+
+type tmxProperty struct {
+	Name       string         `xml:"name,attr"`
+	Type       string         `xml:"type,attr"`
+	Value      *string        `xml:"value,attr"`
+	Text       string         `xml:",chardata"`
+	Properties *tmxProperties `xml:"properties"`
+}
+
+type tmxProperties struct {
+	Properties []tmxProperty `xml:"property"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxTiles struct {
+	Text  string `xml:",chardata"`
+	Tiles []struct {
+		GID uint32 `xml:"gid,attr"`
+	} `xml:"tile"`
+}
+
+type tmxChunk struct {
+	X      int `xml:"x,attr"`
+	Y      int `xml:"y,attr"`
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+	tmxTiles
+}
+
+type tmxData struct {
+	Encoding    string     `xml:"encoding,attr"`
+	Compression string     `xml:"compression,attr"`
+	Chunks      []tmxChunk `xml:"chunk"`
+	tmxTiles
+}
+
+type tmxPolygon struct {
+	Points string `xml:"points,attr"`
+}
+
+type tmxObject struct {
+	ID       int           `xml:"id,attr"`
+	Name     string        `xml:"name,attr"`
+	Type     string        `xml:"type,attr"`
+	Class    string        `xml:"class,attr"`
+	X        float32       `xml:"x,attr"`
+	Y        float32       `xml:"y,attr"`
+	Width    float32       `xml:"width,attr"`
+	Height   float32       `xml:"height,attr"`
+	Rotation float32       `xml:"rotation,attr"`
+	GID      uint32        `xml:"gid,attr"`
+	Visible  *int          `xml:"visible,attr"`
+	Template string        `xml:"template,attr"`
+	Props    tmxProperties `xml:"properties"`
+	Ellipse  *struct{}     `xml:"ellipse"`
+	Point    *struct{}     `xml:"point"`
+	Polygon  *tmxPolygon   `xml:"polygon"`
+	Polyline *tmxPolygon   `xml:"polyline"`
+	Text     *struct {
+		Text string `xml:",chardata"`
+	} `xml:"text"`
+}
+
+/*
+tmxLayer is any layer element, XMLName tells which. Map and group children are
+collected with ",any" to keep their order.
+*/
+type tmxLayer struct {
+	XMLName xml.Name
+	ID      int           `xml:"id,attr"`
+	Name    string        `xml:"name,attr"`
+	Class   string        `xml:"class,attr"`
+	Visible *int          `xml:"visible,attr"`
+	Opacity *float32      `xml:"opacity,attr"`
+	OffsetX float32       `xml:"offsetx,attr"`
+	OffsetY float32       `xml:"offsety,attr"`
+	Width   int           `xml:"width,attr"`
+	Height  int           `xml:"height,attr"`
+	Props   tmxProperties `xml:"properties"`
+	Data    *tmxData      `xml:"data"`
+	Objects []tmxObject   `xml:"object"`
+	Image   *tmxImage     `xml:"image"`
+	Layers  []tmxLayer    `xml:",any"`
+}
+
+type tmxTile struct {
+	ID        int           `xml:"id,attr"`
+	Type      string        `xml:"type,attr"`
+	Class     string        `xml:"class,attr"`
+	Props     tmxProperties `xml:"properties"`
+	Image     *tmxImage     `xml:"image"`
+	Animation struct {
+		Frames []struct {
+			TileID   int `xml:"tileid,attr"`
+			Duration int `xml:"duration,attr"`
+		} `xml:"frame"`
+	} `xml:"animation"`
+	ObjectGroup *tmxLayer `xml:"objectgroup"`
+}
+
+type tmxTileset struct {
+	FirstGID   uint32 `xml:"firstgid,attr"`
+	Source     string `xml:"source,attr"`
+	Name       string `xml:"name,attr"`
+	Class      string `xml:"class,attr"`
+	TileWidth  int    `xml:"tilewidth,attr"`
+	TileHeight int    `xml:"tileheight,attr"`
+	Spacing    int    `xml:"spacing,attr"`
+	Margin     int    `xml:"margin,attr"`
+	TileCount  int    `xml:"tilecount,attr"`
+	Columns    int    `xml:"columns,attr"`
+	TileOffset *struct {
+		X float32 `xml:"x,attr"`
+		Y float32 `xml:"y,attr"`
+	} `xml:"tileoffset"`
+	Image *tmxImage     `xml:"image"`
+	Props tmxProperties `xml:"properties"`
+	Tiles []tmxTile     `xml:"tile"`
+}
+
+type tmxMap struct {
+	Orientation     string        `xml:"orientation,attr"`
+	Width           int           `xml:"width,attr"`
+	Height          int           `xml:"height,attr"`
+	TileWidth       int           `xml:"tilewidth,attr"`
+	TileHeight      int           `xml:"tileheight,attr"`
+	Infinite        int           `xml:"infinite,attr"`
+	BackgroundColor string        `xml:"backgroundcolor,attr"`
+	Props           tmxProperties `xml:"properties"`
+	Tilesets        []tmxTileset  `xml:"tileset"`
+	Layers          []tmxLayer    `xml:",any"`
+}
+
+func (l *loader) loadTMX(data []byte) (*Map, error) {
+	doc := tmxMap{}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to parse TMX")
+	}
+
+	orientation, err := parseOrientation(doc.Orientation)
+	if err != nil {
+		return nil, err
+	}
+	m := &Map{
+		Orientation: orientation,
+		Width:       doc.Width,
+		Height:      doc.Height,
+		TileWidth:   doc.TileWidth,
+		TileHeight:  doc.TileHeight,
+		Infinite:    doc.Infinite != 0,
+	}
+	if m.BackgroundColor, err = parseColor(doc.BackgroundColor); err != nil {
+		return nil, err
+	}
+	if m.Properties, err = doc.Props.parse(l.dir); err != nil {
+		return nil, err
+	}
+
+	for _, t := range doc.Tilesets {
+		var ts *Tileset
+		if t.Source != "" {
+			ts, err = l.externalTileset(t.FirstGID, t.Source)
+		} else {
+			ts, err = t.parse(l.dir)
+			if ts != nil {
+				ts.FirstGID = t.FirstGID
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	sortTilesets(m.Tilesets)
+
+	if m.Layers, err = parseTMXLayers(doc.Layers, l.dir); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseTSX(data []byte, dir string) (*Tileset, error) {
+	doc := tmxTileset{}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to parse TSX")
+	}
+	return doc.parse(dir)
+}
+
+func (p *tmxProperties) parse(dir string) (Properties, error) {
+	props := Properties{}
+	for _, prop := range p.Properties {
+		if prop.Type == "class" {
+			var err error
+			class := Properties{}
+			if prop.Properties != nil {
+				if class, err = prop.Properties.parse(dir); err != nil {
+					return nil, debug.ErrorWrapf(err, "Property %q", prop.Name)
+				}
+			}
+			props[prop.Name] = class
+			continue
+		}
+
+		// multiline strings are stored as text
+		value := prop.Text
+		if prop.Value != nil {
+			value = *prop.Value
+		}
+		v, err := propertyValue(prop.Type, value, dir)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Property %q", prop.Name)
+		}
+		props[prop.Name] = v
+	}
+	return props, nil
+}
+
+func (t *tmxTileset) parse(dir string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:       t.Name,
+		Class:      t.Class,
+		TileWidth:  t.TileWidth,
+		TileHeight: t.TileHeight,
+		Spacing:    t.Spacing,
+		Margin:     t.Margin,
+		TileCount:  t.TileCount,
+		Columns:    t.Columns,
+		Tiles:      map[int]*TileInfo{},
+	}
+	if t.TileOffset != nil {
+		ts.Offset = gmath.Vector2f[float32]{X: t.TileOffset.X, Y: t.TileOffset.Y}
+	}
+	if t.Image != nil {
+		ts.Image, ts.ImageWidth, ts.ImageHeight = resolve(dir, t.Image.Source), t.Image.Width, t.Image.Height
+	}
+
+	var err error
+	if ts.Properties, err = t.Props.parse(dir); err != nil {
+		return nil, err
+	}
+
+	for _, tile := range t.Tiles {
+		info := &TileInfo{ID: tile.ID, Class: tile.Class}
+		if info.Class == "" {
+			info.Class = tile.Type
+		}
+		if info.Properties, err = tile.Props.parse(dir); err != nil {
+			return nil, debug.ErrorWrapf(err, "Tile %d", tile.ID)
+		}
+		if tile.Image != nil {
+			info.Image, info.ImageWidth, info.ImageHeight = resolve(dir, tile.Image.Source), tile.Image.Width, tile.Image.Height
+		}
+		for _, f := range tile.Animation.Frames {
+			info.Animation = append(info.Animation, Frame{TileID: f.TileID, Duration: time.Duration(f.Duration) * time.Millisecond})
+		}
+		if tile.ObjectGroup != nil {
+			if info.Objects, err = parseTMXObjects(tile.ObjectGroup.Objects, dir); err != nil {
+				return nil, debug.ErrorWrapf(err, "Tile %d", tile.ID)
+			}
+		}
+		ts.Tiles[tile.ID] = info
+	}
+
+	return ts, nil
+}
+
+func parseTMXLayers(layers []tmxLayer, dir string) ([]Layer, error) {
+	out := []Layer{}
+	for _, t := range layers {
+		l := Layer{
+			ID:      t.ID,
+			Name:    t.Name,
+			Class:   t.Class,
+			Visible: t.Visible == nil || *t.Visible != 0,
+			Opacity: 1,
+			Offset:  gmath.Vector2f[float32]{X: t.OffsetX, Y: t.OffsetY},
+		}
+		if t.Opacity != nil {
+			l.Opacity = *t.Opacity
+		}
+
+		var err error
+		switch t.XMLName.Local {
+		case "layer":
+			l.Type = LayerTile
+			l.Tiles, err = t.parseTiles()
+		case "objectgroup":
+			l.Type = LayerObject
+			l.Objects, err = parseTMXObjects(t.Objects, dir)
+		case "imagelayer":
+			l.Type = LayerImage
+			if t.Image != nil {
+				l.Image = resolve(dir, t.Image.Source)
+			}
+		case "group":
+			l.Type = LayerGroup
+			l.Layers, err = parseTMXLayers(t.Layers, dir)
+		default:
+			continue
+		}
+		if err == nil {
+			l.Properties, err = t.Props.parse(dir)
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Layer %q", t.Name)
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func (t *tmxTiles) decode(encoding, compression string, width, height int) ([]Tile, error) {
+	if encoding == "" {
+		// deprecated <tile gid=""/> elements
+		if len(t.Tiles) != width*height {
+			return nil, debug.Errorf("Expected %d tiles, got %d", width*height, len(t.Tiles))
+		}
+		tiles := make([]Tile, len(t.Tiles))
+		for i, tile := range t.Tiles {
+			tiles[i] = Tile(tile.GID)
+		}
+		return tiles, nil
+	}
+	return decodeTiles(encoding, compression, t.Text, width*height)
+}
+
+func (t *tmxLayer) parseTiles() (*TileLayer, error) {
+	if t.Data == nil {
+		return newTileLayer(nil), nil
+	}
+	d := t.Data
+
+	if len(d.Chunks) == 0 {
+		tiles, err := d.decode(d.Encoding, d.Compression, t.Width, t.Height)
+		if err != nil {
+			return nil, err
+		}
+		return newTileLayer([]chunk{{0, 0, t.Width, t.Height, tiles}}), nil
+	}
+
+	chunks := make([]chunk, len(d.Chunks))
+	for i, c := range d.Chunks {
+		tiles, err := c.decode(d.Encoding, d.Compression, c.Width, c.Height)
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Chunk %d, %d", c.X, c.Y)
+		}
+		chunks[i] = chunk{c.X, c.Y, c.Width, c.Height, tiles}
+	}
+	return newTileLayer(chunks), nil
+}
+
+func parseTMXPoints(s string) ([]gmath.Point2f[float32], error) {
+	points := []gmath.Point2f[float32]{}
+	for _, p := range strings.Fields(s) {
+		xs, ys, ok := strings.Cut(p, ",")
+		x, errX := strconv.ParseFloat(xs, 32)
+		y, errY := strconv.ParseFloat(ys, 32)
+		if !ok || errX != nil || errY != nil {
+			return nil, debug.Errorf("Invalid point %q", p)
+		}
+		points = append(points, gmath.Point2f[float32]{X: float32(x), Y: float32(y)})
+	}
+	return points, nil
+}
+
+func parseTMXObjects(objects []tmxObject, dir string) ([]Object, error) {
+	out := make([]Object, 0, len(objects))
+	for _, t := range objects {
+		if t.Template != "" {
+			return nil, debug.Errorf("Object %d: Templates are not supported", t.ID)
+		}
+
+		shape, points := ShapeRect, []gmath.Point2f[float32](nil)
+		var err error
+		switch {
+		case t.GID != 0:
+			shape = ShapeTile
+		case t.Ellipse != nil:
+			shape = ShapeEllipse
+		case t.Point != nil:
+			shape = ShapePoint
+		case t.Polygon != nil:
+			shape = ShapePolygon
+			points, err = parseTMXPoints(t.Polygon.Points)
+		case t.Polyline != nil:
+			shape = ShapePolyline
+			points, err = parseTMXPoints(t.Polyline.Points)
+		case t.Text != nil:
+			shape = ShapeText
+		}
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Object %d", t.ID)
+		}
+
+		o := newObject(t.X, t.Y, t.Width, t.Height, shape, points)
+		o.ID, o.Name, o.Class = t.ID, t.Name, t.Class
+		if o.Class == "" {
+			o.Class = t.Type
+		}
+		o.Rotation, o.Tile = t.Rotation, Tile(t.GID)
+		o.Visible = t.Visible == nil || *t.Visible != 0
+		if t.Text != nil {
+			o.Text = t.Text.Text
+		}
+		if o.Properties, err = t.Props.parse(dir); err != nil {
+			return nil, debug.ErrorWrapf(err, "Object %d", t.ID)
+		}
+		out = append(out, o)
+	}
+	return out, nil
+}
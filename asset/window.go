@@ -0,0 +1,269 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package asset
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"runtime"
+	"sync"
+
+	"goarrg.com/debug"
+)
+
+type sysFile interface {
+	mapRegion(offset int64, size int) (sysRegion, error)
+	close()
+}
+
+type sysRegion interface {
+	sys
+	prefetch(offset, size int)
+}
+
+const defaultWindowSize = 16 << 20
+
+type WindowConfig struct {
+	// WindowSize is the number of bytes mapped at a time, it is rounded up to a
+	// multiple of the system's allocation granularity. Defaults to 16MiB if <= 0.
+	WindowSize int
+	// ReadAhead is the number of bytes past the end of a window that are
+	// mapped alongside it, the OS is asked to prefetch the bytes following
+	// every read within this range. Reads that only slightly cross a window
+	// boundary will not cause a remap if ReadAhead is large enough.
+	ReadAhead int
+}
+
+/*
+WindowedFile is a file that is mapped one window at a time rather than all at
+once, allowing files larger than the address space to be read on 32 bit targets
+and limiting the address space used by very large files on 64 bit targets.
+Unlike File, WindowedFile is not cached and every call to LoadWindowed returns
+an independent mapping.
+*/
+type WindowedFile struct {
+	info fileinfo
+	cfg  WindowConfig
+	file sysFile
+
+	mtx    sync.Mutex
+	region sysRegion
+	base   int64
+	cursor int64
+}
+
+func LoadWindowed(name string, cfg WindowConfig) (*WindowedFile, error) {
+	logger.VPrintf("Loading [%s] windowed from disk", name)
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load asset %q", name)
+	}
+
+	if info.Size() == 0 {
+		return nil, debug.ErrorWrapf(debug.Errorf("Empty file"), "Failed to load asset %q", name)
+	}
+
+	if err := verifyLoad(name, nil); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load asset %q", name)
+	}
+
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = defaultWindowSize
+	}
+	if cfg.ReadAhead < 0 {
+		cfg.ReadAhead = 0
+	}
+	if g := allocationGranularity(); cfg.WindowSize%g != 0 {
+		cfg.WindowSize += g - (cfg.WindowSize % g)
+	}
+
+	s, err := openFile(name)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load asset %q", name)
+	}
+
+	f := WindowedFile{
+		info: fileinfo{name: name, size: info.Size()},
+		cfg:  cfg,
+		file: s,
+	}
+	runtime.SetFinalizer(&f, (*WindowedFile).Close)
+
+	return &f, nil
+}
+
+func (f *WindowedFile) Name() string {
+	return f.info.name
+}
+
+func (f *WindowedFile) Size() int64 {
+	return f.info.size
+}
+
+func (f *WindowedFile) Stat() (fs.FileInfo, error) {
+	return &f.info, nil
+}
+
+/*
+window returns the mapped bytes starting at off till the end of the current
+window, remapping if off is outside of it. Must be called with f.mtx held.
+*/
+func (f *WindowedFile) window(off int64) ([]byte, error) {
+	if f.region != nil {
+		if b := f.region.bytes(); off >= f.base && off < f.base+int64(len(b)) {
+			return b[off-f.base:], nil
+		}
+		f.region.close()
+		f.region = nil
+	}
+
+	base := off - (off % int64(f.cfg.WindowSize))
+	size := int(min(int64(f.cfg.WindowSize+f.cfg.ReadAhead), f.info.size-base))
+
+	r, err := f.file.mapRegion(base, size)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to map window [%d, %d) of %q", base, base+int64(size), f.info.name)
+	}
+
+	f.region = r
+	f.base = base
+	return r.bytes()[off-base:], nil
+}
+
+func (f *WindowedFile) readAt(b []byte, off int64) (int, error) {
+	if f.file == nil {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, debug.Errorf("Negative offset")
+	}
+	if off >= f.info.size {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for n < len(b) && off < f.info.size {
+		w, err := f.window(off)
+		if err != nil {
+			return n, err
+		}
+		c := copy(b[n:], w)
+		n += c
+		off += int64(c)
+	}
+
+	if f.cfg.ReadAhead > 0 && off < f.info.size {
+		if w := f.region.bytes(); off >= f.base && off < f.base+int64(len(w)) {
+			start := int(off - f.base)
+			f.region.prefetch(start, min(f.cfg.ReadAhead, len(w)-start))
+		}
+	}
+
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *WindowedFile) Len() int64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.cursor >= f.info.size {
+		return 0
+	}
+	return f.info.size - f.cursor
+}
+
+func (f *WindowedFile) Read(b []byte) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	n, err := f.readAt(b, f.cursor)
+	f.cursor += int64(n)
+
+	// io.Reader allows returning n > 0 with a nil error at EOF
+	if n > 0 && err == io.EOF { //nolint:errorlint
+		err = nil
+	}
+	return n, err
+}
+
+func (f *WindowedFile) ReadAt(b []byte, off int64) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	return f.readAt(b, off)
+}
+
+func (f *WindowedFile) ReadByte() (byte, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	b := [1]byte{}
+	if _, err := f.readAt(b[:], f.cursor); err != nil {
+		return 0, err
+	}
+	f.cursor++
+	return b[0], nil
+}
+
+func (f *WindowedFile) Seek(offset int64, whence int) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.cursor
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, debug.Errorf("Invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, debug.Errorf("Negative position")
+	}
+
+	f.cursor = offset
+	return offset, nil
+}
+
+func (f *WindowedFile) Close() error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if f.file == nil {
+		return fs.ErrClosed
+	}
+
+	if f.region != nil {
+		f.region.close()
+		f.region = nil
+	}
+
+	f.file.close()
+	f.file = nil
+	return nil
+}
@@ -41,6 +41,6 @@ var _ Audio = audioNull{}
 
 func (audioNull) AudioConfig() AudioConfig                  { return AudioConfig{} }
 func (audioNull) Init(PlatformInterface, AudioConfig) error { return nil }
-func (audioNull) Mix() (int, audio.Track)                   { return 0, nil }
+func (audioNull) Mix() (int, audio.Track)                   { return 0, audio.Track{} }
 func (audioNull) Update()                                   {}
 func (audioNull) Destroy()                                  {}
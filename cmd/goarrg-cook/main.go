@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+goarrg-cook converts a tree of source assets into runtime assets, only
+rebuilding files whose content, dependencies or outputs changed:
+
+	goarrg-cook [-j n] [-force] [-db file] [-ignore name,...] [-v] -src dir -out dir
+
+Files are handled by the first matching processor, everything else is skipped:
+
+	.png .jpg .jpeg .tga .bmp  converted to .ktx2 with mips
+	.gltf .glb .obj            validated and copied along with the files they reference
+	.wav .ogg .flac            copied
+	.pack                      the files listed in it, one per line relative to the
+	                           .pack, stored uncompressed in a .zip of the same name
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"goarrg.com/debug"
+	"goarrg.com/toolchain"
+	"goarrg.com/toolchain/cook"
+)
+
+func main() {
+	cfg := cook.Config{Processors: processors()}
+	flag.StringVar(&cfg.Src, "src", "", "source asset directory")
+	flag.StringVar(&cfg.Out, "out", "", "output directory")
+	flag.StringVar(&cfg.DB, "db", "", "dependency database, defaults to .goarrg-cook.json in -out")
+	flag.IntVar(&cfg.Jobs, "j", 0, "files processed in parallel, defaults to the number of CPUs")
+	flag.BoolVar(&cfg.Force, "force", false, "rebuild everything")
+	ignore := flag.String("ignore", "", "comma separated paths relative to -src to skip")
+	verbose := flag.Bool("v", false, "print every file")
+	flag.Parse()
+
+	if cfg.Src == "" || cfg.Out == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *ignore != "" {
+		cfg.Ignore = toolchain.IgnoreBlacklist(strings.Split(*ignore, ",")...)
+	}
+	if *verbose {
+		debug.SetLevel(debug.LogLevelVerbose)
+	}
+
+	result, err := cook.Cook(cfg)
+	if result != nil {
+		if *verbose {
+			for _, name := range result.Built {
+				fmt.Println("built", name)
+			}
+			for _, name := range result.Removed {
+				fmt.Println("removed", name)
+			}
+		}
+		fmt.Printf("%d built, %d up to date, %d failed, %d removed\n",
+			len(result.Built), len(result.UpToDate), len(result.Failed), len(result.Removed))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
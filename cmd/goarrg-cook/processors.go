@@ -0,0 +1,170 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"goarrg.com/asset/image"
+	"goarrg.com/asset/mesh"
+	"goarrg.com/asset/texture"
+	"goarrg.com/debug"
+	"goarrg.com/toolchain/cook"
+)
+
+func processors() []cook.Processor {
+	return []cook.Processor{
+		{
+			Name:    "texture",
+			Version: 1,
+			Match:   cook.MatchExt(".png", ".jpg", ".jpeg", ".tga", ".bmp"),
+			Process: cookTexture,
+		},
+		{
+			Name:    "mesh",
+			Version: 1,
+			Match:   cook.MatchExt(".gltf", ".glb", ".obj"),
+			Process: cookMesh,
+		},
+		{
+			Name:    "audio",
+			Version: 1,
+			Match:   cook.MatchExt(".wav", ".ogg", ".flac"),
+			Process: func(j *cook.Job) error {
+				return j.Copy(j.Name)
+			},
+		},
+		{
+			Name:    "pack",
+			Version: 1,
+			Match:   cook.MatchExt(".pack"),
+			Process: cookPack,
+		},
+	}
+}
+
+func replaceExt(name, ext string) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + ext
+}
+
+func cookTexture(j *cook.Job) (err error) {
+	img, err := image.Load(j.Path())
+	if err != nil {
+		return err
+	}
+
+	out, err := j.Create(replaceExt(j.Name, ".ktx2"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, out.Close())
+	}()
+	w := bufio.NewWriter(out)
+	if err := texture.WriteKTX2Image(w, image.GenerateMips(img, image.FilterBox)); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+/*
+cookMesh loads the mesh through the job's file system so that the buffers and
+material libraries it references are recorded, then copies all of them.
+*/
+func cookMesh(j *cook.Job) error {
+	var err error
+	if path.Ext(strings.ToLower(j.Name)) == ".obj" {
+		_, err = mesh.LoadOBJFS(j.FS(), j.Name, mesh.OBJConfig{})
+	} else {
+		_, err = mesh.LoadFS(j.FS(), j.Name)
+	}
+	if err != nil {
+		return err
+	}
+	for _, name := range j.Inputs() {
+		if err := j.Copy(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+cookPack stores the listed files uncompressed so they can be read straight out
+of a mapped pack, paths in the zip are relative to the .pack file so files
+outside its directory are rejected.
+*/
+func cookPack(j *cook.Job) (err error) {
+	list, err := fs.ReadFile(j.FS(), j.Name)
+	if err != nil {
+		return err
+	}
+	dir := path.Dir(j.Name)
+
+	names := []string{}
+	for _, line := range strings.Split(string(list), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := path.Clean(line)
+		if !fs.ValidPath(name) {
+			return debug.Errorf("Invalid path %q", line)
+		}
+		names = append(names, name)
+	}
+
+	out, err := j.Create(replaceExt(j.Name, ".zip"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, out.Close())
+	}()
+	zw := zip.NewWriter(out)
+	for _, name := range names {
+		if err := addToPack(j, zw, path.Join(dir, name), name); err != nil {
+			return debug.ErrorWrapf(err, "Failed to add %q", name)
+		}
+	}
+	return zw.Close()
+}
+
+func addToPack(j *cook.Job, zw *zip.Writer, src, name string) error {
+	src, err := j.Depend(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+goarrg-manifest generates and verifies asset content manifests:
+
+	goarrg-manifest generate [-ignore name,...] -o manifest dir
+	goarrg-manifest verify [-fast] -m manifest dir
+
+The manifest is skipped when it is written inside dir. verify exits with 1 and
+lists every mismatch, -fast only compares sizes.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goarrg.com/asset"
+	"goarrg.com/debug"
+	"goarrg.com/toolchain"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: goarrg-manifest generate|verify [flags] dir")
+	os.Exit(2)
+}
+
+func generate(args []string) (err error) {
+	set := flag.NewFlagSet("generate", flag.ExitOnError)
+	out := set.String("o", "", "output manifest file")
+	ignore := set.String("ignore", "", "comma separated paths relative to dir to skip")
+	_ = set.Parse(args)
+
+	if *out == "" || set.NArg() != 1 {
+		return debug.Errorf("generate requires -o and exactly one directory")
+	}
+	dir := set.Arg(0)
+
+	skip := []string{}
+	if *ignore != "" {
+		skip = strings.Split(*ignore, ",")
+	}
+	if rel, err := filepath.Rel(dir, *out); err == nil && !strings.HasPrefix(rel, "..") {
+		skip = append(skip, rel)
+	}
+	m, err := asset.GenerateManifest(dir, toolchain.IgnoreBlacklist(skip...))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to write %q", *out)
+	}
+	return errors.Join(m.Encode(f), f.Close())
+}
+
+func verify(args []string) error {
+	set := flag.NewFlagSet("verify", flag.ExitOnError)
+	manifest := set.String("m", "", "manifest file")
+	fast := set.Bool("fast", false, "only compare sizes")
+	_ = set.Parse(args)
+
+	if *manifest == "" || set.NArg() != 1 {
+		return debug.Errorf("verify requires -m and exactly one directory")
+	}
+	m, err := asset.LoadManifest(*manifest)
+	if err != nil {
+		return err
+	}
+	mode := asset.VerifyHash
+	if *fast {
+		mode = asset.VerifySize
+	}
+	return m.Verify(set.Arg(0), mode)
+}
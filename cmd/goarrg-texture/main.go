@@ -0,0 +1,268 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+goarrg-texture processes textures as part of an asset build:
+
+	goarrg-texture mips [-filter box|lanczos] [-premultiply] [-force] -o out.ktx2 in
+	goarrg-texture resize -w width -h height [-filter box|lanczos] [-premultiply] [-force] -o out.(png|ktx2) in
+	goarrg-texture atlas [-padding n] [-max n] [-pot] [-mips] [-force] -o out.(png|ktx2) -meta out.json in...
+
+Outputs are only rebuilt when an input is newer than them unless -force is given,
+atlas inputs may be directories in which case sprites are named by their path
+relative to the directory without the extension.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"goarrg.com/asset/image"
+	"goarrg.com/asset/image/atlas"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+	"goarrg.com/toolchain"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "mips":
+		err = mips(os.Args[2:])
+	case "resize":
+		err = resize(os.Args[2:])
+	case "atlas":
+		err = buildAtlas(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: goarrg-texture mips|resize|atlas [flags] inputs...")
+	os.Exit(2)
+}
+
+func parseFilter(s string) (image.Filter, error) {
+	switch strings.ToLower(s) {
+	case "box":
+		return image.FilterBox, nil
+	case "lanczos", "lanczos3":
+		return image.FilterLanczos3, nil
+	default:
+		return 0, debug.Errorf("Unknown filter %q", s)
+	}
+}
+
+/*
+upToDate returns true if every output exists and is newer than every input.
+*/
+func upToDate(inputs []string, outputs ...string) bool {
+	latest := time.Unix(0, 0)
+	for _, in := range inputs {
+		stat, err := os.Stat(in)
+		if err != nil {
+			return false
+		}
+		mod := stat.ModTime()
+		if stat.IsDir() {
+			mod = toolchain.ScanDirModTime(in, nil)
+		}
+		if mod.After(latest) {
+			latest = mod
+		}
+	}
+	for _, out := range outputs {
+		stat, err := os.Stat(out)
+		if err != nil || stat.ModTime().Before(latest) {
+			return false
+		}
+	}
+	return true
+}
+
+func mips(args []string) error {
+	set := flag.NewFlagSet("mips", flag.ExitOnError)
+	filter := set.String("filter", "box", "box or lanczos")
+	premultiply := set.Bool("premultiply", false, "premultiply alpha")
+	force := set.Bool("force", false, "rebuild even if up to date")
+	out := set.String("o", "", "output .ktx2 file")
+	_ = set.Parse(args)
+
+	if *out == "" || set.NArg() != 1 {
+		return debug.Errorf("mips requires -o and exactly one input")
+	}
+	f, err := parseFilter(*filter)
+	if err != nil {
+		return err
+	}
+	if !*force && upToDate(set.Args(), *out) {
+		return nil
+	}
+
+	img, err := image.Load(set.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *premultiply {
+		img = image.Premultiply(img)
+	}
+	return writeImage(*out, image.GenerateMips(img, f))
+}
+
+func resize(args []string) error {
+	set := flag.NewFlagSet("resize", flag.ExitOnError)
+	width := set.Int("w", 0, "output width")
+	height := set.Int("h", 0, "output height, 0 keeps the aspect ratio")
+	filter := set.String("filter", "lanczos", "box or lanczos")
+	premultiply := set.Bool("premultiply", false, "premultiply alpha")
+	force := set.Bool("force", false, "rebuild even if up to date")
+	out := set.String("o", "", "output .png or .ktx2 file")
+	_ = set.Parse(args)
+
+	if *out == "" || set.NArg() != 1 || *width <= 0 || *height < 0 {
+		return debug.Errorf("resize requires -o, -w and exactly one input")
+	}
+	f, err := parseFilter(*filter)
+	if err != nil {
+		return err
+	}
+	if !*force && upToDate(set.Args(), *out) {
+		return nil
+	}
+
+	img, err := image.Load(set.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *height == 0 {
+		*height = max(1, (*width*img.Spec().Height)/img.Spec().Width)
+	}
+	if *premultiply {
+		img = image.Premultiply(img)
+	}
+	return writeImage(*out, []*image.Image{image.Resize(img, *width, *height, f)})
+}
+
+func buildAtlas(args []string) error {
+	set := flag.NewFlagSet("atlas", flag.ExitOnError)
+	cfg := atlas.Config{}
+	set.IntVar(&cfg.Padding, "padding", 1, "pixels of padding around each sprite")
+	set.IntVar(&cfg.MaxSize, "max", 4096, "maximum atlas width and height")
+	set.BoolVar(&cfg.PowerOfTwo, "pot", false, "round the height up to a power of two")
+	withMips := set.Bool("mips", false, "generate mips, requires a .ktx2 output")
+	force := set.Bool("force", false, "rebuild even if up to date")
+	out := set.String("o", "", "output .png or .ktx2 file")
+	meta := set.String("meta", "", "output metadata .json file")
+	_ = set.Parse(args)
+
+	if *out == "" || *meta == "" || set.NArg() == 0 {
+		return debug.Errorf("atlas requires -o, -meta and at least one input")
+	}
+	if !*force && upToDate(set.Args(), *out, *meta) {
+		return nil
+	}
+
+	sprites := []atlas.Sprite{}
+	for _, in := range set.Args() {
+		found, err := collectSprites(in)
+		if err != nil {
+			return err
+		}
+		sprites = append(sprites, found...)
+	}
+
+	// store as 8 bit sRGB, which is what sprites almost always are
+	buf, m, err := atlas.Build[color.SRGB[uint8]](sprites, cfg)
+	if err != nil {
+		return err
+	}
+
+	img := image.New(image.Spec{Alpha: true}, buf)
+	levels := []*image.Image{img}
+	if *withMips {
+		levels = image.GenerateMips(img, image.FilterBox)
+	}
+	if err := writeImage(*out, levels); err != nil {
+		return err
+	}
+
+	f, err := os.Create(*meta)
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to write %q", *meta)
+	}
+	return errors.Join(m.Encode(f), f.Close())
+}
+
+func collectSprites(in string) ([]atlas.Sprite, error) {
+	stat, err := os.Stat(in)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to collect sprites")
+	}
+
+	if !stat.IsDir() {
+		img, err := image.Load(in)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(in)
+		return []atlas.Sprite{{Name: strings.TrimSuffix(name, filepath.Ext(name)), Image: img}}, nil
+	}
+
+	sprites := []atlas.Sprite{}
+	err = filepath.WalkDir(in, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".png", ".jpg", ".jpeg", ".bmp", ".tga":
+		default:
+			return nil
+		}
+		img, err := image.Load(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(in, path)
+		if err != nil {
+			return err
+		}
+		sprites = append(sprites, atlas.Sprite{
+			Name:  filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel))),
+			Image: img,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to collect sprites from %q", in)
+	}
+	return sprites, nil
+}
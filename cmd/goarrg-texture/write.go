@@ -0,0 +1,97 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	stdimage "image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"goarrg.com/asset/image"
+	"goarrg.com/asset/texture"
+	"goarrg.com/debug"
+	"goarrg.com/gmath/color"
+)
+
+/*
+writeImage writes levels to name based on its extension, only KTX2 can hold
+more than a single level or premultiplied alpha.
+*/
+func writeImage(name string, levels []*image.Image) (err error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	spec := levels[0].Spec()
+
+	if ext != ".ktx2" {
+		if ext != ".png" {
+			return debug.Errorf("Unknown output format %q", ext)
+		}
+		if len(levels) > 1 || spec.Premultiplied {
+			return debug.Errorf("PNG can not hold mips or premultiplied alpha, use .ktx2")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return debug.ErrorWrapf(err, "Failed to write %q", name)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to write %q", name)
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+		if err != nil {
+			// don't leave a partial file that looks up to date
+			os.Remove(name)
+		}
+	}()
+	w := bufio.NewWriter(f)
+	defer func() {
+		err = errors.Join(err, w.Flush())
+	}()
+
+	if ext == ".png" {
+		return debug.ErrorWrapf(encodePNG(w, levels[0]), "Failed to write %q", name)
+	}
+
+	return debug.ErrorWrapf(texture.WriteKTX2Image(w, levels), "Failed to write %q", name)
+}
+
+func encodePNG(w *bufio.Writer, img *image.Image) error {
+	spec := img.Spec()
+	rect := stdimage.Rect(0, 0, spec.Width, spec.Height)
+
+	if spec.BitDepth > 8 {
+		out := stdimage.NewNRGBA64(rect)
+		for i, p := range image.Convert[color.SRGB[uint16]](img).Pix {
+			out.Pix[(i*8)+0], out.Pix[(i*8)+1] = byte(p.R>>8), byte(p.R)
+			out.Pix[(i*8)+2], out.Pix[(i*8)+3] = byte(p.G>>8), byte(p.G)
+			out.Pix[(i*8)+4], out.Pix[(i*8)+5] = byte(p.B>>8), byte(p.B)
+			out.Pix[(i*8)+6], out.Pix[(i*8)+7] = byte(p.A>>8), byte(p.A)
+		}
+		return png.Encode(w, out)
+	}
+
+	out := stdimage.NewNRGBA(rect)
+	for i, p := range image.Convert[color.SRGB[uint8]](img).Pix {
+		copy(out.Pix[i*4:], []byte{p.R, p.G, p.B, p.A})
+	}
+	return png.Encode(w, out)
+}
@@ -4,6 +4,7 @@ go 1.24.0
 
 require (
 	golang.org/x/exp v0.0.0-20251125195548-87e1e737ad39
+	golang.org/x/image v0.33.0
 	golang.org/x/sys v0.38.0
 	golang.org/x/tools v0.39.0
 )
@@ -11,4 +12,5 @@ require (
 require (
 	golang.org/x/mod v0.30.0 // indirect
 	golang.org/x/sync v0.18.0 // indirect
+	golang.org/x/text v0.31.0 // indirect
 )
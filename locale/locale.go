@@ -0,0 +1,273 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package locale translates strings using tables loaded from JSON or gettext PO
+files. A Bundle holds the active locale and its fallback chain, which can be
+swapped at any time while other goroutines are translating.
+
+Messages use positional arguments, "{0} has {1} apples", with "{{" and "}}"
+for literal braces. In development and debug builds keys missing from every
+table in the chain are logged once each.
+*/
+package locale
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"goarrg.com/debug"
+)
+
+/*
+Table holds the messages of a single locale.
+*/
+type Table struct {
+	Tag      string
+	Messages map[string]Message
+}
+
+/*
+Message holds the text for each plural category, messages without plurals only
+set PluralOther.
+*/
+type Message [pluralCount]string
+
+/*
+Bundle loads tables named after their locale tag, e.g. "fr-CA.json" or
+"fr_CA.po", from a directory of a file system.
+*/
+type Bundle struct {
+	fsys     fs.FS
+	dir      string
+	fallback string
+	active   atomic.Pointer[chain]
+}
+
+type chain struct {
+	tag    string
+	tables []*Table
+}
+
+/*
+NewBundle returns a Bundle loading tables from dir within fsys, fallback is the
+last locale of every chain, usually "en". No locale is active until SetLocale
+is called.
+*/
+func NewBundle(fsys fs.FS, dir, fallback string) *Bundle {
+	b := &Bundle{fsys: fsys, dir: dir, fallback: normalizeTag(fallback)}
+	b.active.Store(&chain{})
+	return b
+}
+
+/*
+Chain returns the locales looked up for tag in order, each subtag is dropped in
+turn and fallback comes last.
+*/
+func Chain(tag, fallback string) []string {
+	tags := []string{}
+	for tag = normalizeTag(tag); tag != ""; {
+		tags = append(tags, tag)
+		i := strings.LastIndexByte(tag, '-')
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	if fallback = normalizeTag(fallback); fallback != "" {
+		for _, t := range Chain(fallback, "") {
+			if !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+	}
+	return tags
+}
+
+/*
+normalizeTag converts "fr_ca" and "fr-ca" to "fr-CA", script subtags are title
+cased and anything after a '.' or '@' as in POSIX locales is dropped.
+*/
+func normalizeTag(tag string) string {
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	parts := strings.FieldsFunc(tag, func(r rune) bool { return r == '-' || r == '_' })
+	for i, p := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(p)
+		case len(p) == 2:
+			parts[i] = strings.ToUpper(p)
+		case len(p) == 4:
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+/*
+SetLocale loads the tables of tag's chain and makes them active, tables that
+don't exist are skipped but at least one must. The files are read again on
+every call so it also reloads the current locale.
+*/
+func (b *Bundle) SetLocale(tag string) error {
+	c := &chain{tag: normalizeTag(tag)}
+	for _, t := range Chain(tag, b.fallback) {
+		table, err := b.load(t)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return debug.ErrorWrapf(err, "Failed to set locale %q", tag)
+		}
+		c.tables = append(c.tables, table)
+	}
+	if len(c.tables) == 0 {
+		return debug.ErrorWrapf(debug.Errorf("No tables found for %v", Chain(tag, b.fallback)), "Failed to set locale %q", tag)
+	}
+	b.active.Store(c)
+	return nil
+}
+
+/*
+Locale returns the tag passed to the last successful SetLocale, normalized.
+*/
+func (b *Bundle) Locale() string {
+	return b.active.Load().tag
+}
+
+func (b *Bundle) load(tag string) (*Table, error) {
+	underscore := strings.ReplaceAll(tag, "-", "_")
+	var notFound error
+	for _, name := range []string{tag + ".json", tag + ".po", underscore + ".json", underscore + ".po"} {
+		table, err := LoadFS(b.fsys, path.Join(b.dir, name))
+		if err == nil {
+			table.Tag = tag
+			return table, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		notFound = err
+	}
+	return nil, notFound
+}
+
+/*
+LoadFS loads a table from a .json or .po file, the tag is taken from the file
+name.
+*/
+func LoadFS(fsys fs.FS, name string) (*Table, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load string table")
+	}
+
+	base := path.Base(name)
+	t := &Table{Tag: normalizeTag(strings.TrimSuffix(base, path.Ext(base)))}
+	switch path.Ext(name) {
+	case ".json":
+		t.Messages, err = parseJSON(data)
+	case ".po":
+		t.Messages, err = parsePO(data, t.Tag)
+	default:
+		err = debug.Errorf("Unknown format")
+	}
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load string table %q", name)
+	}
+	return t, nil
+}
+
+/*
+T translates key and formats it with args.
+*/
+func (b *Bundle) T(key string, args ...any) string {
+	return b.translate(key, false, 0, args)
+}
+
+/*
+N translates key choosing the plural form for n and formats it with args, n is
+only used to pick the form so pass it in args to display it.
+*/
+func (b *Bundle) N(key string, n int, args ...any) string {
+	return b.translate(key, true, n, args)
+}
+
+func (b *Bundle) translate(key string, plural bool, n int, args []any) string {
+	c := b.active.Load()
+	for _, t := range c.tables {
+		if m, ok := t.Messages[key]; ok {
+			s := m[PluralOther]
+			if plural {
+				if form := m[PluralCategory(t.Tag, n)]; form != "" {
+					s = form
+				}
+			}
+			return Format(s, args...)
+		}
+	}
+	reportMissing(c.tag, key)
+	return Format(key, args...)
+}
+
+/*
+Format replaces "{i}" in s with the i-th argument, indices without arguments
+are kept as is.
+*/
+func Format(s string, args ...any) string {
+	if !strings.ContainsAny(s, "{}") {
+		return s
+	}
+
+	var b strings.Builder
+	for len(s) > 0 {
+		i := strings.IndexAny(s, "{}")
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		if len(s) > 1 && s[1] == s[0] {
+			b.WriteByte(s[0])
+			s = s[2:]
+			continue
+		}
+		if s[0] == '{' {
+			if end := strings.IndexByte(s, '}'); end > 0 {
+				if idx, err := strconv.Atoi(s[1:end]); err == nil && idx >= 0 && idx < len(args) {
+					b.WriteString(fmt.Sprint(args[idx]))
+					s = s[end+1:]
+					continue
+				}
+			}
+		}
+		b.WriteByte(s[0])
+		s = s[1:]
+	}
+	return b.String()
+}
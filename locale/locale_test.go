@@ -0,0 +1,248 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package locale
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"strings/en.json": {Data: []byte(`{
+	"hello": "Hello {0}",
+	"bye": "Goodbye",
+	"only_en": "English only",
+	"apples": {"one": "{0} apple", "other": "{0} apples"}
+}`)},
+	"strings/fr.json": {Data: []byte(`{
+	"hello": "Bonjour {0}",
+	"bye": "Au revoir",
+	"apples": {"one": "{0} pomme", "other": "{0} pommes"}
+}`)},
+	"strings/fr_CA.po": {Data: []byte(`# French (Canada)
+msgid ""
+msgstr ""
+"Language: fr_CA\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+#, fuzzy
+msgid "hello"
+msgstr "Allo {0}"
+
+msgid "bye"
+msgstr ""
+"Bye "
+"{{là}}"
+
+msgctxt "menu"
+msgid "quit"
+msgstr "Quitter"
+`)},
+	"strings/ru.po": {Data: []byte(`msgid "apples"
+msgid_plural "apples"
+msgstr[0] "{0} яблоко"
+msgstr[1] "{0} яблока"
+msgstr[2] "{0} яблок"
+
+msgid "untranslated"
+msgid_plural "untranslated"
+msgstr[0] "x"
+msgstr[1] ""
+msgstr[2] ""
+`)},
+	"strings/bad.json": {Data: []byte(`{"apples": {"one": "x"}}`)},
+	"strings/bad.po":   {Data: []byte("msgstr[1] \"x\"\n")},
+	"broken/en.json":   {Data: []byte(`{`)},
+}
+
+func TestChain(t *testing.T) {
+	tests := []struct {
+		tag, fallback string
+		want          []string
+	}{
+		{"fr-CA", "en", []string{"fr-CA", "fr", "en"}},
+		{"fr_ca.UTF-8", "en", []string{"fr-CA", "fr", "en"}},
+		{"zh-hant-tw", "en-US", []string{"zh-Hant-TW", "zh-Hant", "zh", "en-US", "en"}},
+		{"en-GB", "en", []string{"en-GB", "en"}},
+		{"", "en", []string{"en"}},
+	}
+	for _, test := range tests {
+		if got := Chain(test.tag, test.fallback); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Chain(%q, %q) = %v, want %v", test.tag, test.fallback, got, test.want)
+		}
+	}
+}
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want map[int]Plural
+	}{
+		{"en", map[int]Plural{0: PluralOther, 1: PluralOne, 2: PluralOther, 11: PluralOther}},
+		{"fr-CA", map[int]Plural{0: PluralOne, 1: PluralOne, 2: PluralOther}},
+		{"pt-PT", map[int]Plural{0: PluralOther, 1: PluralOne}},
+		{"ru", map[int]Plural{1: PluralOne, 2: PluralFew, 5: PluralMany, 11: PluralMany, 12: PluralMany, 21: PluralOne, 22: PluralFew, 111: PluralMany}},
+		{"pl", map[int]Plural{1: PluralOne, 2: PluralFew, 21: PluralMany, 22: PluralFew, 25: PluralMany}},
+		{"cs", map[int]Plural{1: PluralOne, 3: PluralFew, 5: PluralOther}},
+		{"ar", map[int]Plural{0: PluralZero, 1: PluralOne, 2: PluralTwo, 3: PluralFew, 11: PluralMany, 100: PluralOther, 102: PluralOther, 103: PluralFew}},
+		{"ja", map[int]Plural{0: PluralOther, 1: PluralOther, 2: PluralOther}},
+	}
+	for _, test := range tests {
+		for n, want := range test.want {
+			if got := PluralCategory(test.tag, n); got != want {
+				t.Errorf("PluralCategory(%q, %d) = %v, want %v", test.tag, n, got, want)
+			}
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		s    string
+		args []any
+		want string
+	}{
+		{"{0} has {1} apples", []any{"Ann", 3}, "Ann has 3 apples"},
+		{"{1} before {0}", []any{"a", "b"}, "b before a"},
+		{"{{0}} {0}", []any{"x"}, "{0} x"},
+		{"{2} {x} {", []any{"a"}, "{2} {x} {"},
+		{"}}", nil, "}"},
+	}
+	for _, test := range tests {
+		if got := Format(test.s, test.args...); got != test.want {
+			t.Errorf("Format(%q, %v) = %q, want %q", test.s, test.args, got, test.want)
+		}
+	}
+}
+
+func TestLoadPO(t *testing.T) {
+	table, err := LoadFS(testFS, "strings/fr_CA.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Tag != "fr-CA" {
+		t.Errorf("Tag = %q", table.Tag)
+	}
+	want := map[string]Message{
+		"bye":          {PluralOther: "Bye {{là}}"},
+		"menu\x04quit": {PluralOther: "Quitter"},
+	}
+	if !reflect.DeepEqual(table.Messages, want) {
+		t.Errorf("Messages = %q, want %q", table.Messages, want)
+	}
+
+	table, err = LoadFS(testFS, "strings/ru.po")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]Message{
+		"apples": {PluralOne: "{0} яблоко", PluralFew: "{0} яблока", PluralMany: "{0} яблок", PluralOther: "{0} яблок"},
+	}
+	if !reflect.DeepEqual(table.Messages, want) {
+		t.Errorf("Messages = %q, want %q", table.Messages, want)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	b := NewBundle(testFS, "strings", "en")
+	if got := b.T("hello", "Ann"); got != "hello" {
+		t.Errorf("T before SetLocale = %q", got)
+	}
+
+	if err := b.SetLocale("fr_CA"); err != nil {
+		t.Fatal(err)
+	}
+	if b.Locale() != "fr-CA" {
+		t.Errorf("Locale() = %q", b.Locale())
+	}
+	tests := []struct {
+		got, want string
+	}{
+		{b.T("bye"), "Bye {là}"},
+		{b.T("hello", "Ann"), "Bonjour Ann"},
+		{b.T("only_en"), "English only"},
+		{b.T("missing {0}", 1), "missing 1"},
+		{b.N("apples", 0, 0), "0 pomme"},
+		{b.N("apples", 2, 2), "2 pommes"},
+		{b.T("apples", 2), "2 pommes"},
+	}
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("fr-CA %d: got %q, want %q", i, test.got, test.want)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = b.T("hello", "Ann")
+		}
+	}()
+	if err := b.SetLocale("ru"); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	tests = []struct {
+		got, want string
+	}{
+		{b.N("apples", 1, 1), "1 яблоко"},
+		{b.N("apples", 3, 3), "3 яблока"},
+		{b.N("apples", 5, 5), "5 яблок"},
+		{b.N("untranslated", 1), "untranslated"},
+		{b.T("hello", "Ann"), "Hello Ann"},
+		{b.N("apples", 0, 0), "0 яблок"},
+	}
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("ru %d: got %q, want %q", i, test.got, test.want)
+		}
+	}
+
+	if err := b.SetLocale("de"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.N("apples", 1, 1); got != "1 apple" {
+		t.Errorf("de fallback: got %q", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, name := range []string{"strings/bad.json", "strings/bad.po", "broken/en.json", "strings/missing.json", "strings/en.txt"} {
+		if _, err := LoadFS(testFS, name); err == nil {
+			t.Errorf("LoadFS(%q) succeeded", name)
+		}
+	}
+
+	if err := NewBundle(testFS, "broken", "en").SetLocale("en"); err == nil {
+		t.Error("SetLocale with invalid table succeeded")
+	}
+	b := NewBundle(testFS, "strings", "")
+	if err := b.SetLocale("de"); err == nil {
+		t.Error("SetLocale without tables succeeded")
+	}
+	if b.Locale() != "" {
+		t.Errorf("failed SetLocale changed locale to %q", b.Locale())
+	}
+}
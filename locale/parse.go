@@ -0,0 +1,200 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package locale
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"goarrg.com/debug"
+)
+
+/*
+parseJSON parses a flat object of keys to either a string or an object of
+plural categories to strings:
+
+	{"hello": "Hello {0}", "apples": {"one": "{0} apple", "other": "{0} apples"}}
+*/
+func parseJSON(data []byte) (map[string]Message, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]Message, len(raw))
+	for key, value := range raw {
+		var m Message
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			m[PluralOther] = s
+			messages[key] = m
+			continue
+		}
+
+		var forms map[string]string
+		if err := json.Unmarshal(value, &forms); err != nil {
+			return nil, debug.Errorf("Key %q is neither a string nor an object of plural forms", key)
+		}
+		for category, s := range forms {
+			p, ok := parsePlural(category)
+			if !ok {
+				return nil, debug.Errorf("Key %q has unknown plural category %q", key, category)
+			}
+			m[p] = s
+		}
+		if m[PluralOther] == "" {
+			return nil, debug.Errorf("Key %q is missing plural category \"other\"", key)
+		}
+		messages[key] = m
+	}
+	return messages, nil
+}
+
+type poEntry struct {
+	ctxt, id, idPlural string
+	str                []string
+	fuzzy              bool
+}
+
+/*
+parsePO parses a gettext PO file, msgstr[i] is mapped to tag's plural
+categories in CLDR order. The header, fuzzy and untranslated entries are
+skipped, entries with a msgctxt are keyed by "ctxt\x04id" as gettext does.
+*/
+func parsePO(data []byte, tag string) (map[string]Message, error) {
+	messages := map[string]Message{}
+	categories := rule(tag).categories
+
+	var e poEntry
+	// points to the string continuation lines are appended to
+	var last *string
+	flush := func() error {
+		defer func() { e, last = poEntry{}, nil }()
+		if e.id == "" || e.fuzzy {
+			return nil
+		}
+
+		var m Message
+		if e.idPlural == "" {
+			if len(e.str) == 0 || e.str[0] == "" {
+				return nil
+			}
+			m[PluralOther] = e.str[0]
+		} else {
+			if len(e.str) > len(categories) {
+				return debug.Errorf("Entry %q has %d plural forms but %q has %d", e.id, len(e.str), tag, len(categories))
+			}
+			for i, s := range e.str {
+				m[categories[i]] = s
+			}
+			// a translation missing forms is considered untranslated
+			for _, p := range categories {
+				if m[p] == "" {
+					return nil
+				}
+			}
+			m[PluralOther] = m[categories[len(categories)-1]]
+		}
+
+		key := e.id
+		if e.ctxt != "" {
+			key = e.ctxt + "\x04" + e.id
+		}
+		messages[key] = m
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			// comments belong to the next entry
+			if len(e.str) > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			if strings.HasPrefix(line, "#,") && strings.Contains(line, "fuzzy") {
+				e.fuzzy = true
+			}
+			continue
+		}
+
+		if line[0] == '"' {
+			if last == nil {
+				return nil, debug.Errorf("Line %d: string without keyword", lineNum)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, debug.ErrorWrapf(err, "Line %d", lineNum)
+			}
+			*last += s
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		s, err := strconv.Unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, debug.ErrorWrapf(err, "Line %d", lineNum)
+		}
+
+		// a msgctxt or msgid after a msgstr begins the next entry
+		if (keyword == "msgctxt" || keyword == "msgid") && len(e.str) > 0 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			e.ctxt = s
+			last = &e.ctxt
+		case keyword == "msgid":
+			e.id = s
+			last = &e.id
+		case keyword == "msgid_plural":
+			e.idPlural = s
+			last = &e.idPlural
+		case keyword == "msgstr":
+			e.str = append(e.str, s)
+			last = &e.str[len(e.str)-1]
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			i, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || i != len(e.str) {
+				return nil, debug.Errorf("Line %d: invalid %s", lineNum, keyword)
+			}
+			e.str = append(e.str, s)
+			last = &e.str[i]
+		default:
+			return nil, debug.Errorf("Line %d: unknown keyword %q", lineNum, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package locale
+
+import "strings"
+
+/*
+Plural is a CLDR plural category.
+*/
+type Plural int
+
+const (
+	PluralZero Plural = iota
+	PluralOne
+	PluralTwo
+	PluralFew
+	PluralMany
+	PluralOther
+	pluralCount
+)
+
+func (p Plural) String() string {
+	switch p {
+	case PluralZero:
+		return "zero"
+	case PluralOne:
+		return "one"
+	case PluralTwo:
+		return "two"
+	case PluralFew:
+		return "few"
+	case PluralMany:
+		return "many"
+	case PluralOther:
+		return "other"
+	}
+	return ""
+}
+
+func parsePlural(s string) (Plural, bool) {
+	for p := PluralZero; p < pluralCount; p++ {
+		if p.String() == s {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+/*
+pluralRule holds the categories a language uses for integers in CLDR order and
+the function choosing between them.
+*/
+type pluralRule struct {
+	categories []Plural
+	category   func(n int) Plural
+}
+
+var (
+	pluralRuleOther = pluralRule{
+		[]Plural{PluralOther},
+		func(int) Plural { return PluralOther },
+	}
+	pluralRuleOne = pluralRule{
+		[]Plural{PluralOne, PluralOther},
+		func(n int) Plural {
+			if n == 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	// 0 and 1 are singular
+	pluralRuleZeroOne = pluralRule{
+		[]Plural{PluralOne, PluralOther},
+		func(n int) Plural {
+			if n == 0 || n == 1 {
+				return PluralOne
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleSlavic = pluralRule{
+		[]Plural{PluralOne, PluralFew, PluralMany},
+		func(n int) Plural {
+			switch n10, n100 := n%10, n%100; {
+			case n10 == 1 && n100 != 11:
+				return PluralOne
+			case n10 >= 2 && n10 <= 4 && (n100 < 12 || n100 > 14):
+				return PluralFew
+			}
+			return PluralMany
+		},
+	}
+	pluralRulePolish = pluralRule{
+		[]Plural{PluralOne, PluralFew, PluralMany},
+		func(n int) Plural {
+			switch n10, n100 := n%10, n%100; {
+			case n == 1:
+				return PluralOne
+			case n10 >= 2 && n10 <= 4 && (n100 < 12 || n100 > 14):
+				return PluralFew
+			}
+			return PluralMany
+		},
+	}
+	pluralRuleCzech = pluralRule{
+		[]Plural{PluralOne, PluralFew, PluralOther},
+		func(n int) Plural {
+			switch {
+			case n == 1:
+				return PluralOne
+			case n >= 2 && n <= 4:
+				return PluralFew
+			}
+			return PluralOther
+		},
+	}
+	pluralRuleArabic = pluralRule{
+		[]Plural{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther},
+		func(n int) Plural {
+			switch n100 := n % 100; {
+			case n == 0:
+				return PluralZero
+			case n == 1:
+				return PluralOne
+			case n == 2:
+				return PluralTwo
+			case n100 >= 3 && n100 <= 10:
+				return PluralFew
+			case n100 >= 11:
+				return PluralMany
+			}
+			return PluralOther
+		},
+	}
+)
+
+/*
+pluralRules is keyed by language, languages not listed use pluralRuleOne.
+*/
+var pluralRules = map[string]*pluralRule{
+	"ja": &pluralRuleOther, "zh": &pluralRuleOther, "ko": &pluralRuleOther, "vi": &pluralRuleOther,
+	"th": &pluralRuleOther, "id": &pluralRuleOther, "ms": &pluralRuleOther, "lo": &pluralRuleOther,
+	"fr": &pluralRuleZeroOne, "pt": &pluralRuleZeroOne, "hi": &pluralRuleZeroOne, "fa": &pluralRuleZeroOne,
+	"ru": &pluralRuleSlavic, "uk": &pluralRuleSlavic, "be": &pluralRuleSlavic,
+	"pl": &pluralRulePolish,
+	"cs": &pluralRuleCzech, "sk": &pluralRuleCzech,
+	"ar": &pluralRuleArabic,
+}
+
+func rule(tag string) *pluralRule {
+	lang, _, _ := strings.Cut(normalizeTag(tag), "-")
+	if r, ok := pluralRules[lang]; ok {
+		return r
+	}
+	return &pluralRuleOne
+}
+
+/*
+PluralCategory returns the plural category of the integer n in tag's language.
+*/
+func PluralCategory(tag string, n int) Plural {
+	if n < 0 {
+		n = -n
+	}
+	// European Portuguese only treats 1 as singular
+	if normalizeTag(tag) == "pt-PT" {
+		return pluralRuleOne.category(n)
+	}
+	return rule(tag).category(n)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mixer
+
+import (
+	"time"
+
+	"goarrg.com/asset/audio/dsp"
+)
+
+/*
+Bus sums the voices and buses playing into it and mixes the result into its
+parent with its gain applied, e.g. to control the volume of all music or all
+sound effects at once.
+*/
+type Bus struct {
+	m      *Mixer
+	parent *Bus
+	gain   ramp
+	// effects process buf before the gain is applied
+	effects dsp.Chain
+	// buf holds a planar buffer per output channel
+	buf [][]float32
+}
+
+/*
+ramp is a gain that moves linearly towards target, one step per frame.
+*/
+type ramp struct {
+	value  float32
+	target float32
+	step   float32
+	frames int
+}
+
+func (r *ramp) set(target float32, frames int) {
+	if frames <= 0 {
+		r.value, r.target, r.frames = target, target, 0
+		return
+	}
+	r.target, r.frames = target, frames
+	r.step = (target - r.value) / float32(frames)
+}
+
+func (r *ramp) next() float32 {
+	if r.frames > 0 {
+		r.frames--
+		r.value += r.step
+		if r.frames == 0 {
+			r.value = r.target
+		}
+	}
+	return r.value
+}
+
+/*
+Parent returns the bus b mixes into or nil for the master bus.
+*/
+func (b *Bus) Parent() *Bus {
+	return b.parent
+}
+
+/*
+SetGain changes the linear gain of the bus over fade.
+*/
+func (b *Bus) SetGain(gain float32, fade time.Duration) {
+	b.m.mtx.Lock()
+	defer b.m.mtx.Unlock()
+	b.gain.set(max(0, gain), b.m.durationFrames(fade))
+}
+
+/*
+Gain returns the gain the bus is set to or fading towards.
+*/
+func (b *Bus) Gain() float32 {
+	b.m.mtx.Lock()
+	defer b.m.mtx.Unlock()
+	return b.gain.target
+}
+
+/*
+SetEffects replaces the effects of the bus, which process everything playing
+into it before its gain is applied. Effects must not be used anywhere else.
+*/
+func (b *Bus) SetEffects(effects ...dsp.Processor) {
+	b.m.mtx.Lock()
+	defer b.m.mtx.Unlock()
+	b.effects = append(dsp.Chain(nil), effects...)
+	if b.m.spec.Frequency > 0 {
+		b.effects.Init(b.m.spec)
+	}
+}
+
+func (b *Bus) alloc(channels, frames int) {
+	b.buf = make([][]float32, channels)
+	for i := range b.buf {
+		b.buf[i] = make([]float32, frames)
+	}
+}
+
+func (b *Bus) clear(frames int) {
+	for _, c := range b.buf {
+		clear(c[:frames])
+	}
+}
+
+/*
+mixInto runs the effects and mixes the result into out with the gain applied.
+*/
+func (b *Bus) mixInto(out [][]float32, frames int) {
+	b.effects.Process(b.buf, frames)
+	for i := 0; i < frames; i++ {
+		g := b.gain.next()
+		for c := range out {
+			out[c][i] += b.buf[c][i] * g
+		}
+	}
+}
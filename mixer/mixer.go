@@ -0,0 +1,362 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package mixer is a software mixer implementing goarrg.Audio. Voices play
+audio.Asset and audio.StreamAsset sources into a tree of buses rooted at the
+master bus, which is mixed into the spec negotiated with the platform.
+
+	m := mixer.New(mixer.Config{})
+	goarrg.Run(goarrg.Config{Audio: m, ...})
+
+	music := m.NewBus(m.Master())
+	v := m.PlayStream(stream, mixer.PlayConfig{Bus: music, Loop: true, FadeIn: time.Second})
+
+All methods are safe to call from any goroutine.
+*/
+package mixer
+
+import (
+	"sync"
+	"time"
+
+	"goarrg.com"
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/remix"
+	"goarrg.com/asset/audio/resample"
+	"goarrg.com/debug"
+)
+
+const (
+	defaultFrequency = 48000
+	defaultLatency   = 50 * time.Millisecond
+	defaultMaxVoices = 64
+)
+
+var logger = debug.NewLogger("goarrg", "mixer")
+
+type Config struct {
+	// Spec is requested from the platform, which may negotiate a different
+	// one. Defaults to stereo at 48kHz if Channels is empty or Frequency <= 0.
+	Spec audio.Spec
+	// Latency is how far ahead of the wall clock Mix renders, it has to be
+	// longer than the time between two calls to Mix. Defaults to 50ms if <= 0.
+	Latency time.Duration
+	// MaxVoices is the number of voices that can be playing or paused at the
+	// same time, when exceeded the voice with the lowest priority is stolen.
+	// Defaults to 64 if <= 0.
+	MaxVoices int
+	// Resample is how sources are converted to the output frequency and pitch.
+	// Defaults to resample.ModeSinc.
+	Resample resample.Mode
+	// Remix configures how sources are mixed to the output channels, see
+	// remix.New.
+	Remix remix.Config
+	// Clock is what Mix renders up to, it can be a simulated clock to render
+	// faster or slower than real time. Defaults to time.Now if nil.
+	Clock func() time.Time
+	// Loudness is the integrated loudness in LUFS sources are normalised to,
+	// such as -23 for EBU R 128, if their metadata has one. Disabled if 0.
+	Loudness float64
+}
+
+type Mixer struct {
+	mtx sync.Mutex
+	cfg Config
+	// spec is the spec negotiated in Init, Mix does nothing until then
+	spec   audio.Spec
+	voices []*Voice
+	buses  []*Bus
+	// serial orders voices by when they were played for voice stealing
+	serial uint64
+	// out holds a second of output, view the part of it the last Mix rendered
+	out  audio.Track
+	view [][]float32
+	// scratch holds a planar buffer per output channel for voices with effects
+	scratch [][]float32
+
+	started time.Time
+	// mixed is the number of frames mixed since started
+	mixed int
+	// frame is the mix clock, the number of frames rendered
+	frame int64
+	// events are the marker callbacks render queued for Update
+	events []markerEvent
+}
+
+type markerEvent struct {
+	voice  *Voice
+	marker audio.Marker
+}
+
+var _ goarrg.Audio = (*Mixer)(nil)
+
+func New(cfg Config) *Mixer {
+	if len(cfg.Spec.Channels) == 0 {
+		cfg.Spec.Channels = audio.ChannelsStereo()
+	}
+	if cfg.Spec.Frequency <= 0 {
+		cfg.Spec.Frequency = defaultFrequency
+	}
+	if cfg.Latency <= 0 {
+		cfg.Latency = defaultLatency
+	}
+	if cfg.MaxVoices <= 0 {
+		cfg.MaxVoices = defaultMaxVoices
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = time.Now
+	}
+	m := &Mixer{cfg: cfg}
+	m.buses = []*Bus{{m: m, gain: ramp{value: 1, target: 1}}}
+	return m
+}
+
+func (m *Mixer) AudioConfig() goarrg.AudioConfig {
+	return goarrg.AudioConfig{Spec: m.cfg.Spec}
+}
+
+func (m *Mixer) Init(_ goarrg.PlatformInterface, cfg goarrg.AudioConfig) error {
+	if len(cfg.Spec.Channels) == 0 || cfg.Spec.Frequency <= 0 {
+		return debug.Errorf("Failed to init mixer: invalid spec %+v", cfg.Spec)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.spec = audio.Spec{Channels: append([]audio.Channel(nil), cfg.Spec.Channels...), Frequency: cfg.Spec.Frequency}
+	m.out = audio.NewTrack(m.spec.Channels, m.spec.Frequency)
+	m.view = make([][]float32, len(m.spec.Channels))
+	m.scratch = make([][]float32, len(m.spec.Channels))
+	for i := range m.scratch {
+		m.scratch[i] = make([]float32, m.spec.Frequency)
+	}
+	for _, b := range m.buses {
+		b.alloc(len(m.spec.Channels), m.spec.Frequency)
+		b.effects.Init(m.spec)
+	}
+	for _, v := range m.voices {
+		v.routed = false
+		v.effects.Init(m.spec)
+	}
+	m.started, m.mixed = time.Time{}, 0
+
+	logger.IPrintf("Initialized mixer with spec %+v", m.spec)
+	return nil
+}
+
+/*
+Mix renders the frames that are due according to the clock plus the
+configured latency, at most a second at a time. Falling further behind than
+that drops the missing time instead of trying to catch up.
+*/
+func (m *Mixer) Mix() (int, audio.Track) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.view == nil {
+		return 0, audio.Track{}
+	}
+
+	now := m.cfg.Clock()
+	if m.started.IsZero() {
+		m.started = now
+	}
+	due := int((now.Sub(m.started) + m.cfg.Latency).Seconds() * float64(m.spec.Frequency))
+	frames := due - m.mixed
+	if frames > m.spec.Frequency {
+		frames = m.spec.Frequency
+		m.mixed = due - frames
+	}
+	if frames <= 0 {
+		return 0, audio.TrackOf(m.spec.Channels, m.view)
+	}
+	m.mixed += frames
+
+	m.render(frames)
+	return frames, audio.TrackOf(m.spec.Channels, m.view)
+}
+
+/*
+render mixes frames into m.view, must be called with m.mtx held.
+*/
+func (m *Mixer) render(frames int) {
+	for _, b := range m.buses {
+		b.clear(frames)
+	}
+
+	playing := m.voices[:0]
+	for _, v := range m.voices {
+		if v.state != VoiceStopped {
+			v.mix(frames)
+		}
+		if v.state != VoiceStopped {
+			playing = append(playing, v)
+		}
+	}
+	clear(m.voices[len(playing):])
+	m.voices = playing
+
+	// children are always created after their parents
+	for i := len(m.buses) - 1; i > 0; i-- {
+		b := m.buses[i]
+		b.mixInto(b.parent.buf, frames)
+	}
+	master := m.buses[0]
+	for i, s := range m.out.Samples() {
+		m.view[i] = s[:frames]
+		clear(m.view[i])
+	}
+	master.mixInto(m.view, frames)
+	m.frame += int64(frames)
+}
+
+/*
+Spec returns the spec negotiated in Init, or the configured one before that.
+*/
+func (m *Mixer) Spec() audio.Spec {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.spec.Frequency == 0 {
+		return m.cfg.Spec
+	}
+	return m.spec
+}
+
+/*
+Frame returns the mix clock, the number of frames mixed so far which is also
+the next frame Mix renders. It counts frames of the output at the spec's
+frequency and does not count time Mix dropped to catch up.
+*/
+func (m *Mixer) Frame() int64 {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.frame
+}
+
+/*
+ResumeAt resumes paused voices at frame of the mix clock, or right away if it
+was already mixed. Voices resumed together start in the same frame, so playing
+a set of voices paused and resuming them together keeps them in sync, e.g. the
+stems of a piece of music. A fade in of PlayConfig starts with the voice.
+*/
+func (m *Mixer) ResumeAt(frame int64, voices ...*Voice) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, v := range voices {
+		if v.m != m {
+			panic("mixer: voice belongs to a different mixer")
+		}
+		if v.state == VoiceStopped || v.fadeTo == VoiceStopped {
+			continue
+		}
+		v.state, v.fadeTo = VoicePlaying, VoicePlaying
+		if v.fade.target == 0 {
+			// paused by Pause rather than PlayConfig
+			v.fade.set(1, 0)
+		}
+		v.start = frame
+	}
+}
+
+/*
+Update calls the PlayConfig.OnMarker callbacks of markers played since the
+last Update, all the mixing happens in Mix.
+*/
+func (m *Mixer) Update() {
+	m.mtx.Lock()
+	events := m.events
+	m.events = nil
+	m.mtx.Unlock()
+
+	for _, e := range events {
+		e.voice.onMarker(e.voice, e.marker)
+	}
+}
+
+/*
+Destroy stops all voices.
+*/
+func (m *Mixer) Destroy() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, v := range m.voices {
+		v.stop()
+	}
+	m.voices = nil
+}
+
+/*
+Master returns the bus at the root of the bus tree that is mixed to the output.
+*/
+func (m *Mixer) Master() *Bus {
+	return m.buses[0]
+}
+
+/*
+NewBus returns a bus that mixes into parent, or the master bus if parent is
+nil.
+*/
+func (m *Mixer) NewBus(parent *Bus) *Bus {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if parent == nil {
+		parent = m.buses[0]
+	}
+	if parent.m != m {
+		panic("mixer: parent bus belongs to a different mixer")
+	}
+	b := &Bus{m: m, parent: parent, gain: ramp{value: 1, target: 1}}
+	if m.spec.Frequency > 0 {
+		b.alloc(len(m.spec.Channels), m.spec.Frequency)
+	}
+	m.buses = append(m.buses, b)
+	return b
+}
+
+/*
+Play plays a on a new voice, see PlayConfig. The returned voice is already
+stopped if no voice could be stolen for it.
+*/
+func (m *Mixer) Play(a audio.Asset, cfg PlayConfig) *Voice {
+	return m.play(&assetSource{specs: a.Spec(), track: a.Track(), length: a.DurationSamples()}, audio.MetadataOf(a), cfg)
+}
+
+/*
+PlayStream is Play for streams, a stream must only be played by one voice at a
+time and is not closed when the voice stops.
+*/
+func (m *Mixer) PlayStream(s audio.StreamAsset, cfg PlayConfig) *Voice {
+	return m.play(&streamSource{stream: s}, audio.MetadataOf(s), cfg)
+}
+
+/*
+durationFrames converts d to frames of the output, must be called with m.mtx
+held.
+*/
+func (m *Mixer) durationFrames(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	frequency := m.spec.Frequency
+	if frequency == 0 {
+		frequency = m.cfg.Spec.Frequency
+	}
+	return max(1, int(d.Seconds()*float64(frequency)))
+}
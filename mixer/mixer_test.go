@@ -0,0 +1,482 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mixer
+
+import (
+	"io"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"goarrg.com"
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/dsp"
+	"goarrg.com/asset/audio/remix"
+	"goarrg.com/asset/audio/resample"
+)
+
+type testAsset struct {
+	spec     audio.Spec
+	track    audio.Track
+	metadata audio.Metadata
+}
+
+func (a *testAsset) Track() audio.Track { return a.track }
+func (a *testAsset) Spec() audio.Spec   { return a.spec }
+func (a *testAsset) Metadata() audio.Metadata {
+	return a.metadata
+}
+func (a *testAsset) DurationSamples() int { return len(a.track.Channel(a.spec.Channels[0])) }
+func (a *testAsset) DurationSeconds() float64 {
+	return float64(a.DurationSamples()) / float64(a.spec.Frequency)
+}
+
+func monoAsset(frequency int, samples ...float32) *testAsset {
+	return &testAsset{spec: audio.Spec{Channels: audio.ChannelsMono(), Frequency: frequency}, track: audio.TrackOf(audio.ChannelsMono(), [][]float32{samples})}
+}
+
+/*
+testStream is a mono audio.StreamAsset reading from memory, nothing is buffered
+while starved is set.
+*/
+type testStream struct {
+	samples   []float32
+	pos       int
+	loopStart int
+	loopEnd   int
+	looping   bool
+	starved   bool
+	seeks     int
+}
+
+func (s *testStream) Spec() audio.Spec {
+	return audio.Spec{Channels: audio.ChannelsMono(), Frequency: 1000}
+}
+func (s *testStream) DurationSeconds() float64 { return float64(len(s.samples)) / 1000 }
+func (s *testStream) DurationSamples() int     { return len(s.samples) }
+func (s *testStream) Read(frames int) (audio.Track, error) {
+	end := len(s.samples)
+	if s.looping && s.loopEnd > 0 {
+		end = s.loopEnd
+	}
+	if s.looping && s.pos >= end {
+		s.pos = s.loopStart
+	}
+	if s.pos >= end {
+		return audio.Track{}, io.EOF
+	}
+	end = min(end, s.pos+frames)
+	t := audio.TrackOf(audio.ChannelsMono(), [][]float32{s.samples[s.pos:end]})
+	s.pos = end
+	return t, nil
+}
+func (s *testStream) Buffered() int {
+	switch {
+	case s.starved:
+		return 0
+	case s.looping:
+		return len(s.samples)
+	case s.pos >= len(s.samples):
+		return -1
+	}
+	return len(s.samples) - s.pos
+}
+func (s *testStream) Seek(frame int) error { s.pos = frame; s.seeks++; return nil }
+func (s *testStream) Loop(start, end int) error {
+	s.loopStart, s.loopEnd, s.looping = start, end, start >= 0
+	return nil
+}
+func (s *testStream) Position() int { return s.pos }
+func (s *testStream) Close() error  { return nil }
+
+/*
+newTestMixer returns a mixer outputting at 1000Hz in spec channels.
+*/
+func newTestMixer(t *testing.T, channels []audio.Channel, maxVoices int) *Mixer {
+	t.Helper()
+	m := New(Config{Spec: audio.Spec{Channels: channels, Frequency: 1000}, MaxVoices: maxVoices})
+	if err := m.Init(nil, m.AudioConfig()); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+/*
+render mixes frames without the clock and returns the output per channel.
+*/
+func render(m *Mixer, frames int) audio.Track {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.render(frames)
+	return audio.TrackOf(m.spec.Channels, m.view)
+}
+
+func expect(t *testing.T, name string, got, want []float32) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got %v want %v", name, got, want)
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+			t.Fatalf("%s: got %v want %v", name, got, want)
+		}
+	}
+}
+
+func TestMixClock(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsStereo(), 0)
+	now := time.Unix(0, 0)
+	m.cfg.Clock = func() time.Time { return now }
+
+	for _, step := range []struct {
+		advance time.Duration
+		frames  int
+	}{
+		{0, 50}, {16 * time.Millisecond, 16}, {0, 0}, {time.Millisecond, 1}, {5 * time.Second, 1000}, {0, 0}, {10 * time.Millisecond, 10},
+	} {
+		now = now.Add(step.advance)
+		if frames, track := m.Mix(); frames != step.frames || (frames > 0 && len(track.Channel(audio.ChannelRight)) != frames) {
+			t.Fatalf("advancing %v: got %d frames want %d", step.advance, frames, step.frames)
+		}
+	}
+}
+
+func TestVoice(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsStereo(), 0)
+	v := m.Play(monoAsset(1000, 1, 1, 1, 1), PlayConfig{Volume: 0.5})
+	out := render(m, 2)
+	expect(t, "center", out.Channel(audio.ChannelLeft), []float32{0.5 * math.Sqrt2 / 2, 0.5 * math.Sqrt2 / 2})
+
+	v.SetPan(-1)
+	v.SetVolume(1, 0)
+	out = render(m, 3)
+	expect(t, "left", out.Channel(audio.ChannelLeft), []float32{1, 1, 0})
+	expect(t, "right", out.Channel(audio.ChannelRight), []float32{0, 0, 0})
+	if v.State() != VoiceStopped {
+		t.Errorf("got state %v after the end", v.State())
+	}
+
+	stereo := &testAsset{spec: audio.Spec{Channels: audio.ChannelsStereo(), Frequency: 1000}, track: audio.TrackOf(audio.ChannelsStereo(), [][]float32{{1, 1}, {0.5, 0.5}})}
+	m.Play(stereo, PlayConfig{Pan: 0.5})
+	out = render(m, 1)
+	expect(t, "balance left", out.Channel(audio.ChannelLeft), []float32{0.5})
+	expect(t, "balance right", out.Channel(audio.ChannelRight), []float32{0.5})
+}
+
+func TestPitch(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	m.cfg.Resample = resample.ModeLinear
+	v := m.Play(monoAsset(1000, 0, 1, 2, 3, 4, 5, 6), PlayConfig{Pitch: 2})
+	expect(t, "pitch", render(m, 3).Channel(audio.ChannelLeft), []float32{0, 2, 4})
+	v.SetPitch(0.5)
+	expect(t, "pitch", render(m, 3).Channel(audio.ChannelLeft), []float32{6, 3, 0})
+
+	m.Play(monoAsset(500, 0, 1, 2), PlayConfig{})
+	expect(t, "resample", render(m, 6).Channel(audio.ChannelLeft), []float32{0, 0.5, 1, 1.5, 2, 1})
+
+	// a sinc voice of a constant settles at the constant
+	m.cfg.Resample = resample.ModeSinc
+	ones := make([]float32, 200)
+	for i := range ones {
+		ones[i] = 1
+	}
+	m.Play(monoAsset(500, ones...), PlayConfig{})
+	out := render(m, 100).Channel(audio.ChannelLeft)
+	for i := 50; i < 100; i++ {
+		if math.Abs(float64(out[i]-1)) > 1e-3 {
+			t.Fatalf("sinc: got %f at frame %d", out[i], i)
+		}
+	}
+}
+
+func TestLoop(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	// linear reads ahead a single frame so SetLoop applies right away
+	m.cfg.Resample = resample.ModeLinear
+	v := m.Play(monoAsset(1000, 1, 2, 3), PlayConfig{Loop: true})
+	expect(t, "loop", render(m, 7).Channel(audio.ChannelLeft), []float32{1, 2, 3, 1, 2, 3, 1})
+	if p := v.Position(); p != 1 {
+		t.Errorf("got position %d", p)
+	}
+	v.SetLoop(false)
+	expect(t, "end", render(m, 4).Channel(audio.ChannelLeft), []float32{2, 3, 0, 0})
+
+	s := &testStream{samples: []float32{1, 2, 3, 4}}
+	v = m.PlayStream(s, PlayConfig{Loop: true})
+	expect(t, "stream", render(m, 6).Channel(audio.ChannelLeft), []float32{1, 2, 3, 4, 1, 2})
+	if err := v.Seek(2); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "stream seek", render(m, 3).Channel(audio.ChannelLeft), []float32{3, 4, 1})
+	if s.seeks != 1 {
+		t.Errorf("stream was looped by seeking")
+	}
+
+	// a starved stream plays silence instead of waiting for the decoder
+	s.starved = true
+	if err := v.Seek(0); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "starved", render(m, 2).Channel(audio.ChannelLeft), []float32{0, 0})
+	if p := v.Position(); p != 0 {
+		t.Errorf("got position %d while starved", p)
+	}
+	s.starved = false
+	if err := v.Seek(0); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "fed", render(m, 2).Channel(audio.ChannelLeft), []float32{1, 2})
+	v.SetLoop(false)
+	expect(t, "stream loop off", render(m, 4).Channel(audio.ChannelLeft), []float32{3, 4, 0, 0})
+}
+
+func TestLoopMetadata(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	m.cfg.Resample = resample.ModeLinear
+	a := monoAsset(1000, 1, 2, 3, 4, 5)
+	a.metadata = audio.Metadata{LoopStart: 1, LoopEnd: 3}
+	v := m.Play(a, PlayConfig{Loop: true})
+	expect(t, "loop", render(m, 8).Channel(audio.ChannelLeft), []float32{1, 2, 3, 2, 3, 2, 3, 2})
+	if p := v.Position(); p != 2 {
+		t.Errorf("got position %d", p)
+	}
+	// the loop already read ahead still plays
+	v.SetLoop(false)
+	expect(t, "end", render(m, 6).Channel(audio.ChannelLeft), []float32{3, 2, 3, 4, 5, 0})
+
+	// without Loop the loop is ignored
+	m.Play(a, PlayConfig{})
+	expect(t, "no loop", render(m, 6).Channel(audio.ChannelLeft), []float32{1, 2, 3, 4, 5, 0})
+}
+
+func TestMarkers(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	m.cfg.Resample = resample.ModeLinear
+	a := monoAsset(1000, make([]float32, 10)...)
+	a.metadata = audio.Metadata{Markers: []audio.Marker{{Frame: 0, Label: "a"}, {Frame: 4, Label: "b"}, {Frame: 9, Label: "c"}}}
+
+	var got []string
+	var voice *Voice
+	v := m.Play(a, PlayConfig{Loop: true, OnMarker: func(v *Voice, marker audio.Marker) {
+		if v != voice {
+			t.Error("wrong voice")
+		}
+		got = append(got, marker.Label)
+	}})
+	voice = v
+	render(m, 4)
+	if len(got) != 0 {
+		t.Fatalf("got markers %v before Update", got)
+	}
+	m.Update()
+	if !slices.Equal(got, []string{"a"}) {
+		t.Fatalf("got markers %v", got)
+	}
+	render(m, 8)
+	m.Update()
+	if !slices.Equal(got, []string{"a", "b", "c", "a"}) {
+		t.Fatalf("got markers %v", got)
+	}
+	if err := v.Seek(4); err != nil {
+		t.Fatal(err)
+	}
+	render(m, 1)
+	m.Update()
+	if !slices.Equal(got, []string{"a", "b", "c", "a", "b"}) {
+		t.Fatalf("got markers %v after seeking", got)
+	}
+}
+
+func TestLoudness(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	m.cfg.Loudness = -23
+	a := monoAsset(1000, 1, 1)
+	a.metadata.Loudness = -29
+	m.Play(a, PlayConfig{Volume: 0.5})
+	b := monoAsset(1000, 1, 1)
+	b.metadata.Loudness = math.Inf(-1)
+	m.Play(b, PlayConfig{})
+	// 6dB louder
+	gain := float32(0.5*math.Pow(10, 6.0/20)) + 1
+	expect(t, "normalised", render(m, 1).Channel(audio.ChannelLeft), []float32{gain})
+}
+
+func TestSchedule(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	ones := make([]float32, 100)
+	for i := range ones {
+		ones[i] = 1
+	}
+	v := m.Play(monoAsset(1000, ones...), PlayConfig{Paused: true})
+	w := m.Play(monoAsset(1000, ones...), PlayConfig{Paused: true, FadeIn: 2 * time.Millisecond})
+	expect(t, "paused", render(m, 2).Channel(audio.ChannelLeft), []float32{0, 0})
+	if f := m.Frame(); f != 2 {
+		t.Fatalf("got frame %d", f)
+	}
+	m.ResumeAt(5, v, w)
+	expect(t, "resume", render(m, 6).Channel(audio.ChannelLeft), []float32{0, 0, 0, 1.5, 2, 2})
+
+	v.StopAt(10, 2*time.Millisecond)
+	w.StopAt(9, 0)
+	expect(t, "stop", render(m, 4).Channel(audio.ChannelLeft), []float32{2, 1, 0.5, 0})
+	if v.State() != VoiceStopped || w.State() != VoiceStopped {
+		t.Errorf("got states %v %v", v.State(), w.State())
+	}
+
+	// stopping before the start never plays
+	v = m.Play(monoAsset(1000, ones...), PlayConfig{Paused: true})
+	m.ResumeAt(20, v)
+	v.StopAt(16, time.Second)
+	expect(t, "never", render(m, 10).Channel(audio.ChannelLeft), make([]float32, 10))
+	if v.State() != VoiceStopped {
+		t.Errorf("got state %v", v.State())
+	}
+}
+
+func TestFade(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	ones := make([]float32, 100)
+	for i := range ones {
+		ones[i] = 1
+	}
+	v := m.Play(monoAsset(1000, ones...), PlayConfig{FadeIn: 4 * time.Millisecond})
+	expect(t, "fade in", render(m, 5).Channel(audio.ChannelLeft), []float32{0.25, 0.5, 0.75, 1, 1})
+
+	v.Pause(2 * time.Millisecond)
+	expect(t, "pause", render(m, 3).Channel(audio.ChannelLeft), []float32{0.5, 0, 0})
+	if v.State() != VoicePaused {
+		t.Errorf("got state %v want paused", v.State())
+	}
+	v.Resume(0)
+	expect(t, "resume", render(m, 1).Channel(audio.ChannelLeft), []float32{1})
+
+	v.Stop(2 * time.Millisecond)
+	if v.State() != VoicePlaying {
+		t.Errorf("got state %v while fading out", v.State())
+	}
+	expect(t, "stop", render(m, 3).Channel(audio.ChannelLeft), []float32{0.5, 0, 0})
+	if v.State() != VoiceStopped {
+		t.Errorf("got state %v want stopped", v.State())
+	}
+}
+
+func TestBus(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	sfx := m.NewBus(nil)
+	quiet := m.NewBus(sfx)
+	if quiet.Parent() != sfx || sfx.Parent() != m.Master() {
+		t.Fatal("wrong parents")
+	}
+	sfx.SetGain(0.5, 0)
+	quiet.SetGain(0.5, 0)
+	m.Play(monoAsset(1000, 1, 1, 1), PlayConfig{Bus: quiet})
+	m.Play(monoAsset(1000, 1, 1, 1), PlayConfig{})
+	expect(t, "bus", render(m, 1).Channel(audio.ChannelLeft), []float32{1.25})
+
+	m.Master().SetGain(0, 2*time.Millisecond)
+	expect(t, "master fade", render(m, 2).Channel(audio.ChannelLeft), []float32{0.625, 0})
+	if g := m.Master().Gain(); g != 0 {
+		t.Errorf("got gain %f", g)
+	}
+}
+
+func TestVoiceStealing(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 2)
+	a := m.Play(monoAsset(1000, 1, 1), PlayConfig{Priority: 1})
+	b := m.Play(monoAsset(1000, 2, 2), PlayConfig{Priority: 0})
+	c := m.Play(monoAsset(1000, 4, 4), PlayConfig{Priority: 1})
+	if b.State() != VoiceStopped || a.State() != VoicePlaying || c.State() != VoicePlaying {
+		t.Fatalf("got states %v %v %v", a.State(), b.State(), c.State())
+	}
+	d := m.Play(monoAsset(1000, 8, 8), PlayConfig{Priority: 0})
+	if d.State() != VoiceStopped {
+		t.Fatalf("lower priority voice stole a voice")
+	}
+	e := m.Play(monoAsset(1000, 16, 16), PlayConfig{Priority: 1})
+	if a.State() != VoiceStopped || e.State() != VoicePlaying {
+		t.Fatalf("oldest voice was not stolen")
+	}
+	expect(t, "stealing", render(m, 1).Channel(audio.ChannelLeft), []float32{20})
+}
+
+func TestRoutes(t *testing.T) {
+	stereo := audio.ChannelsStereo()
+	r := routes(audio.Channels5Point1(), stereo, 0, remix.Config{})
+	fold := float32(math.Sqrt2 / 2)
+	want := [][]route{{{0, 1}}, {{1, 1}}, {{0, fold}, {1, fold}}, nil, {{0, fold}}, {{1, fold}}}
+	for i := range want {
+		if len(r[i]) != len(want[i]) {
+			t.Fatalf("channel %d: got %v want %v", i, r[i], want[i])
+		}
+		for j := range want[i] {
+			if r[i][j].out != want[i][j].out || math.Abs(float64(r[i][j].gain-want[i][j].gain)) > 1e-6 {
+				t.Fatalf("channel %d: got %v want %v", i, r[i], want[i])
+			}
+		}
+	}
+
+	r = routes(stereo, audio.ChannelsMono(), 0, remix.Config{})
+	if len(r[0]) != 1 || r[0][0].gain != fold || len(r[1]) != 1 || r[1][0].gain != fold {
+		t.Errorf("got %v", r)
+	}
+}
+
+/*
+scale is a dsp.Processor multiplying by a factor, remembering the spec it was
+initialized with.
+*/
+type scale struct {
+	factor float32
+	spec   audio.Spec
+}
+
+func (s *scale) Init(spec audio.Spec) { s.spec = spec }
+func (s *scale) Process(buf [][]float32, frames int) {
+	for _, c := range buf {
+		for i := range c[:frames] {
+			c[i] *= s.factor
+		}
+	}
+}
+
+func TestEffects(t *testing.T) {
+	m := newTestMixer(t, audio.ChannelsMono(), 0)
+	voice, bus := &scale{factor: 2}, &scale{factor: 3}
+	sfx := m.NewBus(nil)
+	sfx.SetEffects(bus)
+	if bus.spec.Frequency != 1000 {
+		t.Fatalf("bus effect was not initialized, got %+v", bus.spec)
+	}
+	v := m.Play(monoAsset(1000, 1, 1, 1), PlayConfig{Bus: sfx, Effects: []dsp.Processor{voice}})
+	m.Play(monoAsset(1000, 1, 1, 1), PlayConfig{Bus: sfx})
+	expect(t, "effects", render(m, 1).Channel(audio.ChannelLeft), []float32{9})
+
+	v.SetEffects()
+	sfx.SetGain(0.5, 0)
+	expect(t, "removed", render(m, 1).Channel(audio.ChannelLeft), []float32{3})
+
+	if err := m.Init(nil, goarrg.AudioConfig{Spec: audio.Spec{Channels: audio.ChannelsStereo(), Frequency: 2000}}); err != nil {
+		t.Fatal(err)
+	}
+	if bus.spec.Frequency != 2000 || len(bus.spec.Channels) != 2 {
+		t.Errorf("bus effect was not initialized again, got %+v", bus.spec)
+	}
+}
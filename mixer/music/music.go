@@ -0,0 +1,338 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package music plays interactive music on a mixer.Mixer. A Controller knows the
+tempo and bars of the playing Track and moves to the next one on a beat or
+bar, with a crossfade or a stinger in between. The stems of a track play in
+sync and fade in and out with the intensity of the controller.
+
+	c := music.New(m, music.Config{Bus: musicBus})
+	_, err := c.Play(explore, music.Transition{})
+
+	// when combat starts
+	c.SetIntensity(1, 2*time.Second)
+	_, err = c.Play(combat, music.Transition{Quantize: music.QuantizeBar, Stinger: hit})
+
+Everything is scheduled on the mix clock, see mixer.Mixer.Frame, so
+transitions are sample accurate no matter when Program.Update runs. All
+methods are safe to call from any goroutine.
+*/
+package music
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+	"goarrg.com/mixer"
+)
+
+const (
+	defaultTempo       = 120
+	defaultBeatsPerBar = 4
+)
+
+type Quantize int
+
+const (
+	// QuantizeNone transitions on the next frame mixed.
+	QuantizeNone Quantize = iota
+	// QuantizeBeat transitions on the next beat of the playing track.
+	QuantizeBeat
+	// QuantizeBar transitions on the next bar of the playing track.
+	QuantizeBar
+)
+
+/*
+Stem is one layer of a Track, either an Asset or a StreamAsset.
+*/
+type Stem struct {
+	Asset  audio.Asset
+	Stream audio.StreamAsset
+	// Volume is the linear gain of the stem at full intensity. Defaults to 1
+	// if <= 0.
+	Volume float32
+	// MinIntensity and MaxIntensity are the range of the controller's
+	// intensity the stem fades in over. If MaxIntensity <= MinIntensity the
+	// stem plays at full volume from MinIntensity on, the zero values make it
+	// always play.
+	MinIntensity float32
+	MaxIntensity float32
+}
+
+/*
+Track is a piece of music made of stems that play in sync, they should have
+the same length and frequency.
+*/
+type Track struct {
+	Stems []Stem
+	// Tempo in beats per minute. Defaults to 120 if <= 0.
+	Tempo float64
+	// BeatsPerBar defaults to 4 if <= 0.
+	BeatsPerBar int
+	// Offset is the time of the first beat from the start of the track, such
+	// as after a pickup.
+	Offset time.Duration
+	// Loop loops the stems, see mixer.PlayConfig.Loop. The loop has to be a
+	// whole number of bars for transitions to stay on the beat.
+	Loop bool
+}
+
+/*
+Transition is how a Controller moves from the playing track to the next.
+*/
+type Transition struct {
+	// Quantize is where the transition happens.
+	Quantize Quantize
+	// FadeOut fades out the playing track from the transition on.
+	FadeOut time.Duration
+	// FadeIn fades in the next track from its start.
+	FadeIn time.Duration
+	// Stinger plays at the transition and the next track starts when it
+	// ends.
+	Stinger audio.Asset
+}
+
+type Config struct {
+	// Bus the music plays into, defaults to the master bus if nil.
+	Bus *mixer.Bus
+	// Priority of the voices of the music, see mixer.PlayConfig.Priority.
+	Priority int
+}
+
+/*
+Controller plays one Track at a time, the tracks it moves away from keep
+playing until they faded out. A stream must not be in two tracks that play at
+the same time, which includes a track crossfading into itself.
+*/
+type Controller struct {
+	mtx       sync.Mutex
+	m         *mixer.Mixer
+	cfg       Config
+	intensity float32
+	// track is the playing or next track, nil if there is none
+	track *playing
+}
+
+type playing struct {
+	Track
+	// start is the mix frame the track starts at
+	start  int64
+	voices []*mixer.Voice
+}
+
+func New(m *mixer.Mixer, cfg Config) *Controller {
+	return &Controller{m: m, cfg: cfg}
+}
+
+/*
+Play moves to t with tr and returns the mix frame t starts at. Streams are
+played from the start.
+*/
+func (c *Controller) Play(t Track, tr Transition) (int64, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for i, s := range t.Stems {
+		if (s.Asset == nil) == (s.Stream == nil) {
+			return 0, debug.Errorf("Failed to play music: stem %d needs either an Asset or a Stream", i)
+		}
+	}
+	if t.Tempo <= 0 {
+		t.Tempo = defaultTempo
+	}
+	if t.BeatsPerBar <= 0 {
+		t.BeatsPerBar = defaultBeatsPerBar
+	}
+
+	p := &playing{Track: t}
+	for _, s := range t.Stems {
+		cfg := mixer.PlayConfig{
+			Bus:      c.cfg.Bus,
+			FadeIn:   tr.FadeIn,
+			Loop:     t.Loop,
+			Paused:   true,
+			Priority: c.cfg.Priority,
+		}
+		if s.Asset != nil {
+			p.voices = append(p.voices, c.m.Play(s.Asset, cfg))
+		} else {
+			p.voices = append(p.voices, c.m.PlayStream(s.Stream, cfg))
+			if err := p.voices[len(p.voices)-1].Seek(0); err != nil {
+				for _, v := range p.voices {
+					v.Stop(0)
+				}
+				return 0, debug.ErrorWrapf(err, "Failed to play music")
+			}
+		}
+		p.voices[len(p.voices)-1].SetVolume(s.volume(c.intensity), 0)
+	}
+
+	at := c.next(tr.Quantize)
+	c.stop(at, tr.FadeOut)
+	p.start = at + c.stinger(tr.Stinger, at)
+	c.track = p
+	c.m.ResumeAt(p.start, p.voices...)
+	return p.start, nil
+}
+
+/*
+Stop stops the playing track with tr and returns the mix frame it stops at.
+FadeIn is unused.
+*/
+func (c *Controller) Stop(tr Transition) int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	at := c.next(tr.Quantize)
+	c.stop(at, tr.FadeOut)
+	c.stinger(tr.Stinger, at)
+	c.track = nil
+	return at
+}
+
+/*
+Stinger plays a on top of the playing track at q and returns the mix frame it
+starts at.
+*/
+func (c *Controller) Stinger(a audio.Asset, q Quantize) int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	at := c.next(q)
+	c.stinger(a, at)
+	return at
+}
+
+/*
+SetIntensity fades the stems of the playing track to the volume for intensity
+over fade, intensity usually goes from 0 to 1.
+*/
+func (c *Controller) SetIntensity(intensity float32, fade time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.intensity = intensity
+	if c.track == nil {
+		return
+	}
+	for i, v := range c.track.voices {
+		v.SetVolume(c.track.Stems[i].volume(intensity), fade)
+	}
+}
+
+func (c *Controller) Intensity() float32 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.intensity
+}
+
+/*
+Beat returns the beat of the playing track at the mix clock, counting from 0
+at its first beat. It is negative before that and 0 if nothing plays. The mix
+clock runs ahead of what is audible by the mixer's latency.
+*/
+func (c *Controller) Beat() float64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.track == nil {
+		return 0
+	}
+	first, length := c.grid(1)
+	return (float64(c.m.Frame()) - first) / length
+}
+
+/*
+grid returns the mix frame of the first beat of the playing track and the
+length of beats beats in frames, must be called with c.mtx held.
+*/
+func (c *Controller) grid(beats int) (float64, float64) {
+	frequency := float64(c.m.Spec().Frequency)
+	first := float64(c.track.start) + c.track.Offset.Seconds()*frequency
+	return first, 60 / c.track.Tempo * frequency * float64(beats)
+}
+
+/*
+next returns the mix frame of the next q of the playing track, must be called
+with c.mtx held.
+*/
+func (c *Controller) next(q Quantize) int64 {
+	now := c.m.Frame()
+	if c.track == nil || q == QuantizeNone {
+		return now
+	}
+	beats := 1
+	if q == QuantizeBar {
+		beats = c.track.BeatsPerBar
+	}
+	first, length := c.grid(beats)
+	if float64(now) <= first {
+		return int64(math.Ceil(first))
+	}
+	n := math.Ceil((float64(now) - first) / length)
+	at := int64(math.Round(first + n*length))
+	if at < now {
+		at = int64(math.Round(first + (n+1)*length))
+	}
+	return at
+}
+
+/*
+stop stops the playing track at the mix frame at, must be called with c.mtx
+held.
+*/
+func (c *Controller) stop(at int64, fade time.Duration) {
+	if c.track == nil {
+		return
+	}
+	for _, v := range c.track.voices {
+		v.StopAt(at, fade)
+	}
+}
+
+/*
+stinger plays a at the mix frame at and returns its length in frames of the
+mix, must be called with c.mtx held.
+*/
+func (c *Controller) stinger(a audio.Asset, at int64) int64 {
+	if a == nil {
+		return 0
+	}
+	v := c.m.Play(a, mixer.PlayConfig{Bus: c.cfg.Bus, Paused: true, Priority: c.cfg.Priority})
+	c.m.ResumeAt(at, v)
+	return int64(math.Round(a.DurationSeconds() * float64(c.m.Spec().Frequency)))
+}
+
+/*
+volume returns the volume of the stem at intensity.
+*/
+func (s *Stem) volume(intensity float32) float32 {
+	volume := s.Volume
+	if volume <= 0 {
+		volume = 1
+	}
+	switch {
+	case s.MaxIntensity > s.MinIntensity:
+		return volume * min(1, max(0, (intensity-s.MinIntensity)/(s.MaxIntensity-s.MinIntensity)))
+	case intensity >= s.MinIntensity:
+		return volume
+	}
+	return 0
+}
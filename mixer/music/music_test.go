@@ -0,0 +1,200 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package music
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/mixer"
+	"goarrg.com/platform/capture"
+)
+
+type testAsset struct {
+	samples []float32
+}
+
+func (a *testAsset) Track() audio.Track {
+	return audio.TrackOf(audio.ChannelsMono(), [][]float32{a.samples})
+}
+func (a *testAsset) Spec() audio.Spec {
+	return audio.Spec{Channels: audio.ChannelsMono(), Frequency: 1000}
+}
+func (a *testAsset) DurationSamples() int     { return len(a.samples) }
+func (a *testAsset) DurationSeconds() float64 { return float64(len(a.samples)) / 1000 }
+
+/*
+constant returns an asset of frames frames of value.
+*/
+func constant(value float32, frames int) *testAsset {
+	a := &testAsset{make([]float32, frames)}
+	for i := range a.samples {
+		a.samples[i] = value
+	}
+	return a
+}
+
+/*
+newTest returns a controller on a mono 1kHz mixer, rendered by the driver into
+buf.
+*/
+func newTest(t *testing.T) (*Controller, *mixer.Mixer, *capture.Driver) {
+	t.Helper()
+	clock := &capture.Clock{}
+	m := mixer.New(mixer.Config{Spec: audio.Spec{Channels: audio.ChannelsMono(), Frequency: 1000}, Clock: clock.Now})
+	d, err := capture.New(m, capture.Config{Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(m, Config{}), m, d
+}
+
+/*
+check compares the frames from start to end of got to want, which is called
+with the frame.
+*/
+func check(t *testing.T, got audio.Track, start, end int64, want func(i int64) float32) {
+	t.Helper()
+	s := got.Channel(audio.ChannelLeft)
+	for i := start; i < end; i++ {
+		if math.Abs(float64(s[i]-want(i))) > 1e-4 {
+			t.Fatalf("frame %d: got %f want %f", i, s[i], want(i))
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	c, m, d := newTest(t)
+	buf := &capture.Buffer{}
+	// a beat is 100 frames and a bar 400
+	track := func(value float32) Track {
+		return Track{Stems: []Stem{{Asset: constant(value, 5000)}}, Tempo: 600}
+	}
+
+	if start, err := c.Play(track(1), Transition{}); err != nil || start != 0 {
+		t.Fatalf("got start %d %v", start, err)
+	}
+	if err := d.Render(buf, 250); err != nil {
+		t.Fatal(err)
+	}
+	bar, err := c.Play(track(2), Transition{Quantize: QuantizeBar})
+	if err != nil || bar != 400 {
+		t.Fatalf("got bar %d %v at frame %d", bar, err, m.Frame())
+	}
+	if err := d.Render(buf, 300); err != nil {
+		t.Fatal(err)
+	}
+
+	now := m.Frame()
+	// the track starts after the stinger
+	beat, err := c.Play(track(3), Transition{Quantize: QuantizeBeat, Stinger: constant(4, 50)})
+	beat -= 50
+	if err != nil || beat < now || beat%100 != 0 || beat-now > 100 {
+		t.Fatalf("got beat %d %v at frame %d", beat, err, now)
+	}
+	if err := d.Render(buf, int(beat+150)-550); err != nil {
+		t.Fatal(err)
+	}
+	check(t, buf.Track(), 0, beat+150, func(i int64) float32 {
+		switch {
+		case i < bar:
+			return 1
+		case i < beat:
+			return 2
+		case i < beat+50:
+			return 4
+		}
+		return 3
+	})
+	if b := c.Beat(); math.Abs(b-float64(m.Frame()-beat-50)/100) > 1e-9 {
+		t.Errorf("got beat %f at frame %d", b, m.Frame())
+	}
+
+	// bars count from the start of the track, a fade out starts at the
+	// transition and buf continues where it ended
+	buf.Reset()
+	stop := c.Stop(Transition{Quantize: QuantizeBar, FadeOut: 100 * time.Millisecond})
+	if (stop-beat-50)%400 != 0 {
+		t.Fatalf("got stop %d", stop)
+	}
+	if err := d.Render(buf, int(stop-beat)); err != nil {
+		t.Fatal(err)
+	}
+	check(t, buf.Track(), 0, stop-beat, func(i int64) float32 {
+		i += beat + 150
+		switch {
+		case i < stop:
+			return 3
+		case i < stop+100:
+			return 3 * (1 - float32(i-stop+1)/100)
+		}
+		return 0
+	})
+}
+
+func TestLayers(t *testing.T) {
+	c, m, d := newTest(t)
+	buf := &capture.Buffer{}
+	ramp := &testAsset{make([]float32, 5000)}
+	for i := range ramp.samples {
+		ramp.samples[i] = float32(i)
+	}
+
+	start, err := c.Play(Track{Stems: []Stem{
+		{Asset: constant(1, 5000)},
+		{Asset: ramp, MinIntensity: 0.5, MaxIntensity: 1},
+	}}, Transition{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Render(buf, 100); err != nil {
+		t.Fatal(err)
+	}
+	full := m.Frame()
+	c.SetIntensity(1, 0)
+	if err := d.Render(buf, int(full)); err != nil {
+		t.Fatal(err)
+	}
+	half := m.Frame()
+	c.SetIntensity(0.75, 0)
+	if c.Intensity() != 0.75 {
+		t.Errorf("got intensity %f", c.Intensity())
+	}
+	if err := d.Render(buf, int(half+100)-buf.Track().Frames()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the stems play in sync to the frame
+	check(t, buf.Track(), 0, half+100, func(i int64) float32 {
+		switch {
+		case i < full:
+			return 1
+		case i < half:
+			return 1 + float32(i-start)
+		}
+		return 1 + 0.5*float32(i-start)
+	})
+
+	if _, err := c.Play(Track{Stems: []Stem{{}}}, Transition{}); err == nil {
+		t.Error("played a stem without audio")
+	}
+}
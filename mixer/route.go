@@ -0,0 +1,84 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mixer
+
+import (
+	"math"
+	"slices"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/remix"
+)
+
+/*
+route sends a source channel to an output channel, out is the index into the
+output spec's channels.
+*/
+type route struct {
+	out  int
+	gain float32
+}
+
+/*
+routes returns the routes of every channel in src to the channels in out with
+pan applied.
+
+A mono source is panned with a constant power law between left and right, or
+played as is on mono outputs. Other sources are remixed to the output with the
+matrix remix builds from cfg and pan balances the left and right sides.
+*/
+func routes(src, out []audio.Channel, pan float32, cfg remix.Config) [][]route {
+	pan = max(-1, min(1, pan))
+	index := func(c audio.Channel) int {
+		return slices.Index(out, c)
+	}
+	l, r := index(audio.ChannelLeft), index(audio.ChannelRight)
+	result := make([][]route, len(src))
+
+	if len(src) == 1 && src[0] == audio.ChannelLeft && l >= 0 && r >= 0 {
+		angle := float64(pan+1) * math.Pi / 4
+		result[0] = []route{{l, float32(math.Cos(angle))}, {r, float32(math.Sin(angle))}}
+		return result
+	}
+
+	m := remix.New(src, out, cfg)
+	for o, row := range m.Gains {
+		for i, g := range row {
+			if g != 0 {
+				result[i] = append(result[i], route{o, g})
+			}
+		}
+	}
+
+	if pan != 0 {
+		monoOut := r < 0
+		left, right := min(1, 1-pan), min(1, 1+pan)
+		for _, rs := range result {
+			for j := range rs {
+				switch out[rs[j].out] {
+				case audio.ChannelLeft, audio.ChannelSurroundLeft, audio.ChannelBackSurroundLeft:
+					if !monoOut {
+						rs[j].gain *= left
+					}
+				case audio.ChannelRight, audio.ChannelSurroundRight, audio.ChannelBackSurroundRight:
+					rs[j].gain *= right
+				}
+			}
+		}
+	}
+	return result
+}
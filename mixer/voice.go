@@ -0,0 +1,748 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mixer
+
+import (
+	"io"
+	"math"
+	"slices"
+	"time"
+
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/dsp"
+	"goarrg.com/asset/audio/resample"
+	"goarrg.com/debug"
+)
+
+// voiceChunk is the number of source frames read at a time
+const voiceChunk = 1024
+
+var (
+	// silence is played by streams whose decoder fell behind
+	silence [voiceChunk]float32
+	// errStarved is returned along with silence by streamSource.read
+	errStarved = debug.Errorf("Stream decoder fell behind")
+)
+
+type VoiceState int
+
+const (
+	VoiceStopped VoiceState = iota
+	VoicePlaying
+	VoicePaused
+)
+
+type PlayConfig struct {
+	// Bus the voice plays into, defaults to the master bus if nil.
+	Bus *Bus
+	// Volume is the linear gain of the voice. Defaults to 1 if <= 0, use
+	// FadeIn to start silent.
+	Volume float32
+	// Pan moves the voice from -1 left to 1 right.
+	Pan float32
+	// Pitch scales the playback rate and with it the pitch. Defaults to 1 if
+	// <= 0.
+	Pitch float32
+	// FadeIn fades the voice in from silence.
+	FadeIn time.Duration
+	// Loop loops the loop of the source's audio.Metadata, after playing
+	// anything before it, or the whole source if it has none.
+	Loop bool
+	// Paused starts the voice paused, see Mixer.ResumeAt.
+	Paused bool
+	// Priority decides which voice is stolen when there are no voices left,
+	// the lowest priority and then the oldest voice is stolen. A voice is
+	// only stolen by a voice with at least its priority.
+	Priority int
+	// Effects process the voice after it is panned, see Voice.SetEffects.
+	// Effects that are a dsp.Pitcher also scale the pitch.
+	Effects []dsp.Processor
+	// OnMarker is called by Mixer.Update for every marker of the source's
+	// audio.Metadata that played since the last Update.
+	OnMarker func(v *Voice, marker audio.Marker)
+}
+
+/*
+Voice is a source playing on the mixer, a stopped voice can not be restarted.
+*/
+type Voice struct {
+	m        *Mixer
+	src      source
+	bus      *Bus
+	state    VoiceState
+	priority int
+	serial   uint64
+
+	volume ramp
+	// fade is used by FadeIn, Pause, Resume and Stop
+	fade ramp
+	// fadeTo is the state the voice goes to when fade finishes
+	fadeTo VoiceState
+	// start is the mix frame the voice starts at and stopAt the one it stops
+	// at, fading out over stopFrames, or -1
+	start      int64
+	stopAt     int64
+	stopFrames int
+	pan        float32
+	pitch      float32
+	loop       bool
+	effects    dsp.Chain
+	// gain normalises the loudness of the source to Config.Loudness
+	gain float32
+
+	// loopStart and loopEnd are the loop of the source's metadata, loopEnd is
+	// 0 to loop the whole source
+	loopStart int
+	loopEnd   int
+	markers   []audio.Marker
+	onMarker  func(*Voice, audio.Marker)
+
+	routed bool
+	routes [][]route
+	// rs converts the source to the output frequency and pitch, in holds the
+	// planar source frames read for it and out its output with view slicing out
+	rs    *resample.Resampler
+	in    [][]float32
+	out   [][]float32
+	view  [][]float32
+	ended bool
+	// written counts the frames written to rs since it was last reset and
+	// played those it consumed by the end of the last render, segments maps
+	// them to frames of the source
+	written  int
+	played   int
+	segments []segment
+}
+
+/*
+segment is a run of frames written to the resampler that are contiguous in the
+source, starting at written frame at and source frame frame. A silent segment
+is silence a stream played while its decoder fell behind, it stays at frame.
+*/
+type segment struct {
+	at     int
+	frame  int
+	silent bool
+}
+
+/*
+source reads planar frames from an asset or stream, frames are appended to dst
+in the channel order of the spec.
+*/
+type source interface {
+	spec() audio.Spec
+	// frames returns the length of the source or -1 if it is unknown
+	frames() int
+	read(dst [][]float32, n int) ([][]float32, error)
+	seek(frame int) error
+	// loop loops the frames [start, end) of the source, end <= 0 is the end
+	// of the source and start < 0 disables looping.
+	loop(start, end int) error
+	position() int
+}
+
+type assetSource struct {
+	specs  audio.Spec
+	track  audio.Track
+	length int
+	pos    int
+	// loopEnd is the end of the loop or 0 if not looping
+	loopStart int
+	loopEnd   int
+}
+
+type streamSource struct {
+	stream audio.StreamAsset
+}
+
+func (m *Mixer) play(src source, meta audio.Metadata, cfg PlayConfig) *Voice {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if cfg.Bus == nil {
+		cfg.Bus = m.buses[0]
+	}
+	if cfg.Bus.m != m {
+		panic("mixer: bus belongs to a different mixer")
+	}
+	if cfg.Volume <= 0 {
+		cfg.Volume = 1
+	}
+	if cfg.Pitch <= 0 {
+		cfg.Pitch = 1
+	}
+
+	m.serial++
+	v := &Voice{
+		m:         m,
+		src:       src,
+		bus:       cfg.Bus,
+		state:     VoicePlaying,
+		priority:  cfg.Priority,
+		serial:    m.serial,
+		volume:    ramp{value: cfg.Volume, target: cfg.Volume},
+		fade:      ramp{value: 1, target: 1},
+		fadeTo:    VoicePlaying,
+		pan:       cfg.Pan,
+		pitch:     cfg.Pitch,
+		loop:      cfg.Loop,
+		effects:   append(dsp.Chain(nil), cfg.Effects...),
+		rs:        resample.New(len(src.spec().Channels), m.cfg.Resample),
+		in:        make([][]float32, len(src.spec().Channels)),
+		out:       make([][]float32, len(src.spec().Channels)),
+		view:      make([][]float32, len(src.spec().Channels)),
+		gain:      1,
+		loopStart: meta.LoopStart,
+		loopEnd:   meta.LoopEnd,
+		markers:   meta.Markers,
+		onMarker:  cfg.OnMarker,
+		segments:  []segment{{0, src.position(), false}},
+		stopAt:    -1,
+	}
+	if m.cfg.Loudness != 0 && meta.Loudness != 0 && !math.IsInf(meta.Loudness, 0) {
+		v.gain = float32(math.Pow(10, (m.cfg.Loudness-meta.Loudness)/20))
+	}
+	if cfg.Loop {
+		if err := src.loop(meta.LoopStart, meta.LoopEnd); err != nil {
+			logger.EPrintf("Failed to loop voice: %v", err)
+		}
+	}
+	if cfg.Paused {
+		v.state = VoicePaused
+	}
+	if m.spec.Frequency > 0 {
+		v.effects.Init(m.spec)
+	}
+	if cfg.FadeIn > 0 {
+		v.fade.value = 0
+		v.fade.set(1, m.durationFrames(cfg.FadeIn))
+	}
+
+	m.voices = slices.DeleteFunc(m.voices, func(o *Voice) bool { return o.state == VoiceStopped })
+	if len(m.voices) >= m.cfg.MaxVoices {
+		var victim *Voice
+		for _, o := range m.voices {
+			if victim == nil || o.priority < victim.priority || (o.priority == victim.priority && o.serial < victim.serial) {
+				victim = o
+			}
+		}
+		if victim == nil || victim.priority > v.priority {
+			logger.VPrintf("No voice available for priority %d", v.priority)
+			v.state = VoiceStopped
+			return v
+		}
+		victim.stop()
+		m.voices = slices.DeleteFunc(m.voices, func(o *Voice) bool { return o == victim })
+	}
+	m.voices = append(m.voices, v)
+	return v
+}
+
+/*
+State returns whether the voice is playing, paused or stopped, a voice that is
+fading out to pause or stop is still playing.
+*/
+func (v *Voice) State() VoiceState {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+	return v.state
+}
+
+/*
+Stop stops the voice after fading it out over fade.
+*/
+func (v *Voice) Stop(fade time.Duration) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	v.stopFade(v.m.durationFrames(fade))
+}
+
+/*
+StopAt stops the voice at frame of the mix clock, see Mixer.Frame, after fading
+it out over fade from there. It stops right away if frame was already mixed.
+*/
+func (v *Voice) StopAt(frame int64, fade time.Duration) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	if v.state == VoiceStopped {
+		return
+	}
+	if frame <= v.m.frame {
+		v.stopFade(v.m.durationFrames(fade))
+		return
+	}
+	v.stopAt, v.stopFrames = frame, v.m.durationFrames(fade)
+}
+
+/*
+stopFade stops the voice after fading it out over frames, must be called with
+m.mtx held.
+*/
+func (v *Voice) stopFade(frames int) {
+	if v.state != VoicePlaying || frames <= 0 {
+		v.stop()
+		return
+	}
+	v.fade.set(0, frames)
+	v.fadeTo = VoiceStopped
+}
+
+/*
+Pause pauses the voice after fading it out over fade.
+*/
+func (v *Voice) Pause(fade time.Duration) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	if v.state != VoicePlaying || v.fadeTo == VoiceStopped {
+		return
+	}
+	if fade <= 0 {
+		v.state = VoicePaused
+		v.fade.set(0, 0)
+		return
+	}
+	v.fade.set(0, v.m.durationFrames(fade))
+	v.fadeTo = VoicePaused
+}
+
+/*
+Resume continues a paused voice, fading it in over fade.
+*/
+func (v *Voice) Resume(fade time.Duration) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	if v.state == VoiceStopped || v.fadeTo == VoiceStopped {
+		return
+	}
+	v.state, v.fadeTo = VoicePlaying, VoicePlaying
+	v.fade.set(1, v.m.durationFrames(fade))
+}
+
+/*
+SetVolume changes the linear gain of the voice over fade.
+*/
+func (v *Voice) SetVolume(volume float32, fade time.Duration) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+	v.volume.set(max(0, volume), v.m.durationFrames(fade))
+}
+
+func (v *Voice) SetPan(pan float32) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+	v.pan, v.routed = pan, false
+}
+
+func (v *Voice) SetPitch(pitch float32) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+	if pitch > 0 {
+		v.pitch = pitch
+	}
+}
+
+/*
+SetLoop changes whether the voice loops, frames the voice already read ahead
+for resampling still play.
+*/
+func (v *Voice) SetLoop(loop bool) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	start := -1
+	if loop {
+		start = v.loopStart
+	}
+	if err := v.src.loop(start, v.loopEnd); err != nil {
+		logger.EPrintf("Failed to loop voice: %v", err)
+	}
+	v.loop = loop
+}
+
+/*
+SetEffects replaces the effects of the voice, which process it in the output
+channels before it is mixed into its bus. They stop with the voice so effects
+with a tail, like reverb, belong on a bus. Effects must not be used anywhere
+else.
+*/
+func (v *Voice) SetEffects(effects ...dsp.Processor) {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+	v.effects = append(dsp.Chain(nil), effects...)
+	if v.m.spec.Frequency > 0 {
+		v.effects.Init(v.m.spec)
+	}
+}
+
+/*
+Position returns the frame of the source that is playing.
+*/
+func (v *Voice) Position() int {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	if v.rs == nil {
+		return v.frameAt(v.played)
+	}
+	return v.frameAt(v.written - v.rs.Buffered())
+}
+
+/*
+frameAt returns the source frame of frame written of the resampler, which must
+not be before the first segment.
+*/
+func (v *Voice) frameAt(written int) int {
+	i := len(v.segments) - 1
+	for i > 0 && v.segments[i].at > written {
+		i--
+	}
+	if v.segments[i].silent {
+		return v.segments[i].frame
+	}
+	return v.segments[i].frame + written - v.segments[i].at
+}
+
+/*
+Seek makes the voice continue at frame of its source.
+*/
+func (v *Voice) Seek(frame int) error {
+	v.m.mtx.Lock()
+	defer v.m.mtx.Unlock()
+
+	if err := v.src.seek(frame); err != nil {
+		return err
+	}
+	if v.rs != nil {
+		v.rs.Reset()
+	}
+	v.ended = false
+	v.written, v.played = 0, 0
+	v.segments = append(v.segments[:0], segment{0, frame, false})
+	return nil
+}
+
+/*
+stop stops the voice immediately, must be called with m.mtx held.
+*/
+func (v *Voice) stop() {
+	v.state, v.fadeTo = VoiceStopped, VoiceStopped
+	v.rs, v.in, v.out, v.view = nil, nil, nil, nil
+}
+
+/*
+fill writes at least need frames of the source to the resampler, ending it if
+the source ends first.
+*/
+func (v *Voice) fill(need int) {
+	for need > 0 && !v.ended {
+		for i := range v.in {
+			v.in[i] = v.in[i][:0]
+		}
+		var err error
+		v.in, err = v.src.read(v.in, v.readSize())
+		silent := err == errStarved //nolint:errorlint
+		if silent {
+			err = nil
+		}
+		if err == nil && len(v.in[0]) == 0 {
+			// a source that returns nothing without ending is treated as ended
+			err = io.EOF
+		}
+		if err != nil {
+			if err != io.EOF {
+				logger.EPrintf("Stopping voice: %v", err)
+			}
+			v.ended = true
+			v.rs.End()
+			return
+		}
+		v.rs.Write(v.in)
+		pos := v.src.position()
+		switch last := v.segments[len(v.segments)-1]; {
+		case silent:
+			if !last.silent {
+				v.segments = append(v.segments, segment{v.written, pos, true})
+			}
+		case last.silent || v.frameAt(v.written) != pos-len(v.in[0]):
+			v.segments = append(v.segments, segment{v.written, pos - len(v.in[0]), false})
+		}
+		v.written += len(v.in[0])
+		need -= len(v.in[0])
+	}
+}
+
+/*
+readSize returns how many frames fill reads at a time, stopping at the end of
+the loop so a read never spans the jump back to its start.
+*/
+func (v *Voice) readSize() int {
+	end := v.loopEnd
+	if end <= 0 {
+		end = v.src.frames()
+	}
+	if v.loop && end > 0 {
+		if p := v.src.position(); p < end {
+			return min(voiceChunk, end-p)
+		}
+	}
+	return voiceChunk
+}
+
+/*
+queueMarkers queues the markers of the source frames consumed by the last render and
+forgets the segments before them.
+*/
+func (v *Voice) queueMarkers() {
+	played := v.written - v.rs.Buffered()
+	if v.onMarker != nil && len(v.markers) > 0 {
+		for i, s := range v.segments {
+			end := played
+			if i+1 < len(v.segments) {
+				end = min(end, v.segments[i+1].at)
+			}
+			start := max(v.played, s.at)
+			if s.silent || start >= end {
+				continue
+			}
+			from, to := s.frame+start-s.at, s.frame+end-s.at
+			j, _ := slices.BinarySearchFunc(v.markers, from, func(mk audio.Marker, f int) int { return mk.Frame - f })
+			for ; j < len(v.markers) && v.markers[j].Frame < to; j++ {
+				v.m.events = append(v.m.events, markerEvent{v, v.markers[j]})
+			}
+		}
+	}
+	for len(v.segments) > 1 && v.segments[1].at <= played {
+		v.segments = v.segments[1:]
+	}
+	v.played = played
+}
+
+/*
+mix mixes the voice into the frames of its bus the mixer is rendering,
+starting and stopping it at the frames they are scheduled for. Must be called
+with m.mtx held.
+*/
+func (v *Voice) mix(frames int) {
+	from := int(min(int64(frames), max(0, v.start-v.m.frame)))
+	to := frames
+	if v.stopAt >= 0 {
+		to = int(min(int64(frames), max(int64(from), v.stopAt-v.m.frame)))
+	}
+	if v.state == VoicePlaying && from < to {
+		v.render(from, to-from)
+	}
+	if v.stopAt < 0 || v.stopAt >= v.m.frame+int64(frames) || v.state == VoiceStopped {
+		return
+	}
+	if v.stopAt <= v.start {
+		v.stop()
+		return
+	}
+	v.stopAt = -1
+	v.stopFade(v.stopFrames)
+	if v.state == VoicePlaying && to < frames {
+		v.render(to, frames-to)
+	}
+}
+
+/*
+render mixes frames of the voice into its bus starting at offset, must be
+called with m.mtx held.
+*/
+func (v *Voice) render(offset, frames int) {
+	spec := v.src.spec()
+	out := v.m.spec
+	if !v.routed {
+		v.routes = routes(spec.Channels, out.Channels, v.pan, v.m.cfg.Remix)
+		v.routed = true
+	}
+	if v.rs == nil {
+		v.stop()
+		return
+	}
+	if v.fadeTo != VoicePlaying {
+		// the voice pauses or stops where the fade ends
+		frames = min(frames, max(1, v.fade.frames))
+	}
+	if len(v.out[0]) < frames {
+		for c := range v.out {
+			v.out[c] = make([]float32, frames)
+		}
+	}
+	pitch := v.pitch
+	for _, e := range v.effects {
+		if p, ok := e.(dsp.Pitcher); ok {
+			pitch *= p.Pitch()
+		}
+	}
+	step := float64(pitch) * float64(spec.Frequency) / float64(out.Frequency)
+
+	n := 0
+	for n < frames {
+		for c := range v.view {
+			v.view[c] = v.out[c][n:frames]
+		}
+		n += v.rs.Read(v.view, step)
+		if n == frames || v.ended {
+			break
+		}
+		v.fill(max(1, v.rs.Need(frames-n, step)))
+	}
+	v.queueMarkers()
+
+	buf, at := v.bus.buf, offset
+	if len(v.effects) > 0 {
+		buf, at = v.m.scratch, 0
+		for _, c := range buf {
+			clear(c[:n])
+		}
+	}
+	for i := 0; i < n; i++ {
+		gain := v.volume.next() * v.fade.next() * v.gain
+		for c, rs := range v.routes {
+			s := v.out[c][i] * gain
+			for _, r := range rs {
+				buf[r.out][at+i] += s * r.gain
+			}
+		}
+	}
+	if len(v.effects) > 0 {
+		v.effects.Process(buf, n)
+		for c, b := range v.bus.buf {
+			for i, s := range buf[c][:n] {
+				b[offset+i] += s
+			}
+		}
+	}
+
+	if n < frames {
+		v.stop()
+		return
+	}
+	if v.fadeTo != VoicePlaying && v.fade.frames == 0 {
+		if v.fadeTo == VoiceStopped {
+			v.stop()
+			return
+		}
+		v.state, v.fadeTo = VoicePaused, VoicePlaying
+	}
+}
+
+func (s *assetSource) spec() audio.Spec {
+	return s.specs
+}
+
+func (s *assetSource) frames() int {
+	return s.length
+}
+
+func (s *assetSource) read(dst [][]float32, n int) ([][]float32, error) {
+	end := s.length
+	if s.loopEnd > 0 && s.pos <= s.loopEnd {
+		end = s.loopEnd
+	}
+	if s.pos >= end {
+		if s.loopEnd == 0 {
+			return dst, io.EOF
+		}
+		s.pos, end = s.loopStart, s.loopEnd
+	}
+	end = min(end, s.pos+n)
+	for i, c := range s.specs.Channels {
+		dst[i] = append(dst[i], s.track.Channel(c)[s.pos:end]...)
+	}
+	s.pos = end
+	return dst, nil
+}
+
+func (s *assetSource) seek(frame int) error {
+	if frame < 0 || frame > s.length {
+		return debug.Errorf("Invalid seek to frame %d", frame)
+	}
+	s.pos = frame
+	return nil
+}
+
+func (s *assetSource) loop(start, end int) error {
+	if start < 0 {
+		s.loopStart, s.loopEnd = 0, 0
+		return nil
+	}
+	if end <= 0 {
+		end = s.length
+	}
+	if start >= end || end > s.length {
+		return debug.Errorf("Invalid loop [%d, %d)", start, end)
+	}
+	s.loopStart, s.loopEnd = start, end
+	return nil
+}
+
+func (s *assetSource) position() int {
+	return s.pos
+}
+
+func (s *streamSource) spec() audio.Spec {
+	return s.stream.Spec()
+}
+
+func (s *streamSource) frames() int {
+	return s.stream.DurationSamples()
+}
+
+/*
+read never waits for the decoder as it is called from the mix with the mixer
+locked, if nothing is buffered it returns n frames of silence and errStarved
+instead.
+*/
+func (s *streamSource) read(dst [][]float32, n int) ([][]float32, error) {
+	switch buffered := s.stream.Buffered(); {
+	case buffered == 0:
+		n = min(n, len(silence))
+		for i := range dst {
+			dst[i] = append(dst[i], silence[:n]...)
+		}
+		return dst, errStarved
+	case buffered > 0:
+		n = min(n, buffered)
+	}
+
+	track, err := s.stream.Read(n)
+	if err != nil {
+		return dst, err
+	}
+	for i, c := range s.stream.Spec().Channels {
+		dst[i] = append(dst[i], track.Channel(c)...)
+	}
+	return dst, nil
+}
+
+func (s *streamSource) seek(frame int) error {
+	return s.stream.Seek(frame)
+}
+
+func (s *streamSource) loop(start, end int) error {
+	return s.stream.Loop(start, end)
+}
+
+func (s *streamSource) position() int {
+	return s.stream.Position()
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package capture is an audio output driver without a device, it calls Mix on a
+goarrg.Audio as a simulated clock advances and hands the result to a Sink
+such as a Buffer in memory or a wav.Encoder. Rendering is as fast as the Audio
+can mix and gives the same result every time, for golden tests in CI or
+exporting gameplay audio.
+
+	clock := &capture.Clock{}
+	m := mixer.New(mixer.Config{Clock: clock.Now})
+	d, err := capture.New(m, capture.Config{Clock: clock})
+	// set up the scene
+	buf := &capture.Buffer{}
+	err = d.Render(buf, 5*d.Spec().Frequency)
+	err = capture.Compare(buf.Track(), golden, 1e-4)
+*/
+package capture
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"goarrg.com"
+	"goarrg.com/asset/audio"
+	"goarrg.com/debug"
+)
+
+const (
+	defaultFrequency = 48000
+	defaultPeriod    = 10 * time.Millisecond
+	// maxSilentTime is how long the clock runs without the Audio mixing
+	// anything before Render gives up
+	maxSilentTime = 10 * time.Second
+)
+
+type Config struct {
+	// Spec the Audio is initialized with. Defaults to the Audio's AudioConfig,
+	// and to stereo at 48kHz if that is empty too.
+	Spec audio.Spec
+	// Clock is advanced between calls to Mix and should be what the Audio
+	// renders by. Defaults to a new Clock if nil.
+	Clock *Clock
+	// Period is how far the clock advances between calls to Mix, like the
+	// time between frames of a game. Defaults to 10ms if <= 0.
+	Period time.Duration
+}
+
+/*
+Clock is a simulated clock that only moves when advanced, it starts at the
+Unix epoch. It is safe to use from any goroutine.
+*/
+type Clock struct {
+	elapsed atomic.Int64
+}
+
+func (c *Clock) Now() time.Time {
+	return time.Unix(0, 0).Add(time.Duration(c.elapsed.Load()))
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.elapsed.Add(int64(d))
+}
+
+/*
+Sink receives the audio rendered by a Driver.
+*/
+type Sink interface {
+	// Write is called with every block of audio in order, t is only valid
+	// until Write returns.
+	Write(t audio.Track) error
+}
+
+/*
+Buffer is a Sink keeping everything written to it in memory.
+*/
+type Buffer struct {
+	channels []audio.Channel
+	samples  [][]float32
+}
+
+func (b *Buffer) Write(t audio.Track) error {
+	if b.samples == nil {
+		b.channels = append([]audio.Channel(nil), t.Channels()...)
+		b.samples = make([][]float32, len(b.channels))
+	}
+	for i, c := range b.channels {
+		if s := t.Channel(c); s != nil {
+			b.samples[i] = append(b.samples[i], s...)
+		} else {
+			b.samples[i] = append(b.samples[i], make([]float32, t.Frames())...)
+		}
+	}
+	return nil
+}
+
+/*
+Track returns everything written so far, in the channels of the first write.
+*/
+func (b *Buffer) Track() audio.Track {
+	return audio.TrackOf(b.channels, b.samples)
+}
+
+func (b *Buffer) Reset() {
+	b.channels, b.samples = nil, nil
+}
+
+/*
+Driver renders a goarrg.Audio on a simulated clock, it is not safe to use from
+multiple goroutines.
+*/
+type Driver struct {
+	audio goarrg.Audio
+	cfg   Config
+	// pending holds frames mixed beyond what the last Render asked for from
+	// start to end, they begin the next Render
+	pending audio.Track
+	start   int
+	end     int
+	view    [][]float32
+}
+
+/*
+New initializes a for cfg.Spec, the Audio should be rendering by cfg.Clock.
+*/
+func New(a goarrg.Audio, cfg Config) (*Driver, error) {
+	if len(cfg.Spec.Channels) == 0 || cfg.Spec.Frequency <= 0 {
+		cfg.Spec = a.AudioConfig().Spec
+	}
+	if len(cfg.Spec.Channels) == 0 {
+		cfg.Spec.Channels = audio.ChannelsStereo()
+	}
+	if cfg.Spec.Frequency <= 0 {
+		cfg.Spec.Frequency = defaultFrequency
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = &Clock{}
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = defaultPeriod
+	}
+	if err := a.Init(platformInterface{}, goarrg.AudioConfig{Spec: cfg.Spec}); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to init capture")
+	}
+	return &Driver{
+		audio: a,
+		cfg:   cfg,
+		view:  make([][]float32, len(cfg.Spec.Channels)),
+	}, nil
+}
+
+func (d *Driver) Spec() audio.Spec {
+	return d.cfg.Spec
+}
+
+func (d *Driver) Clock() *Clock {
+	return d.cfg.Clock
+}
+
+/*
+Render mixes frames frames into sink, advancing the clock by the period after
+every call to Mix. Frames mixed beyond that are kept for the next Render.
+*/
+func (d *Driver) Render(sink Sink, frames int) error {
+	silent := time.Duration(0)
+	for frames > 0 {
+		if d.start < d.end {
+			n := min(frames, d.end-d.start)
+			t := d.slice(d.pending, d.start, d.start+n)
+			d.start += n
+			frames -= n
+			if err := sink.Write(t); err != nil {
+				return debug.ErrorWrapf(err, "Failed to capture audio")
+			}
+			continue
+		}
+
+		n, track := d.audio.Mix()
+		d.cfg.Clock.Advance(d.cfg.Period)
+		if n <= 0 {
+			silent += d.cfg.Period
+			if silent >= maxSilentTime {
+				return debug.Errorf("Failed to capture audio, nothing was mixed in %v", silent)
+			}
+			continue
+		}
+		silent = 0
+
+		if n > frames {
+			// keep the rest for the next Render
+			d.keep(track, frames, n)
+			n = frames
+		}
+		frames -= n
+		if err := sink.Write(d.slice(track, 0, n)); err != nil {
+			return debug.ErrorWrapf(err, "Failed to capture audio")
+		}
+	}
+	return nil
+}
+
+/*
+keep copies frames from start to end of t into d.pending.
+*/
+func (d *Driver) keep(t audio.Track, start, end int) {
+	if d.pending.Frames() < end-start || len(d.pending.Channels()) != len(t.Channels()) {
+		d.pending = audio.NewTrack(append([]audio.Channel(nil), t.Channels()...), max(end-start, d.cfg.Spec.Frequency))
+	}
+	for i, s := range t.Samples() {
+		copy(d.pending.Samples()[i], s[start:end])
+	}
+	d.start, d.end = 0, end-start
+}
+
+/*
+slice returns the frames from start to end of t, the track is only valid until
+the next call.
+*/
+func (d *Driver) slice(t audio.Track, start, end int) audio.Track {
+	if len(d.view) < len(t.Samples()) {
+		d.view = make([][]float32, len(t.Samples()))
+	}
+	view := d.view[:len(t.Samples())]
+	for i, s := range t.Samples() {
+		view[i] = s[start:end]
+	}
+	return audio.TrackOf(t.Channels(), view)
+}
+
+func (d *Driver) Destroy() {
+	d.audio.Destroy()
+}
+
+/*
+Compare returns an error describing the first sample of got that differs from
+want by more than tolerance, or if they differ in channels or length.
+*/
+func Compare(got, want audio.Track, tolerance float32) error {
+	if got.Frames() != want.Frames() {
+		return debug.Errorf("Got %d frames want %d", got.Frames(), want.Frames())
+	}
+	if len(got.Channels()) != len(want.Channels()) {
+		return debug.Errorf("Got channels %v want %v", got.Channels(), want.Channels())
+	}
+	for _, c := range want.Channels() {
+		g, w := got.Channel(c), want.Channel(c)
+		if g == nil {
+			return debug.Errorf("Got channels %v want %v", got.Channels(), want.Channels())
+		}
+		for i := range w {
+			if d := math.Abs(float64(g[i] - w[i])); d > float64(tolerance) || math.IsNaN(d) {
+				return debug.Errorf("Channel %v frame %d: got %f want %f", c, i, g[i], w[i])
+			}
+		}
+	}
+	return nil
+}
+
+type platformInterface struct{}
+
+func (platformInterface) Abort() {
+	panic("Fatal Error")
+}
+
+func (platformInterface) AbortPopup(format string, args ...interface{}) {
+	panic(debug.Errorf(format, args...))
+}
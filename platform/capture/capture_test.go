@@ -0,0 +1,160 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capture
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"goarrg.com"
+	"goarrg.com/asset/audio"
+	"goarrg.com/asset/audio/wav"
+	"goarrg.com/mixer"
+)
+
+const h = math.Sqrt2 / 2
+
+type testAsset struct {
+	samples []float32
+}
+
+func (a *testAsset) Track() audio.Track {
+	return audio.TrackOf(audio.ChannelsMono(), [][]float32{a.samples})
+}
+func (a *testAsset) Spec() audio.Spec {
+	return audio.Spec{Channels: audio.ChannelsMono(), Frequency: 1000}
+}
+func (a *testAsset) DurationSamples() int     { return len(a.samples) }
+func (a *testAsset) DurationSeconds() float64 { return float64(len(a.samples)) / 1000 }
+
+/*
+scene returns a driver for a mixer playing two seconds of ones at 1kHz.
+*/
+func scene(t *testing.T) *Driver {
+	clock := &Clock{}
+	m := mixer.New(mixer.Config{Spec: audio.Spec{Channels: audio.ChannelsStereo(), Frequency: 1000}, Clock: clock.Now})
+	d, err := New(m, Config{Clock: clock, Period: 16 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ones := make([]float32, 2000)
+	for i := range ones {
+		ones[i] = 1
+	}
+	m.Play(&testAsset{ones}, mixer.PlayConfig{})
+	return d
+}
+
+func TestRender(t *testing.T) {
+	d := scene(t)
+	if s := d.Spec(); s.Frequency != 1000 || len(s.Channels) != 2 {
+		t.Fatalf("got spec %+v", s)
+	}
+	buf := &Buffer{}
+	if err := d.Render(buf, 3000); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := audio.NewTrack(audio.ChannelsStereo(), 3000)
+	for _, s := range golden.Samples() {
+		for i := range s[:2000] {
+			s[i] = h
+		}
+	}
+	if err := Compare(buf.Track(), golden, 1e-6); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := d.Clock().Now().Sub(time.Unix(0, 0)); elapsed < 2900*time.Millisecond || elapsed > 3100*time.Millisecond {
+		t.Errorf("clock advanced %v for 3s of audio", elapsed)
+	}
+
+	// rendering in uneven parts gives the same result
+	d = scene(t)
+	parts := &Buffer{}
+	for _, n := range []int{1, 999, 7, 1993} {
+		if err := d.Render(parts, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Compare(parts.Track(), golden, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	golden.Channel(audio.ChannelRight)[1000] = 0
+	if err := Compare(buf.Track(), golden, 0.5); err == nil {
+		t.Error("expected a difference")
+	}
+}
+
+func TestWAV(t *testing.T) {
+	d := scene(t)
+	name := filepath.Join(t.TempDir(), "capture.wav")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := wav.NewEncoder(f, d.Spec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Render(e, 2500); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	a, err := audio.Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &Buffer{}
+	if err := scene(t).Render(buf, 2500); err != nil {
+		t.Fatal(err)
+	}
+	if err := Compare(a.Track(), buf.Track(), 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type silentAudio struct{}
+
+func (silentAudio) AudioConfig() goarrg.AudioConfig                         { return goarrg.AudioConfig{} }
+func (silentAudio) Init(goarrg.PlatformInterface, goarrg.AudioConfig) error { return nil }
+func (silentAudio) Mix() (int, audio.Track)                                 { return 0, audio.Track{} }
+func (silentAudio) Update()                                                 {}
+func (silentAudio) Destroy()                                                {}
+
+func TestSilent(t *testing.T) {
+	d, err := New(silentAudio{}, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := d.Spec(); s.Frequency != 48000 || len(s.Channels) != 2 {
+		t.Errorf("got spec %+v", s)
+	}
+	if err := d.Render(&Buffer{}, 100); err == nil {
+		t.Error("expected an error from an Audio that does not mix")
+	}
+}
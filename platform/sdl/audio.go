@@ -50,7 +50,7 @@ import (
 type audioChannelMask audio.Channel
 
 const (
-	AudioChannelBackCenter audio.Channel = audio.ChannelCount
+	AudioChannelBackCenter audio.Channel = audio.ChannelBackCenter
 	AudioChannelCount                    = audio.ChannelCount + 1
 
 	audioChannelMaskMono            = audioChannelMask(1 << audio.ChannelLeft)
@@ -313,16 +313,8 @@ func (a *audioSystem) update() {
 		return
 	}
 
-	channels := len(a.cfg.Spec.Channels)
-	pushSize, track := a.mixer.Mix()
-	pushSize *= channels
-
-	for i := 0; i < pushSize; i++ {
-		channel := a.cfg.Spec.Channels[i%channels]
-		t := track[channel]
-		cursor := i / channels
-		a.buf[i] = t[cursor]
-	}
+	frames, track := a.mixer.Mix()
+	pushSize := track.Interleave(a.buf[:frames*len(a.cfg.Spec.Channels)], a.cfg.Spec.Channels) * len(a.cfg.Spec.Channels)
 
 	if pushSize > 0 {
 		C.SDL_PutAudioStreamData(a.cStream, unsafe.Pointer(unsafe.SliceData(a.buf)), C.int(pushSize*int(unsafe.Sizeof(float32(0)))))
@@ -30,6 +30,8 @@ const (
 )
 
 type AudioImporterConfig struct {
+	// EnableWAV registers SDL's WAV decoder, formats are tried in registration
+	// order so it is unused if goarrg.com/asset/audio/wav is imported.
 	EnableWAV bool
 }
 
@@ -0,0 +1,215 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package text
+
+import "unicode"
+
+/*
+lbClass is a subset of the line breaking classes from UAX #14, anything not
+listed is treated as AL.
+*/
+type lbClass uint8
+
+const (
+	lbAL lbClass = iota
+	lbBK
+	lbCR
+	lbLF
+	lbNL
+	lbSP
+	lbZW
+	lbWJ
+	lbGL
+	lbBA
+	lbHY
+	lbB2
+	lbOP
+	lbCL
+	lbEX
+	lbIS
+	lbSY
+	lbQU
+	lbNU
+	lbID
+	lbCM
+)
+
+func lineBreakClass(r rune) lbClass {
+	switch r {
+	case '\n':
+		return lbLF
+	case '\r':
+		return lbCR
+	case '\v', '\f', 0x2028, 0x2029:
+		return lbBK
+	case 0x85:
+		return lbNL
+	case ' ':
+		return lbSP
+	case 0x200B:
+		return lbZW
+	case 0x2060, 0xFEFF:
+		return lbWJ
+	case 0xA0, 0x2007, 0x202F, 0x034F:
+		return lbGL
+	case '\t', 0xAD, 0x1680, 0x2010, 0x2012, 0x2013:
+		return lbBA
+	case '-':
+		return lbHY
+	case 0x2014:
+		return lbB2
+	case '(', '[', '{', 0xA1, 0xBF, 0x3008, 0x300A, 0x300C, 0x300E, 0x3010, 0x3014, 0x3016, 0x3018, 0x301A, 0xFF08, 0xFF3B, 0xFF5B:
+		return lbOP
+	case ')', ']', '}', 0x3001, 0x3002, 0x3009, 0x300B, 0x300D, 0x300F, 0x3011, 0x3015, 0x3017, 0x3019, 0x301B, 0xFF09, 0xFF0C, 0xFF0E, 0xFF3D, 0xFF5D:
+		return lbCL
+	case '!', '?', 0xFF01, 0xFF1F:
+		return lbEX
+	case ',', '.', ':', ';':
+		return lbIS
+	case '/':
+		return lbSY
+	case '"', '\'', 0x2018, 0x2019, 0x201C, 0x201D, 0xAB, 0xBB:
+		return lbQU
+	case 0x200D:
+		return lbCM
+	}
+
+	switch {
+	case r >= 0x2000 && r <= 0x200A && r != 0x2007:
+		return lbBA
+	case r >= '0' && r <= '9':
+		return lbNU
+	case (r >= 0x2E80 && r <= 0x9FFF) || (r >= 0xAC00 && r <= 0xD7A3) || (r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0x1F300 && r <= 0x1FAFF) || (r >= 0x20000 && r <= 0x3FFFD):
+		return lbID
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return lbCM
+	}
+	return lbAL
+}
+
+/*
+Break is a line break opportunity before the byte at Offset.
+*/
+type Break struct {
+	Offset int
+	// Mandatory breaks follow newlines and paragraph separators.
+	Mandatory bool
+}
+
+/*
+LineBreaks returns the line break opportunities inside s following the pair
+rules of UAX #14 for the most common classes. The start and end of s are not
+included.
+*/
+func LineBreaks(s string) []Break {
+	breaks := []Break{}
+
+	// prev is the class of the previous character with combining marks
+	// attached to their base, beforeSP is the last class that isn't a space.
+	prev, beforeSP := lbClass(0xFF), lbClass(0xFF)
+	for i, r := range s {
+		c := lineBreakClass(r)
+		if prev == 0xFF {
+			if c == lbCM {
+				c = lbAL
+			}
+			prev, beforeSP = c, c
+			continue
+		}
+
+		brk, mandatory := lineBreakPair(prev, beforeSP, c)
+		if brk {
+			breaks = append(breaks, Break{Offset: i, Mandatory: mandatory})
+		}
+
+		switch {
+		case c == lbCM:
+			// LB9 and LB10: marks take the class of their base unless
+			// the base is a space or break
+			if prev >= lbBK && prev <= lbZW {
+				prev, beforeSP = lbAL, lbAL
+			}
+		case c == lbSP:
+			prev = c
+		default:
+			prev, beforeSP = c, c
+		}
+	}
+	return breaks
+}
+
+func lineBreakPair(prev, beforeSP, c lbClass) (brk, mandatory bool) {
+	switch {
+	// LB4 and LB5
+	case prev == lbCR && c == lbLF:
+		return false, false
+	case prev == lbBK || prev == lbCR || prev == lbLF || prev == lbNL:
+		return true, true
+	// LB6 and LB7
+	case c == lbBK || c == lbCR || c == lbLF || c == lbNL || c == lbSP || c == lbZW:
+		return false, false
+	// LB8
+	case beforeSP == lbZW:
+		return true, false
+	// LB9
+	case c == lbCM:
+		return false, false
+	// LB11 and LB12
+	case c == lbWJ || prev == lbWJ || prev == lbGL:
+		return false, false
+	// LB12a
+	case c == lbGL:
+		return prev == lbSP || prev == lbBA || prev == lbHY, false
+	// LB13
+	case c == lbCL || c == lbEX || c == lbIS || c == lbSY:
+		return false, false
+	// LB14
+	case beforeSP == lbOP:
+		return false, false
+	// LB17
+	case beforeSP == lbB2 && c == lbB2:
+		return false, false
+	// LB18
+	case prev == lbSP:
+		return true, false
+	// LB19
+	case c == lbQU || prev == lbQU:
+		return false, false
+	// LB21
+	case c == lbBA || c == lbHY:
+		return false, false
+	// LB23, LB25 and LB29
+	case (prev == lbAL || prev == lbNU || prev == lbIS) && (c == lbAL || c == lbNU):
+		return false, false
+	// LB25
+	case prev == lbSY && c == lbNU:
+		return false, false
+	// LB30
+	case (prev == lbAL || prev == lbNU) && c == lbOP, prev == lbCL && (c == lbAL || c == lbNU):
+		return false, false
+	}
+	// LB31
+	return true, false
+}
+
+/*
+isSpace reports whether r is dropped from the width of a line when it ends it.
+*/
+func isSpace(r rune) bool {
+	return unicode.IsSpace(r) || (r == 0x200B)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package text
+
+import (
+	"strconv"
+	"strings"
+
+	"goarrg.com/asset/font"
+	"goarrg.com/gmath/color"
+)
+
+/*
+span applies styles[style] from the byte at start until the next span.
+*/
+type span struct {
+	start int
+	style int
+}
+
+/*
+parseMarkup strips the markup from s, returning the plain text and the spans of
+styles that apply to it. Tags that can't be parsed are kept as text.
+*/
+func parseMarkup(s string, base Style, fonts map[string]*font.Font) (string, []Style, []span) {
+	type open struct {
+		tag   string
+		style int
+	}
+
+	var b strings.Builder
+	styles := []Style{base}
+	spans := []span{{0, 0}}
+	stack := []open{{"", 0}}
+
+	setStyle := func(style int) {
+		if spans[len(spans)-1].start == b.Len() {
+			spans[len(spans)-1].style = style
+		} else {
+			spans = append(spans, span{b.Len(), style})
+		}
+	}
+
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '[')
+		if i < 0 {
+			b.WriteString(s)
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+
+		if strings.HasPrefix(s, "[[") {
+			b.WriteByte('[')
+			s = s[2:]
+			continue
+		}
+
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			b.WriteString(s)
+			break
+		}
+		tag := s[1:end]
+
+		if name, ok := strings.CutPrefix(tag, "/"); ok {
+			if top := stack[len(stack)-1]; len(stack) > 1 && top.tag == name {
+				stack = stack[:len(stack)-1]
+				setStyle(stack[len(stack)-1].style)
+				s = s[end+1:]
+				continue
+			}
+		} else if style, ok := applyTag(styles[stack[len(stack)-1].style], tag, fonts); ok {
+			name, _, _ := strings.Cut(tag, "=")
+			styles = append(styles, style)
+			stack = append(stack, open{name, len(styles) - 1})
+			setStyle(len(styles) - 1)
+			s = s[end+1:]
+			continue
+		}
+
+		b.WriteByte('[')
+		s = s[1:]
+	}
+
+	return b.String(), styles, spans
+}
+
+/*
+applyTag returns style modified by tag, one of:
+
+	[color=#rgb], [color=#rrggbb] or [color=#rrggbbaa] in sRGB
+	[size=pixels]
+	[font=name] using a font from Config.Fonts
+	[b] and [i], short for [font=bold] and [font=italic]
+*/
+func applyTag(style Style, tag string, fonts map[string]*font.Font) (Style, bool) {
+	name, value, _ := strings.Cut(tag, "=")
+	switch name {
+	case "b":
+		name, value = "font", "bold"
+	case "i":
+		name, value = "font", "italic"
+	}
+
+	switch name {
+	case "color":
+		c, ok := parseColor(value)
+		if !ok {
+			return style, false
+		}
+		style.Color = c
+	case "size":
+		size, err := strconv.ParseFloat(value, 32)
+		if err != nil || size <= 0 {
+			return style, false
+		}
+		style.Size = float32(size)
+	case "font":
+		f, ok := fonts[value]
+		if !ok || f == nil {
+			return style, false
+		}
+		style.Font = f
+	default:
+		return style, false
+	}
+	return style, true
+}
+
+func parseColor(s string) (color.UNorm[float32], bool) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok {
+		return color.UNorm[float32]{}, false
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.UNorm[float32]{}, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.UNorm[float32]{}, false
+	}
+	c := color.SRGB[uint8]{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}
+	return color.Convert[color.UNorm[float32], color.SRGB[uint8], float32, uint8](c), true
+}
@@ -0,0 +1,470 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package text lays out UTF-8 strings into positioned glyph quads using the
+metrics of asset/font fonts, leaving drawing to the renderer. Coordinates are in
+pixels from the top left of the block with +Y pointing down.
+*/
+package text
+
+import (
+	"math"
+
+	"goarrg.com/asset/font"
+	"goarrg.com/debug"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+type Style struct {
+	Font *font.Font
+	// Size is the pixel size of an em, glyphs are scaled from the font's
+	// atlas. 0 uses the font's size.
+	Size float32
+	// Color is linear, zero is opaque white.
+	Color color.UNorm[float32]
+}
+
+type Config struct {
+	Style Style
+	// Width is the width lines wrap at, 0 disables wrapping.
+	Width float32
+	Align Align
+	// LineSpacing multiplies the height of lines, defaults to 1.
+	LineSpacing float32
+	// TabSize is the distance between tab stops in spaces, defaults to 4.
+	TabSize int
+
+	/*
+		Markup enables inline tags that change the style until the matching
+		closing tag, e.g. "[color=#f00]red[/color]". The tags are:
+
+			[color=#rgb], [color=#rrggbb] or [color=#rrggbbaa] in sRGB
+			[size=pixels]
+			[font=name] using a font from Fonts
+			[b] and [i], short for [font=bold] and [font=italic]
+
+		"[[" is a literal "[", tags that can't be parsed are kept as text.
+	*/
+	Markup bool
+	Fonts  map[string]*font.Font
+}
+
+/*
+Quad is a glyph to draw, Src is in the atlas page of Font and Dst in the
+block.
+*/
+type Quad struct {
+	Rune rune
+	// Offset is the byte offset of Rune in Block.Text.
+	Offset int
+	Font   *font.Font
+	Page   int
+	Src    gmath.Rect[int]
+	Dst    gmath.Rect[float32]
+	Color  color.UNorm[float32]
+}
+
+/*
+Caret is a position the caret can be placed at, X is relative to the line.
+*/
+type Caret struct {
+	Offset int
+	X      float32
+}
+
+type Line struct {
+	// Start and End are byte offsets in Block.Text, End excludes the line
+	// break if there is one.
+	Start int
+	End   int
+
+	X        float32
+	Y        float32
+	Width    float32
+	Height   float32
+	Baseline float32
+
+	// Carets holds every caret position in the line in order, from Start to
+	// End inclusive. Combining marks share the caret of their base.
+	Carets []Caret
+}
+
+type Block struct {
+	// Text is the laid out string with markup removed, all offsets are
+	// into it.
+	Text  string
+	Quads []Quad
+	Lines []Line
+	// Width is Config.Width when wrapping, otherwise the widest line.
+	Width  float32
+	Height float32
+}
+
+type item struct {
+	offset  int
+	r       rune
+	style   *Style
+	glyph   font.Glyph
+	scale   float32
+	advance float32
+	// kern is added before the item unless it starts a line.
+	kern      float32
+	space     bool
+	newline   bool
+	combining bool
+}
+
+/*
+Layout lays out s, wrapping it to cfg.Width and breaking lines following
+LineBreaks.
+*/
+func Layout(s string, cfg Config) (*Block, error) {
+	if cfg.Style.Font == nil {
+		return nil, debug.Errorf("Style has no font")
+	}
+	if cfg.Style.Size <= 0 {
+		cfg.Style.Size = cfg.Style.Font.Size
+	}
+	if cfg.Style.Color == (color.UNorm[float32]{}) {
+		cfg.Style.Color = color.UNorm[float32]{R: 1, G: 1, B: 1, A: 1}
+	}
+	if cfg.LineSpacing <= 0 {
+		cfg.LineSpacing = 1
+	}
+	if cfg.TabSize <= 0 {
+		cfg.TabSize = 4
+	}
+
+	styles, spans := []Style{cfg.Style}, []span{{0, 0}}
+	if cfg.Markup {
+		s, styles, spans = parseMarkup(s, cfg.Style, cfg.Fonts)
+	}
+
+	items := make([]item, 0, len(s))
+	index := make(map[int]int, len(s))
+	for offset, r := range s {
+		for len(spans) > 1 && spans[1].start <= offset {
+			spans = spans[1:]
+		}
+		style := &styles[spans[0].style]
+		c := lineBreakClass(r)
+
+		it := item{
+			offset:    offset,
+			r:         r,
+			style:     style,
+			scale:     1,
+			space:     isSpace(r),
+			newline:   c == lbBK || c == lbCR || c == lbLF || c == lbNL,
+			combining: c == lbCM,
+		}
+		if style.Font.Size > 0 {
+			it.scale = style.Size / style.Font.Size
+		}
+		if !it.newline && c != lbZW && c != lbWJ && r != 0xAD {
+			it.glyph, _ = style.Font.Glyph(r)
+			it.advance = it.glyph.Advance * it.scale
+		}
+		if n := len(items); n > 0 && items[n-1].style.Font == style.Font && items[n-1].scale == it.scale {
+			it.kern = style.Font.Kern(items[n-1].r, r) * it.scale
+		}
+
+		index[offset] = len(items)
+		items = append(items, it)
+	}
+
+	brk := make([]uint8, len(items)+1)
+	for _, b := range LineBreaks(s) {
+		brk[index[b.Offset]] = 1
+		if b.Mandatory {
+			brk[index[b.Offset]] = 2
+		}
+	}
+
+	l := layout{cfg: cfg, items: items}
+	l.wrap(brk)
+
+	block := &Block{Text: s}
+	for _, r := range l.lines {
+		block.Lines = append(block.Lines, l.place(r[0], r[1], len(s), &block.Height))
+	}
+
+	block.Width = cfg.Width
+	if cfg.Width <= 0 {
+		for _, line := range block.Lines {
+			block.Width = max(block.Width, line.Width)
+		}
+	}
+	for i := range block.Lines {
+		line := &block.Lines[i]
+		switch cfg.Align {
+		case AlignCenter:
+			line.X = (block.Width - line.Width) / 2
+		case AlignRight:
+			line.X = block.Width - line.Width
+		}
+	}
+
+	for i, line := range block.Lines {
+		for _, k := range l.ranges[i] {
+			it := items[k]
+			g := it.glyph
+			if it.space || g.Rect.W <= 0 || g.Rect.H <= 0 {
+				continue
+			}
+			block.Quads = append(block.Quads, Quad{
+				Rune:   it.r,
+				Offset: it.offset,
+				Font:   it.style.Font,
+				Page:   g.Page,
+				Src:    g.Rect,
+				Dst: gmath.Rect[float32]{
+					X: line.X + l.pens[k] + (g.Offset.X * it.scale),
+					Y: line.Baseline + (g.Offset.Y * it.scale),
+					W: float32(g.Rect.W) * it.scale,
+					H: float32(g.Rect.H) * it.scale,
+				},
+				Color: it.style.Color,
+			})
+		}
+	}
+
+	return block, nil
+}
+
+type layout struct {
+	cfg   Config
+	items []item
+	lines [][2]int
+	// ranges holds the item indices of each line and pens the x position of
+	// each item within its line, both filled by place.
+	ranges [][]int
+	pens   []float32
+}
+
+/*
+advance returns the pen position after item i when it is drawn at x.
+*/
+func (l *layout) advance(i int, x float32, lineStart bool) float32 {
+	it := &l.items[i]
+	if it.r == '\t' {
+		space, _ := it.style.Font.Glyph(' ')
+		stop := space.Advance * it.scale * float32(l.cfg.TabSize)
+		if stop <= 0 {
+			return x
+		}
+		return (float32(math.Floor(float64(x/stop))) + 1) * stop
+	}
+	if !lineStart {
+		x += it.kern
+	}
+	return x + it.advance
+}
+
+/*
+measure returns the pen position after items [from, to) starting at x, and the
+position after the last item that isn't a space.
+*/
+func (l *layout) measure(from, to int, x float32, lineStart int) (float32, float32) {
+	trimmed := x
+	for i := from; i < to; i++ {
+		x = l.advance(i, x, i == lineStart)
+		if !l.items[i].space {
+			trimmed = x
+		}
+	}
+	return x, trimmed
+}
+
+func (l *layout) wrap(brk []uint8) {
+	n := len(l.items)
+	wrap := l.cfg.Width > 0
+	lineStart, lineW := 0, float32(0)
+	emit := func(end int) {
+		l.lines = append(l.lines, [2]int{lineStart, end})
+		lineStart, lineW = end, 0
+	}
+
+	for s := 0; s < n; {
+		e := s + 1
+		for e < n && brk[e] == 0 {
+			e++
+		}
+
+		x, trimmed := l.measure(s, e, lineW, lineStart)
+		if wrap && s > lineStart && trimmed > l.cfg.Width {
+			emit(s)
+			x, trimmed = l.measure(s, e, 0, lineStart)
+		}
+		if wrap && trimmed > l.cfg.Width {
+			// the segment doesn't fit on a line of its own, break
+			// anywhere that isn't before a space or combining mark
+			for k := s; k < e; k++ {
+				x := l.advance(k, lineW, k == lineStart)
+				if x > l.cfg.Width && k > lineStart && !l.items[k].space && !l.items[k].combining {
+					emit(k)
+					x = l.advance(k, 0, true)
+				}
+				lineW = x
+			}
+		} else {
+			lineW = x
+		}
+
+		if e < n && brk[e] == 2 {
+			emit(e)
+		}
+		s = e
+	}
+
+	emit(n)
+	if n > 0 && l.items[n-1].newline {
+		emit(n)
+	}
+}
+
+/*
+place positions the items [from, to) on a line starting at *y and advances *y
+past it. textLen is the length of the text, used as the end offset of the last
+line.
+*/
+func (l *layout) place(from, to, textLen int, y *float32) Line {
+	if l.pens == nil {
+		l.pens = make([]float32, len(l.items))
+	}
+
+	end := textLen
+	if to < len(l.items) {
+		end = l.items[to].offset
+	}
+	for k := to - 1; k >= from && l.items[k].newline; k-- {
+		end = l.items[k].offset
+	}
+
+	line := Line{Start: end, End: end, Y: *y}
+	if from < to {
+		line.Start = l.items[from].offset
+	}
+
+	// empty lines take the style of the text before them
+	styleItems := l.items[from:to]
+	if from == to && from > 0 {
+		styleItems = l.items[from-1 : from]
+	}
+	ascent, height := float32(0), float32(0)
+	if len(styleItems) == 0 {
+		f := l.cfg.Style.Font
+		scale := float32(1)
+		if f.Size > 0 {
+			scale = l.cfg.Style.Size / f.Size
+		}
+		ascent, height = f.Ascent*scale, f.LineHeight*scale
+	}
+	for _, it := range styleItems {
+		ascent = max(ascent, it.style.Font.Ascent*it.scale)
+		height = max(height, it.style.Font.LineHeight*it.scale)
+	}
+	line.Baseline = *y + ascent
+	line.Height = height * l.cfg.LineSpacing
+	*y += line.Height
+
+	x := float32(0)
+	indices := []int{}
+	for k := from; k < to; k++ {
+		it := &l.items[k]
+		if it.newline {
+			break
+		}
+		pen := x
+		if k > from {
+			pen += it.kern
+		}
+		if !it.combining {
+			line.Carets = append(line.Carets, Caret{Offset: it.offset, X: pen})
+		}
+		l.pens[k] = pen
+		x = l.advance(k, x, k == from)
+		if !it.space {
+			line.Width = x
+		}
+		indices = append(indices, k)
+	}
+	line.Carets = append(line.Carets, Caret{Offset: end, X: x})
+	l.ranges = append(l.ranges, indices)
+
+	return line
+}
+
+/*
+Caret returns the top of the caret before the byte at offset and its height,
+offsets inside a character or combining sequence use the caret before it.
+*/
+func (b *Block) Caret(offset int) (gmath.Point2f[float32], float32) {
+	line := b.lineAt(offset)
+	c := line.Carets[0]
+	for _, cc := range line.Carets {
+		if cc.Offset > offset {
+			break
+		}
+		c = cc
+	}
+	return gmath.Point2f[float32]{X: line.X + c.X, Y: line.Y}, line.Height
+}
+
+/*
+lineAt returns the line containing offset, an offset at a soft wrap belongs to
+the line after it.
+*/
+func (b *Block) lineAt(offset int) *Line {
+	for i := len(b.Lines) - 1; i > 0; i-- {
+		if b.Lines[i].Start <= offset {
+			return &b.Lines[i]
+		}
+	}
+	return &b.Lines[0]
+}
+
+/*
+HitTest returns the offset of the caret closest to p.
+*/
+func (b *Block) HitTest(p gmath.Point2f[float32]) int {
+	line := &b.Lines[len(b.Lines)-1]
+	for i := range b.Lines {
+		if p.Y < b.Lines[i].Y+b.Lines[i].Height {
+			line = &b.Lines[i]
+			break
+		}
+	}
+
+	x := p.X - line.X
+	best := line.Carets[0]
+	for _, c := range line.Carets[1:] {
+		if math.Abs(float64(c.X-x)) < math.Abs(float64(best.X-x)) {
+			best = c
+		}
+	}
+	return best.Offset
+}
@@ -0,0 +1,221 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package text
+
+import (
+	"reflect"
+	"testing"
+
+	"goarrg.com/asset/font"
+	"goarrg.com/gmath"
+	"goarrg.com/gmath/color"
+)
+
+/*
+testFont is monospaced with 10 pixel advances and 8x10 glyphs sitting on the
+baseline, A followed by V is kerned by -2.
+*/
+func testFont(size float32) *font.Font {
+	f := &font.Font{
+		Size:       size,
+		LineHeight: 16,
+		Ascent:     12,
+		Descent:    4,
+		Glyphs:     map[rune]font.Glyph{},
+		Kerning:    map[font.KerningPair]float32{{First: 'A', Second: 'V'}: -2},
+	}
+	for r := rune(' '); r <= '~'; r++ {
+		g := font.Glyph{Advance: 10}
+		if r != ' ' {
+			g.Rect = gmath.Rect[int]{X: int(r-' ') * 8, W: 8, H: 10}
+			g.Offset = gmath.Vector2f[float32]{X: 1, Y: -10}
+		}
+		f.Glyphs[r] = g
+	}
+	return f
+}
+
+func TestLineBreaks(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want []Break
+	}{
+		{"hello world", []Break{{Offset: 6}}},
+		{"a\nb", []Break{{Offset: 2, Mandatory: true}}},
+		{"a\r\nb", []Break{{Offset: 3, Mandatory: true}}},
+		{"well-known", []Break{{Offset: 5}}},
+		{"(a) b.", []Break{{Offset: 4}}},
+		{"1.5 x", []Break{{Offset: 4}}},
+		{"a b c", []Break{{Offset: 5}}},
+		{"a  b", []Break{{Offset: 2}}},
+		{"日本語", []Break{{Offset: 3}, {Offset: 6}}},
+		{"日本。", []Break{{Offset: 3}}},
+		{"e\u0301 x", []Break{{Offset: 4}}},
+		{"a\u200bb", []Break{{Offset: 4}}},
+	} {
+		if got := LineBreaks(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v want %v", test.text, got, test.want)
+		}
+	}
+}
+
+func lineTexts(b *Block) []string {
+	lines := []string{}
+	for _, l := range b.Lines {
+		lines = append(lines, b.Text[l.Start:l.End])
+	}
+	return lines
+}
+
+func TestLayout(t *testing.T) {
+	f := testFont(16)
+
+	if _, err := Layout("a", Config{}); err == nil {
+		t.Fatal("Layout without a font succeeded")
+	}
+
+	for _, test := range []struct {
+		text  string
+		width float32
+		want  []string
+	}{
+		{"", 0, []string{""}},
+		{"aaa bbb ccc", 75, []string{"aaa bbb ", "ccc"}},
+		{"aaa bbb    ccc", 70, []string{"aaa bbb    ", "ccc"}},
+		{"aaaaaaaaaa", 35, []string{"aaa", "aaa", "aaa", "a"}},
+		{"ab\ncd\n", 0, []string{"ab", "cd", ""}},
+		{"ab\r\ncd", 0, []string{"ab", "cd"}},
+	} {
+		b, err := Layout(test.text, Config{Style: Style{Font: f}, Width: test.width})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := lineTexts(b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got lines %q want %q", test.text, got, test.want)
+		}
+		for _, l := range b.Lines {
+			if test.width > 0 && l.Width > test.width {
+				t.Errorf("%q: line %+v wider than %v", test.text, l, test.width)
+			}
+		}
+	}
+
+	b, err := Layout("AV a\tb", Config{Style: Style{Font: f, Size: 32}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := []gmath.Rect[float32]{}
+	for _, q := range b.Quads {
+		dst = append(dst, q.Dst)
+	}
+	// scaled by 2, V is kerned and b is on the first tab stop at 80
+	want := []gmath.Rect[float32]{
+		{X: 2, Y: 4, W: 16, H: 20},
+		{X: 18, Y: 4, W: 16, H: 20},
+		{X: 58, Y: 4, W: 16, H: 20},
+		{X: 82, Y: 4, W: 16, H: 20},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("Got quads %v want %v", dst, want)
+	}
+	if b.Width != 100 || b.Height != 32 || b.Lines[0].Baseline != 24 {
+		t.Fatalf("Unexpected block: %v x %v, %+v", b.Width, b.Height, b.Lines[0])
+	}
+
+	for align, x := range map[Align]float32{AlignLeft: 0, AlignCenter: 40, AlignRight: 80} {
+		b, err := Layout("ab", Config{Style: Style{Font: f}, Width: 100, Align: align})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Lines[0].X != x || b.Quads[0].Dst.X != x+1 {
+			t.Errorf("Align %d: got line at %v want %v", align, b.Lines[0].X, x)
+		}
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	f, bold := testFont(16), testFont(16)
+	b, err := Layout("a[color=#f00]b[b]c[/b][/color][[d[/b][x]", Config{
+		Style:  Style{Font: f},
+		Markup: true,
+		Fonts:  map[string]*font.Font{"bold": bold},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Text != "abc[d[/b][x]" {
+		t.Fatalf("Unexpected text %q", b.Text)
+	}
+
+	white := color.UNorm[float32]{R: 1, G: 1, B: 1, A: 1}
+	red := color.UNorm[float32]{R: 1, A: 1}
+	for i, want := range []struct {
+		font  *font.Font
+		color color.UNorm[float32]
+	}{{f, white}, {f, red}, {bold, red}, {f, white}, {f, white}} {
+		if q := b.Quads[i]; q.Font != want.font || q.Color != want.color {
+			t.Errorf("Quad %d %q: unexpected style %v", i, q.Rune, q.Color)
+		}
+	}
+}
+
+func TestCaret(t *testing.T) {
+	f := testFont(16)
+	f.Glyphs[0x301] = font.Glyph{}
+	b, err := Layout("ab\ncd e\u0301", Config{Style: Style{Font: f}, Width: 35})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lineTexts(b); !reflect.DeepEqual(got, []string{"ab", "cd ", "e\u0301"}) {
+		t.Fatalf("Unexpected lines %q", got)
+	}
+
+	for offset, want := range map[int]gmath.Point2f[float32]{
+		0: {X: 0, Y: 0},
+		2: {X: 20, Y: 0},
+		3: {X: 0, Y: 16},
+		5: {X: 20, Y: 16},
+		// soft wraps belong to the next line
+		6: {X: 0, Y: 32},
+		// inside the combining sequence
+		8: {X: 0, Y: 32},
+		9: {X: 10, Y: 32},
+	} {
+		p, h := b.Caret(offset)
+		if p != want || h != 16 {
+			t.Errorf("Caret(%d): got %v %v want %v", offset, p, h, want)
+		}
+	}
+
+	for _, test := range []struct {
+		p    gmath.Point2f[float32]
+		want int
+	}{
+		{gmath.Point2f[float32]{X: -5, Y: -5}, 0},
+		{gmath.Point2f[float32]{X: 14, Y: 5}, 1},
+		{gmath.Point2f[float32]{X: 100, Y: 5}, 2},
+		{gmath.Point2f[float32]{X: 16, Y: 20}, 5},
+		{gmath.Point2f[float32]{X: 100, Y: 100}, 9},
+	} {
+		if got := b.HitTest(test.p); got != test.want {
+			t.Errorf("HitTest(%v): got %d want %d", test.p, got, test.want)
+		}
+	}
+}
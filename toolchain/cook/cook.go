@@ -0,0 +1,469 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package cook incrementally converts a tree of source assets into a tree of
+runtime assets. Every file under the source directory is given to the first
+Processor that matches it, the inputs it read and the outputs it wrote are
+recorded in a database keyed by content hash so the next run only processes
+files whose inputs, outputs or processor changed.
+*/
+package cook
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"goarrg.com/debug"
+)
+
+/*
+Processor converts matching source files, Version must be changed whenever the
+output of Process changes so that files cooked by an older version are rebuilt.
+*/
+type Processor struct {
+	Name    string
+	Version int
+	// Match is passed the slash separated path relative to the source directory.
+	Match   func(name string) bool
+	Process func(*Job) error
+}
+
+/*
+MatchExt returns a Match function that matches any of the extensions, case
+insensitively.
+*/
+func MatchExt(exts ...string) func(string) bool {
+	return func(name string) bool {
+		ext := path.Ext(name)
+		for _, e := range exts {
+			if strings.EqualFold(ext, e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type Config struct {
+	Src string
+	Out string
+	// DB defaults to ".goarrg-cook.json" within Out.
+	DB         string
+	Processors []Processor
+	// Jobs is the number of files processed in parallel, defaults to runtime.NumCPU().
+	Jobs int
+	// Ignore is passed paths relative to Src as with toolchain.ScanDirModTime.
+	Ignore func(string) bool
+	// Force rebuilds everything.
+	Force bool
+}
+
+/*
+Result lists source files by what happened to them and outputs that were
+deleted because nothing produces them anymore, all paths are slash separated and
+relative to Src or Out.
+*/
+type Result struct {
+	Built    []string
+	UpToDate []string
+	Failed   []string
+	Removed  []string
+}
+
+/*
+Cook processes every file in cfg.Src that is out of date. Failing files are
+reported in the returned error and processed again on the next run, everything
+else is saved to the database even on failure.
+*/
+func Cook(cfg Config) (*Result, error) {
+	if cfg.Src == "" || cfg.Out == "" {
+		return nil, debug.Errorf("Src and Out are required")
+	}
+	if cfg.DB == "" {
+		cfg.DB = filepath.Join(cfg.Out, ".goarrg-cook.json")
+	}
+	if cfg.Jobs <= 0 {
+		cfg.Jobs = runtime.NumCPU()
+	}
+
+	db, err := loadDB(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	type work struct {
+		name string
+		p    *Processor
+	}
+	queue := []work{}
+	err = filepath.WalkDir(cfg.Src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == cfg.Src {
+			return err
+		}
+		rel, err := filepath.Rel(cfg.Src, p)
+		if err != nil {
+			return err
+		}
+		if cfg.Ignore != nil && cfg.Ignore(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+		for i := range cfg.Processors {
+			if cfg.Processors[i].Match(name) {
+				queue = append(queue, work{name, &cfg.Processors[i]})
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to scan %q", cfg.Src)
+	}
+
+	type done struct {
+		name  string
+		rec   *record
+		built bool
+		err   error
+	}
+	jobs := make(chan work)
+	results := make(chan done)
+	wg := sync.WaitGroup{}
+	for i := 0; i < min(cfg.Jobs, max(1, len(queue))); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w := range jobs {
+				old := db.Files[w.name]
+				if !cfg.Force && old.upToDate(cfg, w.p, w.name) {
+					results <- done{name: w.name, rec: old}
+					continue
+				}
+				debug.VPrintf("Cooking %q with %q", w.name, w.p.Name)
+				rec, err := run(cfg, w.p, w.name)
+				results <- done{name: w.name, rec: rec, built: true, err: debug.ErrorWrapf(err, "Failed to cook %q", w.name)}
+			}
+		}()
+	}
+	go func() {
+		for _, w := range queue {
+			jobs <- w
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	result := &Result{}
+	next := newDB()
+	errs := []error{}
+	for d := range results {
+		switch {
+		case d.err != nil:
+			result.Failed = append(result.Failed, d.name)
+			errs = append(errs, d.err)
+		case d.built:
+			result.Built = append(result.Built, d.name)
+			next.Files[d.name] = d.rec
+		default:
+			result.UpToDate = append(result.UpToDate, d.name)
+			next.Files[d.name] = d.rec
+		}
+	}
+
+	// two inputs writing different content to the same output would overwrite
+	// each other depending on scheduling, fail both so that it is noticed,
+	// identical outputs such as a buffer shared by two meshes are fine
+	owners := map[string]string{}
+	conflicts := map[string]struct{}{}
+	for _, name := range sortedKeys(next.Files) {
+		for out, state := range next.Files[name].Outputs {
+			if owner, ok := owners[out]; ok {
+				if rec, ok := next.Files[owner]; ok && rec.Outputs[out].Hash == state.Hash {
+					continue
+				}
+				errs = append(errs, debug.Errorf("Output %q is written by both %q and %q", out, owner, name))
+				delete(next.Files, owner)
+				delete(next.Files, name)
+				conflicts[owner], conflicts[name] = struct{}{}, struct{}{}
+				continue
+			}
+			owners[out] = name
+		}
+	}
+	if len(conflicts) > 0 {
+		failed := func(name string) bool {
+			_, ok := conflicts[name]
+			return ok
+		}
+		result.Built = slices.DeleteFunc(result.Built, failed)
+		result.UpToDate = slices.DeleteFunc(result.UpToDate, failed)
+		result.Failed = append(result.Failed, sortedKeys(conflicts)...)
+	}
+
+	// outputs of failed files are kept so a failure doesn't delete the last
+	// good output, they are not in the database so they get rebuilt
+	for _, name := range result.Failed {
+		if old, ok := db.Files[name]; ok {
+			for out := range old.Outputs {
+				owners[out] = name
+			}
+		}
+	}
+	for _, name := range sortedKeys(db.Files) {
+		for out := range db.Files[name].Outputs {
+			if _, ok := owners[out]; ok {
+				continue
+			}
+			owners[out] = name
+			err := os.Remove(filepath.Join(cfg.Out, filepath.FromSlash(out)))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, debug.ErrorWrapf(err, "Failed to remove stale output"))
+				continue
+			}
+			result.Removed = append(result.Removed, out)
+		}
+	}
+
+	slices.Sort(result.Built)
+	slices.Sort(result.UpToDate)
+	slices.Sort(result.Failed)
+	slices.Sort(result.Removed)
+	errs = append(errs, next.save(cfg.DB))
+	return result, errors.Join(errs...)
+}
+
+func run(cfg Config, p *Processor, name string) (*record, error) {
+	j := &Job{
+		Name:    name,
+		src:     cfg.Src,
+		out:     cfg.Out,
+		deps:    map[string]struct{}{},
+		outputs: map[string]struct{}{},
+	}
+	if err := p.Process(j); err != nil {
+		return nil, err
+	}
+
+	rec := &record{
+		Processor: p.Name,
+		Version:   p.Version,
+		Deps:      map[string]fileState{},
+		Outputs:   map[string]fileState{},
+	}
+	var err error
+	if rec.Input, err = stateOf(filepath.Join(cfg.Src, filepath.FromSlash(name)), nil); err != nil {
+		return nil, err
+	}
+	for dep := range j.deps {
+		if dep == name {
+			continue
+		}
+		if rec.Deps[dep], err = stateOf(filepath.Join(cfg.Src, filepath.FromSlash(dep)), nil); err != nil {
+			return nil, err
+		}
+	}
+	for out := range j.outputs {
+		if rec.Outputs[out], err = stateOf(filepath.Join(cfg.Out, filepath.FromSlash(out)), nil); err != nil {
+			return nil, debug.ErrorWrapf(err, "Output %q was not written", out)
+		}
+	}
+	return rec, nil
+}
+
+/*
+Job is passed to Processor.Process, paths given to it are slash separated and
+relative to the source or output directory. Processors must read inputs through
+Job so that they are tracked as dependencies.
+*/
+type Job struct {
+	// Name is the path of the file being processed.
+	Name string
+
+	src     string
+	out     string
+	mtx     sync.Mutex
+	deps    map[string]struct{}
+	outputs map[string]struct{}
+}
+
+/*
+Path returns the OS path of the file being processed.
+*/
+func (j *Job) Path() string {
+	// Name comes from walking the source directory so it is always valid
+	p, _ := j.Depend(j.Name)
+	return p
+}
+
+/*
+Depend records name as an input and returns its OS path, names outside the
+source directory are rejected.
+*/
+func (j *Job) Depend(name string) (string, error) {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return "", debug.Errorf("Invalid input %q", name)
+	}
+	j.mtx.Lock()
+	j.deps[name] = struct{}{}
+	j.mtx.Unlock()
+	return filepath.Join(j.src, filepath.FromSlash(name)), nil
+}
+
+/*
+FS returns the source directory as a file system that records every file
+opened through it as an input, loaders that take an fs.FS pick up their
+dependencies this way.
+*/
+func (j *Job) FS() fs.FS {
+	return jobFS{j, os.DirFS(j.src)}
+}
+
+type jobFS struct {
+	job  *Job
+	fsys fs.FS
+}
+
+func (f jobFS) Open(name string) (fs.File, error) {
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if stat, err := file.Stat(); err == nil && !stat.IsDir() {
+		f.job.Depend(name)
+	}
+	return file, nil
+}
+
+/*
+Inputs returns the inputs recorded so far, sorted.
+*/
+func (j *Job) Inputs() []string {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return sortedKeys(j.deps)
+}
+
+/*
+Output records name as an output, creates its parent directories and returns
+its OS path.
+*/
+func (j *Job) Output(name string) (string, error) {
+	name = path.Clean(name)
+	if !fs.ValidPath(name) {
+		return "", debug.Errorf("Invalid output %q", name)
+	}
+	j.mtx.Lock()
+	j.outputs[name] = struct{}{}
+	j.mtx.Unlock()
+
+	p := filepath.Join(j.out, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", debug.ErrorWrapf(err, "Failed to create output %q", name)
+	}
+	return p, nil
+}
+
+/*
+Create is Output followed by os.Create.
+*/
+func (j *Job) Create(name string) (*os.File, error) {
+	p, err := j.Output(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Create(p)
+	return f, debug.ErrorWrapf(err, "Failed to create output %q", name)
+}
+
+/*
+upToDate compares the recorded states to the file system, only files whose size
+or modification time changed are hashed.
+*/
+func (r *record) upToDate(cfg Config, p *Processor, name string) bool {
+	if r == nil || r.Processor != p.Name || r.Version != p.Version {
+		return false
+	}
+	input, err := stateOf(filepath.Join(cfg.Src, filepath.FromSlash(name)), &r.Input)
+	if err != nil || input.Hash != r.Input.Hash {
+		return false
+	}
+	r.Input = input
+
+	check := func(dir string, files map[string]fileState) bool {
+		for name, want := range files {
+			got, err := stateOf(filepath.Join(dir, filepath.FromSlash(name)), &want)
+			if err != nil || got.Hash != want.Hash {
+				return false
+			}
+			files[name] = got
+		}
+		return true
+	}
+	return check(cfg.Src, r.Deps) && check(cfg.Out, r.Outputs)
+}
+
+/*
+Copy copies the source file name to the same path in the output directory,
+recording both. The copy is written to a temporary file and renamed so several
+files can copy the same dependency without seeing each other's partial writes.
+*/
+func (j *Job) Copy(name string) (err error) {
+	src, err := j.Depend(name)
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to copy %q", name)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to copy %q", name)
+	}
+	defer in.Close()
+	dst, err := j.Output(name)
+	if err != nil {
+		return err
+	}
+	out, err := os.CreateTemp(filepath.Dir(dst), ".cook-*")
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to copy %q", name)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(out.Name())
+		}
+	}()
+	_, err = io.Copy(out, in)
+	err = errors.Join(err, out.Chmod(0o644), out.Close())
+	if err == nil {
+		err = os.Rename(out.Name(), dst)
+	}
+	return debug.ErrorWrapf(err, "Failed to copy %q", name)
+}
@@ -0,0 +1,236 @@
+//go:build !goarrg_build_debug
+// +build !goarrg_build_debug
+
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cook
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"goarrg.com/debug"
+	"goarrg.com/internal/testutil"
+)
+
+/*
+upper converts .txt files to upper case .out files, lines starting with
+"include " are replaced by the named file.
+*/
+func upper(version int) Processor {
+	return Processor{
+		Name:    "upper",
+		Version: version,
+		Match:   MatchExt(".txt"),
+		Process: func(j *Job) error {
+			data, err := fs.ReadFile(j.FS(), j.Name)
+			if err != nil {
+				return err
+			}
+			if bytes.HasPrefix(data, []byte("fail")) {
+				return debug.Errorf("Asked to fail")
+			}
+			lines := strings.Split(string(data), "\n")
+			for i, l := range lines {
+				if name, ok := strings.CutPrefix(l, "include "); ok {
+					inc, err := fs.ReadFile(j.FS(), name)
+					if err != nil {
+						return err
+					}
+					lines[i] = string(inc)
+				}
+			}
+			out, err := j.Output(strings.TrimSuffix(j.Name, ".txt") + ".out")
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(out, []byte(strings.ToUpper(strings.Join(lines, "\n"))), 0o644)
+		},
+	}
+}
+
+func TestCook(t *testing.T) {
+	dir := t.TempDir()
+	src, out := filepath.Join(dir, "src"), filepath.Join(dir, "out")
+	testutil.WriteFile(t, src, "a.txt", []byte("a\ninclude inc/common.inc"))
+	testutil.WriteFile(t, src, "inc/common.inc", []byte("common"))
+	testutil.WriteFile(t, src, "sub/b.txt", []byte("b"))
+	testutil.WriteFile(t, src, "ignored/c.txt", []byte("c"))
+
+	cfg := Config{
+		Src:        src,
+		Out:        out,
+		Processors: []Processor{upper(1)},
+		Jobs:       2,
+		Ignore: func(p string) bool {
+			return p == "ignored"
+		},
+	}
+	check := func(name string, want Result) {
+		t.Helper()
+		got, err := Cook(cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("%s: got %+v want %+v", name, *got, want)
+		}
+	}
+	readOut := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	check("initial", Result{Built: []string{"a.txt", "sub/b.txt"}})
+	if got := readOut("a.out"); got != "A\nCOMMON" {
+		t.Errorf("a.out = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(out, "ignored")); err == nil {
+		t.Error("ignored directory was cooked")
+	}
+	check("no changes", Result{UpToDate: []string{"a.txt", "sub/b.txt"}})
+
+	// touching without changing the content must not rebuild
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(src, "sub/b.txt"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	check("touched", Result{UpToDate: []string{"a.txt", "sub/b.txt"}})
+
+	testutil.WriteFile(t, src, "inc/common.inc", []byte("changed"))
+	check("dependency changed", Result{Built: []string{"a.txt"}, UpToDate: []string{"sub/b.txt"}})
+	if got := readOut("a.out"); got != "A\nCHANGED" {
+		t.Errorf("a.out = %q", got)
+	}
+
+	testutil.WriteFile(t, out, "sub/b.out", []byte("tampered"))
+	check("output changed", Result{Built: []string{"sub/b.txt"}, UpToDate: []string{"a.txt"}})
+	if got := readOut("sub/b.out"); got != "B" {
+		t.Errorf("b.out = %q", got)
+	}
+
+	cfg.Processors = []Processor{upper(2)}
+	check("version changed", Result{Built: []string{"a.txt", "sub/b.txt"}})
+
+	if err := os.Remove(filepath.Join(src, "sub/b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	check("input removed", Result{UpToDate: []string{"a.txt"}, Removed: []string{"sub/b.out"}})
+	if _, err := os.Stat(filepath.Join(out, "sub/b.out")); err == nil {
+		t.Error("stale output was not removed")
+	}
+
+	testutil.WriteFile(t, src, "a.txt", []byte("fail"))
+	got, err := Cook(cfg)
+	if err == nil {
+		t.Fatal("failing processor succeeded")
+	}
+	if want := (Result{Failed: []string{"a.txt"}}); !reflect.DeepEqual(*got, want) {
+		t.Errorf("failed: got %+v want %+v", *got, want)
+	}
+	if got := readOut("a.out"); got != "A\nCHANGED" {
+		t.Errorf("failure modified a.out to %q", got)
+	}
+
+	testutil.WriteFile(t, src, "a.txt", []byte("a"))
+	check("fixed", Result{Built: []string{"a.txt"}})
+	cfg.Force = true
+	check("force", Result{Built: []string{"a.txt"}})
+}
+
+func TestCookConflict(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	testutil.WriteFile(t, src, "a.txt", []byte("a"))
+	testutil.WriteFile(t, src, "b.txt", []byte("b"))
+
+	same := Processor{
+		Name:  "same",
+		Match: MatchExt(".TXT"),
+		Process: func(j *Job) error {
+			out, err := j.Output("same.out")
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(out, []byte(j.Name), 0o644)
+		},
+	}
+	result, err := Cook(Config{Src: src, Out: filepath.Join(dir, "out"), Processors: []Processor{same}})
+	if err == nil {
+		t.Error("conflicting outputs succeeded")
+	}
+	if len(result.Built) != 0 || !reflect.DeepEqual(result.Failed, []string{"a.txt", "b.txt"}) {
+		t.Errorf("Wrong result: %+v", result)
+	}
+}
+
+func TestCookShared(t *testing.T) {
+	dir := t.TempDir()
+	src, out := filepath.Join(dir, "src"), filepath.Join(dir, "out")
+	testutil.WriteFile(t, src, "a.txt", []byte("shared.dat"))
+	testutil.WriteFile(t, src, "b.txt", []byte("shared.dat"))
+	testutil.WriteFile(t, src, "c.txt", []byte("../outside.dat"))
+	testutil.WriteFile(t, dir, "outside.dat", []byte("outside"))
+	testutil.WriteFile(t, src, "shared.dat", []byte("shared"))
+
+	copyList := Processor{
+		Name:  "copy",
+		Match: MatchExt(".txt"),
+		Process: func(j *Job) error {
+			list, err := fs.ReadFile(j.FS(), j.Name)
+			if err != nil {
+				return err
+			}
+			return j.Copy(string(list))
+		},
+	}
+	result, err := Cook(Config{Src: src, Out: out, Processors: []Processor{copyList}})
+	if err == nil || !strings.Contains(err.Error(), "Invalid input") {
+		t.Fatalf("Expected invalid input, got: %v", err)
+	}
+	if !reflect.DeepEqual(result.Built, []string{"a.txt", "b.txt"}) || !reflect.DeepEqual(result.Failed, []string{"c.txt"}) {
+		t.Fatalf("Wrong result: %+v", result)
+	}
+	if data, err := os.ReadFile(filepath.Join(out, "shared.dat")); err != nil || string(data) != "shared" {
+		t.Fatalf("Wrong shared output: %q %v", data, err)
+	}
+
+	// the output is still used by b.txt
+	if err := os.Remove(filepath.Join(src, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(src, "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+	result, err = Cook(Config{Src: src, Out: out, Processors: []Processor{copyList}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 0 || !reflect.DeepEqual(result.UpToDate, []string{"b.txt"}) {
+		t.Fatalf("Wrong result: %+v", result)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2026 The goARRG Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"goarrg.com/debug"
+)
+
+const dbVersion = 1
+
+/*
+db is stored as JSON, keyed by source file.
+*/
+type db struct {
+	Version int
+	Files   map[string]*record
+}
+
+type record struct {
+	Processor string
+	Version   int
+	Input     fileState
+	Deps      map[string]fileState
+	Outputs   map[string]fileState
+}
+
+/*
+fileState caches the hash of a file along with the size and modification time
+it was computed at.
+*/
+type fileState struct {
+	Size    int64
+	ModTime int64
+	Hash    string
+}
+
+func newDB() *db {
+	return &db{Version: dbVersion, Files: map[string]*record{}}
+}
+
+/*
+loadDB returns an empty database if the file doesn't exist or was written by
+another version, in which case everything is rebuilt.
+*/
+func loadDB(name string) (*db, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return newDB(), nil
+		}
+		return nil, debug.ErrorWrapf(err, "Failed to load cook database")
+	}
+	d := newDB()
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, debug.ErrorWrapf(err, "Failed to load cook database %q", name)
+	}
+	if d.Version != dbVersion || d.Files == nil {
+		debug.WPrintf("Cook database %q is version %d, rebuilding", name, d.Version)
+		return newDB(), nil
+	}
+	return d, nil
+}
+
+/*
+save writes through a temporary file so an interrupted save can't leave a
+truncated database behind.
+*/
+func (d *db) save(name string) error {
+	data, err := json.MarshalIndent(d, "", "\t")
+	if err != nil {
+		return debug.ErrorWrapf(err, "Failed to save cook database")
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return debug.ErrorWrapf(err, "Failed to save cook database")
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return debug.ErrorWrapf(err, "Failed to save cook database")
+	}
+	return debug.ErrorWrapf(os.Rename(tmp, name), "Failed to save cook database")
+}
+
+/*
+stateOf returns the state of the file at p, reusing the hash in cached if the
+size and modification time are unchanged.
+*/
+func stateOf(p string, cached *fileState) (fileState, error) {
+	stat, err := os.Stat(p)
+	if err != nil {
+		return fileState{}, err
+	}
+	if stat.IsDir() {
+		return fileState{}, debug.Errorf("%q is a directory", p)
+	}
+	s := fileState{Size: stat.Size(), ModTime: stat.ModTime().UnixNano()}
+	if cached != nil && cached.Size == s.Size && cached.ModTime == s.ModTime {
+		s.Hash = cached.Hash
+		return s, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return fileState{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fileState{}, err
+	}
+	s.Hash = hex.EncodeToString(h.Sum(nil))
+	return s, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}